@@ -36,6 +36,14 @@ type PowerConfigSpec struct {
 
 	// The PowerProfiles that will be created by the Operator
 	PowerProfiles []string `json:"powerProfiles,omitempty"`
+
+	// The PowerProfiles Pods in each namespace may request. A namespace without an entry falls back
+	// to the '*' entry, and is unrestricted if there is none
+	NamespaceProfiles map[string][]string `json:"namespaceProfiles,omitempty"`
+
+	// The number of exclusive cores the Pods in each namespace may collectively have power-managed on a Node.
+	// A namespace without an entry falls back to the '*' entry, and is unlimited if there is none
+	NamespaceCoreQuotas map[string]int `json:"namespaceCoreQuotas,omitempty"`
 }
 
 // PowerConfigStatus defines the observed state of PowerConfig
@@ -51,6 +51,32 @@ type PowerNodeStatus struct {
 
 	// The state of the Guaranteed Pods and Shared Pool in a cluster
 	PowerNodeCPUState `json:"powerNodeCPUState,omitempty"`
+
+	// The EPP value AppQoS has applied to each managed core, keyed by core ID
+	AppliedEpp map[string]string `json:"appliedEpp,omitempty"`
+
+	// The version of the Node's AppQoS instance
+	AppQoSVersion string `json:"appQoSVersion,omitempty"`
+
+	// The features the Node's AppQoS instance advertises, such as 'power' or 'sstbf'
+	Capabilities []string `json:"capabilities,omitempty"`
+
+	// Conditions report whether the Node Agent is able to manage this Node
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// How much of the Node's configured package power budget the active PowerProfiles are estimated to commit
+	PowerBudget *PowerBudgetStatus `json:"powerBudget,omitempty"`
+}
+
+type PowerBudgetStatus struct {
+	// The package power budget configured for the Node, in watts
+	BudgetWatts int `json:"budgetWatts"`
+
+	// The package power estimated to be committed by the active PowerProfiles' exclusive cores, in watts
+	CommittedWatts int `json:"committedWatts"`
+
+	// The committed power as a percentage of the budget
+	UtilizationPercent int `json:"utilizationPercent"`
 }
 
 type PowerNodeCPUState struct {
@@ -82,9 +108,15 @@ type Container struct {
 	// The ID of the Container
 	Id string `json:"id,omitempty"`
 
+	// The container runtime the ID was given by, such as docker, containerd or cri-o
+	Runtime string `json:"runtime,omitempty"`
+
 	// The name of the Pod the Container is running on
 	Pod string `json:"pod,omitempty"`
 
+	// The UID of the Pod the Container is running on
+	PodUID string `json:"podUID,omitempty"`
+
 	// The exclusive CPUs given to this Container
 	ExclusiveCPUs []int `json:"exclusiveCpus,omitempty"`
 
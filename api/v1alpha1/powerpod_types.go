@@ -33,6 +33,12 @@ type PowerPodSpec struct {
 type PowerPodStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
+
+	// The name of the Node the Pod is running on
+	Node string `json:"node,omitempty"`
+
+	// The Pod's Containers with exclusive CPUs, along with the PowerProfile applied to them
+	Containers []Container `json:"containers,omitempty"`
 }
 
 // +kubebuilder:object:root=true
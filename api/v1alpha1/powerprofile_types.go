@@ -23,6 +23,10 @@ import (
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
+// FamilyLabel groups related PowerProfiles, e.g. perf-high, perf-med and perf-low, so they can be
+// managed together. PowerWorkloads inherit the label from their PowerProfile
+const FamilyLabel = "power.intel.com/family"
+
 // PowerProfileSpec defines the desired state of PowerProfile
 type PowerProfileSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
@@ -37,8 +41,42 @@ type PowerProfileSpec struct {
 	// The minimum frequency the core is allowed go
 	Min int `json:"min,omitempty"`
 
+	// How far below the Node's maximum frequency, in MHz, the core is allowed go. Resolved on each Node against
+	// its own maximum frequency so the PowerProfile follows the hardware it runs on. Takes precedence over max
+	// +kubebuilder:validation:Minimum=0
+	MaxDelta *int `json:"maxDelta,omitempty"`
+
+	// How far below the Node's maximum frequency, in MHz, the core's minimum frequency is. Resolved on each Node
+	// against its own maximum frequency. Takes precedence over min
+	// +kubebuilder:validation:Minimum=0
+	MinDelta *int `json:"minDelta,omitempty"`
+
 	// The priority value associated with this Power Profile
 	Epp string `json:"epp"`
+
+	// The scaling governor the EPP value is intended for, which must be coherent with it
+	Governor string `json:"governor,omitempty"`
+
+	// The labels a Node must have for the PowerProfile to be available on it. Empty makes it available on every Node
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// How long a PowerWorkload may run with this PowerProfile, e.g. '30m', before its cores revert to the
+	// baseline. Unset lets the PowerProfile run indefinitely
+	MaxLifetime *metav1.Duration `json:"maxLifetime,omitempty"`
+
+	// The PowerProfile a PowerWorkload's cores revert to once the maxLifetime has passed. A base PowerProfile
+	// resolves to its PowerProfile for the Node. Defaults to the Shared pool's PowerProfile
+	Baseline string `json:"baseline,omitempty"`
+
+	// The socket, or physical package, a Container's cores with this PowerProfile should all be on, keeping them
+	// within one uncore domain. The kubelet assigns the cores, so a Container whose cores are not is only warned of
+	// +kubebuilder:validation:Minimum=0
+	PreferredSocket *int `json:"preferredSocket,omitempty"`
+
+	// The NUMA node a Container's cores with this PowerProfile should all be on. As with preferredSocket, a
+	// Container whose cores are not is only warned of
+	// +kubebuilder:validation:Minimum=0
+	PreferredNUMA *int `json:"preferredNUMA,omitempty"`
 }
 
 // PowerProfileStatus defines the observed state of PowerProfile
@@ -0,0 +1,94 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// governorEppCoherence lists the EPP values with defined behaviour under each scaling governor. The
+// performance governor holds the core at its maximum frequency, so only the performance EPP makes sense with it
+var governorEppCoherence = map[string][]string{
+	"performance": {"performance"},
+	"powersave":   {"performance", "balance_performance", "balance_power", "power"},
+}
+
+// ValidateGovernorEpp returns an error if the PowerProfile's EPP value is not coherent with its governor.
+// A PowerProfile without a governor leaves the node's governor alone and is always coherent
+func (r *PowerProfile) ValidateGovernorEpp() error {
+	if r.Spec.Governor == "" {
+		return nil
+	}
+
+	governorPath := field.NewPath("spec").Child("governor")
+	allowedEpps, exists := governorEppCoherence[r.Spec.Governor]
+	if !exists {
+		governors := make([]string, 0, len(governorEppCoherence))
+		for governor := range governorEppCoherence {
+			governors = append(governors, governor)
+		}
+		sort.Strings(governors)
+
+		return apierrors.NewInvalid(GroupVersion.WithKind("PowerProfile").GroupKind(), r.Name, field.ErrorList{
+			field.NotSupported(governorPath, r.Spec.Governor, governors),
+		})
+	}
+
+	for _, epp := range allowedEpps {
+		if r.Spec.Epp == epp {
+			return nil
+		}
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("PowerProfile").GroupKind(), r.Name, field.ErrorList{
+		field.Invalid(field.NewPath("spec").Child("epp"), r.Spec.Epp,
+			fmt.Sprintf("EPP has no defined behaviour under the '%s' governor, must be one of: %s", r.Spec.Governor, strings.Join(allowedEpps, ", "))),
+	})
+}
+
+func (r *PowerProfile) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-power-intel-com-v1alpha1-powerprofile,mutating=false,failurePolicy=fail,groups=power.intel.com,resources=powerprofiles,versions=v1alpha1,name=vpowerprofile.kb.io
+
+var _ webhook.Validator = &PowerProfile{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *PowerProfile) ValidateCreate() error {
+	return r.ValidateGovernorEpp()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *PowerProfile) ValidateUpdate(old runtime.Object) error {
+	return r.ValidateGovernorEpp()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *PowerProfile) ValidateDelete() error {
+	return nil
+}
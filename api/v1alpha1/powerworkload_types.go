@@ -27,6 +27,9 @@ type NodeInfo struct {
 	// The name of the node associated with these containers and CPUs
 	Name string `json:"name,omitempty"`
 
+	// The UID of the node, used to tell when a node has been replaced by another of the same name
+	UID string `json:"uid,omitempty"`
+
 	// The containers that are utilizing this workload
 	Containers []Container `json:"containers,omitempty"`
 
@@ -67,8 +70,29 @@ type PowerWorkloadStatus struct {
 	// Shared Cores is the Core List that represents the Shared Cores on the node, only used by a Shared PowerWorkload
 	SharedCores []int `json:"sharedCores,omitempty"`
 
+	// UnallocatedCores is the Core List of the node's cores that are neither reserved nor in an exclusive
+	// PowerWorkload, kept by the shared-pool controller on a Shared PowerWorkload. Only the PowerWorkload
+	// controller writes the Shared pool to AppQoS and SharedCores
+	UnallocatedCores []int `json:"unallocatedCores,omitempty"`
+
 	// The Node that this Shared PowerWorkload is associated with
 	Node string `json:"node:,omitempty"`
+
+	// Conditions report problems the Node Agent encountered while managing this PowerWorkload
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// AppliedCpuIds is the Core List last applied to the PowerWorkload's AppQoS Pool
+	AppliedCpuIds []int `json:"appliedCpuIds,omitempty"`
+
+	// ObservedGeneration is the PowerWorkload generation last applied to the Node's AppQoS instance
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// AppliedTime is when the PowerWorkload's PowerProfile was first applied to its AppQoS Pool, from which the
+	// PowerProfile's maxLifetime is measured
+	AppliedTime *metav1.Time `json:"appliedTime,omitempty"`
+
+	// ProfileExpired is set once the PowerProfile's maxLifetime has passed and the Pool reverted to the baseline
+	ProfileExpired bool `json:"profileExpired,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -21,6 +21,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -93,6 +94,21 @@ func (in *NodeInfo) DeepCopy() *NodeInfo {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PowerBudgetStatus) DeepCopyInto(out *PowerBudgetStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PowerBudgetStatus.
+func (in *PowerBudgetStatus) DeepCopy() *PowerBudgetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PowerBudgetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PowerConfig) DeepCopyInto(out *PowerConfig) {
 	*out = *in
@@ -167,6 +183,28 @@ func (in *PowerConfigSpec) DeepCopyInto(out *PowerConfigSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.NamespaceProfiles != nil {
+		in, out := &in.NamespaceProfiles, &out.NamespaceProfiles
+		*out = make(map[string][]string, len(*in))
+		for key, val := range *in {
+			var outVal []string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make([]string, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.NamespaceCoreQuotas != nil {
+		in, out := &in.NamespaceCoreQuotas, &out.NamespaceCoreQuotas
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PowerConfigSpec.
@@ -332,6 +370,30 @@ func (in *PowerNodeSpec) DeepCopy() *PowerNodeSpec {
 func (in *PowerNodeStatus) DeepCopyInto(out *PowerNodeStatus) {
 	*out = *in
 	in.PowerNodeCPUState.DeepCopyInto(&out.PowerNodeCPUState)
+	if in.AppliedEpp != nil {
+		in, out := &in.AppliedEpp, &out.AppliedEpp
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Capabilities != nil {
+		in, out := &in.Capabilities, &out.Capabilities
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PowerBudget != nil {
+		in, out := &in.PowerBudget, &out.PowerBudget
+		*out = new(PowerBudgetStatus)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PowerNodeStatus.
@@ -350,7 +412,7 @@ func (in *PowerPod) DeepCopyInto(out *PowerPod) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PowerPod.
@@ -421,6 +483,13 @@ func (in *PowerPodSpec) DeepCopy() *PowerPodSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PowerPodStatus) DeepCopyInto(out *PowerPodStatus) {
 	*out = *in
+	if in.Containers != nil {
+		in, out := &in.Containers, &out.Containers
+		*out = make([]Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PowerPodStatus.
@@ -438,7 +507,7 @@ func (in *PowerProfile) DeepCopyInto(out *PowerProfile) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	out.Status = in.Status
 }
 
@@ -495,6 +564,38 @@ func (in *PowerProfileList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PowerProfileSpec) DeepCopyInto(out *PowerProfileSpec) {
 	*out = *in
+	if in.MaxDelta != nil {
+		in, out := &in.MaxDelta, &out.MaxDelta
+		*out = new(int)
+		**out = **in
+	}
+	if in.MinDelta != nil {
+		in, out := &in.MinDelta, &out.MinDelta
+		*out = new(int)
+		**out = **in
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.MaxLifetime != nil {
+		in, out := &in.MaxLifetime, &out.MaxLifetime
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.PreferredSocket != nil {
+		in, out := &in.PreferredSocket, &out.PreferredSocket
+		*out = new(int)
+		**out = **in
+	}
+	if in.PreferredNUMA != nil {
+		in, out := &in.PreferredNUMA, &out.PreferredNUMA
+		*out = new(int)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PowerProfileSpec.
@@ -617,6 +718,27 @@ func (in *PowerWorkloadStatus) DeepCopyInto(out *PowerWorkloadStatus) {
 		*out = make([]int, len(*in))
 		copy(*out, *in)
 	}
+	if in.UnallocatedCores != nil {
+		in, out := &in.UnallocatedCores, &out.UnallocatedCores
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AppliedCpuIds != nil {
+		in, out := &in.AppliedCpuIds, &out.AppliedCpuIds
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	if in.AppliedTime != nil {
+		in, out := &in.AppliedTime, &out.AppliedTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PowerWorkloadStatus.
@@ -17,6 +17,7 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"os"
 
@@ -26,6 +27,8 @@ import (
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
 
@@ -49,10 +52,19 @@ func init() {
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
+	var enableWebhooks bool
+	var createDefaultProfiles bool
+	var defaultProfilesNamespace string
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", true,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false,
+		"Serve the validating admission webhooks. Requires the webhook certificates to be deployed.")
+	flag.BoolVar(&createDefaultProfiles, "create-default-profiles", false,
+		"Create the performance, balance-performance, balance-power and power PowerProfiles on startup if they don't exist.")
+	flag.StringVar(&defaultProfilesNamespace, "default-profiles-namespace", controllers.NodeAgentDSNamespace,
+		"The namespace the default PowerProfiles are created in.")
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
@@ -80,6 +92,26 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "PowerConfig")
 		os.Exit(1)
 	}
+	if createDefaultProfiles {
+		// Runs once the manager is elected leader and its caches have synced
+		err = mgr.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+			return controllers.CreateDefaultProfiles(context.TODO(), mgr.GetClient(), ctrl.Log.WithName("default-profiles"), defaultProfilesNamespace)
+		}))
+		if err != nil {
+			setupLog.Error(err, "unable to add default PowerProfile creation")
+			os.Exit(1)
+		}
+	}
+	metrics.Registry.MustRegister(&controllers.ProfileResourceCollector{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("profile-resources"),
+	})
+	if enableWebhooks {
+		if err = (&powerv1alpha1.PowerProfile{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "PowerProfile")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	setupLog.Info("starting manager")
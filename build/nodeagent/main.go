@@ -17,21 +17,30 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"os"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/cgroup"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/cpumanager"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/memorymanager"
 	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/podresourcesclient"
 
 	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/controllers"
-	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/appqos"
 	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/podstate"
 	// +kubebuilder:scaffold:imports
 )
@@ -51,13 +60,213 @@ func init() {
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
+	var deletionCoalesceWindow time.Duration
+	var minWorkloadWriteInterval time.Duration
+	var trackSharedPool bool
+	var rolloutWindow time.Duration
+	var workloadNamespace string
+	var managedNodeSelector string
+	var appQoSWriteRate float64
+	var appQoSWriteBurst int
+	var reconcileTimeout time.Duration
+	var podWorkers int
+	var profileCleanup string
+	var adoptAppQoSAllocations bool
+	var adoptDryRun bool
+	var adoptNamespace string
+	var appQoSCredentialsLabel string
+	var appQoSCredentialsDir string
+	var appQoSPodSelector string
+	var appQoSPodNamespace string
+	var appQoSPodIPFamily string
+	var appQoSPodProbeTimeout time.Duration
+	var appQoSVersionTimeout time.Duration
+	var watchAppQoSAddress bool
+	var defaultReleaseProfile string
+	var strictResourceRequests bool
+	var allowAppQoSOnlyProfiles bool
+	var cpuSetStabilizationAttempts int
+	var cpuSetStabilizationInterval time.Duration
+	var scopeMode string
+	var verifyCgroupCPUSet bool
+	var checkCPUManagerPolicy bool
+	var reportUnprofiledContainers bool
+	var annotateMultiProfilePods bool
+	var retryBudget int
+	var manageEphemeralContainers bool
+	var profileChangeCooldown time.Duration
+	var crashLoopBackoff time.Duration
+	var reportPowerPods bool
+	var annotateAppliedCPUs bool
+	var addCleanupFinalizer bool
+	var appQoSRestartCheckInterval time.Duration
+	var reassertOnStartup bool
+	var allocationAPIAddr string
+	var debugAllocations bool
+	var maxWorkloadNodes int
+	var packagePowerBudget int
+	var coreWattsPerGHz float64
+	var freeExclusiveCPUsThreshold int
+	var taintAppQoSUnreachable bool
+	var allocationWebhookURL string
+	var allocationWebhookTimeout time.Duration
+	var allocationWebhookRetries int
+	var allocationWebhookRetryInterval time.Duration
+	var decisionSinkURL string
+	var decisionSinkTimeout time.Duration
 	flag.StringVar(&metricsAddr, "metrics-addr", ":10001", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.DurationVar(&deletionCoalesceWindow, "deletion-coalesce-window", 0,
+		"How long to collect Pod deletion cleanups for the same PowerWorkload before writing them as one update. "+
+			"Zero disables coalescing.")
+	flag.DurationVar(&minWorkloadWriteInterval, "min-workload-write-interval", 0,
+		"The least time between writes to the same PowerWorkload. Pod changes arriving sooner are held per PowerWorkload and written together once it passes. Zero disables the limit.")
+	flag.BoolVar(&trackSharedPool, "track-shared-pool", false,
+		"Record the complement of the node's reserved and exclusive cores as the Shared PowerWorkload's unallocatedCores, recomputed whenever a PowerWorkload changes.")
+	flag.DurationVar(&rolloutWindow, "rollout-window", 0,
+		"How long a PowerWorkload's frequency increase waits for the Node's pending frequency reductions to be applied first. "+
+			"Zero disables the ordering.")
+	flag.StringVar(&workloadNamespace, "workload-namespace", "",
+		"The namespace to keep every PowerWorkload in, instead of the namespace of the Pods using it. "+
+			"The Node's PowerWorkloads in other namespaces are moved into it.")
+	flag.StringVar(&managedNodeSelector, "managed-node-selector", "",
+		"Label selector, e.g. 'power.intel.com/appqos=enabled', a node must match for its Pods to be managed. "+
+			"Empty manages every node.")
+	flag.Float64Var(&appQoSWriteRate, "appqos-write-rate", 0,
+		"Maximum sustained writes per second to the node's AppQoS instance. Zero disables rate limiting.")
+	flag.IntVar(&appQoSWriteBurst, "appqos-write-burst", 5,
+		"Number of AppQoS writes allowed in a burst before the write rate applies.")
+	flag.DurationVar(&reconcileTimeout, "reconcile-timeout", 0,
+		"Maximum time a single Pod reconcile may take before it is aborted and requeued. Zero disables the deadline.")
+	flag.IntVar(&podWorkers, "pod-workers", 1,
+		"Number of Pods reconciled in parallel. Waiting Pods are handed to the workers highest PriorityClass first.")
+	flag.StringVar(&profileCleanup, "profile-cleanup", string(controllers.ProfileCleanupRetain),
+		"What to do with a PowerProfile once no PowerWorkload uses it: 'Retain', 'DeleteAppQoSProfile' to remove it from AppQoS "+
+			"once the PowerProfile is gone, or 'DeleteProfile' to delete operator-created PowerProfiles first.")
+	flag.BoolVar(&adoptAppQoSAllocations, "adopt-appqos-allocations", false,
+		"Create PowerWorkloads for the node's existing AppQoS Pools that have none, then exit.")
+	flag.BoolVar(&adoptDryRun, "adopt-dry-run", false,
+		"With --adopt-appqos-allocations, only log the PowerWorkloads that would be created.")
+	flag.StringVar(&adoptNamespace, "adopt-namespace", "",
+		"Namespace the adopted PowerWorkloads are created in. Empty uses --workload-namespace, or 'default' if that is unset too.")
+	flag.StringVar(&appQoSCredentialsLabel, "appqos-credentials-label", "",
+		"Node label whose value names the node's pool, selecting the AppQoS credentials under --appqos-credentials-dir. "+
+			"Empty uses the default credentials on every node.")
+	flag.StringVar(&appQoSCredentialsDir, "appqos-credentials-dir", "/etc/certs/pools",
+		"Directory holding a subdirectory of AppQoS credentials (appqos.crt, appqos.key, ca.crt) for each pool.")
+	flag.StringVar(&appQoSPodSelector, "appqos-pod-selector", "",
+		"Label selector of the AppQoS Pods, when AppQoS runs in its own Pod. The node's AppQoS Pod is looked up again "+
+			"whenever a connection to it fails. Empty reaches AppQoS on localhost.")
+	flag.StringVar(&appQoSPodNamespace, "appqos-pod-namespace", "default",
+		"Namespace of the AppQoS Pods matched by --appqos-pod-selector.")
+	flag.StringVar(&appQoSPodIPFamily, "appqos-pod-ip-family", "",
+		"IP family, IPv4 or IPv6, of the AppQoS Pod IP to use when the Pod has more than one. Empty uses the Pod's first IP.")
+	flag.DurationVar(&appQoSPodProbeTimeout, "appqos-pod-probe-timeout", 0,
+		"Timeout for probing each of the AppQoS Pod's IPs in turn, using the first to accept a connection. Zero disables the probe.")
+	flag.DurationVar(&appQoSVersionTimeout, "appqos-version-timeout", 5*time.Minute,
+		"How long to retry reading the AppQoS version at startup, e.g. while AppQoS is still starting. The Node Agent exits "+
+			"to be restarted if it still cannot be read. Zero reads it once.")
+	flag.BoolVar(&watchAppQoSAddress, "watch-appqos-address", false,
+		"Reach AppQoS on the host in the node's "+controllers.AppQoSAddressAnnotation+" annotation, falling back to --appqos-pod-selector or localhost, "+
+			"re-applying PowerProfiles and PowerWorkloads whenever the annotation changes.")
+	flag.StringVar(&defaultReleaseProfile, "default-release-profile", "",
+		"PowerProfile given to freed cores returned to the Default pool, held in a Pool of their own named "+controllers.ReleasePool+". Empty leaves them at AppQoS defaults.")
+	flag.BoolVar(&strictResourceRequests, "strict-resource-requests", false,
+		"Only manage Pods requesting a 'power.intel.com/' resource, ignoring the PowerProfile annotation.")
+	flag.BoolVar(&allowAppQoSOnlyProfiles, "allow-appqos-only-profiles", false,
+		"Accept Pods requesting a PowerProfile that exists only in AppQoS, without a PowerProfile CR.")
+	flag.IntVar(&cpuSetStabilizationAttempts, "cpuset-stabilization-attempts", 5,
+		"Maximum reads of a Container's cpuset while waiting for two consecutive reads to agree. One or fewer takes the first read.")
+	flag.DurationVar(&cpuSetStabilizationInterval, "cpuset-stabilization-interval", 100*time.Millisecond,
+		"Delay between reads of a Container's cpuset while it stabilizes.")
+	flag.StringVar(&scopeMode, "scope-mode", string(controllers.ScopeCore),
+		"Granularity at which the node's AppQoS instance applies frequencies: 'Core', or 'Package' to give every "+
+			"PowerWorkload on a package the PowerProfile with the highest maximum frequency among them and the Shared pool's.")
+	flag.BoolVar(&verifyCgroupCPUSet, "verify-cgroup-cpuset", false,
+		"Cross-check each Container's cores from the PodResources API against its cpuset cgroup, emitting a Warning Event if they diverge.")
+	flag.StringVar(&cgroup.CgroupPath, "cgroup-path", cgroup.CgroupPath,
+		"The root of the cgroup filesystem, read when verifying Container cpusets.")
+	flag.BoolVar(&checkCPUManagerPolicy, "check-cpu-manager-policy", true,
+		"Emit a Warning Event on Pods requesting a PowerProfile if the kubelet is not running the static CPU Manager policy.")
+	flag.BoolVar(&reportUnprofiledContainers, "report-unprofiled-containers", false,
+		"Log and emit a Warning Event for each Container given exclusive CPUs without a PowerProfile.")
+	flag.StringVar(&cpumanager.StatePath, "cpu-manager-state-file", cpumanager.StatePath,
+		"The kubelet's CPU Manager checkpoint file, read to find the CPU Manager policy.")
+	flag.StringVar(&memorymanager.StatePath, "memory-manager-state-file", memorymanager.StatePath,
+		"The kubelet's Memory Manager checkpoint file, read to warn when a Pod's exclusive CPUs are not on the NUMA nodes its memory is pinned to.")
+	flag.BoolVar(&annotateMultiProfilePods, "annotate-multi-profile-pods", false,
+		"Deprecated, has no effect: Pods whose Containers request different PowerProfiles are power-managed, so none are annotated.")
+	flag.IntVar(&retryBudget, "retry-budget", 0,
+		"Consecutive failed reconciles after which a Pod stops being requeued until it changes. Zero retries indefinitely.")
+	flag.DurationVar(&profileChangeCooldown, "profile-change-cooldown", 0,
+		"How long after a core's PowerProfile is switched that further switches of it are deferred. Zero disables the cooldown.")
+	flag.DurationVar(&crashLoopBackoff, "crash-loop-backoff", 0,
+		"How long power setup of a crash looping Pod is deferred for, and how long a restarted Container must stay running to be considered stable. Zero disables the check.")
+	flag.BoolVar(&reportPowerPods, "report-power-pods", false,
+		"Record each managed Pod's Node, cores and PowerProfiles in the status of a PowerPod of the same name.")
+	flag.BoolVar(&annotateAppliedCPUs, "annotate-applied-cpus", false,
+		"Annotate each managed Pod with '"+controllers.AppliedCPUsAnnotationPrefix+"<container>' holding the cores applied for the Container.")
+	flag.BoolVar(&addCleanupFinalizer, "add-cleanup-finalizer", false,
+		"Attach the '"+controllers.PodCleanupFinalizer+"' finalizer to each managed Pod, holding back its deletion until its cores are released from its PowerWorkloads.")
+	flag.DurationVar(&appQoSRestartCheckInterval, "appqos-restart-check-interval", 0,
+		"How often to check whether AppQoS has restarted and lost the Pools applied to it, re-applying PowerProfiles and PowerWorkloads if so. Zero disables the check.")
+	flag.BoolVar(&reassertOnStartup, "reassert-on-startup", false,
+		"Re-apply every PowerProfile and the node's PowerWorkloads to AppQoS on startup, even those whose generation was already applied.")
+	flag.IntVar(&packagePowerBudget, "package-power-budget", 0,
+		"The node's package power budget in watts, against which the power committed by active PowerProfiles is reported. Zero disables the report.")
+	flag.Float64Var(&coreWattsPerGHz, "core-watts-per-ghz", controllers.DefaultCoreWattsPerGHz,
+		"The estimated power a core draws for every GHz of its PowerProfile's maximum frequency.")
+	flag.IntVar(&freeExclusiveCPUsThreshold, "free-exclusive-cpus-threshold", 0,
+		"Number of free exclusive CPUs, exported as power_exclusive_cpus_free_threshold, below which alerts should fire. Zero leaves it unset.")
+	flag.BoolVar(&taintAppQoSUnreachable, "taint-appqos-unreachable", false,
+		"Taint the node with "+controllers.AppQoSUnreachableTaint+":NoSchedule while its AppQoS instance is unreachable.")
+	flag.IntVar(&maxWorkloadNodes, "max-workload-nodes", 0,
+		"The most Nodes whose Pods may have cores in a single PowerWorkload, past which a PowerProfile's PowerWorkload is sharded. Each shard holds one Node's cores, so values above 1 act as 1. Zero disables sharding.")
+	flag.StringVar(&allocationAPIAddr, "allocation-api-addr", "",
+		"The address the AllocationService gRPC API, exposing the node's core-to-profile mapping, binds to. Empty disables the API.")
+	flag.BoolVar(&debugAllocations, "debug-allocations", false,
+		"Serve the node agent's internal State of Guaranteed Pods and their cores as JSON on "+controllers.AllocationsDebugPath+" of the metrics server.")
+	flag.StringVar(&allocationWebhookURL, "allocation-webhook-url", "",
+		"URL notified with a JSON event whenever cores are allocated to or released from a PowerWorkload. Empty disables notifications.")
+	flag.DurationVar(&allocationWebhookTimeout, "allocation-webhook-timeout", 5*time.Second,
+		"Maximum time a single allocation notification attempt may take.")
+	flag.IntVar(&allocationWebhookRetries, "allocation-webhook-retries", 3,
+		"Number of times a failed allocation notification is retried.")
+	flag.DurationVar(&allocationWebhookRetryInterval, "allocation-webhook-retry-interval", time.Second,
+		"Delay between attempts to send an allocation notification.")
+	flag.StringVar(&decisionSinkURL, "decision-sink-url", "",
+		"URL to POST a JSON record of every allocation decision to, with its Pod, PowerWorkload, PowerProfile and cores. Empty disables the records.")
+	flag.DurationVar(&decisionSinkTimeout, "decision-sink-timeout", 5*time.Second,
+		"Timeout for each decision record sent to --decision-sink-url.")
+	flag.BoolVar(&manageEphemeralContainers, "manage-ephemeral-containers", false,
+		"Apply PowerProfiles to the exclusive cores of a Pod's running ephemeral containers, releasing them when the containers exit.")
+	// --zap-log-level=2 or above also logs the bodies of requests to and responses from AppQoS
+	logOpts := zap.Options{}
+	logOpts.BindFlags(flag.CommandLine)
+	// BindFlags resets Development, which the Node Agent has always logged in unless --zap-devel=false is given
+	logOpts.Development = true
 	flag.Parse()
 
-	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&logOpts)))
+
+	profileCleanupPolicy, err := controllers.ParseProfileCleanupPolicy(profileCleanup)
+	if err != nil {
+		setupLog.Error(err, "invalid --profile-cleanup")
+		os.Exit(1)
+	}
+	scope, err := controllers.ParseScopeMode(scopeMode)
+	if err != nil {
+		setupLog.Error(err, "invalid --scope-mode")
+		os.Exit(1)
+	}
+	if annotateMultiProfilePods {
+		setupLog.Info("--annotate-multi-profile-pods is deprecated and has no effect, as a Pod's Containers may request different PowerProfiles")
+	}
+	if maxWorkloadNodes > 1 {
+		setupLog.Info("a PowerWorkload's NodeInfo describes a single Node, so each shard holds one Node's cores", "max-workload-nodes", maxWorkloadNodes)
+	}
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:             scheme,
@@ -71,11 +280,85 @@ func main() {
 		os.Exit(1)
 	}
 
-	appQoSClient, err := appqos.NewOperatorAppQoSClient()
+	// The default metrics endpoint does not negotiate OpenMetrics, which is needed to expose exemplars
+	err = mgr.AddMetricsExtraHandler("/openmetrics", promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{
+		EnableOpenMetrics: true,
+	}))
+	if err != nil {
+		setupLog.Error(err, "unable to add OpenMetrics handler")
+		os.Exit(1)
+	}
+
+	// The manager's client is only usable once the manager has started, so objects needed at startup are read directly
+	directClient, err := client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme})
+	if err != nil {
+		setupLog.Error(err, "unable to create client")
+		os.Exit(1)
+	}
+
+	appQoSClient, err := controllers.NewAppQoSClientForNode(context.Background(), directClient, os.Getenv("NODE_NAME"), appQoSCredentialsLabel, appQoSCredentialsDir)
 	if err != nil {
 		setupLog.Error(err, "unable to create AppQoSClient")
 		os.Exit(1)
 	}
+	appQoSClient.SetWriteRateLimit(appQoSWriteRate, appQoSWriteBurst)
+	var appQoSAddressResolver func() (string, error)
+	if appQoSPodSelector != "" {
+		appQoSPodResolver := &controllers.AppQoSPodResolver{
+			Client:       directClient,
+			NodeName:     os.Getenv("NODE_NAME"),
+			Namespace:    appQoSPodNamespace,
+			Selector:     appQoSPodSelector,
+			IPFamily:     corev1.IPFamily(appQoSPodIPFamily),
+			ProbeTimeout: appQoSPodProbeTimeout,
+		}
+		appQoSAddressResolver = appQoSPodResolver.Resolve
+	}
+	if watchAppQoSAddress {
+		appQoSNodeAddressResolver := &controllers.AppQoSNodeAddressResolver{
+			Client:   directClient,
+			NodeName: os.Getenv("NODE_NAME"),
+			Fallback: appQoSAddressResolver,
+		}
+		appQoSAddressResolver = appQoSNodeAddressResolver.Resolve
+	}
+	if appQoSAddressResolver != nil {
+		appQoSClient.SetAddressResolver(controllers.AppQoSClientAddress, appQoSAddressResolver)
+	}
+	controllers.ObserveAppQoSReachability(os.Getenv("NODE_NAME"), appQoSClient)
+	appQoSClient.SetPayloadLogger(ctrl.Log.WithName("appqos"))
+
+	appQoSIncompatibility := ""
+	appQoSVersion, err := controllers.WaitForAppQoSVersion(appQoSClient, appQoSVersionTimeout)
+	if err != nil && appQoSVersion == "" {
+		// An unreadable version says nothing about whether the node can be managed, so rather than leaving it
+		// unmanaged the Node Agent is restarted to negotiate again
+		setupLog.Error(err, "unable to read AppQoS version")
+		os.Exit(1)
+	}
+	if err != nil {
+		setupLog.Error(err, "unable to negotiate AppQoS version, node will not be managed", "version", appQoSVersion)
+		appQoSIncompatibility = err.Error()
+	} else {
+		setupLog.Info("AppQoS version supported", "version", appQoSVersion)
+	}
+
+	if adoptAppQoSAllocations {
+		// The adopted PowerWorkloads must be where the PowerWorkload controller will look for them
+		if adoptNamespace == "" {
+			adoptNamespace = workloadNamespace
+		}
+		if adoptNamespace == "" {
+			adoptNamespace = "default"
+		}
+		adopted, err := controllers.AdoptAppQoSAllocations(context.Background(), directClient, appQoSClient, ctrl.Log.WithName("adoption"), adoptNamespace, os.Getenv("NODE_NAME"), adoptDryRun)
+		if err != nil {
+			setupLog.Error(err, "unable to adopt AppQoS allocations")
+			os.Exit(1)
+		}
+		setupLog.Info("AppQoS allocations adopted", "powerWorkloads", len(adopted), "dryRun", adoptDryRun)
+		os.Exit(0)
+	}
 
 	powerNodeState, err := podstate.NewState()
 	if err != nil {
@@ -88,42 +371,182 @@ func main() {
 		setupLog.Error(err, "unable to create internal client")
 		os.Exit(1)
 	}
-	if err = (&controllers.PowerProfileReconciler{
-		Client:       mgr.GetClient(),
-		Log:          ctrl.Log.WithName("controllers").WithName("PowerProfile"),
-		Scheme:       mgr.GetScheme(),
-		AppQoSClient: appQoSClient,
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "PowerProfile")
-		os.Exit(1)
-	}
-	if err = (&controllers.PowerWorkloadReconciler{
-		Client:       mgr.GetClient(),
-		Log:          ctrl.Log.WithName("controllers").WithName("PowerWorkload"),
-		Scheme:       mgr.GetScheme(),
-		AppQoSClient: appQoSClient,
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "PowerWorkload")
-		os.Exit(1)
+	powerNodeReconciler := &controllers.PowerNodeReconciler{
+		Client:                     mgr.GetClient(),
+		Log:                        ctrl.Log.WithName("controllers").WithName("PowerNode"),
+		Scheme:                     mgr.GetScheme(),
+		AppQoSClient:               appQoSClient,
+		AppQoSIncompatibility:      appQoSIncompatibility,
+		AppQoSVersion:              appQoSVersion,
+		PackagePowerBudget:         packagePowerBudget,
+		CoreWattsPerGHz:            coreWattsPerGHz,
+		FreeExclusiveCPUsThreshold: freeExclusiveCPUsThreshold,
+		TaintAppQoSUnreachable:     taintAppQoSUnreachable,
 	}
-	if err = (&controllers.PowerNodeReconciler{
-		Client:       mgr.GetClient(),
-		Log:          ctrl.Log.WithName("controllers").WithName("PowerNode"),
-		Scheme:       mgr.GetScheme(),
-		AppQoSClient: appQoSClient,
-	}).SetupWithManager(mgr); err != nil {
+	if err = powerNodeReconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "PowerNode")
 		os.Exit(1)
 	}
-	if err = (&controllers.PowerPodReconciler{
-		Client:             mgr.GetClient(),
-		Log:                ctrl.Log.WithName("controllers").WithName("PowerPod"),
-		Scheme:             mgr.GetScheme(),
-		State:              *powerNodeState,
-		PodResourcesClient: *podResourcesClient,
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "PowerPod")
-		os.Exit(1)
+
+	// Only manage the node if its AppQoS instance is a supported version
+	if appQoSIncompatibility == "" {
+		powerProfileReconciler := &controllers.PowerProfileReconciler{
+			Client:            mgr.GetClient(),
+			Log:               ctrl.Log.WithName("controllers").WithName("PowerProfile"),
+			Scheme:            mgr.GetScheme(),
+			AppQoSClient:      appQoSClient,
+			Recorder:          mgr.GetEventRecorderFor("powerprofile-controller"),
+			WorkloadNamespace: workloadNamespace,
+		}
+		powerWorkloadReconciler := &controllers.PowerWorkloadReconciler{
+			Client:                mgr.GetClient(),
+			Log:                   ctrl.Log.WithName("controllers").WithName("PowerWorkload"),
+			Scheme:                mgr.GetScheme(),
+			AppQoSClient:          appQoSClient,
+			ProfileCleanup:        profileCleanupPolicy,
+			DefaultReleaseProfile: defaultReleaseProfile,
+			ScopeMode:             scope,
+			RolloutWindow:         rolloutWindow,
+			WorkloadNamespace:     workloadNamespace,
+			ReassertOnStartup:     reassertOnStartup,
+		}
+		// The restart watcher, the AppQoS address watch and the startup reassertion share the channels re-applying PowerProfiles and PowerWorkloads
+		reapplyProfiles := make(chan event.GenericEvent)
+		reapplyWorkloads := make(chan event.GenericEvent)
+		if appQoSRestartCheckInterval > 0 || watchAppQoSAddress || reassertOnStartup {
+			powerProfileReconciler.Reapply = reapplyProfiles
+			powerWorkloadReconciler.Reapply = reapplyWorkloads
+		}
+		if appQoSRestartCheckInterval > 0 {
+			restartWatcher := controllers.NewAppQoSRestartWatcher(mgr.GetClient(), appQoSClient, ctrl.Log.WithName("appqos-restart"), os.Getenv("NODE_NAME"), appQoSRestartCheckInterval)
+			restartWatcher.Profiles = reapplyProfiles
+			restartWatcher.Workloads = reapplyWorkloads
+			err = mgr.Add(restartWatcher)
+			if err != nil {
+				setupLog.Error(err, "unable to add AppQoS restart watcher")
+				os.Exit(1)
+			}
+		}
+		if reassertOnStartup {
+			err = mgr.Add(&controllers.StartupReassertion{
+				Client:    mgr.GetClient(),
+				Log:       ctrl.Log.WithName("startup-reassertion"),
+				NodeName:  os.Getenv("NODE_NAME"),
+				Profiles:  reapplyProfiles,
+				Workloads: reapplyWorkloads,
+			})
+			if err != nil {
+				setupLog.Error(err, "unable to add startup reassertion")
+				os.Exit(1)
+			}
+		}
+		if watchAppQoSAddress {
+			appQoSAddressReconciler := &controllers.AppQoSAddressReconciler{
+				Client:       mgr.GetClient(),
+				Log:          ctrl.Log.WithName("controllers").WithName("AppQoSAddress"),
+				AppQoSClient: appQoSClient,
+				NodeName:     os.Getenv("NODE_NAME"),
+				Profiles:     reapplyProfiles,
+				Workloads:    reapplyWorkloads,
+			}
+			if err = appQoSAddressReconciler.SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", "AppQoSAddress")
+				os.Exit(1)
+			}
+		}
+
+		if err = powerProfileReconciler.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "PowerProfile")
+			os.Exit(1)
+		}
+		if err = powerWorkloadReconciler.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "PowerWorkload")
+			os.Exit(1)
+		}
+		if trackSharedPool {
+			sharedPoolReconciler := &controllers.SharedPoolReconciler{
+				Client:   mgr.GetClient(),
+				Log:      ctrl.Log.WithName("controllers").WithName("SharedPool"),
+				NodeName: os.Getenv("NODE_NAME"),
+			}
+			if err = sharedPoolReconciler.SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", "SharedPool")
+				os.Exit(1)
+			}
+		}
+		powerPodReconciler := &controllers.PowerPodReconciler{
+			Client:                      mgr.GetClient(),
+			Log:                         ctrl.Log.WithName("controllers").WithName("PowerPod"),
+			Scheme:                      mgr.GetScheme(),
+			State:                       powerNodeState,
+			PodResourcesClient:          *podResourcesClient,
+			DeletionCoalesceWindow:      deletionCoalesceWindow,
+			MinWorkloadWriteInterval:    minWorkloadWriteInterval,
+			WorkloadNamespace:           workloadNamespace,
+			CPUSetStabilizationAttempts: cpuSetStabilizationAttempts,
+			CPUSetStabilizationInterval: cpuSetStabilizationInterval,
+			ReconcileTimeout:            reconcileTimeout,
+			MaxConcurrentReconciles:     podWorkers,
+			ManagedNodeSelector:         managedNodeSelector,
+			AppQoSClient:                appQoSClient,
+			Recorder:                    mgr.GetEventRecorderFor("powerpod-controller"),
+			ProfileResolvers: []controllers.ProfileResolver{
+				controllers.ResourceRequestResolver{},
+				controllers.AnnotationProfileResolver{},
+			},
+			StrictResourceRequests:     strictResourceRequests,
+			AllowAppQoSOnlyProfiles:    allowAppQoSOnlyProfiles,
+			VerifyCgroupCPUSet:         verifyCgroupCPUSet,
+			CheckCPUManagerPolicy:      checkCPUManagerPolicy,
+			ReportUnprofiledContainers: reportUnprofiledContainers,
+			ProfileChangeCooldown:      profileChangeCooldown,
+			MaxWorkloadNodes:           maxWorkloadNodes,
+			RetryBudget:                retryBudget,
+			ManageEphemeralContainers:  manageEphemeralContainers,
+			CrashLoopBackoff:           crashLoopBackoff,
+			ReportPowerPods:            reportPowerPods,
+			AnnotateAppliedCPUs:        annotateAppliedCPUs,
+			AddCleanupFinalizer:        addCleanupFinalizer,
+		}
+		if allocationWebhookURL != "" {
+			powerPodReconciler.AllocationNotifier = controllers.NewAllocationNotifier(allocationWebhookURL, allocationWebhookTimeout,
+				allocationWebhookRetries, allocationWebhookRetryInterval, ctrl.Log.WithName("allocation-notifier"))
+		}
+		if decisionSinkURL != "" {
+			powerPodReconciler.DecisionSink = controllers.NewHTTPDecisionSink(decisionSinkURL, decisionSinkTimeout, ctrl.Log.WithName("decision-sink"))
+		}
+
+		if err = powerPodReconciler.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "PowerPod")
+			os.Exit(1)
+		}
+		powerNodeReconciler.State = powerPodReconciler.State
+
+		err = mgr.AddMetricsExtraHandler(controllers.TopologySnapshotPath, controllers.NewTopologySnapshotHandler(mgr.GetClient(), powerPodReconciler.State))
+		if err != nil {
+			setupLog.Error(err, "unable to add topology snapshot handler")
+			os.Exit(1)
+		}
+
+		if debugAllocations {
+			err = mgr.AddMetricsExtraHandler(controllers.AllocationsDebugPath, controllers.NewAllocationsDebugHandler(powerPodReconciler.State))
+			if err != nil {
+				setupLog.Error(err, "unable to add allocations debug handler")
+				os.Exit(1)
+			}
+		}
+
+		if allocationAPIAddr != "" {
+			err = mgr.Add(&controllers.AllocationServer{
+				Address: allocationAPIAddr,
+				State:   powerPodReconciler.State,
+				Log:     ctrl.Log.WithName("allocation-api"),
+			})
+			if err != nil {
+				setupLog.Error(err, "unable to add AllocationService server")
+				os.Exit(1)
+			}
+		}
 	}
 	// +kubebuilder:scaffold:builder
 
@@ -0,0 +1,140 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// AllocationEventType is whether cores were allocated to or released from a PowerWorkload
+type AllocationEventType string
+
+const (
+	// AllocationEventAllocated is sent when a Pod's cores are added to a PowerWorkload
+	AllocationEventAllocated AllocationEventType = "Allocated"
+
+	// AllocationEventReleased is sent when a Pod's cores are removed from a PowerWorkload
+	AllocationEventReleased AllocationEventType = "Released"
+)
+
+// AllocationEvent is the body of a webhook notification
+type AllocationEvent struct {
+	Type      AllocationEventType `json:"type"`
+	Node      string              `json:"node"`
+	Profile   string              `json:"profile"`
+	Cores     []int               `json:"cores"`
+	PodUID    string              `json:"podUID"`
+	Timestamp time.Time           `json:"timestamp"`
+}
+
+// AllocationNotifier posts an AllocationEvent to an external webhook whenever cores are allocated or released
+type AllocationNotifier struct {
+	URL           string
+	Timeout       time.Duration
+	Retries       int
+	RetryInterval time.Duration
+	Log           logr.Logger
+
+	client *http.Client
+}
+
+// NewAllocationNotifier returns an AllocationNotifier posting to the URL, retrying a failed notification up to
+// retries times. Each attempt is abandoned after the timeout
+func NewAllocationNotifier(url string, timeout time.Duration, retries int, retryInterval time.Duration, logger logr.Logger) *AllocationNotifier {
+	return &AllocationNotifier{
+		URL:           url,
+		Timeout:       timeout,
+		Retries:       retries,
+		RetryInterval: retryInterval,
+		Log:           logger,
+		client:        &http.Client{},
+	}
+}
+
+// notify sends the event in the background so a slow or unavailable receiver never holds up a reconcile.
+// It does nothing if no notifier is configured or there are no cores to report
+func (n *AllocationNotifier) notify(eventType AllocationEventType, node string, profile string, podUID string, cores []int) {
+	if n == nil || n.URL == "" || len(cores) == 0 {
+		return
+	}
+
+	event := AllocationEvent{
+		Type:      eventType,
+		Node:      node,
+		Profile:   profile,
+		Cores:     cores,
+		PodUID:    podUID,
+		Timestamp: time.Now().UTC(),
+	}
+	go func() {
+		err := n.send(event)
+		if err != nil {
+			n.Log.Error(err, "error sending allocation notification", "type", event.Type, "profile", event.Profile, "podUID", event.PodUID)
+		}
+	}()
+}
+
+// send posts the event, retrying until it is accepted or the retries are used up
+func (n *AllocationNotifier) send(event AllocationEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		err = n.post(body)
+		if err == nil || attempt >= n.Retries {
+			return err
+		}
+
+		time.Sleep(n.RetryInterval)
+	}
+}
+
+func (n *AllocationNotifier) post(body []byte) error {
+	ctx := context.Background()
+	if n.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, n.Timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("allocation webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
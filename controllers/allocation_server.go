@@ -0,0 +1,102 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"net"
+	"sort"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
+
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/allocationapi"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/podstate"
+)
+
+// AllocationServer serves the node agent's live core-to-PowerProfile mapping over the AllocationService gRPC API
+type AllocationServer struct {
+	allocationapi.UnimplementedAllocationServiceServer
+
+	// Address is the address the gRPC server listens on, such as ':9091'
+	Address string
+	State   *podstate.State
+	Log     logr.Logger
+}
+
+// GetAllocations returns the exclusive cores of the Guaranteed Pods held in the State and the PowerProfile each
+// is assigned, filtered to the Node and PowerProfile in the request if given
+func (s *AllocationServer) GetAllocations(ctx context.Context, req *allocationapi.GetAllocationsRequest) (*allocationapi.GetAllocationsResponse, error) {
+	response := &allocationapi.GetAllocationsResponse{
+		Allocations: make([]*allocationapi.CoreAllocation, 0),
+	}
+
+	for _, pod := range s.State.GetGuaranteedPods() {
+		if req.GetNode() != "" && pod.Node != req.GetNode() {
+			continue
+		}
+
+		for _, container := range pod.Containers {
+			if req.GetProfile() != "" && container.PowerProfile != req.GetProfile() {
+				continue
+			}
+
+			for _, core := range container.ExclusiveCPUs {
+				response.Allocations = append(response.Allocations, &allocationapi.CoreAllocation{
+					Node:      pod.Node,
+					Core:      int32(core),
+					Profile:   container.PowerProfile,
+					Pod:       pod.Name,
+					PodUid:    pod.UID,
+					Container: container.Name,
+				})
+			}
+		}
+	}
+	sort.Slice(response.Allocations, func(i, j int) bool {
+		if response.Allocations[i].Node != response.Allocations[j].Node {
+			return response.Allocations[i].Node < response.Allocations[j].Node
+		}
+		return response.Allocations[i].Core < response.Allocations[j].Core
+	})
+
+	return response, nil
+}
+
+// Serve serves the AllocationService on the listener until stop is closed
+func (s *AllocationServer) Serve(listener net.Listener, stop <-chan struct{}) error {
+	server := grpc.NewServer()
+	allocationapi.RegisterAllocationServiceServer(server, s)
+
+	go func() {
+		<-stop
+		server.GracefulStop()
+	}()
+
+	return server.Serve(listener)
+}
+
+// Start listens on the Address and serves the AllocationService, so the server can be run by the manager
+func (s *AllocationServer) Start(stop <-chan struct{}) error {
+	listener, err := net.Listen("tcp", s.Address)
+	if err != nil {
+		return err
+	}
+
+	s.Log.Info("serving AllocationService", "address", s.Address)
+	return s.Serve(listener, stop)
+}
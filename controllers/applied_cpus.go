@@ -0,0 +1,70 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/cpuset"
+)
+
+// AppliedCPUsAnnotationPrefix is followed by a Container's name to form the annotation set on its Pod holding the
+// cores the Container's PowerProfile was applied to, as a range-compressed list such as '2-4,7'. Container names
+// too long for an annotation key are shortened with a hash
+const AppliedCPUsAnnotationPrefix = "power.intel.com/applied-cpus."
+
+// appliedCPUsAnnotation returns the applied-cpus annotation key of the Container
+func appliedCPUsAnnotation(containerName string) string {
+	parts := strings.SplitN(AppliedCPUsAnnotationPrefix+containerName, "/", 2)
+	return parts[0] + "/" + boundedLabelName(parts[1])
+}
+
+// annotateAppliedCPUs sets an applied-cpus annotation on the Pod for each of its power-managed Containers, removing
+// those of Containers that no longer have cores applied. Nothing is written while workload writes are read-only
+func (r *PowerPodReconciler) annotateAppliedCPUs(ctx context.Context, pod *corev1.Pod, powerContainers []powerv1alpha1.Container) {
+	if r.workloadWritesReadOnly() {
+		return
+	}
+
+	annotations := make(map[string]string)
+	for key, value := range pod.GetAnnotations() {
+		if !strings.HasPrefix(key, AppliedCPUsAnnotationPrefix) {
+			annotations[key] = value
+		}
+	}
+	for _, container := range powerContainers {
+		cores := cpuset.NewCPUSet(container.ExclusiveCPUs...)
+		annotations[appliedCPUsAnnotation(container.Name)] = cores.String()
+	}
+
+	if reflect.DeepEqual(annotations, pod.GetAnnotations()) || (len(annotations) == 0 && len(pod.GetAnnotations()) == 0) {
+		return
+	}
+
+	patch := client.MergeFrom(pod.DeepCopy())
+	pod.SetAnnotations(annotations)
+	err := r.Patch(ctx, pod, patch)
+	if err != nil {
+		r.Log.Error(err, "error updating Pod's applied-cpus annotations", "pod", client.ObjectKey{Namespace: pod.GetNamespace(), Name: pod.GetName()}.String())
+	}
+}
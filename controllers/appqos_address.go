@@ -0,0 +1,161 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// AppQoSAddressAnnotation on a Node overrides the host of the Node's AppQoS instance, keeping the scheme and port
+// of AppQoSClientAddress. It lets AppQoS be moved to another host without restarting the Node Agent
+const AppQoSAddressAnnotation = "power.intel.com/appqos-address"
+
+// AppQoSPodResolver finds the AppQoS Pod on a Node, for when AppQoS runs in a Pod of its own rather than on the
+// Node Agent's host network. Given to the AppQoS client's SetAddressResolver, it lets a rescheduled AppQoS Pod be
+// followed to its new IP
+type AppQoSPodResolver struct {
+	Client    client.Reader
+	NodeName  string
+	Namespace string
+
+	// Selector is the label selector matching the AppQoS Pods
+	Selector string
+
+	// IPFamily, if set to IPv4 or IPv6, prefers the Pod's IP of that family, for a dual-stack or multi-homed AppQoS
+	// Pod whose first IP isn't on the cluster's Pod network
+	IPFamily corev1.IPFamily
+
+	// ProbeTimeout, if set, probes each of the Pod's IPs in turn with a TCP connection to the port of
+	// AppQoSClientAddress, using the first to accept. Zero uses the preferred IP without probing
+	ProbeTimeout time.Duration
+}
+
+// Resolve returns the IP of the Node's running AppQoS Pod
+func (r *AppQoSPodResolver) Resolve() (string, error) {
+	selector, err := labels.Parse(r.Selector)
+	if err != nil {
+		return "", err
+	}
+
+	pods := &corev1.PodList{}
+	err = r.Client.List(context.TODO(), pods, client.InNamespace(r.Namespace), client.MatchingLabelsSelector{Selector: selector})
+	if err != nil {
+		return "", err
+	}
+
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == r.NodeName && pod.Status.Phase == corev1.PodRunning && pod.Status.PodIP != "" && pod.ObjectMeta.DeletionTimestamp.IsZero() {
+			return r.selectPodIP(pod)
+		}
+	}
+
+	return "", fmt.Errorf("no running AppQoS Pod matching '%s' found on Node '%s'", r.Selector, r.NodeName)
+}
+
+// selectPodIP returns the Pod's IPs of IPFamily ahead of the others, then probes them in that order if a
+// ProbeTimeout is set. If none of them answer the preferred IP is used, so the failed connection is reported
+func (r *AppQoSPodResolver) selectPodIP(pod corev1.Pod) (string, error) {
+	podIPs := make([]string, 0)
+	for _, podIP := range pod.Status.PodIPs {
+		podIPs = append(podIPs, podIP.IP)
+	}
+	if len(podIPs) == 0 {
+		podIPs = append(podIPs, pod.Status.PodIP)
+	}
+
+	preferred := make([]string, 0)
+	others := make([]string, 0)
+	for _, podIP := range podIPs {
+		if r.IPFamily == "" || ipFamily(podIP) == r.IPFamily {
+			preferred = append(preferred, podIP)
+		} else {
+			others = append(others, podIP)
+		}
+	}
+	candidates := append(preferred, others...)
+
+	if r.ProbeTimeout <= 0 || len(candidates) == 1 {
+		return candidates[0], nil
+	}
+
+	address, err := url.Parse(AppQoSClientAddress)
+	if err != nil {
+		return "", err
+	}
+	for _, candidate := range candidates {
+		conn, err := net.DialTimeout("tcp", net.JoinHostPort(candidate, address.Port()), r.ProbeTimeout)
+		if err == nil {
+			conn.Close()
+			return candidate, nil
+		}
+	}
+
+	return candidates[0], nil
+}
+
+// ipFamily returns the family of the IP address
+func ipFamily(ip string) corev1.IPFamily {
+	parsed := net.ParseIP(ip)
+	if parsed != nil && parsed.To4() == nil {
+		return corev1.IPv6Protocol
+	}
+
+	return corev1.IPv4Protocol
+}
+
+// AppQoSNodeAddressResolver finds the host of a Node's AppQoS instance from the Node's AppQoSAddressAnnotation.
+// Given to the AppQoS client's SetAddressResolver, it lets AppQoS be relocated by annotating the Node
+type AppQoSNodeAddressResolver struct {
+	Client   client.Reader
+	NodeName string
+
+	// Fallback, if set, resolves the host when the Node isn't annotated, such as an AppQoSPodResolver. Without it
+	// the host of AppQoSClientAddress is used
+	Fallback func() (string, error)
+}
+
+// Resolve returns the host in the Node's AppQoSAddressAnnotation, or the fallback host if it has none
+func (r *AppQoSNodeAddressResolver) Resolve() (string, error) {
+	node := &corev1.Node{}
+	err := r.Client.Get(context.TODO(), client.ObjectKey{Name: r.NodeName}, node)
+	if err != nil {
+		return "", err
+	}
+
+	if host := node.Annotations[AppQoSAddressAnnotation]; host != "" {
+		return host, nil
+	}
+
+	if r.Fallback != nil {
+		return r.Fallback()
+	}
+
+	address, err := url.Parse(AppQoSClientAddress)
+	if err != nil {
+		return "", err
+	}
+
+	return address.Hostname(), nil
+}
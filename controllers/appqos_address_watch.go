@@ -0,0 +1,108 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/appqos"
+)
+
+// AppQoSAddressReconciler watches the Node's AppQoSAddressAnnotation. When it changes the AppQoS client's cached
+// host is dropped, so requests follow AppQoS to its new address, and every PowerProfile and the Node's
+// PowerWorkloads are sent to their controllers to be applied to the AppQoS instance found there
+type AppQoSAddressReconciler struct {
+	client.Client
+	Log          logr.Logger
+	AppQoSClient *appqos.AppQoSClient
+	NodeName     string
+
+	// Profiles and Workloads receive the PowerProfiles and PowerWorkloads to reconcile again once AppQoS has moved.
+	// The PowerProfile and PowerWorkload controllers watch them through their Reapply fields
+	Profiles  chan event.GenericEvent
+	Workloads chan event.GenericEvent
+
+	// address is the value of the annotation when the Node was last reconciled, and observed whether it has been yet
+	address  string
+	observed bool
+
+	// stop is the manager's stop channel, which ends a re-apply that would otherwise block on the channels during
+	// shutdown
+	stop <-chan struct{}
+}
+
+// InjectStopChannel is called by the manager with its stop channel when the controller is set up
+func (r *AppQoSAddressReconciler) InjectStopChannel(stop <-chan struct{}) error {
+	r.stop = stop
+	return nil
+}
+
+func (r *AppQoSAddressReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	logger := r.Log.WithValues("node", req.NamespacedName)
+
+	if req.NamespacedName.Name != r.NodeName {
+		return ctrl.Result{}, nil
+	}
+
+	node := &corev1.Node{}
+	err := r.Get(ctx, req.NamespacedName, node)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	address := node.Annotations[AppQoSAddressAnnotation]
+	if !r.observed {
+		// The address resolver reads the annotation itself, so nothing can have been sent to an old address yet
+		r.address = address
+		r.observed = true
+		return ctrl.Result{}, nil
+	}
+	if address == r.address {
+		return ctrl.Result{}, nil
+	}
+
+	logger.Info("AppQoS address annotation changed, re-applying PowerProfiles and PowerWorkloads", "previous", r.address, "address", address)
+	r.AppQoSClient.ForgetResolvedHost(AppQoSClientAddress)
+
+	err = reapplyToNode(ctx, r.Client, r.NodeName, r.Profiles, r.Workloads, r.stop)
+	if err != nil {
+		logger.Error(err, "error re-applying PowerProfiles and PowerWorkloads")
+		return ctrl.Result{}, err
+	}
+	r.address = address
+
+	return ctrl.Result{}, nil
+}
+
+func (r *AppQoSAddressReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("appqosaddress").
+		For(&corev1.Node{}).
+		Complete(r)
+}
@@ -0,0 +1,111 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/appqos"
+)
+
+// AdoptAppQoSAllocations creates a PowerWorkload for every Pool in the node's AppQoS instance that has a Power
+// Profile and cores but no PowerWorkload yet, so allocations made before the operator was installed are managed
+// by it from then on. The PowerWorkload takes the Pool's name, which is how the PowerWorkload controller finds
+// the Pool again. With dryRun set nothing is created; the PowerWorkloads that would be created are returned
+func AdoptAppQoSAllocations(ctx context.Context, c client.Client, ac *appqos.AppQoSClient, logger logr.Logger, namespace string, nodeName string, dryRun bool) ([]powerv1alpha1.PowerWorkload, error) {
+	adopted := make([]powerv1alpha1.PowerWorkload, 0)
+
+	pools, err := ac.GetPools(AppQoSClientAddress)
+	if err != nil {
+		return adopted, err
+	}
+
+	profiles, err := ac.GetPowerProfiles(AppQoSClientAddress)
+	if err != nil {
+		return adopted, err
+	}
+
+	profileNames := make(map[int]string)
+	for _, profile := range profiles {
+		if profile.ID != nil && profile.Name != nil {
+			profileNames[*profile.ID] = *profile.Name
+		}
+	}
+
+	for _, pool := range pools {
+		if pool.Name == nil || *pool.Name == appqos.SharedPoolName || *pool.Name == appqos.DefaultPoolName || *pool.Name == ReleasePool {
+			continue
+		}
+
+		if pool.PowerProfile == nil || pool.Cores == nil || len(*pool.Cores) == 0 {
+			continue
+		}
+
+		poolLogger := logger.WithValues("pool", *pool.Name)
+
+		profileName, ok := profileNames[*pool.PowerProfile]
+		if !ok {
+			poolLogger.Info("Power Profile of Pool not found in AppQoS, skipping", "profileID", *pool.PowerProfile)
+			continue
+		}
+
+		existing := &powerv1alpha1.PowerWorkload{}
+		err = c.Get(ctx, client.ObjectKey{Name: *pool.Name, Namespace: namespace}, existing)
+		if err == nil {
+			poolLogger.Info("PowerWorkload already exists for Pool, skipping")
+			continue
+		}
+		if !errors.IsNotFound(err) {
+			return adopted, err
+		}
+
+		workload := powerv1alpha1.PowerWorkload{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      *pool.Name,
+				Namespace: namespace,
+			},
+			Spec: powerv1alpha1.PowerWorkloadSpec{
+				Name: *pool.Name,
+				Node: powerv1alpha1.NodeInfo{
+					Name:   nodeName,
+					CpuIds: *pool.Cores,
+				},
+				PowerProfile: profileName,
+			},
+		}
+
+		if dryRun {
+			poolLogger.Info("Would adopt Pool as PowerWorkload", "profile", profileName, "cores", *pool.Cores)
+		} else {
+			err = c.Create(ctx, &workload)
+			if err != nil {
+				return adopted, err
+			}
+			poolLogger.Info("Adopted Pool as PowerWorkload", "profile", profileName, "cores", *pool.Cores)
+		}
+
+		adopted = append(adopted, workload)
+	}
+
+	return adopted, nil
+}
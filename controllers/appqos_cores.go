@@ -0,0 +1,115 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// AppQoSCoresCondition reports whether the node's AppQoS instance knows of every core the Kubelet reports.
+	// AppQoS running with a constrained view of the node, such as in a container limited to some of its CPUs,
+	// fails to apply PowerProfiles to the cores it doesn't know of
+	AppQoSCoresCondition = "AppQoSCoresMatch"
+)
+
+// kubeletCores returns the cores the Kubelet reports the Node as having, numbered from zero up to its CPU capacity.
+// The bool is false if the Node doesn't report a CPU capacity
+func kubeletCores(node *corev1.Node) ([]int, bool) {
+	capacity, exists := node.Status.Capacity[corev1.ResourceCPU]
+	if !exists {
+		return []int{}, false
+	}
+
+	cores := make([]int, 0)
+	for core := 0; core < int(capacity.Value()); core++ {
+		cores = append(cores, core)
+	}
+
+	return cores, true
+}
+
+// missingCores returns the cores the Kubelet reports that aren't in any of AppQoS's Pools
+func missingCores(nodeCores []int, appQoSCores map[int]bool) []int {
+	missing := make([]int, 0)
+	for _, core := range nodeCores {
+		if !appQoSCores[core] {
+			missing = append(missing, core)
+		}
+	}
+
+	sort.Ints(missing)
+	return missing
+}
+
+// appQoSCoresCondition compares the cores in the AppQoS instance's Pools against the cores the Kubelet reports
+// for the Node. A nil condition means the comparison couldn't be made, as the Node reports no CPU capacity
+func (r *PowerNodeReconciler) appQoSCoresCondition(nodeName string) (*metav1.Condition, error) {
+	node := &corev1.Node{}
+	err := r.Client.Get(context.TODO(), client.ObjectKey{Name: nodeName}, node)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	nodeCores, reported := kubeletCores(node)
+	if !reported {
+		return nil, nil
+	}
+
+	pools, err := r.AppQoSClient.GetPools(AppQoSClientAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	appQoSCores := make(map[int]bool)
+	for _, pool := range pools {
+		if pool.Cores == nil {
+			continue
+		}
+
+		for _, core := range *pool.Cores {
+			appQoSCores[core] = true
+		}
+	}
+
+	missing := missingCores(nodeCores, appQoSCores)
+	if len(missing) > 0 {
+		return &metav1.Condition{
+			Type:    AppQoSCoresCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  "CoreSetMismatch",
+			Message: fmt.Sprintf("AppQoS knows of %d of the %d cores the Kubelet reports, missing cores %v", len(nodeCores)-len(missing), len(nodeCores), missing),
+		}, nil
+	}
+
+	return &metav1.Condition{
+		Type:   AppQoSCoresCondition,
+		Status: metav1.ConditionTrue,
+		Reason: "CoreSetMatches",
+	}, nil
+}
@@ -0,0 +1,79 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/appqos"
+)
+
+const (
+	// AppQoSCertFile, AppQoSKeyFile and AppQoSCAFile are the names of the credential files in each pool's directory
+	AppQoSCertFile = "appqos.crt"
+	AppQoSKeyFile  = "appqos.key"
+	AppQoSCAFile   = "ca.crt"
+)
+
+// AppQoSCredentialsForNode selects the AppQoS credentials for the Node's pool. The value of poolLabel on the Node
+// names a directory under credentialsDir holding the pool's certificate, key and CA. Nodes without the label,
+// or an empty poolLabel, use the default credentials
+func AppQoSCredentialsForNode(ctx context.Context, c client.Reader, nodeName string, poolLabel string, credentialsDir string) (appqos.Credentials, error) {
+	if poolLabel == "" {
+		return appqos.DefaultCredentials(), nil
+	}
+
+	node := &corev1.Node{}
+	err := c.Get(ctx, client.ObjectKey{Name: nodeName}, node)
+	if err != nil {
+		return appqos.Credentials{}, err
+	}
+
+	pool, ok := node.Labels[poolLabel]
+	if !ok || pool == "" {
+		return appqos.DefaultCredentials(), nil
+	}
+
+	// Presenting another pool's credentials would be refused by AppQoS anyway, so a missing directory is an error
+	poolDir := filepath.Join(credentialsDir, pool)
+	_, err = os.Stat(poolDir)
+	if err != nil {
+		return appqos.Credentials{}, fmt.Errorf("credentials for AppQoS pool '%s' not found: %v", pool, err)
+	}
+
+	return appqos.Credentials{
+		CertPath: filepath.Join(poolDir, AppQoSCertFile),
+		KeyPath:  filepath.Join(poolDir, AppQoSKeyFile),
+		CAPath:   filepath.Join(poolDir, AppQoSCAFile),
+	}, nil
+}
+
+// NewAppQoSClientForNode returns an AppQoS client presenting the credentials of the Node's pool
+func NewAppQoSClientForNode(ctx context.Context, c client.Reader, nodeName string, poolLabel string, credentialsDir string) (*appqos.AppQoSClient, error) {
+	credentials, err := AppQoSCredentialsForNode(ctx, c, nodeName, poolLabel, credentialsDir)
+	if err != nil {
+		return &appqos.AppQoSClient{}, err
+	}
+
+	return appqos.NewOperatorAppQoSClientWithCredentials(credentials)
+}
@@ -0,0 +1,176 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/appqos"
+)
+
+// AppQoSRestartWatcher periodically checks whether the node's AppQoS instance has restarted and lost the Pools and
+// profiles applied to it, leaving the node back at its defaults while the PowerWorkloads still describe the intended
+// state. AppQoS doesn't report its uptime, so a restart is recognised by none of the Pools of the PowerWorkloads
+// applied on the Node existing any more. Every PowerProfile and the Node's PowerWorkloads are then sent to their
+// controllers to be reconciled again, restoring the profiles and Pools
+type AppQoSRestartWatcher struct {
+	Client       client.Client
+	AppQoSClient *appqos.AppQoSClient
+	Log          logr.Logger
+	NodeName     string
+
+	// Interval is how often AppQoS is checked for lost state
+	Interval time.Duration
+
+	// Profiles and Workloads receive the PowerProfiles and PowerWorkloads to reconcile again once AppQoS has lost
+	// its state. The PowerProfile and PowerWorkload controllers watch them through their Reapply fields
+	Profiles  chan event.GenericEvent
+	Workloads chan event.GenericEvent
+
+	// lost is whether AppQoS had lost its state at the last check, so a restart is only acted on once
+	lost bool
+}
+
+// NewAppQoSRestartWatcher returns an AppQoSRestartWatcher checking the node's AppQoS instance every interval
+func NewAppQoSRestartWatcher(c client.Client, appQoSClient *appqos.AppQoSClient, logger logr.Logger, nodeName string, interval time.Duration) *AppQoSRestartWatcher {
+	return &AppQoSRestartWatcher{
+		Client:       c,
+		AppQoSClient: appQoSClient,
+		Log:          logger,
+		NodeName:     nodeName,
+		Interval:     interval,
+		Profiles:     make(chan event.GenericEvent),
+		Workloads:    make(chan event.GenericEvent),
+	}
+}
+
+// Start checks AppQoS every Interval until stop is closed, so the watcher can be run by the manager
+func (w *AppQoSRestartWatcher) Start(stop <-chan struct{}) error {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			lost, err := w.lostState(context.TODO())
+			if err != nil {
+				w.Log.Error(err, "error checking AppQoS for lost state")
+				continue
+			}
+
+			if lost && !w.lost {
+				w.Log.Info("AppQoS has lost the Pools applied to it, likely after a restart, re-applying PowerProfiles and PowerWorkloads")
+				err = w.reapply(context.TODO(), stop)
+				if err != nil {
+					w.Log.Error(err, "error re-applying PowerProfiles and PowerWorkloads")
+					continue
+				}
+			}
+			w.lost = lost
+		}
+	}
+}
+
+// lostState reports whether AppQoS has lost the Pools of every PowerWorkload applied on the Node. A Node with
+// nothing applied has nothing to lose
+func (w *AppQoSRestartWatcher) lostState(ctx context.Context) (bool, error) {
+	workloads := &powerv1alpha1.PowerWorkloadList{}
+	err := w.Client.List(ctx, workloads)
+	if err != nil {
+		return false, err
+	}
+
+	applied := make([]string, 0)
+	for _, workload := range workloads.Items {
+		if !workload.Spec.AllCores && workload.Spec.Node.Name == w.NodeName && workload.Status.ObservedGeneration != 0 {
+			applied = append(applied, workload.Name)
+		}
+	}
+	if len(applied) == 0 {
+		return false, nil
+	}
+
+	pools, err := w.AppQoSClient.GetPools(AppQoSClientAddress)
+	if err != nil {
+		return false, err
+	}
+
+	poolNames := make(map[string]bool)
+	for _, pool := range pools {
+		if pool.Name != nil {
+			poolNames[*pool.Name] = true
+		}
+	}
+	for _, workload := range applied {
+		if poolNames[workload] {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// reapply sends every PowerProfile, and the PowerWorkloads on the Node, to be reconciled again
+func (w *AppQoSRestartWatcher) reapply(ctx context.Context, stop <-chan struct{}) error {
+	return reapplyToNode(ctx, w.Client, w.NodeName, w.Profiles, w.Workloads, stop)
+}
+
+// reapplyToNode sends every PowerProfile, and the PowerWorkloads on the Node, to be reconciled again. The
+// PowerProfiles go first so the AppQoS profiles exist by the time the Pools are recreated
+func reapplyToNode(ctx context.Context, c client.Client, nodeName string, profileEvents chan<- event.GenericEvent, workloadEvents chan<- event.GenericEvent, stop <-chan struct{}) error {
+	profiles := &powerv1alpha1.PowerProfileList{}
+	err := c.List(ctx, profiles)
+	if err != nil {
+		return err
+	}
+
+	for i := range profiles.Items {
+		select {
+		case profileEvents <- event.GenericEvent{Meta: &profiles.Items[i], Object: &profiles.Items[i]}:
+		case <-stop:
+			return nil
+		}
+	}
+
+	workloads := &powerv1alpha1.PowerWorkloadList{}
+	err = c.List(ctx, workloads)
+	if err != nil {
+		return err
+	}
+
+	for i := range workloads.Items {
+		if !workloads.Items[i].Spec.AllCores && workloads.Items[i].Spec.Node.Name != nodeName {
+			continue
+		}
+
+		select {
+		case workloadEvents <- event.GenericEvent{Meta: &workloads.Items[i], Object: &workloads.Items[i]}:
+		case <-stop:
+			return nil
+		}
+	}
+
+	return nil
+}
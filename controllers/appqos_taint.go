@@ -0,0 +1,69 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// AppQoSUnreachableTaint is the NoSchedule taint kept on a Node while its AppQoS instance is unreachable, so Pods
+// that need power management are not scheduled where it cannot be applied
+const AppQoSUnreachableTaint = "power.intel.com/appqos-unreachable"
+
+// setAppQoSUnreachableTaint adds the AppQoSUnreachableTaint to the Node if AppQoS is unreachable, and removes it
+// once AppQoS can be reached again. Only the Node's taints are patched, leaving the rest of the Node to its
+// other writers
+func (r *PowerNodeReconciler) setAppQoSUnreachableTaint(nodeName string, unreachable bool) error {
+	node := &corev1.Node{}
+	err := r.Client.Get(context.TODO(), client.ObjectKey{Name: nodeName}, node)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	patch := client.MergeFrom(node.DeepCopy())
+	taints := make([]corev1.Taint, 0)
+	tainted := false
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == AppQoSUnreachableTaint && taint.Effect == corev1.TaintEffectNoSchedule {
+			tainted = true
+			continue
+		}
+		taints = append(taints, taint)
+	}
+
+	if tainted == unreachable {
+		return nil
+	}
+
+	if unreachable {
+		taints = append(taints, corev1.Taint{
+			Key:    AppQoSUnreachableTaint,
+			Effect: corev1.TaintEffectNoSchedule,
+		})
+	}
+
+	node.Spec.Taints = taints
+	return r.Client.Patch(context.TODO(), node, patch)
+}
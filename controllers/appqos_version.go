@@ -0,0 +1,84 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/version"
+
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/appqos"
+)
+
+const (
+	// AppQoSCompatibleCondition is set on a PowerNode to report whether its AppQoS instance can be managed
+	AppQoSCompatibleCondition = "AppQoSCompatible"
+)
+
+// appQoSVersionRetryDelay is the delay before the first retry of an AppQoS version that could not be read,
+// doubling after each attempt up to maxAppQoSVersionRetryDelay
+var appQoSVersionRetryDelay = time.Second
+
+const maxAppQoSVersionRetryDelay = 30 * time.Second
+
+// The range of AppQoS versions the operator supports. The minimum is inclusive and the maximum exclusive
+var (
+	MinAppQoSVersion = "4.0.0"
+	MaxAppQoSVersion = "5.0.0"
+)
+
+// NegotiateAppQoSVersion queries the version of the node's AppQoS instance and returns an error
+// if it cannot be determined or falls outside the supported range
+func NegotiateAppQoSVersion(appQoSClient *appqos.AppQoSClient) (string, error) {
+	appQoSVersion, err := appQoSClient.GetVersion(AppQoSClientAddress)
+	if err != nil {
+		return "", err
+	}
+
+	parsedVersion, err := version.ParseGeneric(appQoSVersion)
+	if err != nil {
+		return appQoSVersion, err
+	}
+
+	if !parsedVersion.AtLeast(version.MustParseGeneric(MinAppQoSVersion)) || parsedVersion.AtLeast(version.MustParseGeneric(MaxAppQoSVersion)) {
+		return appQoSVersion, errors.NewServiceUnavailable(fmt.Sprintf("AppQoS version '%s' is outside the supported range [%s, %s)", appQoSVersion, MinAppQoSVersion, MaxAppQoSVersion))
+	}
+
+	return appQoSVersion, nil
+}
+
+// WaitForAppQoSVersion negotiates the version of the node's AppQoS instance, retrying with backoff for up to
+// timeout while the version cannot be read at all, e.g. while AppQoS is still starting alongside the Node Agent.
+// A version that is read but unsupported is returned straight away, as retrying would not change it
+func WaitForAppQoSVersion(appQoSClient *appqos.AppQoSClient, timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	delay := appQoSVersionRetryDelay
+	for {
+		appQoSVersion, err := NegotiateAppQoSVersion(appQoSClient)
+		if err == nil || appQoSVersion != "" || time.Now().Add(delay).After(deadline) {
+			return appQoSVersion, err
+		}
+
+		time.Sleep(delay)
+		delay *= 2
+		if delay > maxAppQoSVersionRetryDelay {
+			delay = maxAppQoSVersionRetryDelay
+		}
+	}
+}
@@ -0,0 +1,81 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/podstate"
+)
+
+// allocatableExclusiveCPUs returns the whole CPUs of the Node's allocatable CPU, the most the Kubelet can hand
+// out exclusively to Guaranteed Pods
+func allocatableExclusiveCPUs(node *corev1.Node) int {
+	allocatable, exists := node.Status.Allocatable[corev1.ResourceCPU]
+	if !exists {
+		return 0
+	}
+
+	return int(allocatable.MilliValue() / 1000)
+}
+
+// exclusiveCPUCapacity returns how many of the allocatable exclusive CPUs are held by the Guaranteed Pods in
+// the State and how many are left. A CPU is counted once however many Containers list it
+func exclusiveCPUCapacity(allocatable int, state *podstate.State) (int, int) {
+	allocatedCPUs := make(map[int]bool)
+	for _, pod := range state.GetGuaranteedPods() {
+		for _, cpu := range state.GetCPUsFromPodState(pod) {
+			allocatedCPUs[cpu] = true
+		}
+	}
+
+	allocated := len(allocatedCPUs)
+	free := allocatable - allocated
+	if free < 0 {
+		free = 0
+	}
+
+	return allocated, free
+}
+
+// recordExclusiveCPUCapacity keeps the exclusive CPU capacity gauges for the Node up to date, so alerts can
+// fire before the Node runs out of CPUs to give Guaranteed Pods. It does nothing without a State to read
+func (r *PowerNodeReconciler) recordExclusiveCPUCapacity(logger logr.Logger, nodeName string) {
+	if r.State == nil {
+		return
+	}
+
+	node := &corev1.Node{}
+	err := r.Client.Get(context.TODO(), client.ObjectKey{Name: nodeName}, node)
+	if err != nil {
+		logger.Error(err, "error retrieving Node to report exclusive CPU capacity")
+		return
+	}
+
+	allocatable := allocatableExclusiveCPUs(node)
+	allocated, free := exclusiveCPUCapacity(allocatable, r.State)
+	exclusiveCPUsAllocatable.WithLabelValues(nodeName).Set(float64(allocatable))
+	exclusiveCPUsAllocated.WithLabelValues(nodeName).Set(float64(allocated))
+	exclusiveCPUsFree.WithLabelValues(nodeName).Set(float64(free))
+	if r.FreeExclusiveCPUsThreshold > 0 {
+		exclusiveCPUsFreeThreshold.WithLabelValues(nodeName).Set(float64(r.FreeExclusiveCPUsThreshold))
+	}
+}
@@ -0,0 +1,137 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/cpuhotplug"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/util"
+)
+
+// excludeOfflineCores removes any offline cores from the container's cores, as AppQoS rejects a Pool holding
+// an offline CPU, emitting a Warning Event on the Pod naming the cores left out
+func (r *PowerPodReconciler) excludeOfflineCores(pod *corev1.Pod, containerName string, cores []int) ([]int, error) {
+	onlineCores := make([]int, 0)
+	offlineCores := make([]int, 0)
+	for _, core := range cores {
+		online, err := cpuhotplug.IsCPUOnline(core)
+		if err != nil {
+			return []int{}, err
+		}
+
+		if online {
+			onlineCores = append(onlineCores, core)
+		} else {
+			offlineCores = append(offlineCores, core)
+		}
+	}
+
+	if len(offlineCores) > 0 {
+		r.Log.WithValues("pod", pod.GetName(), "container", containerName).Info("excluding offline CPUs from PowerWorkload", "cpus", offlineCores)
+		r.Recorder.Eventf(pod, corev1.EventTypeWarning, "OfflineCPUsExcluded", "CPUs %v of Container '%s' are offline and will not have a Power Profile applied", offlineCores, containerName)
+	}
+
+	return onlineCores, nil
+}
+
+// parkSiblings takes offline the sibling hyperthreads of the Pod's exclusive CPUs that are not themselves
+// assigned to the Pod. Siblings that are another Pod's exclusive CPUs or in the Node's Shared pool are left
+// online. The parked threads are recorded in the State so they can be restored on deletion
+func (r *PowerPodReconciler) parkSiblings(ctx context.Context, namespace string, pod *corev1.Pod, containers []powerv1alpha1.Container) error {
+	podName := pod.GetName()
+	podCPUs := make([]int, 0)
+	for _, container := range containers {
+		podCPUs = append(podCPUs, container.ExclusiveCPUs...)
+	}
+
+	allocatedCPUs, err := r.getAllocatedCPUs(ctx, namespace, podName, pod.Spec.NodeName)
+	if err != nil {
+		return err
+	}
+
+	parkedSiblings := r.State.GetParkedSiblings(podName)
+	defer func() {
+		r.State.UpdateParkedSiblings(podName, parkedSiblings)
+	}()
+
+	for _, cpu := range podCPUs {
+		siblings, err := cpuhotplug.GetThreadSiblings(cpu)
+		if err != nil {
+			return err
+		}
+
+		for _, sibling := range siblings {
+			if util.CPUInCPUList(sibling, podCPUs) || util.CPUInCPUList(sibling, parkedSiblings) || util.CPUInCPUList(sibling, allocatedCPUs) {
+				continue
+			}
+
+			err = cpuhotplug.SetCPUOnline(sibling, false)
+			if err != nil {
+				return err
+			}
+			parkedSiblings = append(parkedSiblings, sibling)
+		}
+	}
+
+	return nil
+}
+
+// getAllocatedCPUs returns the CPUs on the Node that must not be parked for the given Pod: the exclusive CPUs
+// and parked siblings of every other Pod in the State, and the cores of the Node's Shared PowerWorkload
+func (r *PowerPodReconciler) getAllocatedCPUs(ctx context.Context, namespace string, podName string, nodeName string) ([]int, error) {
+	allocatedCPUs := make([]int, 0)
+	for _, guaranteedPod := range r.State.GetGuaranteedPods() {
+		if guaranteedPod.Name == podName {
+			continue
+		}
+		allocatedCPUs = append(allocatedCPUs, r.State.GetCPUsFromPodState(guaranteedPod)...)
+		allocatedCPUs = append(allocatedCPUs, r.State.GetParkedSiblings(guaranteedPod.Name)...)
+	}
+
+	workloads := &powerv1alpha1.PowerWorkloadList{}
+	err := r.Client.List(ctx, workloads, client.InNamespace(namespace))
+	if err != nil {
+		return allocatedCPUs, err
+	}
+
+	for _, workload := range workloads.Items {
+		if workload.Spec.AllCores && strings.HasPrefix(workload.Name, "shared-") && (workload.Status.Node == nodeName || workload.Spec.Node.Name == nodeName) {
+			allocatedCPUs = append(allocatedCPUs, workload.Status.SharedCores...)
+		}
+	}
+
+	return allocatedCPUs, nil
+}
+
+// restoreParkedSiblings brings back online any sibling hyperthreads that were parked for the Pod
+func (r *PowerPodReconciler) restoreParkedSiblings(podName string) error {
+	for _, sibling := range r.State.GetParkedSiblings(podName) {
+		err := cpuhotplug.SetCPUOnline(sibling, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	r.State.DeleteParkedSiblings(podName)
+	return nil
+}
@@ -0,0 +1,121 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/cgroup"
+)
+
+// cpuSetReads remembers the stable cpuset last read for each Container, so a reconcile finding it unchanged can
+// take it without waiting for it to stabilize again
+type cpuSetReads struct {
+	mutex   sync.Mutex
+	cpuSets map[types.UID]map[string]string
+}
+
+func (c *cpuSetReads) last(podUID types.UID, containerName string) string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.cpuSets[podUID][containerName]
+}
+
+func (c *cpuSetReads) record(podUID types.UID, containerName string, cpuSet string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.cpuSets == nil {
+		c.cpuSets = make(map[types.UID]map[string]string)
+	}
+	if c.cpuSets[podUID] == nil {
+		c.cpuSets[podUID] = make(map[string]string)
+	}
+	c.cpuSets[podUID][containerName] = cpuSet
+}
+
+// forget drops the Pod's Containers once it is deleted
+func (c *cpuSetReads) forget(podUID types.UID) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.cpuSets, podUID)
+}
+
+// getStableContainerCPUs reads the Container's cpuset until two consecutive reads agree on a non-empty cpuset,
+// as the CPU Manager may not have finalized it when the Pod first reports Running. A cpuset the same as the one
+// last found stable is taken from the first read, so reconciles of an unchanged Pod aren't held up
+func (r *PowerPodReconciler) getStableContainerCPUs(ctx context.Context, pod *corev1.Pod, containerName string) (string, error) {
+	coreIDs, err := r.PodResourcesClient.GetContainerCPUs(ctx, pod.GetName(), containerName)
+	if err != nil || r.CPUSetStabilizationAttempts <= 1 {
+		return coreIDs, err
+	}
+	if coreIDs != "" && coreIDs == r.cpuSetReads.last(pod.GetUID(), containerName) {
+		return coreIDs, nil
+	}
+
+	for attempt := 1; attempt < r.CPUSetStabilizationAttempts; attempt++ {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(r.CPUSetStabilizationInterval):
+		}
+
+		nextCoreIDs, err := r.PodResourcesClient.GetContainerCPUs(ctx, pod.GetName(), containerName)
+		if err != nil {
+			return "", err
+		}
+
+		if nextCoreIDs != "" && nextCoreIDs == coreIDs {
+			r.cpuSetReads.record(pod.GetUID(), containerName, coreIDs)
+			return coreIDs, nil
+		}
+		coreIDs = nextCoreIDs
+	}
+
+	return "", errors.NewServiceUnavailable(fmt.Sprintf("cpuset of Pod:%v Container:%v did not stabilize after %d reads", pod.GetName(), containerName, r.CPUSetStabilizationAttempts))
+}
+
+// verifyCgroupCPUSet compares the container's cores reported by the PodResources API against those in its cpuset
+// cgroup, emitting a Warning Event on the Pod if the kubelet's two views have diverged
+func (r *PowerPodReconciler) verifyCgroupCPUSet(pod *corev1.Pod, containerName string, containerID string, cores []int) {
+	logger := r.Log.WithValues("pod", pod.GetName(), "container", containerName)
+
+	cgroupCores, err := cgroup.GetContainerCPUs(string(pod.GetUID()), containerID)
+	if err != nil {
+		logger.Error(err, "error reading container's cpuset cgroup")
+		return
+	}
+
+	podResourcesCores := append([]int{}, cores...)
+	sort.Ints(podResourcesCores)
+	sort.Ints(cgroupCores)
+	if !reflect.DeepEqual(podResourcesCores, cgroupCores) {
+		logger.Info("PodResources API and cpuset cgroup disagree on container's CPUs", "podResources", podResourcesCores, "cgroup", cgroupCores)
+		r.Recorder.Eventf(pod, corev1.EventTypeWarning, "CPUSetMismatch", "Container '%s' has CPUs %v from the PodResources API but %v in its cpuset cgroup, using %v", containerName, podResourcesCores, cgroupCores, podResourcesCores)
+	}
+}
@@ -0,0 +1,47 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// CrashLoopBackOffReason is the waiting reason the Kubelet gives a Container it is backing off restarting
+	CrashLoopBackOffReason = "CrashLoopBackOff"
+)
+
+// crashLoopingContainer returns the name of the first of the Pod's Containers that is crash looping, or an empty
+// string if none are. A Container is crash looping while it is backing off restarting, or once restarted after a
+// crash until it has stayed running for the stable period, as a crash looping Pod is Running in between crashes
+func crashLoopingContainer(pod *corev1.Pod, stablePeriod time.Duration, now time.Time) string {
+	for _, containerStatus := range podContainerStatuses(pod) {
+		if containerStatus.State.Waiting != nil && containerStatus.State.Waiting.Reason == CrashLoopBackOffReason {
+			return containerStatus.Name
+		}
+
+		crashed := containerStatus.LastTerminationState.Terminated != nil && containerStatus.LastTerminationState.Terminated.ExitCode != 0
+		running := containerStatus.State.Running
+		if crashed && running != nil && now.Sub(running.StartedAt.Time) < stablePeriod {
+			return containerStatus.Name
+		}
+	}
+
+	return ""
+}
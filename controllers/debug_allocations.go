@@ -0,0 +1,39 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/podstate"
+)
+
+// AllocationsDebugPath is the path on the metrics server the Node Agent's internal State is dumped on
+const AllocationsDebugPath = "/debug/allocations"
+
+// NewAllocationsDebugHandler serves the State as JSON, exactly as the Node Agent holds it: the Guaranteed Pods with
+// their Containers and exclusive cores, alongside the parked siblings, restart counts, profile changes and retry
+// budgets tracked for them. It is intended for field debugging, not as a stable API
+func NewAllocationsDebugHandler(state *podstate.State) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(state)
+	})
+}
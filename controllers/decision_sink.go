@@ -0,0 +1,132 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// DecisionRecord describes one allocation decision: the PowerWorkload write that gave a Pod's cores to, or took
+// them back from, a PowerProfile
+type DecisionRecord struct {
+	// Action is the write made to the PowerWorkload: "create", "update" or "delete"
+	Action         string    `json:"action"`
+	Workload       string    `json:"workload"`
+	Namespace      string    `json:"namespace"`
+	Node           string    `json:"node"`
+	Profile        string    `json:"profile"`
+	PodName        string    `json:"podName,omitempty"`
+	PodUID         string    `json:"podUID"`
+	AllocatedCores []int     `json:"allocatedCores,omitempty"`
+	ReleasedCores  []int     `json:"releasedCores,omitempty"`
+	WorkloadCores  []int     `json:"workloadCores"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// DecisionSink receives a DecisionRecord for every allocation decision, such as to stream them to an external
+// audit pipeline. Record is called during the reconcile, so a sink that may block should hand the record off
+type DecisionSink interface {
+	Record(record DecisionRecord)
+}
+
+// NoopDecisionSink discards every record. It is used when no DecisionSink is configured
+type NoopDecisionSink struct{}
+
+func (NoopDecisionSink) Record(record DecisionRecord) {}
+
+// HTTPDecisionSink posts each DecisionRecord as JSON to an HTTP endpoint in the background
+type HTTPDecisionSink struct {
+	URL     string
+	Timeout time.Duration
+	Log     logr.Logger
+
+	client *http.Client
+}
+
+// NewHTTPDecisionSink returns an HTTPDecisionSink posting to the URL, abandoning each post after the timeout
+func NewHTTPDecisionSink(url string, timeout time.Duration, logger logr.Logger) *HTTPDecisionSink {
+	return &HTTPDecisionSink{
+		URL:     url,
+		Timeout: timeout,
+		Log:     logger,
+		client:  &http.Client{},
+	}
+}
+
+func (s *HTTPDecisionSink) Record(record DecisionRecord) {
+	go func() {
+		err := s.post(record)
+		if err != nil {
+			s.Log.Error(err, "error sending decision record", "action", record.Action, "workload", record.Workload, "podUID", record.PodUID)
+		}
+	}()
+}
+
+func (s *HTTPDecisionSink) post(record DecisionRecord) error {
+	body, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("decision sink responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// recordDecision sends the record to the DecisionSink, if one is configured. Writes that moved no cores aren't
+// allocation decisions, so they aren't recorded
+func (r *PowerPodReconciler) recordDecision(record DecisionRecord) {
+	if len(record.AllocatedCores) == 0 && len(record.ReleasedCores) == 0 {
+		return
+	}
+
+	sink := r.DecisionSink
+	if sink == nil {
+		sink = NoopDecisionSink{}
+	}
+
+	record.Timestamp = time.Now().UTC()
+	sink.Record(record)
+}
@@ -0,0 +1,81 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
+)
+
+const (
+	// DefaultProfileLabel marks the base PowerProfiles the operator created as its default set on install
+	DefaultProfileLabel = "power.intel.com/default-profile"
+)
+
+// DefaultProfiles are the base PowerProfiles the operator can create on install so a new cluster has
+// PowerProfiles to request without authoring any by hand
+var DefaultProfiles = []string{"performance", "balance-performance", "balance-power", "power"}
+
+// CreateDefaultProfiles creates whichever of the default PowerProfiles are missing from the namespace. Existing
+// PowerProfiles of the same name are left as they are. The node agents' PowerProfile controllers then extend
+// each one for their Node and push it to the Node's AppQoS instance
+func CreateDefaultProfiles(ctx context.Context, c client.Client, logger logr.Logger, namespace string) error {
+	for _, profile := range DefaultProfiles {
+		existing := &powerv1alpha1.PowerProfile{}
+		err := c.Get(ctx, client.ObjectKey{
+			Name:      profile,
+			Namespace: namespace,
+		}, existing)
+		if err == nil {
+			continue
+		}
+		if !errors.IsNotFound(err) {
+			logger.Error(err, fmt.Sprintf("error retrieving PowerProfile '%s'", profile))
+			return err
+		}
+
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      profile,
+				Labels: map[string]string{
+					DefaultProfileLabel: "true",
+				},
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: profile,
+				Epp:  basePowerProfileToEppValue[profile],
+			},
+		}
+		err = c.Create(ctx, powerProfile)
+		if err != nil && !errors.IsAlreadyExists(err) {
+			logger.Error(err, fmt.Sprintf("error creating PowerProfile '%s'", profile))
+			return err
+		}
+
+		logger.Info("Created default PowerProfile", "profile", profile)
+	}
+
+	return nil
+}
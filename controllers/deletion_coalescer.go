@@ -0,0 +1,270 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/util"
+)
+
+// podRelease holds the CPUs and Containers a deleted Pod gives back to a PowerWorkload
+type podRelease struct {
+	Node       string
+	Pod        string
+	UID        string
+	CPUs       []int
+	Containers []powerv1alpha1.Container
+}
+
+// maxDeletionRetryDelay caps the backoff between attempts to write releases that failed to be flushed
+const maxDeletionRetryDelay = 5 * time.Minute
+
+// deletionCoalescer collects the releases for each PowerWorkload so that Pods deleted together,
+// such as during a Deployment scale-down, are cleaned up with a single write
+type deletionCoalescer struct {
+	mutex   sync.Mutex
+	pending map[client.ObjectKey][]podRelease
+	// failures counts the consecutive failed flushes of each PowerWorkload, backing off its retries
+	failures map[client.ObjectKey]int
+	flushes  sync.WaitGroup
+}
+
+// coalesceDeletion queues the release against the PowerWorkload, scheduling a flush at the end of the
+// window if this is the first release queued for it
+func (r *PowerPodReconciler) coalesceDeletion(logger logr.Logger, workloadKey client.ObjectKey, release podRelease) {
+	c := &r.deletions
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.pending == nil {
+		c.pending = make(map[client.ObjectKey][]podRelease)
+	}
+
+	if _, scheduled := c.pending[workloadKey]; !scheduled {
+		r.scheduleFlush(logger, workloadKey, r.DeletionCoalesceWindow)
+	}
+
+	c.pending[workloadKey] = append(c.pending[workloadKey], release)
+}
+
+// scheduleFlush flushes the releases queued against the PowerWorkload after the delay. The caller must hold the
+// coalescer's mutex
+func (r *PowerPodReconciler) scheduleFlush(logger logr.Logger, workloadKey client.ObjectKey, delay time.Duration) {
+	c := &r.deletions
+	c.flushes.Add(1)
+	time.AfterFunc(delay, func() {
+		defer c.flushes.Done()
+		r.flushDeletions(logger, workloadKey)
+	})
+}
+
+// flushDeletions writes every release queued against the PowerWorkload as a single update. Releases that
+// couldn't be written, including while in read-only mode, are queued again and retried with a backoff
+func (r *PowerPodReconciler) flushDeletions(logger logr.Logger, workloadKey client.ObjectKey) {
+	c := &r.deletions
+	c.mutex.Lock()
+	releases := c.pending[workloadKey]
+	delete(c.pending, workloadKey)
+	c.mutex.Unlock()
+
+	err := r.releaseWorkloadCPUs(context.Background(), logger, workloadKey, releases)
+	if err == nil && !r.workloadWritesReadOnly() {
+		c.mutex.Lock()
+		delete(c.failures, workloadKey)
+		c.mutex.Unlock()
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.failures == nil {
+		c.failures = make(map[client.ObjectKey]int)
+	}
+	c.failures[workloadKey]++
+	delay := deletionRetryDelay(r.DeletionCoalesceWindow, c.failures[workloadKey])
+	if err != nil {
+		logger.Error(err, "error releasing coalesced CPUs from PowerWorkload, retrying", "workload", workloadKey.Name, "pods", len(releases), "retryAfter", delay.String())
+	}
+
+	// Releases queued since this flush started already have a flush scheduled, which these join
+	if _, scheduled := c.pending[workloadKey]; !scheduled {
+		r.scheduleFlush(logger, workloadKey, delay)
+	}
+	c.pending[workloadKey] = append(releases, c.pending[workloadKey]...)
+}
+
+// deletionRetryDelay doubles the coalescing window for each consecutive failed flush, up to maxDeletionRetryDelay
+func deletionRetryDelay(window time.Duration, failures int) time.Duration {
+	delay := window
+	for i := 0; i < failures && delay < maxDeletionRetryDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > maxDeletionRetryDelay {
+		return maxDeletionRetryDelay
+	}
+
+	return delay
+}
+
+// wait blocks until every scheduled flush has completed
+func (c *deletionCoalescer) wait() {
+	c.flushes.Wait()
+}
+
+// releaseWorkloadCPUs removes the CPUs and Containers of the deleted Pods from the PowerWorkload,
+// deleting the PowerWorkload entirely if no CPUs remain
+func (r *PowerPodReconciler) releaseWorkloadCPUs(ctx context.Context, logger logr.Logger, workloadKey client.ObjectKey, releases []podRelease) error {
+	r.workloadLocks.lock(workloadKey)
+	defer r.workloadLocks.unlock(workloadKey)
+
+	workload := &powerv1alpha1.PowerWorkload{}
+	err := r.Get(ctx, workloadKey, workload)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		logger.Error(err, "error while trying to retrieve PowerWorkload")
+		return err
+	}
+
+	cpus := make([]int, 0)
+	for _, release := range releases {
+		cpus = append(cpus, release.CPUs...)
+	}
+
+	workloadCPUs := workload.Spec.Node.CpuIds
+	updatedWorkloadCPUList := getNewWorkloadCPUList(cpus, workloadCPUs)
+	var written bool
+	action := "update"
+	if len(updatedWorkloadCPUList) == 0 {
+		// We can delete this PowerWorkload as no CPUs are utilizing it
+
+		action = "delete"
+		written, err = r.writeWorkload(ctx, logger, action, workload, func() error {
+			return r.Client.Delete(ctx, workload)
+		})
+		if err != nil {
+			logger.Error(err, "error deleting PowerWorkload")
+			return err
+		}
+	} else {
+		workload.Spec.Node.CpuIds = updatedWorkloadCPUList
+
+		// We don't need to check if there's no containers because if there weren't, that would have been caught while checking the number of CPUs above
+		updatedWorkloadContainerList := getNewWorkloadContainerList(workload.Spec.Node.Containers, releases)
+		workload.Spec.Node.Containers = updatedWorkloadContainerList
+
+		// A Node leaves the PowerWorkload's shard once none of its Pods have cores left in it
+		releasedUIDs := make(map[string]bool)
+		for _, release := range releases {
+			releasedUIDs[release.UID] = true
+		}
+		if !r.nodeUsesWorkload(workloadKey.Name, releasedUIDs) {
+			for _, release := range releases {
+				setWorkloadNode(workload, release.Node, false)
+			}
+		}
+
+		written, err = r.writeWorkload(ctx, logger, action, workload, func() error {
+			return r.Client.Update(ctx, workload)
+		})
+		if err != nil {
+			logger.Error(err, "Failed updating PowerWorkload")
+			return err
+		}
+	}
+
+	if written {
+		for _, release := range releases {
+			releasedCPUs := util.CommonCPUs(release.CPUs, workloadCPUs)
+			recordCPUs(cpuReleasesTotal, release.Node, workload.Spec.PowerProfile, release.UID, len(releasedCPUs))
+			r.AllocationNotifier.notify(AllocationEventReleased, release.Node, workload.Spec.PowerProfile, release.UID, releasedCPUs)
+			r.recordDecision(DecisionRecord{
+				Action:        action,
+				Workload:      workload.Name,
+				Namespace:     workload.Namespace,
+				Node:          release.Node,
+				Profile:       workload.Spec.PowerProfile,
+				PodName:       release.Pod,
+				PodUID:        release.UID,
+				ReleasedCores: releasedCPUs,
+				WorkloadCores: updatedWorkloadCPUList,
+			})
+		}
+	}
+
+	return nil
+}
+
+func getNewWorkloadCPUList(cpuList []int, nodeCpuIds []int) []int {
+	updatedWorkloadCPUList := make([]int, 0)
+
+	for _, cpu := range nodeCpuIds {
+		if !util.CPUInCPUList(cpu, cpuList) {
+			updatedWorkloadCPUList = append(updatedWorkloadCPUList, cpu)
+		}
+	}
+
+	return updatedWorkloadCPUList
+}
+
+// getNewWorkloadContainerList returns the PowerWorkload's Containers without those being released. Containers are
+// matched on their Pod as well as their name, as the replicas of a Deployment all share the same Container names
+func getNewWorkloadContainerList(nodeContainers []powerv1alpha1.Container, releases []podRelease) []powerv1alpha1.Container {
+	newNodeContainers := make([]powerv1alpha1.Container, 0)
+
+	for _, container := range nodeContainers {
+		if !isContainerReleased(container, releases) {
+			newNodeContainers = append(newNodeContainers, container)
+		}
+	}
+
+	return newNodeContainers
+}
+
+func isContainerReleased(container powerv1alpha1.Container, releases []podRelease) bool {
+	for _, release := range releases {
+		if containerBelongsToPod(container, release.Pod, release.UID) && isContainerInList(container.Name, release.Containers) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// containerBelongsToPod matches a PowerWorkload's Container to a Pod by UID, falling back to the Pod's name for
+// Containers recorded before the UID was kept. A Container recorded with neither is matched on its name alone
+func containerBelongsToPod(container powerv1alpha1.Container, podName string, podUID string) bool {
+	if container.PodUID != "" && podUID != "" {
+		return container.PodUID == podUID
+	}
+	if container.Pod != "" {
+		return container.Pod == podName
+	}
+
+	return true
+}
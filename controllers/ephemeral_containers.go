@@ -0,0 +1,71 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Ephemeral containers are added to a running Pod, e.g. by 'kubectl debug', and are only considered when the
+// PowerPodReconciler's ManageEphemeralContainers is set. Like init containers, they hold their cores only while
+// they run, so their PowerProfile is released once they exit
+
+// runningEphemeralContainers returns the Pod's ephemeral containers that are running
+func runningEphemeralContainers(pod *corev1.Pod) []corev1.Container {
+	running := make([]corev1.Container, 0)
+	for _, ephemeralContainer := range pod.Spec.EphemeralContainers {
+		for _, containerStatus := range pod.Status.EphemeralContainerStatuses {
+			if containerStatus.Name == ephemeralContainer.Name && containerStatus.State.Running != nil {
+				running = append(running, corev1.Container(ephemeralContainer.EphemeralContainerCommon))
+			}
+		}
+	}
+
+	return running
+}
+
+// exitedEphemeralContainer reports whether the named Container is an ephemeral container that has exited
+func exitedEphemeralContainer(pod *corev1.Pod, containerName string) bool {
+	for _, containerStatus := range pod.Status.EphemeralContainerStatuses {
+		if containerStatus.Name == containerName {
+			return containerStatus.State.Terminated != nil
+		}
+	}
+
+	return false
+}
+
+// ephemeralContainersRequestingExclusiveCPUs returns the Pod's running ephemeral containers given exclusive CPUs
+func ephemeralContainersRequestingExclusiveCPUs(pod *corev1.Pod) []corev1.Container {
+	containersRequestingExclusiveCPUs := make([]corev1.Container, 0)
+	for _, container := range runningEphemeralContainers(pod) {
+		if exclusiveCPUs(pod, &container) {
+			containersRequestingExclusiveCPUs = append(containersRequestingExclusiveCPUs, container)
+		}
+	}
+
+	return containersRequestingExclusiveCPUs
+}
+
+// releaseExitedEphemeralContainers releases the cores of the ephemeral containers recorded in the State that have
+// since exited from the PowerWorkloads of their PowerProfiles
+func (r *PowerPodReconciler) releaseExitedEphemeralContainers(ctx context.Context, logger logr.Logger, namespace string, pod *corev1.Pod) error {
+	return r.releaseFinishedContainers(ctx, logger, namespace, pod, "Ephemeral containers exited", exitedEphemeralContainer)
+}
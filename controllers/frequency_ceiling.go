@@ -0,0 +1,116 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/appqos"
+)
+
+// MaxFrequencyAnnotation on a Node sets, in MHz, a ceiling no PowerProfile's frequencies may exceed on that Node,
+// as a thermal safeguard independent of the PowerProfiles themselves. It takes effect as each PowerProfile is next reconciled
+const MaxFrequencyAnnotation = "power.intel.com/max-frequency"
+
+// invalidCeilings tracks the malformed MaxFrequencyAnnotation value last reported on each Node, so it is reported
+// once rather than on every reconcile
+type invalidCeilings struct {
+	mutex    sync.Mutex
+	reported map[string]string
+}
+
+// report records the Node's malformed value, returning whether it had not been reported yet
+func (c *invalidCeilings) report(nodeName string, value string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.reported == nil {
+		c.reported = make(map[string]string)
+	}
+	if reported, exists := c.reported[nodeName]; exists && reported == value {
+		return false
+	}
+	c.reported[nodeName] = value
+	return true
+}
+
+// forget drops the Node's reported value once its annotation is valid or removed
+func (c *invalidCeilings) forget(nodeName string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.reported, nodeName)
+}
+
+// nodeFrequencyCeiling returns the Node's maximum frequency ceiling in MHz, or zero if it has none. A malformed
+// ceiling is ignored, with a single Warning Event on the Node, so PowerProfiles are still applied without it
+func (r *PowerProfileReconciler) nodeFrequencyCeiling(nodeName string) (int, error) {
+	node := &corev1.Node{}
+	err := r.Client.Get(context.TODO(), client.ObjectKey{
+		Name: nodeName,
+	}, node)
+	if err != nil {
+		return 0, err
+	}
+
+	value, exists := node.GetAnnotations()[MaxFrequencyAnnotation]
+	if !exists {
+		r.invalidCeilings.forget(nodeName)
+		return 0, nil
+	}
+
+	ceiling, err := strconv.Atoi(value)
+	if err != nil || ceiling <= 0 {
+		if r.invalidCeilings.report(nodeName, value) {
+			r.Log.Info("ignoring invalid maximum frequency ceiling", "node", nodeName, "annotation", MaxFrequencyAnnotation, "value", value)
+			r.Recorder.Eventf(node, corev1.EventTypeWarning, "InvalidMaxFrequency", "Invalid %s annotation '%s', expected a positive frequency in MHz. PowerProfiles are applied without a ceiling", MaxFrequencyAnnotation, value)
+		}
+		return 0, nil
+	}
+	r.invalidCeilings.forget(nodeName)
+
+	return ceiling, nil
+}
+
+// applyFrequencyCeiling clamps the frequencies about to be sent to AppQoS to the Node's ceiling, emitting a Warning
+// Event on the PowerProfile when they are. The PowerProfile itself is left as it is, so lifting the ceiling restores it
+func (r *PowerProfileReconciler) applyFrequencyCeiling(profile *powerv1alpha1.PowerProfile, powerProfile *appqos.PowerProfile, nodeName string) error {
+	ceiling, err := r.nodeFrequencyCeiling(nodeName)
+	if err != nil {
+		return err
+	}
+	if ceiling == 0 || powerProfile.MaxFreq == nil || *powerProfile.MaxFreq <= ceiling {
+		return nil
+	}
+
+	requestedMaximum := *powerProfile.MaxFreq
+	clampedMaximum := ceiling
+	powerProfile.MaxFreq = &clampedMaximum
+	if powerProfile.MinFreq != nil && *powerProfile.MinFreq > ceiling {
+		clampedMinimum := ceiling
+		powerProfile.MinFreq = &clampedMinimum
+	}
+
+	r.Log.WithValues("powerprofile", profile.GetName()).Info("clamping PowerProfile to the Node's maximum frequency", "node", nodeName, "requestedMax", requestedMaximum, "ceiling", ceiling)
+	r.Recorder.Eventf(profile, corev1.EventTypeWarning, "FrequencyClamped", "Maximum frequency %dMHz exceeds the %dMHz ceiling of Node '%s' and was clamped to it", requestedMaximum, ceiling, nodeName)
+
+	return nil
+}
@@ -0,0 +1,124 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+
+	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
+)
+
+// A Pod's init containers and app containers never run at the same time, so each phase resolves its own
+// PowerProfile. An init container's cores are only held while it runs, after which the kubelet hands them
+// back to the shared pool or on to the app containers, so its PowerProfile is released once it completes
+
+// containerPhase is the phase of a Pod's lifecycle a Container runs in
+type containerPhase int
+
+const (
+	appContainerPhase containerPhase = iota
+	initContainerPhase
+)
+
+// podContainerPhase returns the phase the Pod is currently in: the init phase while one of its init containers
+// is running, otherwise the app phase
+func podContainerPhase(pod *corev1.Pod) containerPhase {
+	if initContainerRunning(pod) {
+		return initContainerPhase
+	}
+
+	return appContainerPhase
+}
+
+// initContainerRunning reports whether the Pod is in its init phase, with one of its init containers running
+func initContainerRunning(pod *corev1.Pod) bool {
+	for _, containerStatus := range pod.Status.InitContainerStatuses {
+		if containerStatus.State.Running != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// completedInitContainer reports whether the named Container is an init container that has run to completion
+func completedInitContainer(pod *corev1.Pod, containerName string) bool {
+	for _, containerStatus := range pod.Status.InitContainerStatuses {
+		if containerStatus.Name == containerName {
+			return containerStatus.State.Terminated != nil
+		}
+	}
+
+	return false
+}
+
+// phaseContainers returns the Containers of the Pod's current phase: the running init containers while the Pod
+// is initializing, otherwise the app containers
+func phaseContainers(pod *corev1.Pod) []corev1.Container {
+	if !initContainerRunning(pod) {
+		return pod.Spec.Containers
+	}
+
+	running := make([]corev1.Container, 0)
+	for _, container := range pod.Spec.InitContainers {
+		for _, containerStatus := range pod.Status.InitContainerStatuses {
+			if containerStatus.Name == container.Name && containerStatus.State.Running != nil {
+				running = append(running, container)
+			}
+		}
+	}
+
+	return running
+}
+
+// releaseCompletedInitContainers releases the cores of the init containers recorded in the State that have since
+// completed from the PowerWorkloads of their PowerProfiles, dropping them from the State so they are released once
+func (r *PowerPodReconciler) releaseCompletedInitContainers(ctx context.Context, logger logr.Logger, namespace string, pod *corev1.Pod) error {
+	return r.releaseFinishedContainers(ctx, logger, namespace, pod, "Init containers completed", completedInitContainer)
+}
+
+// releaseFinishedContainers releases the cores of the Containers recorded in the State for which finished holds
+// from the PowerWorkloads of their PowerProfiles, dropping them from the State so they are released once
+func (r *PowerPodReconciler) releaseFinishedContainers(ctx context.Context, logger logr.Logger, namespace string, pod *corev1.Pod, reason string, finished func(pod *corev1.Pod, containerName string) bool) error {
+	previousState := r.State.GetPodFromState(pod.GetName())
+
+	completed := make([]powerv1alpha1.Container, 0)
+	remaining := make([]powerv1alpha1.Container, 0)
+	for _, previous := range previousState.Containers {
+		if finished(pod, previous.Name) {
+			completed = append(completed, previous)
+		} else {
+			remaining = append(remaining, previous)
+		}
+	}
+	if len(completed) == 0 {
+		return nil
+	}
+
+	logger.Info(reason+", releasing their cores", "containers", len(completed))
+	err := r.releaseContainerCores(ctx, logger, namespace, pod, completed)
+	if err != nil {
+		return err
+	}
+
+	previousState.Containers = remaining
+	r.State.UpdateManagedCores(pod.GetName(), pod.GetNamespace(), containerCores(remaining))
+	return r.State.UpdateStateGuaranteedPods(previousState)
+}
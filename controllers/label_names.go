@@ -0,0 +1,44 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	// maxLabelNameLength is the most characters Kubernetes allows in the name part of a label or annotation key,
+	// after any '<prefix>/'
+	maxLabelNameLength = 63
+
+	labelNameHashLength = 10
+)
+
+// boundedLabelName shortens the name part of a label or annotation key that is longer than Kubernetes allows,
+// replacing its tail with a hash of the whole name so that distinct long names stay distinct
+func boundedLabelName(name string) string {
+	if len(name) <= maxLabelNameLength {
+		return name
+	}
+
+	sum := sha256.Sum256([]byte(name))
+	truncated := strings.TrimRight(name[:maxLabelNameLength-labelNameHashLength-1], "-_.")
+
+	return truncated + "-" + hex.EncodeToString(sum[:])[:labelNameHashLength]
+}
@@ -0,0 +1,129 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/cpuhotplug"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/memorymanager"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/util"
+)
+
+// memoryAlignments remembers the cores of each Container last found off the NUMA nodes its memory is pinned to, so
+// the Warning Event is only emitted when that changes rather than on every reconcile of the Pod
+type memoryAlignments struct {
+	mutex      sync.Mutex
+	misaligned map[types.UID]map[string][]int
+}
+
+// changed records the Container's misaligned cores, reporting whether they differ from those last recorded
+func (m *memoryAlignments) changed(podUID types.UID, containerName string, misalignedCores []int) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.misaligned == nil {
+		m.misaligned = make(map[types.UID]map[string][]int)
+	}
+	if m.misaligned[podUID] == nil {
+		m.misaligned[podUID] = make(map[string][]int)
+	}
+
+	previous, seen := m.misaligned[podUID][containerName]
+	m.misaligned[podUID][containerName] = misalignedCores
+	return !seen || !sameCPUs(previous, misalignedCores)
+}
+
+// forget drops the Pod's Containers once it is deleted
+func (m *memoryAlignments) forget(podUID types.UID) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	delete(m.misaligned, podUID)
+}
+
+// checkTopologyHints compares the NUMA node of each of the container's exclusive CPUs against the NUMA nodes
+// the device plugin hinted the container's devices are local to, emitting a Warning Event on the Pod for any
+// CPU that falls outside them. A mismatch only degrades performance so the Pod is still power-managed
+func (r *PowerPodReconciler) checkTopologyHints(ctx context.Context, pod *corev1.Pod, containerName string, cores []int) {
+	logger := r.Log.WithValues("pod", pod.GetName(), "container", containerName)
+
+	deviceNodes, err := r.PodResourcesClient.GetContainerDeviceNUMANodes(ctx, pod.GetName(), containerName)
+	if err != nil {
+		logger.Error(err, "error retrieving device topology hints")
+		return
+	}
+
+	for resourceName, hintedNodes := range deviceNodes {
+		mismatchedCores := make([]int, 0)
+		for _, core := range cores {
+			node, err := cpuhotplug.GetNUMANode(core)
+			if err != nil {
+				logger.Error(err, "error retrieving NUMA node of CPU", "cpu", core)
+				return
+			}
+
+			if !util.CPUInCPUList(node, hintedNodes) {
+				mismatchedCores = append(mismatchedCores, core)
+			}
+		}
+
+		if len(mismatchedCores) > 0 {
+			logger.Info("exclusive CPUs are not on the NUMA nodes hinted for the container's devices", "resource", resourceName, "hintedNodes", hintedNodes, "cpus", mismatchedCores)
+			r.Recorder.Eventf(pod, corev1.EventTypeWarning, "TopologyHintMismatch", "CPUs %v of Container '%s' are not on NUMA nodes %v hinted for '%s'", mismatchedCores, containerName, hintedNodes, resourceName)
+		}
+	}
+}
+
+// checkMemoryNUMAAlignment compares the NUMA node of each of the container's exclusive CPUs against the NUMA nodes
+// the Memory Manager pinned the container's memory to, emitting a Warning Event on the Pod when the CPUs that fall
+// outside them change, as uncore frequency scaling only benefits memory-bound work on the CPUs' own NUMA node. A
+// mismatch only degrades performance so the Pod is still power-managed
+func (r *PowerPodReconciler) checkMemoryNUMAAlignment(pod *corev1.Pod, containerName string, cores []int) {
+	logger := r.Log.WithValues("pod", pod.GetName(), "container", containerName)
+
+	memoryNodes, err := memorymanager.GetContainerNUMANodes(string(pod.GetUID()), containerName)
+	if err != nil {
+		logger.Error(err, "error retrieving Memory Manager NUMA assignment")
+		return
+	}
+	if len(memoryNodes) == 0 {
+		return
+	}
+
+	misalignedCores := make([]int, 0)
+	for _, core := range cores {
+		node, err := cpuhotplug.GetNUMANode(core)
+		if err != nil {
+			logger.Error(err, "error retrieving NUMA node of CPU", "cpu", core)
+			return
+		}
+
+		if !util.CPUInCPUList(node, memoryNodes) {
+			misalignedCores = append(misalignedCores, core)
+		}
+	}
+
+	if r.memoryAlignments.changed(pod.GetUID(), containerName, misalignedCores) && len(misalignedCores) > 0 {
+		logger.Info("exclusive CPUs are not on the NUMA nodes the container's memory is pinned to", "memoryNodes", memoryNodes, "cpus", misalignedCores)
+		r.Recorder.Eventf(pod, corev1.EventTypeWarning, "MemoryNUMAMismatch", "CPUs %v of Container '%s' are not on NUMA nodes %v its memory is pinned to", misalignedCores, containerName, memoryNodes)
+	}
+}
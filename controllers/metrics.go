@@ -0,0 +1,182 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/appqos"
+)
+
+const (
+	// PodUIDExemplarLabel is the exemplar label linking an allocation sample to the Pod responsible for it
+	PodUIDExemplarLabel = "pod_uid"
+)
+
+var (
+	cpuAllocationsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "power_cpu_allocations_total",
+			Help: "Number of exclusive CPUs added to PowerWorkloads",
+		},
+		[]string{"node", "profile"},
+	)
+
+	cpuReleasesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "power_cpu_releases_total",
+			Help: "Number of exclusive CPUs removed from PowerWorkloads",
+		},
+		[]string{"node", "profile"},
+	)
+
+	appQoSReachable = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "power_appqos_reachable",
+			Help: "Whether the last connection attempt to the node's AppQoS instance succeeded (1) or failed (0)",
+		},
+		[]string{"node"},
+	)
+
+	profileApplyDelaySeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "power_profile_apply_delay_seconds",
+			Help:    "Time from a Pod's containers all running to its cores being applied to the PowerWorkload of its PowerProfile",
+			Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+		},
+		[]string{"node", "profile"},
+	)
+
+	packageBudgetUtilization = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "power_package_budget_utilization_ratio",
+			Help: "Estimated package power committed by the node's active PowerProfiles as a fraction of its configured package power budget",
+		},
+		[]string{"node"},
+	)
+
+	exclusiveCPUsAllocatable = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "power_exclusive_cpus_allocatable",
+			Help: "Number of CPUs on the node the Kubelet can allocate exclusively to Guaranteed Pods",
+		},
+		[]string{"node"},
+	)
+
+	exclusiveCPUsAllocated = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "power_exclusive_cpus_allocated",
+			Help: "Number of exclusive CPUs currently held by the node's Guaranteed Pods",
+		},
+		[]string{"node"},
+	)
+
+	exclusiveCPUsFree = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "power_exclusive_cpus_free",
+			Help: "Number of the node's allocatable exclusive CPUs not held by any Guaranteed Pod",
+		},
+		[]string{"node"},
+	)
+
+	exclusiveCPUsFreeThreshold = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "power_exclusive_cpus_free_threshold",
+			Help: "Configured number of free exclusive CPUs below which the node should be alerted on",
+		},
+		[]string{"node"},
+	)
+
+	workloadCores = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "power_workload_cores",
+			Help:    "Number of cores in a PowerWorkload each time it is created or updated",
+			Buckets: []float64{1, 2, 4, 8, 16, 32, 64, 128},
+		},
+		[]string{"node"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(cpuAllocationsTotal, cpuReleasesTotal, appQoSReachable, profileApplyDelaySeconds, packageBudgetUtilization,
+		exclusiveCPUsAllocatable, exclusiveCPUsAllocated, exclusiveCPUsFree, exclusiveCPUsFreeThreshold, workloadCores)
+}
+
+// recordCPUs adds the number of CPUs to the counter, attaching the Pod UID as an exemplar so the
+// sample can be correlated with the Pod in a tracing backend
+func recordCPUs(counter *prometheus.CounterVec, node string, profile string, podUID string, numCPUs int) {
+	if numCPUs == 0 {
+		return
+	}
+
+	c := counter.WithLabelValues(node, profile)
+	if adder, ok := c.(prometheus.ExemplarAdder); ok && podUID != "" {
+		adder.AddWithExemplar(float64(numCPUs), prometheus.Labels{PodUIDExemplarLabel: podUID})
+		return
+	}
+
+	c.Add(float64(numCPUs))
+}
+
+// podRunningTime returns when the Pod finished transitioning to Running, i.e. when the last of its containers
+// started, falling back to the Pod's start time if no container has reported running yet
+func podRunningTime(pod *corev1.Pod) time.Time {
+	runningTime := time.Time{}
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if containerStatus.State.Running != nil && containerStatus.State.Running.StartedAt.Time.After(runningTime) {
+			runningTime = containerStatus.State.Running.StartedAt.Time
+		}
+	}
+
+	if runningTime.IsZero() && pod.Status.StartTime != nil {
+		runningTime = pod.Status.StartTime.Time
+	}
+
+	return runningTime
+}
+
+// recordApplyDelay observes how long after the Pod reached Running its cores were applied to the PowerWorkload
+func recordApplyDelay(pod *corev1.Pod, profile string) {
+	runningTime := podRunningTime(pod)
+	if runningTime.IsZero() {
+		return
+	}
+
+	profileApplyDelaySeconds.WithLabelValues(pod.Spec.NodeName, profile).Observe(time.Since(runningTime).Seconds())
+}
+
+// recordWorkloadCores observes the number of cores the PowerWorkload now holds
+func recordWorkloadCores(workload *powerv1alpha1.PowerWorkload) {
+	workloadCores.WithLabelValues(workload.Spec.Node.Name).Observe(float64(len(workload.Spec.Node.CpuIds)))
+}
+
+// ObserveAppQoSReachability keeps the power_appqos_reachable gauge for the node up to date with every
+// connection attempt the client makes to the node's AppQoS instance
+func ObserveAppQoSReachability(nodeName string, ac *appqos.AppQoSClient) {
+	ac.SetReachabilityObserver(func(address string, reachable bool) {
+		value := 0.0
+		if reachable {
+			value = 1
+		}
+		appQoSReachable.WithLabelValues(nodeName).Set(value)
+	})
+}
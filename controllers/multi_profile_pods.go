@@ -0,0 +1,46 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// MultiProfileAnnotation was set on Pods whose Containers requested more than one PowerProfile while only one
+// PowerProfile per Pod was supported. Such Pods are now power-managed, so the annotation is only ever removed
+const MultiProfileAnnotation = "power.intel.com/multi-profile-blocked"
+
+// clearMultiProfileAnnotation removes the MultiProfileAnnotation left on the Pod by an earlier Node Agent, as a Pod
+// whose Containers request different PowerProfiles is no longer blocked
+func (r *PowerPodReconciler) clearMultiProfileAnnotation(ctx context.Context, pod *corev1.Pod) {
+	if _, annotated := pod.GetAnnotations()[MultiProfileAnnotation]; !annotated || r.workloadWritesReadOnly() {
+		return
+	}
+
+	patch := client.MergeFrom(pod.DeepCopy())
+	annotations := pod.GetAnnotations()
+	delete(annotations, MultiProfileAnnotation)
+	pod.SetAnnotations(annotations)
+
+	err := r.Patch(ctx, pod, patch)
+	if err != nil {
+		r.Log.Error(err, "error removing Pod's multi-profile annotation", "pod", client.ObjectKey{Namespace: pod.GetNamespace(), Name: pod.GetName()}.String())
+	}
+}
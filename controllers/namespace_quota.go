@@ -0,0 +1,156 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
+)
+
+// namespaceCoreQuota returns how many exclusive cores the PowerConfigs allow the Pods in the namespace to have
+// power-managed on the Node, and whether the namespace has a quota at all. If more than one PowerConfig sets a
+// quota for the namespace the smallest applies
+func (r *PowerPodReconciler) namespaceCoreQuota(ctx context.Context, namespace string) (int, bool, error) {
+	powerConfigs := &powerv1alpha1.PowerConfigList{}
+	err := r.Client.List(ctx, powerConfigs)
+	if err != nil {
+		return 0, false, err
+	}
+
+	quota := 0
+	limited := false
+	for _, key := range []string{namespace, "*"} {
+		for _, powerConfig := range powerConfigs.Items {
+			cores, exists := powerConfig.Spec.NamespaceCoreQuotas[key]
+			if !exists {
+				continue
+			}
+
+			if !limited || cores < quota {
+				quota = cores
+			}
+			limited = true
+		}
+
+		// The wildcard entry only applies to namespaces without their own entry
+		if limited {
+			break
+		}
+	}
+
+	return quota, limited, nil
+}
+
+// checkNamespaceQuota reports whether the Pod's Containers fit in the namespace's quota, their cores added to
+// those the rest of the namespace's Pods already have power-managed on the Node. A rejected Pod isn't retried, as
+// it would only be rejected again until it changes, and the Event is emitted only the first time it is rejected
+func (r *PowerPodReconciler) checkNamespaceQuota(ctx context.Context, pod *corev1.Pod, powerContainers []powerv1alpha1.Container) (bool, error) {
+	quota, limited, err := r.namespaceCoreQuota(ctx, pod.GetNamespace())
+	if err != nil {
+		return false, err
+	}
+
+	requested := containerCores(powerContainers)
+	managed := r.State.GetNamespaceManagedCores(pod.GetNamespace(), pod.GetName())
+	if !limited || managed+requested <= quota {
+		r.quotaRejections.forget(pod.GetUID())
+		return true, nil
+	}
+
+	if r.quotaRejections.reject(pod.GetUID()) {
+		r.Recorder.Eventf(pod, corev1.EventTypeWarning, "NamespaceCoreQuotaExceeded", "Pod requests %d power-managed cores but namespace '%s' already has %d of its quota of %d", requested, pod.GetNamespace(), managed, quota)
+	}
+	return false, nil
+}
+
+// quotaRejections remembers the Pods rejected for exceeding their namespace's quota, so each is only reported once
+type quotaRejections struct {
+	mutex    sync.Mutex
+	rejected map[types.UID]bool
+}
+
+// reject records the Pod as rejected, reporting whether it wasn't already
+func (q *quotaRejections) reject(podUID types.UID) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.rejected == nil {
+		q.rejected = make(map[types.UID]bool)
+	}
+	if q.rejected[podUID] {
+		return false
+	}
+
+	q.rejected[podUID] = true
+	return true
+}
+
+// forget drops the Pod once it is admitted or deleted
+func (q *quotaRejections) forget(podUID types.UID) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	delete(q.rejected, podUID)
+}
+
+// containerCores returns how many exclusive cores the Containers hold between them
+func containerCores(containers []powerv1alpha1.Container) int {
+	cores := 0
+	for _, container := range containers {
+		cores += len(container.ExclusiveCPUs)
+	}
+
+	return cores
+}
+
+// allowedNamespaceProfiles returns the PowerProfiles the PowerConfigs allow Pods in the namespace to request,
+// and whether the namespace is restricted at all
+func (r *PowerPodReconciler) allowedNamespaceProfiles(ctx context.Context, namespace string) (map[string]bool, bool, error) {
+	powerConfigs := &powerv1alpha1.PowerConfigList{}
+	err := r.Client.List(ctx, powerConfigs)
+	if err != nil {
+		return map[string]bool{}, false, err
+	}
+
+	allowedProfiles := make(map[string]bool)
+	restricted := false
+	for _, key := range []string{namespace, "*"} {
+		for _, powerConfig := range powerConfigs.Items {
+			profiles, exists := powerConfig.Spec.NamespaceProfiles[key]
+			if !exists {
+				continue
+			}
+
+			restricted = true
+			for _, profile := range profiles {
+				allowedProfiles[profile] = true
+			}
+		}
+
+		// The wildcard entry only applies to namespaces without their own entry
+		if restricted {
+			break
+		}
+	}
+
+	return allowedProfiles, restricted, nil
+}
@@ -0,0 +1,58 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
+)
+
+// resetReplacedNode records the UID of the Node named in the PowerWorkload's NodeInfo the first time it is seen.
+// A Node recreated under the same name has a new UID, so if the recorded UID differs the NodeInfo's Containers and
+// cores, which belonged to the old Node, are reset. Returns whether the PowerWorkload was updated
+func (r *PowerWorkloadReconciler) resetReplacedNode(logger logr.Logger, workload *powerv1alpha1.PowerWorkload) (bool, error) {
+	node := &corev1.Node{}
+	err := r.Client.Get(context.TODO(), client.ObjectKey{Name: workload.Spec.Node.Name}, node)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	nodeUID := string(node.GetUID())
+	if nodeUID == "" || workload.Spec.Node.UID == nodeUID {
+		return false, nil
+	}
+
+	if workload.Spec.Node.UID != "" {
+		logger.Info("Node has been replaced, resetting the PowerWorkload's NodeInfo", "node", node.Name, "previousUID", workload.Spec.Node.UID, "uid", nodeUID)
+		workload.Spec.Node = powerv1alpha1.NodeInfo{
+			Name: workload.Spec.Node.Name,
+		}
+	}
+	workload.Spec.Node.UID = nodeUID
+
+	return true, r.Client.Update(context.TODO(), workload)
+}
@@ -0,0 +1,167 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/appqos"
+)
+
+// rolloutRecheckInterval is how often a deferred frequency increase checks whether the Node's reductions have been applied
+const rolloutRecheckInterval = time.Second
+
+// rolloutSequencer remembers when each PowerWorkload's frequency increase was first deferred, so it is applied
+// anyway once the rollout window has passed rather than waiting on the Node's reductions indefinitely
+type rolloutSequencer struct {
+	mutex         sync.Mutex
+	deferredSince map[client.ObjectKey]time.Time
+}
+
+func (s *rolloutSequencer) deferral(key client.ObjectKey, now time.Time) time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.deferredSince == nil {
+		s.deferredSince = make(map[client.ObjectKey]time.Time)
+	}
+	if _, deferred := s.deferredSince[key]; !deferred {
+		s.deferredSince[key] = now
+	}
+
+	return s.deferredSince[key]
+}
+
+func (s *rolloutSequencer) clear(key client.ObjectKey) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.deferredSince, key)
+}
+
+// sequenceIncrease returns how long to defer moving the PowerWorkload's Pool to the Power Profile if doing so raises
+// its maximum frequency while other PowerWorkloads on the Node still have a reduction to apply. Applying the
+// reductions first keeps the Node within its package power budget while a rollout is in progress
+func (r *PowerWorkloadReconciler) sequenceIncrease(logger logr.Logger, workload *powerv1alpha1.PowerWorkload, pool *appqos.Pool, powerProfile *appqos.PowerProfile) (time.Duration, error) {
+	key := client.ObjectKey{Name: workload.Name, Namespace: workload.Namespace}
+
+	profiles, err := r.AppQoSClient.GetPowerProfiles(AppQoSClientAddress)
+	if err != nil {
+		return 0, err
+	}
+	if !raisesMaxFrequency(profiles, pool.PowerProfile, powerProfile) {
+		r.rollout.clear(key)
+		return 0, nil
+	}
+
+	reductions, err := r.pendingReductions(workload, profiles)
+	if err != nil {
+		return 0, err
+	}
+	if len(reductions) == 0 {
+		r.rollout.clear(key)
+		return 0, nil
+	}
+
+	remaining := r.RolloutWindow - time.Since(r.rollout.deferral(key, time.Now()))
+	if remaining <= 0 {
+		logger.Info("rollout window passed with frequency reductions still pending, applying the increase", "reductions", reductions)
+		r.rollout.clear(key)
+		return 0, nil
+	}
+
+	logger.Info("deferring frequency increase until the Node's pending reductions are applied", "reductions", reductions)
+	if remaining > rolloutRecheckInterval {
+		return rolloutRecheckInterval, nil
+	}
+
+	return remaining, nil
+}
+
+// pendingReductions returns the names of the Node's other PowerWorkloads whose Pool has yet to be moved to a
+// PowerProfile with a lower maximum frequency than the one it has in AppQoS
+func (r *PowerWorkloadReconciler) pendingReductions(workload *powerv1alpha1.PowerWorkload, profiles []appqos.PowerProfile) ([]string, error) {
+	powerWorkloads := &powerv1alpha1.PowerWorkloadList{}
+	err := r.Client.List(context.TODO(), powerWorkloads, client.InNamespace(workload.Namespace))
+	if err != nil {
+		return []string{}, err
+	}
+
+	pools, err := r.AppQoSClient.GetPools(AppQoSClientAddress)
+	if err != nil {
+		return []string{}, err
+	}
+
+	reductions := make([]string, 0)
+	for _, powerWorkload := range powerWorkloads.Items {
+		if powerWorkload.Name == workload.Name || powerWorkload.Spec.AllCores || powerWorkload.Spec.Node.Name != workload.Spec.Node.Name {
+			continue
+		}
+
+		desired := findProfileByName(profiles, powerWorkload.Spec.PowerProfile)
+		if desired == nil {
+			continue
+		}
+		for i := range pools {
+			if pools[i].Name != nil && *pools[i].Name == powerWorkload.Name && raisesMaxFrequency(profiles, desired.ID, findProfileByID(profiles, pools[i].PowerProfile)) {
+				reductions = append(reductions, powerWorkload.Name)
+			}
+		}
+	}
+
+	return reductions, nil
+}
+
+// raisesMaxFrequency reports whether moving a Pool from the Power Profile with the current ID to the next Power
+// Profile raises its maximum frequency. A Pool with no known Power Profile is not compared
+func raisesMaxFrequency(profiles []appqos.PowerProfile, currentID *int, next *appqos.PowerProfile) bool {
+	current := findProfileByID(profiles, currentID)
+	if current == nil || next == nil || current.MaxFreq == nil || next.MaxFreq == nil {
+		return false
+	}
+
+	return *next.MaxFreq > *current.MaxFreq
+}
+
+func findProfileByID(profiles []appqos.PowerProfile, id *int) *appqos.PowerProfile {
+	if id == nil {
+		return nil
+	}
+	for i := range profiles {
+		if profiles[i].ID != nil && *profiles[i].ID == *id {
+			return &profiles[i]
+		}
+	}
+
+	return nil
+}
+
+func findProfileByName(profiles []appqos.PowerProfile, name string) *appqos.PowerProfile {
+	for i := range profiles {
+		if profiles[i].Name != nil && *profiles[i].Name == name {
+			return &profiles[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,264 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/appqos"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/cpuhotplug"
+)
+
+// cpuPackages returns the set of packages holding the given cores
+func cpuPackages(cpus []int) (map[int]bool, error) {
+	packages := make(map[int]bool)
+	for _, cpu := range cpus {
+		packageID, err := cpuhotplug.GetPackageID(cpu)
+		if err != nil {
+			return map[int]bool{}, err
+		}
+		packages[packageID] = true
+	}
+
+	return packages, nil
+}
+
+// packageWorkloads returns the PowerWorkloads on the Node with cores on the same packages as the given cores.
+// PowerWorkloads spanning several packages pull in the PowerWorkloads on each of them, so every PowerWorkload
+// returned settles on the same PowerProfile
+func (r *PowerWorkloadReconciler) packageWorkloads(namespace string, nodeName string, cpus []int) ([]powerv1alpha1.PowerWorkload, error) {
+	workloads := &powerv1alpha1.PowerWorkloadList{}
+	err := r.Client.List(context.TODO(), workloads, client.InNamespace(namespace))
+	if err != nil {
+		return []powerv1alpha1.PowerWorkload{}, err
+	}
+
+	packages, err := cpuPackages(cpus)
+	if err != nil {
+		return []powerv1alpha1.PowerWorkload{}, err
+	}
+
+	candidates := make([]powerv1alpha1.PowerWorkload, 0)
+	candidatePackages := make([]map[int]bool, 0)
+	for _, workload := range workloads.Items {
+		if workload.Spec.AllCores || workload.Spec.Node.Name != nodeName || len(workload.Spec.Node.CpuIds) == 0 {
+			continue
+		}
+
+		workloadPackages, err := cpuPackages(workload.Spec.Node.CpuIds)
+		if err != nil {
+			return []powerv1alpha1.PowerWorkload{}, err
+		}
+		candidates = append(candidates, workload)
+		candidatePackages = append(candidatePackages, workloadPackages)
+	}
+
+	included := make([]bool, len(candidates))
+	for changed := true; changed; {
+		changed = false
+		for i := range candidates {
+			if included[i] || !sharesPackage(packages, candidatePackages[i]) {
+				continue
+			}
+
+			included[i] = true
+			changed = true
+			for packageID := range candidatePackages[i] {
+				packages[packageID] = true
+			}
+		}
+	}
+
+	packageWorkloads := make([]powerv1alpha1.PowerWorkload, 0)
+	for i, workload := range candidates {
+		if included[i] {
+			packageWorkloads = append(packageWorkloads, workload)
+		}
+	}
+
+	return packageWorkloads, nil
+}
+
+func sharesPackage(packagesOne map[int]bool, packagesTwo map[int]bool) bool {
+	for packageID := range packagesOne {
+		if packagesTwo[packageID] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// effectivePackageProfile picks the single PowerProfile for PowerWorkloads sharing packages: the one with the
+// highest maximum frequency, so no PowerWorkload runs slower than it requested. The Shared pool's cores on the
+// packages run at the packages' frequency too, so its PowerProfile is counted among them. The fallback is
+// returned if none of their PowerProfiles exist in AppQoS
+func (r *PowerWorkloadReconciler) effectivePackageProfile(workloads []powerv1alpha1.PowerWorkload, fallback *appqos.PowerProfile) (*appqos.PowerProfile, error) {
+	effectiveProfile := fallback
+	checked := make(map[string]bool)
+	for _, workload := range workloads {
+		if checked[workload.Spec.PowerProfile] {
+			continue
+		}
+		checked[workload.Spec.PowerProfile] = true
+
+		profile, err := r.AppQoSClient.GetProfileByName(workload.Spec.PowerProfile, AppQoSClientAddress)
+		if err != nil {
+			return nil, err
+		}
+		if reflect.DeepEqual(profile, &appqos.PowerProfile{}) || profile.ID == nil {
+			continue
+		}
+
+		if effectiveProfile == nil || effectiveProfile.ID == nil || profileOutranks(profile, effectiveProfile) {
+			effectiveProfile = profile
+		}
+	}
+
+	sharedProfile, err := r.sharedPoolPackageProfile(workloads)
+	if err != nil {
+		return nil, err
+	}
+	if sharedProfile != nil && effectiveProfile != nil && effectiveProfile.ID != nil && profileOutranks(sharedProfile, effectiveProfile) {
+		effectiveProfile = sharedProfile
+	}
+
+	return effectiveProfile, nil
+}
+
+// sharedPoolPackageProfile returns the PowerProfile of the Shared pool if it has cores on the packages of the
+// PowerWorkloads, or nil if it hasn't or is the Default pool, which has no PowerProfile
+func (r *PowerWorkloadReconciler) sharedPoolPackageProfile(workloads []powerv1alpha1.PowerWorkload) (*appqos.PowerProfile, error) {
+	workloadCPUs := make([]int, 0)
+	for _, workload := range workloads {
+		workloadCPUs = append(workloadCPUs, workload.Spec.Node.CpuIds...)
+	}
+	if len(workloadCPUs) == 0 {
+		return nil, nil
+	}
+
+	sharedPool, err := r.AppQoSClient.GetSharedPool(AppQoSClientAddress)
+	if err != nil {
+		return nil, err
+	}
+	if sharedPool.PowerProfile == nil || sharedPool.Cores == nil {
+		return nil, nil
+	}
+
+	packages, err := cpuPackages(workloadCPUs)
+	if err != nil {
+		return nil, err
+	}
+	sharedPackages, err := cpuPackages(*sharedPool.Cores)
+	if err != nil {
+		return nil, err
+	}
+	if !sharesPackage(packages, sharedPackages) {
+		return nil, nil
+	}
+
+	profiles, err := r.AppQoSClient.GetPowerProfiles(AppQoSClientAddress)
+	if err != nil {
+		return nil, err
+	}
+	for i := range profiles {
+		if profiles[i].ID != nil && *profiles[i].ID == *sharedPool.PowerProfile {
+			return &profiles[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// profileOutranks reports whether the candidate PowerProfile allows a higher maximum frequency than the
+// current one, breaking ties by name so every PowerWorkload on the package picks the same PowerProfile
+func profileOutranks(candidate *appqos.PowerProfile, current *appqos.PowerProfile) bool {
+	candidateMax, currentMax := 0, 0
+	if candidate.MaxFreq != nil {
+		candidateMax = *candidate.MaxFreq
+	}
+	if current.MaxFreq != nil {
+		currentMax = *current.MaxFreq
+	}
+
+	if candidateMax != currentMax {
+		return candidateMax > currentMax
+	}
+
+	return current.Name == nil || (candidate.Name != nil && *candidate.Name < *current.Name)
+}
+
+// reconcilePackageScope gives the Pool of every PowerWorkload on the packages holding the given cores the
+// PowerProfile in effect for those packages. It does nothing unless frequencies are set per package
+func (r *PowerWorkloadReconciler) reconcilePackageScope(logger logr.Logger, namespace string, nodeName string, cpus []int) error {
+	if r.ScopeMode != ScopePackage || len(cpus) == 0 {
+		return nil
+	}
+
+	workloads, err := r.packageWorkloads(namespace, nodeName, cpus)
+	if err != nil {
+		logger.Error(err, "error retrieving PowerWorkloads sharing a package")
+		return err
+	}
+
+	effectiveProfile, err := r.effectivePackageProfile(workloads, nil)
+	if err != nil {
+		logger.Error(err, "error resolving the PowerProfile in effect for the packages")
+		return err
+	}
+	if effectiveProfile == nil {
+		return nil
+	}
+
+	// Every Pool on the packages is updated together so they don't run at different frequencies in between
+	updatedPools := make([]appqos.Pool, 0)
+	updatedWorkloads := make([]string, 0)
+	for _, workload := range workloads {
+		pool, err := r.AppQoSClient.GetPoolByName(AppQoSClientAddress, workload.Name)
+		if err != nil {
+			logger.Error(err, "error retrieving Pool from AppQoS")
+			return err
+		}
+		if reflect.DeepEqual(pool, &appqos.Pool{}) || (pool.PowerProfile != nil && *pool.PowerProfile == *effectiveProfile.ID) {
+			continue
+		}
+
+		updatedPools = append(updatedPools, appqos.Pool{
+			Name:         pool.Name,
+			ID:           pool.ID,
+			Cores:        pool.Cores,
+			PowerProfile: effectiveProfile.ID,
+		})
+		updatedWorkloads = append(updatedWorkloads, workload.Name)
+	}
+	if len(updatedPools) == 0 {
+		return nil
+	}
+
+	err = r.applyPools(logger, updatedPools)
+	if err != nil {
+		return err
+	}
+	logger.Info("Applied the package's PowerProfile to the PowerWorkloads sharing it", "powerWorkloads", updatedWorkloads, "profile", *effectiveProfile.Name)
+
+	return nil
+}
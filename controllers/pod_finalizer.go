@@ -0,0 +1,221 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PodCleanupFinalizer holds back the deletion of a power-managed Pod until its cores have been released from
+// its PowerWorkloads, so the release isn't lost if the Node Agent is down when the Pod is deleted
+const PodCleanupFinalizer = "power.intel.com/pod-cleanup"
+
+// hasPodCleanupFinalizer checks whether the Pod carries the PodCleanupFinalizer
+func hasPodCleanupFinalizer(pod *corev1.Pod) bool {
+	for _, finalizer := range pod.GetFinalizers() {
+		if finalizer == PodCleanupFinalizer {
+			return true
+		}
+	}
+
+	return false
+}
+
+// addPodCleanupFinalizer attaches the PodCleanupFinalizer to the Pod if it doesn't already carry it
+func (r *PowerPodReconciler) addPodCleanupFinalizer(ctx context.Context, pod *corev1.Pod) error {
+	if hasPodCleanupFinalizer(pod) {
+		return nil
+	}
+
+	patch := client.MergeFrom(pod.DeepCopy())
+	pod.SetFinalizers(append(pod.GetFinalizers(), PodCleanupFinalizer))
+	return r.Patch(ctx, pod, patch)
+}
+
+// removePodCleanupFinalizer removes the PodCleanupFinalizer from the Pod, letting its deletion complete. A Pod
+// without the finalizer, or already gone, is left as is
+func (r *PowerPodReconciler) removePodCleanupFinalizer(ctx context.Context, pod *corev1.Pod) error {
+	if !hasPodCleanupFinalizer(pod) {
+		return nil
+	}
+
+	patch := client.MergeFrom(pod.DeepCopy())
+	finalizers := make([]string, 0, len(pod.GetFinalizers()))
+	for _, finalizer := range pod.GetFinalizers() {
+		if finalizer != PodCleanupFinalizer {
+			finalizers = append(finalizers, finalizer)
+		}
+	}
+	pod.SetFinalizers(finalizers)
+
+	err := r.Patch(ctx, pod, patch)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
+
+// podStateFromWorkloads rebuilds the Pod's internal state from the PowerWorkloads its Containers are recorded in.
+// The internal state doesn't survive a restart of the Node Agent, so a Pod deleted while it was down is released
+// using this instead. Pods in other namespaces may share its name and PowerWorkloads, so Containers are matched on
+// the Pod's UID, or on their own ID if recorded without one
+func (r *PowerPodReconciler) podStateFromWorkloads(ctx context.Context, namespace string, pod *corev1.Pod) (powerv1alpha1.GuaranteedPod, error) {
+	guaranteedPod := powerv1alpha1.GuaranteedPod{
+		Node:       pod.Spec.NodeName,
+		Name:       pod.GetName(),
+		UID:        string(pod.GetUID()),
+		Containers: make([]powerv1alpha1.Container, 0),
+	}
+
+	workloads := &powerv1alpha1.PowerWorkloadList{}
+	err := r.Client.List(ctx, workloads, client.InNamespace(namespace))
+	if err != nil {
+		return guaranteedPod, err
+	}
+
+	containerIDs := make(map[string]bool)
+	for _, containerStatus := range podContainerStatuses(pod) {
+		_, containerID := splitContainerID(containerStatus.ContainerID)
+		containerIDs[containerID] = true
+	}
+
+	for _, workload := range workloads.Items {
+		for _, container := range workload.Spec.Node.Containers {
+			if container.PodUID != "" && container.PodUID != string(pod.GetUID()) {
+				continue
+			}
+			if container.PodUID == "" && (container.Pod != pod.GetName() || !containerIDs[container.Id]) {
+				continue
+			}
+
+			if container.Workload == "" {
+				container.Workload = workload.GetName()
+			}
+			guaranteedPod.Containers = append(guaranteedPod.Containers, container)
+		}
+	}
+
+	return guaranteedPod, nil
+}
+
+// releaseDeletedPod releases the deleted Pod's cores from each of its PowerWorkloads, then forgets it. A Pod no
+// longer in the State but still carrying the cleanup finalizer has its cores found from the PowerWorkloads
+func (r *PowerPodReconciler) releaseDeletedPod(ctx context.Context, logger logr.Logger, req ctrl.Request, pod *corev1.Pod) (ctrl.Result, error) {
+	// Any change held for the Pod would add back the cores being released
+	r.workloadWrites.drop(req.NamespacedName)
+
+	powerPodState := r.State.GetPodFromState(pod.GetName())
+	if powerPodState.Name == "" && hasPodCleanupFinalizer(pod) {
+		var err error
+		powerPodState, err = r.podStateFromWorkloads(ctx, r.workloadNamespace(req.NamespacedName.Namespace), pod)
+		if err != nil {
+			logger.Error(err, "error retrieving Pod's cores from PowerWorkloads")
+			return ctrl.Result{}, err
+		}
+	}
+
+	// The State's cores were recorded against another Node's PowerWorkloads, so releasing them here would
+	// strip cores from the wrong Node
+	if powerPodState.Name != "" && powerPodState.Node != pod.Spec.NodeName {
+		logger.Info("Pod recorded in internal state against a different Node, skipping release of its cores", "stateNode", powerPodState.Node, "podNode", pod.Spec.NodeName)
+		return r.forgetDeletedPod(ctx, logger, req, pod)
+	}
+
+	// Containers with different PowerProfiles are in different PowerWorkloads, so each PowerWorkload is only
+	// given back the cores and Containers that were added to it
+	workloadContainers := make(map[string][]powerv1alpha1.Container)
+	for _, container := range powerPodState.Containers {
+		workloadName := containerWorkloadName(container, profileNameForNode(container.PowerProfile, powerPodState.Node))
+		workloadContainers[workloadName] = append(workloadContainers[workloadName], container)
+	}
+
+	// The Pod stays in the State until its cores have been released, so a failed release is retried
+	for workloadName, containers := range workloadContainers {
+		workloadKey := client.ObjectKey{
+			Namespace: r.workloadNamespace(req.NamespacedName.Namespace),
+			Name:      workloadName,
+		}
+		release := podRelease{
+			Node:       powerPodState.Node,
+			Pod:        powerPodState.Name,
+			UID:        powerPodState.UID,
+			CPUs:       make([]int, 0),
+			Containers: containers,
+		}
+		for _, container := range containers {
+			release.CPUs = append(release.CPUs, container.ExclusiveCPUs...)
+		}
+
+		// A queued release would be lost with the Node Agent, so the finalizer can't be removed until it's written
+		if r.DeletionCoalesceWindow > 0 && !hasPodCleanupFinalizer(pod) {
+			r.coalesceDeletion(logger, workloadKey, release)
+			continue
+		}
+
+		err := r.releaseWorkloadCPUs(ctx, logger, workloadKey, []podRelease{release})
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// In read-only mode the cores weren't released, so the Pod is kept until they can be
+	if r.workloadWritesReadOnly() {
+		return r.readOnlyResult(), nil
+	}
+
+	return r.forgetDeletedPod(ctx, logger, req, pod)
+}
+
+// forgetDeletedPod drops a deleted Pod, whose cores have been released, from the internal state and restores
+// its parked sibling threads. The cleanup finalizer is removed last, once nothing is left to be done for the Pod
+func (r *PowerPodReconciler) forgetDeletedPod(ctx context.Context, logger logr.Logger, req ctrl.Request, pod *corev1.Pod) (ctrl.Result, error) {
+	err := r.State.DeletePodFromState(pod.GetName())
+	if err != nil {
+		logger.Error(err, "error removing Pod from internal state")
+		return ctrl.Result{}, err
+	}
+	r.State.DeleteRestartCounts(pod.GetName())
+	r.State.DeleteManagedCores(pod.GetName(), pod.GetNamespace())
+	r.memoryAlignments.forget(pod.GetUID())
+	r.quotaRejections.forget(pod.GetUID())
+	r.cpuSetReads.forget(pod.GetUID())
+	if r.ReportPowerPods {
+		r.deletePowerPod(ctx, logger, req.NamespacedName.Namespace, pod.GetName())
+	}
+
+	err = r.restoreParkedSiblings(pod.GetName())
+	if err != nil {
+		logger.Error(err, "error restoring parked sibling threads")
+		return ctrl.Result{}, err
+	}
+
+	err = r.removePodCleanupFinalizer(ctx, pod)
+	if err != nil {
+		logger.Error(err, "error removing Pod cleanup finalizer")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
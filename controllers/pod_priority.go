@@ -0,0 +1,147 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// priorityBuffer holds Pod requests back from the controller's work queue while it is busy, releasing them
+// highest PriorityClass first so critical Pods get their profiles before best-effort ones during a storm. As many
+// requests are released as there are workers, so every worker is kept busy
+type priorityBuffer struct {
+	mutex   sync.Mutex
+	queue   workqueue.Interface
+	tracker *queueTracker
+	pending map[reconcile.Request]bufferedRequest
+	arrived uint64
+	workers int
+}
+
+type bufferedRequest struct {
+	priority int32
+	arrival  uint64
+}
+
+func (b *priorityBuffer) add(req reconcile.Request, priority int32, q workqueue.Interface) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.pending == nil {
+		b.pending = make(map[reconcile.Request]bufferedRequest)
+	}
+	b.queue = q
+	if b.tracker != nil {
+		b.tracker.add(req)
+	}
+
+	// A repeated request keeps its place in line, but takes the Pod's latest priority
+	if buffered, ok := b.pending[req]; ok {
+		buffered.priority = priority
+		b.pending[req] = buffered
+	} else {
+		b.arrived++
+		b.pending[req] = bufferedRequest{priority: priority, arrival: b.arrived}
+	}
+
+	b.releaseLocked()
+}
+
+// started drops a request that a worker has picked up and releases the next one if the queue has room
+func (b *priorityBuffer) started(req reconcile.Request) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	delete(b.pending, req)
+	b.releaseLocked()
+}
+
+// releaseLocked hands the highest priority requests to the queue until it holds one for each worker
+func (b *priorityBuffer) releaseLocked() {
+	if b.queue == nil {
+		return
+	}
+
+	workers := b.workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	for len(b.pending) > 0 && b.queue.Len() < workers {
+		// Equal priorities are released in the order they arrived
+		var next reconcile.Request
+		var nextBuffered bufferedRequest
+		found := false
+		for req, buffered := range b.pending {
+			if !found || buffered.priority > nextBuffered.priority ||
+				(buffered.priority == nextBuffered.priority && buffered.arrival < nextBuffered.arrival) {
+				next, nextBuffered, found = req, buffered, true
+			}
+		}
+
+		delete(b.pending, next)
+		b.queue.Add(next)
+	}
+}
+
+// podPriorityHandler enqueues a request for each Pod event through the priorityBuffer
+type podPriorityHandler struct {
+	buffer *priorityBuffer
+}
+
+func (h *podPriorityHandler) enqueue(meta types.NamespacedName, obj interface{}, q workqueue.RateLimitingInterface) {
+	priority := int32(0)
+	if pod, ok := obj.(*corev1.Pod); ok && pod.Spec.Priority != nil {
+		priority = *pod.Spec.Priority
+	}
+
+	h.buffer.add(reconcile.Request{NamespacedName: meta}, priority, q)
+}
+
+func (h *podPriorityHandler) Create(e event.CreateEvent, q workqueue.RateLimitingInterface) {
+	if e.Meta == nil {
+		return
+	}
+	h.enqueue(types.NamespacedName{Namespace: e.Meta.GetNamespace(), Name: e.Meta.GetName()}, e.Object, q)
+}
+
+func (h *podPriorityHandler) Update(e event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	if e.MetaNew == nil {
+		return
+	}
+	h.enqueue(types.NamespacedName{Namespace: e.MetaNew.GetNamespace(), Name: e.MetaNew.GetName()}, e.ObjectNew, q)
+}
+
+func (h *podPriorityHandler) Delete(e event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	if e.Meta == nil {
+		return
+	}
+	h.enqueue(types.NamespacedName{Namespace: e.Meta.GetNamespace(), Name: e.Meta.GetName()}, e.Object, q)
+}
+
+func (h *podPriorityHandler) Generic(e event.GenericEvent, q workqueue.RateLimitingInterface) {
+	if e.Meta == nil {
+		return
+	}
+	h.enqueue(types.NamespacedName{Namespace: e.Meta.GetNamespace(), Name: e.Meta.GetName()}, e.Object, q)
+}
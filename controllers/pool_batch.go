@@ -0,0 +1,76 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"github.com/go-logr/logr"
+
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/appqos"
+)
+
+const (
+	// BatchCapability is advertised by AppQoS instances able to apply several Pool updates in one transaction
+	BatchCapability = "batch"
+)
+
+// batchSupported reports whether the node's AppQoS instance can apply several Pool updates in one transaction.
+// Its capabilities don't change while the Node Agent runs, so AppQoS is only queried until it first answers
+func (r *PowerWorkloadReconciler) batchSupported(logger logr.Logger) bool {
+	if r.capabilities == nil {
+		capabilities, err := r.AppQoSClient.GetCapabilities(AppQoSClientAddress)
+		if err != nil {
+			logger.Info("Could not discover AppQoS capabilities, applying Pool updates one at a time", "error", err.Error())
+			return false
+		}
+		r.capabilities = capabilities
+	}
+
+	for _, capability := range r.capabilities {
+		if capability == BatchCapability {
+			return true
+		}
+	}
+
+	return false
+}
+
+// applyPools updates the given Pools in AppQoS. If the node's AppQoS instance supports it they are applied in
+// one transaction, so the PowerWorkloads never run with only some of the updates in place, otherwise one at a
+// time in the order given
+func (r *PowerWorkloadReconciler) applyPools(logger logr.Logger, pools []appqos.Pool) error {
+	if len(pools) > 1 && r.batchSupported(logger) {
+		appqosBatchResponse, err := r.AppQoSClient.PostBatch(&appqos.Batch{Pools: &pools}, AppQoSClientAddress)
+		if err != nil {
+			logger.Error(err, appqosBatchResponse)
+			return err
+		}
+
+		return nil
+	}
+
+	for _, pool := range pools {
+		id := *pool.ID
+		pool.ID = nil
+		appqosPutResponse, err := r.AppQoSClient.PutPool(&pool, AppQoSClientAddress, id)
+		if err != nil {
+			logger.Error(err, appqosPutResponse)
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"math"
+
+	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
+)
+
+// DefaultCoreWattsPerGHz is the estimated power a single core draws for every GHz of its maximum frequency
+const DefaultCoreWattsPerGHz = 2.5
+
+// estimateCommittedPower estimates the package power, in watts, committed by the exclusive cores of the Node's
+// PowerWorkloads. Each core is taken to draw coreWattsPerGHz for every GHz of its PowerProfile's maximum
+// frequency, the worst case the PowerProfile allows. Cores whose PowerProfile sets no maximum aren't counted
+func estimateCommittedPower(nodeName string, profiles []powerv1alpha1.PowerProfile, workloads []powerv1alpha1.PowerWorkload, coreWattsPerGHz float64) float64 {
+	maxFrequencies := make(map[string]int)
+	for _, profile := range profiles {
+		maxFrequencies[profile.Name] = profile.Spec.Max
+	}
+
+	committed := 0.0
+	for _, workload := range workloads {
+		if workload.Spec.AllCores || workload.Spec.Node.Name != nodeName {
+			continue
+		}
+
+		maxGHz := float64(maxFrequencies[workload.Spec.PowerProfile]) / 1000
+		committed += float64(len(workload.Spec.Node.CpuIds)) * maxGHz * coreWattsPerGHz
+	}
+
+	return committed
+}
+
+// powerBudgetStatus reports the committed power of the Node's PowerWorkloads against the configured package
+// power budget, keeping the power_package_budget_utilization_ratio gauge in step
+func (r *PowerNodeReconciler) powerBudgetStatus(nodeName string, profiles []powerv1alpha1.PowerProfile, workloads []powerv1alpha1.PowerWorkload) *powerv1alpha1.PowerBudgetStatus {
+	if r.PackagePowerBudget <= 0 {
+		return nil
+	}
+
+	coreWattsPerGHz := r.CoreWattsPerGHz
+	if coreWattsPerGHz <= 0 {
+		coreWattsPerGHz = DefaultCoreWattsPerGHz
+	}
+
+	committed := estimateCommittedPower(nodeName, profiles, workloads, coreWattsPerGHz)
+	utilization := committed / float64(r.PackagePowerBudget)
+	packageBudgetUtilization.WithLabelValues(nodeName).Set(utilization)
+
+	return &powerv1alpha1.PowerBudgetStatus{
+		BudgetWatts:        r.PackagePowerBudget,
+		CommittedWatts:     int(math.Round(committed)),
+		UtilizationPercent: int(math.Round(utilization * 100)),
+	}
+}
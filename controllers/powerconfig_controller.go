@@ -265,8 +265,12 @@ func (r *PowerConfigReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error)
 		return ctrl.Result{}, err
 	}
 
-	// Check PowerProfiles for any that are no longer requested; only check base profiles
+	// Check PowerProfiles for any that are no longer requested; only check base profiles. The default set
+	// created on install stays available whether or not the PowerConfig requests it
 	for _, profile := range powerProfiles.Items {
+		if profile.Labels[DefaultProfileLabel] == "true" {
+			continue
+		}
 		if _, exists := extendedResourcePercentage[profile.Spec.Name]; exists {
 			if !util.StringInStringList(profile.Spec.Name, config.Spec.PowerProfiles) {
 				err = r.Client.Delete(context.TODO(), &profile)
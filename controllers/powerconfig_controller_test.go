@@ -7,7 +7,10 @@ import (
 
 	"testing"
 
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -17,6 +20,7 @@ import (
 
 	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
 	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/state"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/util"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -2287,3 +2291,184 @@ func TestPowerConfigCreationDaemonSetAlreadyExists(t *testing.T) {
 		}
 	}
 }
+
+func TestProfileResourceUtilization(t *testing.T) {
+	profilePod := func(name string, nodeName string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: PowerConfigNamespace,
+			},
+			Spec: corev1.PodSpec{
+				NodeName: nodeName,
+				Containers: []corev1.Container{
+					{
+						Name: "example-container",
+						Resources: corev1.ResourceRequirements{
+							Requests: map[corev1.ResourceName]resource.Quantity{
+								corev1.ResourceName("power.intel.com/performance"): *resource.NewQuantity(2, resource.DecimalSI),
+							},
+						},
+					},
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase: phase,
+			},
+		}
+	}
+
+	tcases := []struct {
+		testCase           string
+		pods               []*corev1.Pod
+		expectedAdvertised float64
+		expectedAllocated  float64
+	}{
+		{
+			testCase: "Test Case 1",
+			pods: []*corev1.Pod{
+				profilePod("example-pod1", "example-node1", corev1.PodRunning),
+				profilePod("example-pod2", "example-node1", corev1.PodRunning),
+			},
+			expectedAdvertised: 10,
+			expectedAllocated:  4,
+		},
+		{
+			testCase: "Test Case 2",
+			pods: []*corev1.Pod{
+				profilePod("example-pod1", "example-node1", corev1.PodRunning),
+				profilePod("example-pod2", "", corev1.PodPending),
+				profilePod("example-pod3", "example-node1", corev1.PodSucceeded),
+			},
+			expectedAdvertised: 10,
+			expectedAllocated:  2,
+		},
+	}
+
+	for _, tc := range tcases {
+		objs := []runtime.Object{
+			&corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "example-node1",
+				},
+				Status: corev1.NodeStatus{
+					Allocatable: map[corev1.ResourceName]resource.Quantity{
+						corev1.ResourceName("power.intel.com/performance"): *resource.NewQuantity(10, resource.DecimalSI),
+						corev1.ResourceCPU: resource.MustParse("8"),
+					},
+				},
+			},
+		}
+		for _, pod := range tc.pods {
+			objs = append(objs, pod)
+		}
+
+		collector := &ProfileResourceCollector{
+			Client: fake.NewFakeClient(objs...),
+			Log:    ctrl.Log.WithName("testing"),
+		}
+
+		ch := make(chan prometheus.Metric, 10)
+		collector.Collect(ch)
+		close(ch)
+
+		values := make(map[string]float64)
+		for metric := range ch {
+			m := &dto.Metric{}
+			err := metric.Write(m)
+			if err != nil {
+				t.Fatal(fmt.Sprintf("%s - error writing metric", tc.testCase))
+			}
+			if len(m.Label) != 1 || m.Label[0].GetValue() != "performance" {
+				t.Errorf("%s - Failed: Expected metrics only for the 'performance' PowerProfile, got labels %v", tc.testCase, m.Label)
+			}
+			values[metric.Desc().String()] = m.Gauge.GetValue()
+		}
+
+		if values[profileResourcesAdvertisedDesc.String()] != tc.expectedAdvertised {
+			t.Errorf("%s - Failed: Expected %v advertised resources, got %v", tc.testCase, tc.expectedAdvertised, values[profileResourcesAdvertisedDesc.String()])
+		}
+
+		if values[profileResourcesAllocatedDesc.String()] != tc.expectedAllocated {
+			t.Errorf("%s - Failed: Expected %v allocated resources, got %v", tc.testCase, tc.expectedAllocated, values[profileResourcesAllocatedDesc.String()])
+		}
+	}
+}
+
+func TestDefaultProfileCreation(t *testing.T) {
+	tcases := []struct {
+		testCase         string
+		existingProfiles []runtime.Object
+		expectedEpps     map[string]string
+		expectedDefaults []string
+	}{
+		{
+			testCase:         "Test Case 1",
+			existingProfiles: []runtime.Object{},
+			expectedEpps: map[string]string{
+				"performance":         "performance",
+				"balance-performance": "balance_performance",
+				"balance-power":       "balance_power",
+				"power":               "power",
+			},
+			expectedDefaults: []string{"performance", "balance-performance", "balance-power", "power"},
+		},
+		{
+			testCase: "Test Case 2",
+			existingProfiles: []runtime.Object{
+				&powerv1alpha1.PowerProfile{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "performance",
+						Namespace: PowerConfigNamespace,
+					},
+					Spec: powerv1alpha1.PowerProfileSpec{
+						Name: "performance",
+						Epp:  "balance_performance",
+					},
+				},
+			},
+			expectedEpps: map[string]string{
+				"performance":         "balance_performance",
+				"balance-performance": "balance_performance",
+				"balance-power":       "balance_power",
+				"power":               "power",
+			},
+			expectedDefaults: []string{"balance-performance", "balance-power", "power"},
+		},
+	}
+
+	for _, tc := range tcases {
+		r, err := createPowerConfigReconcilerObject(tc.existingProfiles)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		err = CreateDefaultProfiles(context.TODO(), r.Client, r.Log, PowerConfigNamespace)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating default PowerProfiles", tc.testCase))
+		}
+
+		for name, epp := range tc.expectedEpps {
+			profile := &powerv1alpha1.PowerProfile{}
+			err = r.Client.Get(context.TODO(), client.ObjectKey{
+				Name:      name,
+				Namespace: PowerConfigNamespace,
+			}, profile)
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error retrieving PowerProfile '%s'", tc.testCase, name))
+			}
+
+			if profile.Spec.Epp != epp {
+				t.Errorf("%s - Failed: Expected PowerProfile '%s' to have EPP '%s', got '%s'", tc.testCase, name, epp, profile.Spec.Epp)
+			}
+
+			isDefault := profile.Labels[DefaultProfileLabel] == "true"
+			if isDefault != util.StringInStringList(name, tc.expectedDefaults) {
+				t.Errorf("%s - Failed: Expected PowerProfile '%s' default label to be %v, got %v", tc.testCase, name, !isDefault, isDefault)
+			}
+		}
+	}
+}
@@ -21,16 +21,35 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
 	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/appqos"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/podstate"
+)
+
+const (
+	// AppQoSReachableCondition reports whether the Node Agent can currently reach the node's AppQoS instance
+	AppQoSReachableCondition = "AppQoSReachable"
+
+	// ProfileLabelPrefix prefixes the Node label, e.g. 'power.intel.com/profile-performance=true', marking the base
+	// profile of each PowerProfile with a PowerWorkload active on the Node so Pods can target it with nodeAffinity.
+	// A profile name too long for a label is truncated and suffixed with a hash of the full name
+	ProfileLabelPrefix = profileLabelDomain + "profile-"
+
+	profileLabelDomain = "power.intel.com/"
 )
 
 // PowerNodeReconciler reconciles a PowerNode object
@@ -39,10 +58,40 @@ type PowerNodeReconciler struct {
 	Log          logr.Logger
 	Scheme       *runtime.Scheme
 	AppQoSClient *appqos.AppQoSClient
+
+	// AppQoSIncompatibility is set when the node's AppQoS version found at startup is not supported.
+	// The PowerNode then only reports why the node is not being managed
+	AppQoSIncompatibility string
+
+	// AppQoSVersion is the version of the node's AppQoS instance found at startup, reported on the PowerNode
+	AppQoSVersion string
+
+	// PackagePowerBudget is the Node's package power budget in watts, against which the power committed by the
+	// active PowerProfiles is reported. Zero disables the report
+	PackagePowerBudget int
+
+	// CoreWattsPerGHz is the estimated power a core draws for every GHz of its PowerProfile's maximum frequency.
+	// Defaults to DefaultCoreWattsPerGHz
+	CoreWattsPerGHz float64
+
+	// State is the PowerPod controller's record of the Guaranteed Pods on the Node, read to report its exclusive
+	// CPU capacity. Nil disables the report
+	State *podstate.State
+
+	// FreeExclusiveCPUsThreshold is exported alongside the exclusive CPU capacity for alerts to compare the free
+	// CPUs against. Zero leaves it unset
+	FreeExclusiveCPUsThreshold int
+
+	// TaintAppQoSUnreachable keeps the AppQoSUnreachableTaint on the Node while its AppQoS instance is unreachable
+	TaintAppQoSUnreachable bool
+
+	// capabilities caches the features the node's AppQoS instance advertises once they have been discovered
+	capabilities []string
 }
 
 // +kubebuilder:rbac:groups=power.intel.com,resources=powernodes,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=power.intel.com,resources=powernodes/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch;update;patch
 
 func (r *PowerNodeReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	_ = context.Background()
@@ -69,6 +118,28 @@ func (r *PowerNodeReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		return ctrl.Result{}, err
 	}
 
+	powerNode.Status.AppQoSVersion = r.AppQoSVersion
+	if capabilities := r.appQoSCapabilities(logger); capabilities != nil {
+		powerNode.Status.Capabilities = capabilities
+	}
+
+	if r.AppQoSIncompatibility != "" {
+		meta.SetStatusCondition(&powerNode.Status.Conditions, metav1.Condition{
+			Type:    AppQoSCompatibleCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  "UnsupportedAppQoSVersion",
+			Message: r.AppQoSIncompatibility,
+		})
+		err = r.Client.Status().Update(context.TODO(), powerNode)
+		if err != nil {
+			logger.Error(err, "error reporting AppQoS incompatibility")
+			return ctrl.Result{}, err
+		}
+
+		logger.Info("Node is not being managed", "reason", r.AppQoSIncompatibility)
+		return ctrl.Result{}, nil
+	}
+
 	profiles := &powerv1alpha1.PowerProfileList{}
 	err = r.Client.List(context.TODO(), profiles)
 	if err != nil {
@@ -121,12 +192,21 @@ func (r *PowerNodeReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	defaultPool, err := r.AppQoSClient.GetPoolByName(AppQoSClientAddress, "Default")
 	if err != nil {
 		logger.Error(err, "error retrieving Default AppQoS Pool")
+		r.reportAppQoSUnreachable(logger, powerNode, err)
 		return ctrl.Result{}, err
 	}
 
 	sharedPool, err := r.AppQoSClient.GetPoolByName(AppQoSClientAddress, "Shared")
 	if err != nil {
 		logger.Error(err, "error retrieving Shared AppQoS Pool")
+		r.reportAppQoSUnreachable(logger, powerNode, err)
+		return ctrl.Result{}, err
+	}
+
+	releasePool, err := r.AppQoSClient.GetPoolByName(AppQoSClientAddress, ReleasePool)
+	if err != nil {
+		logger.Error(err, "error retrieving Release AppQoS Pool")
+		r.reportAppQoSUnreachable(logger, powerNode, err)
 		return ctrl.Result{}, err
 	}
 
@@ -148,19 +228,199 @@ func (r *PowerNodeReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		sharedPools = append(sharedPools, *sharedPoolInfo)
 	}
 
+	if !reflect.DeepEqual(releasePool, &appqos.Pool{}) {
+		releasePoolInfo := &powerv1alpha1.SharedPoolInfo{
+			Name:             ReleasePool,
+			SharedPoolCpuIds: *releasePool.Cores,
+		}
+		sharedPools = append(sharedPools, *releasePoolInfo)
+	}
+
 	powerNode.Spec.ActiveProfiles = powerProfilesInUse
 	powerNode.Spec.ActiveWorkloads = powerWorkloads
 	powerNode.Spec.PowerContainers = powerContainers
 	powerNode.Spec.SharedPools = sharedPools
 
+	appliedEpp, err := r.getAppliedEpp()
+	if err != nil {
+		logger.Error(err, "error retrieving applied EPP values from AppQoS")
+		return ctrl.Result{}, err
+	}
+
 	err = r.Client.Update(context.TODO(), powerNode)
 	if err != nil {
 		return ctrl.Result{RequeueAfter: time.Second * 5}, err
 	}
 
+	err = r.labelActiveProfiles(nodeName, powerProfilesInUse)
+	if err != nil {
+		logger.Error(err, "error labelling Node with its active PowerProfiles")
+		return ctrl.Result{RequeueAfter: time.Second * 5}, err
+	}
+
+	r.recordExclusiveCPUCapacity(logger, nodeName)
+
+	coresCondition, err := r.appQoSCoresCondition(nodeName)
+	if err != nil {
+		logger.Error(err, "error comparing AppQoS's cores against the Kubelet's")
+	} else if coresCondition != nil {
+		if coresCondition.Status == metav1.ConditionFalse {
+			logger.Info("AppQoS and the Kubelet disagree about the Node's cores", "reason", coresCondition.Message)
+		}
+		meta.SetStatusCondition(&powerNode.Status.Conditions, *coresCondition)
+	}
+
+	powerNode.Status.AppliedEpp = appliedEpp
+	powerNode.Status.PowerBudget = r.powerBudgetStatus(nodeName, profiles.Items, workloads.Items)
+	meta.SetStatusCondition(&powerNode.Status.Conditions, metav1.Condition{
+		Type:   AppQoSCompatibleCondition,
+		Status: metav1.ConditionTrue,
+		Reason: "SupportedAppQoSVersion",
+	})
+	meta.SetStatusCondition(&powerNode.Status.Conditions, metav1.Condition{
+		Type:   AppQoSReachableCondition,
+		Status: metav1.ConditionTrue,
+		Reason: "Connected",
+	})
+	err = r.Client.Status().Update(context.TODO(), powerNode)
+	if err != nil {
+		return ctrl.Result{RequeueAfter: time.Second * 5}, err
+	}
+
+	if r.TaintAppQoSUnreachable {
+		err = r.setAppQoSUnreachableTaint(nodeName, false)
+		if err != nil {
+			logger.Error(err, "error removing AppQoS unreachable taint from Node")
+			return ctrl.Result{RequeueAfter: time.Second * 5}, err
+		}
+	}
+
 	return ctrl.Result{RequeueAfter: time.Second * 5}, nil
 }
 
+// appQoSCapabilities returns the features the node's AppQoS instance advertises. They don't change while the
+// Node Agent runs, so AppQoS is only queried until it first answers
+func (r *PowerNodeReconciler) appQoSCapabilities(logger logr.Logger) []string {
+	if r.capabilities != nil {
+		return r.capabilities
+	}
+
+	capabilities, err := r.AppQoSClient.GetCapabilities(AppQoSClientAddress)
+	if err != nil {
+		logger.Error(err, "error discovering AppQoS capabilities, retrying on the next reconcile")
+		return nil
+	}
+
+	sort.Strings(capabilities)
+	r.capabilities = capabilities
+	return r.capabilities
+}
+
+// getAppliedEpp reads back the EPP value of the Power Profile AppQoS has applied to each Pool's cores
+func (r *PowerNodeReconciler) getAppliedEpp() (map[string]string, error) {
+	pools, err := r.AppQoSClient.GetPools(AppQoSClientAddress)
+	if err != nil {
+		return map[string]string{}, err
+	}
+
+	profiles, err := r.AppQoSClient.GetPowerProfiles(AppQoSClientAddress)
+	if err != nil {
+		return map[string]string{}, err
+	}
+
+	profileEpp := make(map[int]string)
+	for _, profile := range profiles {
+		if profile.ID != nil && profile.Epp != nil {
+			profileEpp[*profile.ID] = *profile.Epp
+		}
+	}
+
+	appliedEpp := make(map[string]string)
+	for _, pool := range pools {
+		if pool.PowerProfile == nil || pool.Cores == nil {
+			continue
+		}
+
+		epp, exists := profileEpp[*pool.PowerProfile]
+		if !exists {
+			continue
+		}
+
+		for _, core := range *pool.Cores {
+			appliedEpp[strconv.Itoa(core)] = epp
+		}
+	}
+
+	return appliedEpp, nil
+}
+
+// profileLabel returns the Node label marking the PowerProfile as active, named after the base profile the
+// node's PowerProfile was created from so the same label selects it on every Node
+func profileLabel(profile string, nodeName string) string {
+	labelName := strings.TrimPrefix(ProfileLabelPrefix, profileLabelDomain) + strings.TrimSuffix(profile, "-"+nodeName)
+
+	return profileLabelDomain + boundedLabelName(labelName)
+}
+
+// labelActiveProfiles keeps a ProfileLabelPrefix label on the Node for every PowerProfile in use, removing
+// the labels of PowerProfiles no longer in use
+func (r *PowerNodeReconciler) labelActiveProfiles(nodeName string, powerProfilesInUse map[string]bool) error {
+	node := &corev1.Node{}
+	err := r.Client.Get(context.TODO(), client.ObjectKey{Name: nodeName}, node)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	labels := make(map[string]string)
+	for label, value := range node.Labels {
+		if !strings.HasPrefix(label, ProfileLabelPrefix) {
+			labels[label] = value
+		}
+	}
+	for profile, inUse := range powerProfilesInUse {
+		if inUse {
+			labels[profileLabel(profile, nodeName)] = "true"
+		}
+	}
+
+	if reflect.DeepEqual(labels, node.Labels) || (len(labels) == 0 && len(node.Labels) == 0) {
+		return nil
+	}
+
+	node.Labels = labels
+	return r.Client.Update(context.TODO(), node)
+}
+
+// reportAppQoSUnreachable sets the AppQoSReachable condition to False if the error came from failing to
+// connect to the AppQoS instance, rather than from a response it sent
+func (r *PowerNodeReconciler) reportAppQoSUnreachable(logger logr.Logger, powerNode *powerv1alpha1.PowerNode, appQoSErr error) {
+	if reachable, known := r.AppQoSClient.Reachable(AppQoSClientAddress); !known || reachable {
+		return
+	}
+
+	meta.SetStatusCondition(&powerNode.Status.Conditions, metav1.Condition{
+		Type:    AppQoSReachableCondition,
+		Status:  metav1.ConditionFalse,
+		Reason:  "ConnectionFailed",
+		Message: appQoSErr.Error(),
+	})
+	err := r.Client.Status().Update(context.TODO(), powerNode)
+	if err != nil {
+		logger.Error(err, "error reporting AppQoS connection failure")
+	}
+
+	if r.TaintAppQoSUnreachable {
+		err = r.setAppQoSUnreachableTaint(powerNode.Name, true)
+		if err != nil {
+			logger.Error(err, "error tainting Node with unreachable AppQoS")
+		}
+	}
+}
+
 func (r *PowerNodeReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&powerv1alpha1.PowerNode{}).
@@ -2,23 +2,38 @@ package controllers
 
 import (
 	"context"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
 	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/appqos"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/podstate"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -45,7 +60,7 @@ func createPowerNodeReconcilerObject(objs []runtime.Object) (*PowerNodeReconcile
 	return r, nil
 }
 
-func createListeners(appqosPools []appqos.Pool) (*httptest.Server, error) {
+func createListeners(appqosPools []appqos.Pool, appqosProfiles []appqos.PowerProfile, appqosVersion string) (*httptest.Server, error) {
 	var err error
 
 	newListener, err := net.Listen("tcp", "127.0.0.1:5000")
@@ -62,6 +77,22 @@ func createListeners(appqosPools []appqos.Pool) (*httptest.Server, error) {
 			}
 		}
 	}))
+	mux.HandleFunc("/power_profiles", (func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			b, err := json.Marshal(appqosProfiles)
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}
+	}))
+	mux.HandleFunc("/version", (func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			b, err := json.Marshal(appqos.Version{Version: &appqosVersion})
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}
+	}))
 
 	ts := httptest.NewUnstartedServer(mux)
 
@@ -823,7 +854,7 @@ func TestPowerNodeReconciler(t *testing.T) {
 			t.Fatal("error creating reconcile object")
 		}
 
-		server, err := createListeners(appqosPools)
+		server, err := createListeners(appqosPools, []appqos.PowerProfile{}, "4.0.0")
 		if err != nil {
 			t.Error(err)
 			t.Fatal(fmt.Sprintf("%s - error creating Listeners", tc.testCase))
@@ -871,3 +902,2072 @@ func TestPowerNodeReconciler(t *testing.T) {
 		}
 	}
 }
+
+func TestPowerNodeAppliedEpp(t *testing.T) {
+	tcases := []struct {
+		testCase           string
+		powerNode          *powerv1alpha1.PowerNode
+		pools              []appqos.Pool
+		profiles           []appqos.PowerProfile
+		expectedAppliedEpp map[string]string
+	}{
+		{
+			testCase: "Test Case 1",
+			powerNode: &powerv1alpha1.PowerNode{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "example-node1",
+					Namespace: PowerNodeNamespace,
+				},
+				Spec: powerv1alpha1.PowerNodeSpec{
+					NodeName: "example-node1",
+				},
+			},
+			pools: []appqos.Pool{
+				{
+					Name:         stringPtr("Default"),
+					ID:           intPtr(1),
+					Cores:        &[]int{4, 5, 6},
+					PowerProfile: intPtr(2),
+				},
+				{
+					Name:         stringPtr("performance-example-node1"),
+					ID:           intPtr(2),
+					Cores:        &[]int{1, 2},
+					PowerProfile: intPtr(1),
+				},
+				{
+					Name:  stringPtr("Shared"),
+					ID:    intPtr(3),
+					Cores: &[]int{7, 8},
+				},
+			},
+			profiles: []appqos.PowerProfile{
+				{
+					ID:   intPtr(1),
+					Name: stringPtr("performance-example-node1"),
+					Epp:  stringPtr("performance"),
+				},
+				{
+					ID:   intPtr(2),
+					Name: stringPtr("shared-example-node1"),
+					Epp:  stringPtr("power"),
+				},
+			},
+			expectedAppliedEpp: map[string]string{
+				"1": "performance",
+				"2": "performance",
+				"4": "power",
+				"5": "power",
+				"6": "power",
+			},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", tc.powerNode.Name)
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		r, err := createPowerNodeReconcilerObject([]runtime.Object{tc.powerNode})
+		if err != nil {
+			t.Error(err)
+			t.Fatal("error creating reconcile object")
+		}
+
+		server, err := createListeners(tc.pools, tc.profiles, "4.0.0")
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating Listeners", tc.testCase))
+		}
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      tc.powerNode.Name,
+				Namespace: PowerNodeNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		server.Close()
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling PowerNode object", tc.testCase))
+		}
+
+		powerNode := &powerv1alpha1.PowerNode{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{
+			Name:      tc.powerNode.Name,
+			Namespace: PowerNodeNamespace,
+		}, powerNode)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerNode object", tc.testCase))
+		}
+
+		if !reflect.DeepEqual(powerNode.Status.AppliedEpp, tc.expectedAppliedEpp) {
+			t.Errorf("%s - Failed: Expected Applied EPP to be %v, got %v", tc.testCase, tc.expectedAppliedEpp, powerNode.Status.AppliedEpp)
+		}
+	}
+}
+
+func TestWaitForAppQoSVersion(t *testing.T) {
+	tcases := []struct {
+		testCase        string
+		appqosVersion   string
+		startAfter      time.Duration
+		timeout         time.Duration
+		expectedVersion string
+		expectedError   bool
+	}{
+		{
+			testCase:        "Test Case 1 - AppQoS starts after the Node Agent",
+			appqosVersion:   "4.1.0",
+			startAfter:      200 * time.Millisecond,
+			timeout:         5 * time.Second,
+			expectedVersion: "4.1.0",
+		},
+		{
+			testCase:        "Test Case 2 - AppQoS starts too late",
+			appqosVersion:   "4.1.0",
+			startAfter:      time.Second,
+			timeout:         100 * time.Millisecond,
+			expectedVersion: "",
+			expectedError:   true,
+		},
+		{
+			testCase:        "Test Case 3 - Unsupported version not retried",
+			appqosVersion:   "3.2.0",
+			timeout:         5 * time.Second,
+			expectedVersion: "3.2.0",
+			expectedError:   true,
+		},
+	}
+
+	retryDelay := appQoSVersionRetryDelay
+	defer func() { appQoSVersionRetryDelay = retryDelay }()
+	appQoSVersionRetryDelay = 20 * time.Millisecond
+
+	for _, tc := range tcases {
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		r, err := createPowerNodeReconcilerObject([]runtime.Object{})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		servers := make(chan *httptest.Server, 1)
+		go func(appqosVersion string, startAfter time.Duration) {
+			time.Sleep(startAfter)
+			server, err := createListeners([]appqos.Pool{}, []appqos.PowerProfile{}, appqosVersion)
+			if err != nil {
+				t.Error(err)
+			}
+			servers <- server
+		}(tc.appqosVersion, tc.startAfter)
+
+		start := time.Now()
+		appqosVersion, err := WaitForAppQoSVersion(r.AppQoSClient, tc.timeout)
+		elapsed := time.Since(start)
+		if server := <-servers; server != nil {
+			server.Close()
+		}
+
+		if (err != nil) != tc.expectedError {
+			t.Errorf("%s - Failed: Expected error to be %v, got %v", tc.testCase, tc.expectedError, err)
+		}
+
+		if appqosVersion != tc.expectedVersion {
+			t.Errorf("%s - Failed: Expected AppQoS version '%s', got '%s'", tc.testCase, tc.expectedVersion, appqosVersion)
+		}
+
+		if elapsed > tc.timeout {
+			t.Errorf("%s - Failed: Expected to give up within %v, took %v", tc.testCase, tc.timeout, elapsed)
+		}
+	}
+}
+
+func TestUnsupportedAppQoSVersion(t *testing.T) {
+	tcases := []struct {
+		testCase                 string
+		appqosVersion            string
+		expectedCompatible       bool
+		expectedConditionStatus  metav1.ConditionStatus
+		expectedNumOfSharedPools int
+	}{
+		{
+			testCase:                 "Test Case 1",
+			appqosVersion:            "4.1.0",
+			expectedCompatible:       true,
+			expectedConditionStatus:  metav1.ConditionTrue,
+			expectedNumOfSharedPools: 1,
+		},
+		{
+			testCase:                 "Test Case 2",
+			appqosVersion:            "3.2.0",
+			expectedCompatible:       false,
+			expectedConditionStatus:  metav1.ConditionFalse,
+			expectedNumOfSharedPools: 0,
+		},
+		{
+			testCase:                 "Test Case 3",
+			appqosVersion:            "5.0.0",
+			expectedCompatible:       false,
+			expectedConditionStatus:  metav1.ConditionFalse,
+			expectedNumOfSharedPools: 0,
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		powerNode := &powerv1alpha1.PowerNode{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example-node1",
+				Namespace: PowerNodeNamespace,
+			},
+			Spec: powerv1alpha1.PowerNodeSpec{
+				NodeName: "example-node1",
+			},
+		}
+		pools := []appqos.Pool{
+			{
+				Name:  stringPtr("Default"),
+				ID:    intPtr(1),
+				Cores: &[]int{0, 1, 2, 3},
+			},
+		}
+
+		r, err := createPowerNodeReconcilerObject([]runtime.Object{powerNode})
+		if err != nil {
+			t.Error(err)
+			t.Fatal("error creating reconcile object")
+		}
+
+		server, err := createListeners(pools, []appqos.PowerProfile{}, tc.appqosVersion)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating Listeners", tc.testCase))
+		}
+
+		_, err = NegotiateAppQoSVersion(r.AppQoSClient)
+		if (err == nil) != tc.expectedCompatible {
+			t.Errorf("%s - Failed: Expected AppQoS version '%s' to be supported to be %v, got %v", tc.testCase, tc.appqosVersion, tc.expectedCompatible, err == nil)
+		}
+		if err != nil {
+			r.AppQoSIncompatibility = err.Error()
+		}
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      powerNode.Name,
+				Namespace: PowerNodeNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		server.Close()
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling PowerNode object", tc.testCase))
+		}
+
+		updatedPowerNode := &powerv1alpha1.PowerNode{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{
+			Name:      powerNode.Name,
+			Namespace: PowerNodeNamespace,
+		}, updatedPowerNode)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerNode object", tc.testCase))
+		}
+
+		condition := meta.FindStatusCondition(updatedPowerNode.Status.Conditions, AppQoSCompatibleCondition)
+		if condition == nil {
+			t.Fatal(fmt.Sprintf("%s - Failed: Expected PowerNode to have %s condition", tc.testCase, AppQoSCompatibleCondition))
+		}
+
+		if condition.Status != tc.expectedConditionStatus {
+			t.Errorf("%s - Failed: Expected %s condition to be %v, got %v", tc.testCase, AppQoSCompatibleCondition, tc.expectedConditionStatus, condition.Status)
+		}
+
+		if len(updatedPowerNode.Spec.SharedPools) != tc.expectedNumOfSharedPools {
+			t.Errorf("%s - Failed: Expected number of Shared Pools to be %v, got %v", tc.testCase, tc.expectedNumOfSharedPools, len(updatedPowerNode.Spec.SharedPools))
+		}
+	}
+}
+
+func TestAppQoSReachability(t *testing.T) {
+	tcases := []struct {
+		testCase                string
+		appqosRunning           bool
+		expectedGauge           float64
+		expectedConditionStatus metav1.ConditionStatus
+	}{
+		{
+			testCase:                "Test Case 1",
+			appqosRunning:           true,
+			expectedGauge:           1,
+			expectedConditionStatus: metav1.ConditionTrue,
+		},
+		{
+			testCase:                "Test Case 2",
+			appqosRunning:           false,
+			expectedGauge:           0,
+			expectedConditionStatus: metav1.ConditionFalse,
+		},
+		{
+			testCase:                "Test Case 3",
+			appqosRunning:           true,
+			expectedGauge:           1,
+			expectedConditionStatus: metav1.ConditionTrue,
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		powerNode := &powerv1alpha1.PowerNode{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example-node1",
+				Namespace: PowerNodeNamespace,
+			},
+			Spec: powerv1alpha1.PowerNodeSpec{
+				NodeName: "example-node1",
+			},
+		}
+		pools := []appqos.Pool{
+			{
+				Name:  stringPtr("Default"),
+				ID:    intPtr(1),
+				Cores: &[]int{0, 1, 2, 3},
+			},
+		}
+
+		r, err := createPowerNodeReconcilerObject([]runtime.Object{powerNode})
+		if err != nil {
+			t.Error(err)
+			t.Fatal("error creating reconcile object")
+		}
+		ObserveAppQoSReachability("example-node1", r.AppQoSClient)
+
+		var server *httptest.Server
+		if tc.appqosRunning {
+			server, err = createListeners(pools, []appqos.PowerProfile{}, "4.1.0")
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error creating Listeners", tc.testCase))
+			}
+		}
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      powerNode.Name,
+				Namespace: PowerNodeNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if server != nil {
+			server.Close()
+		}
+		if (err == nil) != tc.appqosRunning {
+			t.Errorf("%s - Failed: Expected reconcile to succeed to be %v, got error %v", tc.testCase, tc.appqosRunning, err)
+		}
+
+		metric := &dto.Metric{}
+		err = appQoSReachable.WithLabelValues("example-node1").Write(metric)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reading AppQoS reachability gauge", tc.testCase))
+		}
+
+		if metric.GetGauge().GetValue() != tc.expectedGauge {
+			t.Errorf("%s - Failed: Expected power_appqos_reachable to be %v, got %v", tc.testCase, tc.expectedGauge, metric.GetGauge().GetValue())
+		}
+
+		updatedPowerNode := &powerv1alpha1.PowerNode{}
+		err = r.Client.Get(context.TODO(), req.NamespacedName, updatedPowerNode)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerNode object", tc.testCase))
+		}
+
+		condition := meta.FindStatusCondition(updatedPowerNode.Status.Conditions, AppQoSReachableCondition)
+		if condition == nil {
+			t.Fatal(fmt.Sprintf("%s - Failed: Expected PowerNode to have %s condition", tc.testCase, AppQoSReachableCondition))
+		}
+
+		if condition.Status != tc.expectedConditionStatus {
+			t.Errorf("%s - Failed: Expected %s condition to be %v, got %v", tc.testCase, AppQoSReachableCondition, tc.expectedConditionStatus, condition.Status)
+		}
+	}
+}
+
+func TestAppQoSCredentialsForNode(t *testing.T) {
+	credentialsDir := t.TempDir()
+	for _, pool := range []string{"pool-a", "pool-b"} {
+		err := os.MkdirAll(filepath.Join(credentialsDir, pool), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tcases := []struct {
+		testCase            string
+		nodeLabels          map[string]string
+		poolLabel           string
+		expectedCredentials appqos.Credentials
+		expectedError       bool
+	}{
+		{
+			testCase:   "Test Case 1",
+			nodeLabels: map[string]string{"power.intel.com/node-pool": "pool-a"},
+			poolLabel:  "power.intel.com/node-pool",
+			expectedCredentials: appqos.Credentials{
+				CertPath: filepath.Join(credentialsDir, "pool-a", "appqos.crt"),
+				KeyPath:  filepath.Join(credentialsDir, "pool-a", "appqos.key"),
+				CAPath:   filepath.Join(credentialsDir, "pool-a", "ca.crt"),
+			},
+		},
+		{
+			testCase:   "Test Case 2",
+			nodeLabels: map[string]string{"power.intel.com/node-pool": "pool-b"},
+			poolLabel:  "power.intel.com/node-pool",
+			expectedCredentials: appqos.Credentials{
+				CertPath: filepath.Join(credentialsDir, "pool-b", "appqos.crt"),
+				KeyPath:  filepath.Join(credentialsDir, "pool-b", "appqos.key"),
+				CAPath:   filepath.Join(credentialsDir, "pool-b", "ca.crt"),
+			},
+		},
+		{
+			testCase:            "Test Case 3",
+			nodeLabels:          map[string]string{},
+			poolLabel:           "power.intel.com/node-pool",
+			expectedCredentials: appqos.DefaultCredentials(),
+		},
+		{
+			testCase:            "Test Case 4",
+			nodeLabels:          map[string]string{"power.intel.com/node-pool": "pool-a"},
+			poolLabel:           "",
+			expectedCredentials: appqos.DefaultCredentials(),
+		},
+		{
+			testCase:            "Test Case 5",
+			nodeLabels:          map[string]string{"power.intel.com/node-pool": "pool-c"},
+			poolLabel:           "power.intel.com/node-pool",
+			expectedCredentials: appqos.Credentials{},
+			expectedError:       true,
+		},
+	}
+
+	for _, tc := range tcases {
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "example-node1",
+				Labels: tc.nodeLabels,
+			},
+		}
+
+		r, err := createPowerNodeReconcilerObject([]runtime.Object{node})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		credentials, err := AppQoSCredentialsForNode(context.TODO(), r.Client, node.Name, tc.poolLabel, credentialsDir)
+		if (err != nil) != tc.expectedError {
+			t.Errorf("%s - Failed: Expected error to be %v, got %v", tc.testCase, tc.expectedError, err)
+		}
+
+		if !reflect.DeepEqual(credentials, tc.expectedCredentials) {
+			t.Errorf("%s - Failed: Expected credentials to be %v, got %v", tc.testCase, tc.expectedCredentials, credentials)
+		}
+	}
+}
+
+func TestTopologySnapshot(t *testing.T) {
+	powerNode := &powerv1alpha1.PowerNode{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-node1",
+			Namespace: PowerNodeNamespace,
+		},
+		Spec: powerv1alpha1.PowerNodeSpec{
+			NodeName: "example-node1",
+		},
+		Status: powerv1alpha1.PowerNodeStatus{
+			PowerNodeCPUState: powerv1alpha1.PowerNodeCPUState{
+				SharedPool: []int{0, 1, 4, 5},
+			},
+		},
+	}
+	powerProfile := &powerv1alpha1.PowerProfile{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "performance-example-node1",
+			Namespace: PowerNodeNamespace,
+		},
+		Spec: powerv1alpha1.PowerProfileSpec{
+			Name: "performance-example-node1",
+			Min:  3000,
+			Max:  3200,
+			Epp:  "performance",
+		},
+	}
+	powerWorkload := &powerv1alpha1.PowerWorkload{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "performance-example-node1-workload",
+			Namespace: PowerNodeNamespace,
+		},
+		Spec: powerv1alpha1.PowerWorkloadSpec{
+			Name: "performance-example-node1-workload",
+			Node: powerv1alpha1.NodeInfo{
+				Name:   "example-node1",
+				CpuIds: []int{2, 3},
+			},
+			PowerProfile: "performance-example-node1",
+		},
+	}
+
+	r, err := createPowerNodeReconcilerObject([]runtime.Object{powerNode, powerProfile, powerWorkload})
+	if err != nil {
+		t.Error(err)
+		t.Fatal("error creating reconciler object")
+	}
+
+	state, err := podstate.NewState()
+	if err != nil {
+		t.Error(err)
+		t.Fatal("error creating State")
+	}
+	err = state.UpdateStateGuaranteedPods(powerv1alpha1.GuaranteedPod{
+		Node: "example-node1",
+		Name: "example-pod",
+		Containers: []powerv1alpha1.Container{
+			{
+				Name:          "example-container",
+				ExclusiveCPUs: []int{3, 2},
+				PowerProfile:  "performance-example-node1",
+			},
+		},
+	})
+	if err != nil {
+		t.Error(err)
+		t.Fatal("error updating State")
+	}
+
+	recorder := httptest.NewRecorder()
+	NewTopologySnapshotHandler(r.Client, state).ServeHTTP(recorder, httptest.NewRequest("GET", TopologySnapshotPath, nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatal(fmt.Sprintf("Failed: Expected status code to be %v, got %v", http.StatusOK, recorder.Code))
+	}
+
+	document := map[string]json.RawMessage{}
+	err = json.Unmarshal(recorder.Body.Bytes(), &document)
+	if err != nil {
+		t.Error(err)
+		t.Fatal("error decoding snapshot document")
+	}
+	for _, key := range []string{"timestamp", "nodes", "profiles", "workloads", "cores"} {
+		if _, exists := document[key]; !exists {
+			t.Errorf("Failed: Expected snapshot document to have key '%s'", key)
+		}
+	}
+
+	snapshot := &TopologySnapshot{}
+	err = json.Unmarshal(recorder.Body.Bytes(), snapshot)
+	if err != nil {
+		t.Error(err)
+		t.Fatal("error decoding snapshot")
+	}
+
+	expectedNodes := []NodeSnapshot{{Name: "example-node1", SharedCores: []int{0, 1, 4, 5}}}
+	if !reflect.DeepEqual(snapshot.Nodes, expectedNodes) {
+		t.Errorf("Failed: Expected nodes to be %v, got %v", expectedNodes, snapshot.Nodes)
+	}
+
+	expectedProfiles := []ProfileSnapshot{{Name: "performance-example-node1", Min: 3000, Max: 3200, Epp: "performance"}}
+	if !reflect.DeepEqual(snapshot.Profiles, expectedProfiles) {
+		t.Errorf("Failed: Expected profiles to be %v, got %v", expectedProfiles, snapshot.Profiles)
+	}
+
+	expectedWorkloads := []WorkloadSnapshot{{Name: "performance-example-node1-workload", Node: "example-node1", Profile: "performance-example-node1", Cores: []int{2, 3}}}
+	if !reflect.DeepEqual(snapshot.Workloads, expectedWorkloads) {
+		t.Errorf("Failed: Expected workloads to be %v, got %v", expectedWorkloads, snapshot.Workloads)
+	}
+
+	expectedCores := []CoreAssignment{
+		{Node: "example-node1", Core: 2, Profile: "performance-example-node1", Pod: "example-pod", Container: "example-container"},
+		{Node: "example-node1", Core: 3, Profile: "performance-example-node1", Pod: "example-pod", Container: "example-container"},
+	}
+	if !reflect.DeepEqual(snapshot.Cores, expectedCores) {
+		t.Errorf("Failed: Expected cores to be %v, got %v", expectedCores, snapshot.Cores)
+	}
+}
+
+func TestActiveProfileNodeLabels(t *testing.T) {
+	longProfileName := "balance-performance-with-a-name-far-too-long-to-fit-in-a-node-label"
+
+	tcases := []struct {
+		testCase       string
+		nodeLabels     map[string]string
+		workloads      []powerv1alpha1.PowerWorkload
+		expectedLabels map[string]string
+	}{
+		{
+			testCase: "Test Case 1",
+			nodeLabels: map[string]string{
+				"kubernetes.io/hostname": "example-node1",
+			},
+			workloads: []powerv1alpha1.PowerWorkload{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "performance-example-node1-workload",
+						Namespace: PowerNodeNamespace,
+					},
+					Spec: powerv1alpha1.PowerWorkloadSpec{
+						Name: "performance-example-node1-workload",
+						Node: powerv1alpha1.NodeInfo{
+							Name:   "example-node1",
+							CpuIds: []int{2, 3},
+						},
+						PowerProfile: "performance-example-node1",
+					},
+				},
+			},
+			expectedLabels: map[string]string{
+				"kubernetes.io/hostname":              "example-node1",
+				"power.intel.com/profile-performance": "true",
+			},
+		},
+		{
+			testCase: "Test Case 2",
+			nodeLabels: map[string]string{
+				"kubernetes.io/hostname":                            "example-node1",
+				"power.intel.com/profile-performance-example-node1": "true",
+			},
+			workloads: []powerv1alpha1.PowerWorkload{},
+			expectedLabels: map[string]string{
+				"kubernetes.io/hostname": "example-node1",
+			},
+		},
+		{
+			testCase: "Test Case 3 - Profile name too long for a label",
+			nodeLabels: map[string]string{
+				"kubernetes.io/hostname": "example-node1",
+			},
+			workloads: []powerv1alpha1.PowerWorkload{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      longProfileName + "-example-node1-workload",
+						Namespace: PowerNodeNamespace,
+					},
+					Spec: powerv1alpha1.PowerWorkloadSpec{
+						Name: longProfileName + "-example-node1-workload",
+						Node: powerv1alpha1.NodeInfo{
+							Name:   "example-node1",
+							CpuIds: []int{2, 3},
+						},
+						PowerProfile: longProfileName + "-example-node1",
+					},
+				},
+			},
+			expectedLabels: map[string]string{
+				"kubernetes.io/hostname": "example-node1",
+				"power.intel.com/profile-balance-performance-with-a-name-far-too-long-2b4910d6c4": "true",
+			},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		objs := []runtime.Object{
+			&powerv1alpha1.PowerNode{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "example-node1",
+					Namespace: PowerNodeNamespace,
+				},
+				Spec: powerv1alpha1.PowerNodeSpec{
+					NodeName: "example-node1",
+				},
+			},
+			&powerv1alpha1.PowerProfile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "performance-example-node1",
+					Namespace: PowerNodeNamespace,
+				},
+				Spec: powerv1alpha1.PowerProfileSpec{
+					Name: "performance-example-node1",
+					Epp:  "performance",
+				},
+			},
+			&powerv1alpha1.PowerProfile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      longProfileName + "-example-node1",
+					Namespace: PowerNodeNamespace,
+				},
+				Spec: powerv1alpha1.PowerProfileSpec{
+					Name: longProfileName + "-example-node1",
+					Epp:  "balance_performance",
+				},
+			},
+			&corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "example-node1",
+					Labels: tc.nodeLabels,
+				},
+			},
+		}
+		for i := range tc.workloads {
+			objs = append(objs, &tc.workloads[i])
+		}
+
+		r, err := createPowerNodeReconcilerObject(objs)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		pools := []appqos.Pool{
+			{
+				Name:  stringPtr("Default"),
+				ID:    intPtr(1),
+				Cores: &[]int{0, 1, 4, 5},
+			},
+		}
+		server, err := createListeners(pools, []appqos.PowerProfile{}, "4.0.0")
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating Listeners", tc.testCase))
+		}
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      "example-node1",
+				Namespace: PowerNodeNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		server.Close()
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling PowerNode object", tc.testCase))
+		}
+
+		node := &corev1.Node{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{Name: "example-node1"}, node)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving Node object", tc.testCase))
+		}
+
+		if !reflect.DeepEqual(node.Labels, tc.expectedLabels) {
+			t.Errorf("%s - Failed: Expected Node labels to be %v, got %v", tc.testCase, tc.expectedLabels, node.Labels)
+		}
+	}
+}
+
+func TestAppQoSCapabilities(t *testing.T) {
+	tcases := []struct {
+		testCase             string
+		capabilities         []string
+		expectedCapabilities []string
+	}{
+		{
+			testCase:             "Test Case 1",
+			capabilities:         []string{"power", "cat"},
+			expectedCapabilities: []string{"cat", "power"},
+		},
+		{
+			testCase:             "Test Case 2",
+			capabilities:         []string{"cat", "mba", "sstbf", "power"},
+			expectedCapabilities: []string{"cat", "mba", "power", "sstbf"},
+		},
+		{
+			testCase:             "Test Case 3",
+			capabilities:         nil,
+			expectedCapabilities: nil,
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		powerNode := &powerv1alpha1.PowerNode{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example-node1",
+				Namespace: PowerNodeNamespace,
+			},
+			Spec: powerv1alpha1.PowerNodeSpec{
+				NodeName: "example-node1",
+			},
+		}
+
+		r, err := createPowerNodeReconcilerObject([]runtime.Object{powerNode})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		r.AppQoSVersion = "4.1.0"
+
+		listener, err := net.Listen("tcp", "127.0.0.1:5000")
+		if err != nil {
+			t.Fatal(fmt.Sprintf("%s - error creating Listener: %v", tc.testCase, err))
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/pools", (func(w http.ResponseWriter, r *http.Request) {
+			b, err := json.Marshal([]appqos.Pool{{Name: stringPtr("Default"), ID: intPtr(1), Cores: &[]int{0, 1, 2, 3}}})
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+		mux.HandleFunc("/power_profiles", (func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintln(w, "[]")
+		}))
+		// An AppQoS instance without capability discovery answers with a 404
+		if tc.capabilities != nil {
+			mux.HandleFunc("/caps", (func(w http.ResponseWriter, r *http.Request) {
+				b, err := json.Marshal(appqos.Capabilities{Capabilities: &tc.capabilities})
+				if err == nil {
+					fmt.Fprintln(w, string(b[:]))
+				}
+			}))
+		}
+		server := httptest.NewUnstartedServer(mux)
+		server.Listener.Close()
+		server.Listener = listener
+		server.Start()
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      "example-node1",
+				Namespace: PowerNodeNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		server.Close()
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling PowerNode object", tc.testCase))
+		}
+
+		updatedPowerNode := &powerv1alpha1.PowerNode{}
+		err = r.Client.Get(context.TODO(), req.NamespacedName, updatedPowerNode)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerNode object", tc.testCase))
+		}
+
+		if updatedPowerNode.Status.AppQoSVersion != "4.1.0" {
+			t.Errorf("%s - Failed: Expected AppQoS version to be '4.1.0', got '%s'", tc.testCase, updatedPowerNode.Status.AppQoSVersion)
+		}
+
+		if !reflect.DeepEqual(updatedPowerNode.Status.Capabilities, tc.expectedCapabilities) {
+			t.Errorf("%s - Failed: Expected capabilities to be %v, got %v", tc.testCase, tc.expectedCapabilities, updatedPowerNode.Status.Capabilities)
+		}
+	}
+}
+
+func TestPackagePowerBudget(t *testing.T) {
+	tcases := []struct {
+		testCase             string
+		budget               int
+		coreWattsPerGHz      float64
+		expectedPowerBudget  *powerv1alpha1.PowerBudgetStatus
+		expectedGaugeMinimum float64
+		expectedGaugeMaximum float64
+	}{
+		{
+			testCase:        "Test Case 1",
+			budget:          120,
+			coreWattsPerGHz: 0,
+			expectedPowerBudget: &powerv1alpha1.PowerBudgetStatus{
+				BudgetWatts:        120,
+				CommittedWatts:     48,
+				UtilizationPercent: 40,
+			},
+			expectedGaugeMinimum: 0.399,
+			expectedGaugeMaximum: 0.401,
+		},
+		{
+			testCase:        "Test Case 2",
+			budget:          40,
+			coreWattsPerGHz: 1,
+			expectedPowerBudget: &powerv1alpha1.PowerBudgetStatus{
+				BudgetWatts:        40,
+				CommittedWatts:     19,
+				UtilizationPercent: 48,
+			},
+			expectedGaugeMinimum: 0.479,
+			expectedGaugeMaximum: 0.481,
+		},
+		{
+			testCase:            "Test Case 3",
+			budget:              0,
+			coreWattsPerGHz:     0,
+			expectedPowerBudget: nil,
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		objs := []runtime.Object{
+			&powerv1alpha1.PowerNode{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "example-node1",
+					Namespace: PowerNodeNamespace,
+				},
+				Spec: powerv1alpha1.PowerNodeSpec{
+					NodeName: "example-node1",
+				},
+			},
+		}
+		for name, max := range map[string]int{"performance-example-node1": 3600, "balance-power-example-node1": 2400, "shared-example-node1": 1000} {
+			objs = append(objs, &powerv1alpha1.PowerProfile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: PowerNodeNamespace,
+				},
+				Spec: powerv1alpha1.PowerProfileSpec{
+					Name: name,
+					Max:  max,
+					Epp:  "performance",
+				},
+			})
+		}
+		workloads := []powerv1alpha1.PowerWorkload{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "performance-example-node1-workload", Namespace: PowerNodeNamespace},
+				Spec: powerv1alpha1.PowerWorkloadSpec{
+					Name:         "performance-example-node1-workload",
+					PowerProfile: "performance-example-node1",
+					Node:         powerv1alpha1.NodeInfo{Name: "example-node1", CpuIds: []int{1, 2, 3, 4}},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "balance-power-example-node1-workload", Namespace: PowerNodeNamespace},
+				Spec: powerv1alpha1.PowerWorkloadSpec{
+					Name:         "balance-power-example-node1-workload",
+					PowerProfile: "balance-power-example-node1",
+					Node:         powerv1alpha1.NodeInfo{Name: "example-node1", CpuIds: []int{5, 6}},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "performance-example-node2-workload", Namespace: PowerNodeNamespace},
+				Spec: powerv1alpha1.PowerWorkloadSpec{
+					Name:         "performance-example-node2-workload",
+					PowerProfile: "performance-example-node1",
+					Node:         powerv1alpha1.NodeInfo{Name: "example-node2", CpuIds: []int{1, 2}},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "shared-example-node1-workload", Namespace: PowerNodeNamespace},
+				Spec: powerv1alpha1.PowerWorkloadSpec{
+					Name:         "shared-example-node1-workload",
+					PowerProfile: "shared-example-node1",
+					AllCores:     true,
+					Node:         powerv1alpha1.NodeInfo{Name: "example-node1"},
+				},
+			},
+		}
+		for i := range workloads {
+			objs = append(objs, &workloads[i])
+		}
+
+		r, err := createPowerNodeReconcilerObject(objs)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		r.PackagePowerBudget = tc.budget
+		r.CoreWattsPerGHz = tc.coreWattsPerGHz
+
+		server, err := createListeners([]appqos.Pool{{Name: stringPtr("Default"), ID: intPtr(1), Cores: &[]int{0, 7}}}, []appqos.PowerProfile{}, "")
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating Listeners", tc.testCase))
+		}
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      "example-node1",
+				Namespace: PowerNodeNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		server.Close()
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling PowerNode object", tc.testCase))
+		}
+
+		updatedPowerNode := &powerv1alpha1.PowerNode{}
+		err = r.Client.Get(context.TODO(), req.NamespacedName, updatedPowerNode)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerNode object", tc.testCase))
+		}
+
+		if !reflect.DeepEqual(updatedPowerNode.Status.PowerBudget, tc.expectedPowerBudget) {
+			t.Errorf("%s - Failed: Expected PowerBudget to be %v, got %v", tc.testCase, tc.expectedPowerBudget, updatedPowerNode.Status.PowerBudget)
+		}
+
+		if tc.expectedPowerBudget == nil {
+			continue
+		}
+
+		metric := &dto.Metric{}
+		err = packageBudgetUtilization.WithLabelValues("example-node1").Write(metric)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reading power_package_budget_utilization_ratio", tc.testCase))
+		}
+		if metric.Gauge.GetValue() < tc.expectedGaugeMinimum || metric.Gauge.GetValue() > tc.expectedGaugeMaximum {
+			t.Errorf("%s - Failed: Expected power_package_budget_utilization_ratio between %v and %v, got %v", tc.testCase, tc.expectedGaugeMinimum, tc.expectedGaugeMaximum, metric.Gauge.GetValue())
+		}
+	}
+}
+
+func TestAppQoSPodAddressRefresh(t *testing.T) {
+	tcases := []struct {
+		testCase          string
+		podNode           string
+		initialPodIP      string
+		rescheduledPodIP  string
+		expectedVersions  []string
+		expectedLookups   int
+		expectedReachable bool
+	}{
+		{
+			testCase:          "Test Case 1",
+			podNode:           "example-node1",
+			initialPodIP:      "127.0.0.2",
+			rescheduledPodIP:  "127.0.0.1",
+			expectedVersions:  []string{"", "4.1.0", "4.1.0"},
+			expectedLookups:   3,
+			expectedReachable: true,
+		},
+		{
+			testCase:          "Test Case 2",
+			podNode:           "example-node1",
+			initialPodIP:      "127.0.0.1",
+			rescheduledPodIP:  "127.0.0.1",
+			expectedVersions:  []string{"4.1.0", "4.1.0", "4.1.0"},
+			expectedLookups:   1,
+			expectedReachable: true,
+		},
+		{
+			testCase:          "Test Case 3",
+			podNode:           "example-node2",
+			initialPodIP:      "127.0.0.1",
+			rescheduledPodIP:  "127.0.0.1",
+			expectedVersions:  []string{"", "", ""},
+			expectedLookups:   3,
+			expectedReachable: false,
+		},
+	}
+
+	for _, tc := range tcases {
+		AppQoSClientAddress = "http://localhost:5000"
+
+		appQoSPod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "appqos-pod",
+				Namespace: PowerNodeNamespace,
+				Labels:    map[string]string{"app": "appqos"},
+			},
+			Spec: corev1.PodSpec{
+				NodeName: tc.podNode,
+			},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				PodIP: tc.initialPodIP,
+			},
+		}
+
+		r, err := createPowerNodeReconcilerObject([]runtime.Object{appQoSPod})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		resolver := &AppQoSPodResolver{
+			Client:    r.Client,
+			NodeName:  "example-node1",
+			Namespace: PowerNodeNamespace,
+			Selector:  "app=appqos",
+		}
+		lookups := 0
+		r.AppQoSClient.SetAddressResolver(AppQoSClientAddress, func() (string, error) {
+			lookups++
+			return resolver.Resolve()
+		})
+
+		server, err := createListeners([]appqos.Pool{}, []appqos.PowerProfile{}, "4.1.0")
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating Listeners", tc.testCase))
+		}
+
+		for i, expectedVersion := range tc.expectedVersions {
+			if i == 1 {
+				// AppQoS is rescheduled onto a new IP
+				appQoSPod.Status.PodIP = tc.rescheduledPodIP
+				err = r.Client.Update(context.TODO(), appQoSPod)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			version, err := r.AppQoSClient.GetVersion(AppQoSClientAddress)
+			if (err == nil) != (expectedVersion != "") || version != expectedVersion {
+				t.Errorf("%s - Failed: Expected request %d to return version '%s', got '%s' (%v)", tc.testCase, i+1, expectedVersion, version, err)
+			}
+		}
+		server.Close()
+
+		if lookups != tc.expectedLookups {
+			t.Errorf("%s - Failed: Expected %d AppQoS Pod lookups, got %d", tc.testCase, tc.expectedLookups, lookups)
+		}
+
+		reachable, _ := r.AppQoSClient.Reachable(AppQoSClientAddress)
+		if reachable != tc.expectedReachable {
+			t.Errorf("%s - Failed: Expected AppQoS to be reachable to be %v, got %v", tc.testCase, tc.expectedReachable, reachable)
+		}
+	}
+}
+
+func TestAppQoSVersionStatusChecked(t *testing.T) {
+	tcases := []struct {
+		testCase        string
+		statusCode      int
+		expectedVersion string
+	}{
+		{
+			testCase:        "Test Case 1 - Version returned on success",
+			statusCode:      http.StatusOK,
+			expectedVersion: "4.1.0",
+		},
+		{
+			testCase:        "Test Case 2 - Error returned on failure status",
+			statusCode:      http.StatusInternalServerError,
+			expectedVersion: "",
+		},
+	}
+
+	for _, tc := range tcases {
+		version := "4.1.0"
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, err := json.Marshal(appqos.Version{Version: &version})
+			if err == nil {
+				w.WriteHeader(tc.statusCode)
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+
+		appQoSClient := appqos.NewDefaultAppQoSClient()
+		receivedVersion, err := appQoSClient.GetVersion(server.URL)
+		if (err == nil) != (tc.expectedVersion != "") || receivedVersion != tc.expectedVersion {
+			t.Errorf("%s - Failed: Expected version '%s', got '%s' (%v)", tc.testCase, tc.expectedVersion, receivedVersion, err)
+		}
+
+		server.Close()
+	}
+}
+
+func TestAppQoSRedirectVerifiesHostName(t *testing.T) {
+	tcases := []struct {
+		testCase        string
+		hostName        string
+		resolvedHost    string
+		expectedVersion string
+	}{
+		{
+			testCase:        "Test Case 1 - Redirected request verified against the host name",
+			hostName:        "example.com",
+			resolvedHost:    "localhost",
+			expectedVersion: "4.1.0",
+		},
+		{
+			testCase:        "Test Case 2 - Host name the certificate isn't issued for",
+			hostName:        "localhost",
+			expectedVersion: "",
+		},
+	}
+
+	version := "4.1.0"
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := json.Marshal(appqos.Version{Version: &version})
+		if err == nil {
+			fmt.Fprintln(w, string(b[:]))
+		}
+	}))
+	defer server.Close()
+
+	// The test server's certificate is issued for example.com and 127.0.0.1, and presented by the client too
+	credentialsDir := t.TempDir()
+	credentials := appqos.Credentials{
+		CertPath: filepath.Join(credentialsDir, "appqos.crt"),
+		KeyPath:  filepath.Join(credentialsDir, "appqos.key"),
+		CAPath:   filepath.Join(credentialsDir, "ca.crt"),
+	}
+	key, err := x509.MarshalPKCS8PrivateKey(server.TLS.Certificates[0].PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key})
+	for path, contents := range map[string][]byte{credentials.CertPath: certPEM, credentials.KeyPath: keyPEM, credentials.CAPath: certPEM} {
+		err = ioutil.WriteFile(path, contents, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range tcases {
+		appQoSClient, err := appqos.NewOperatorAppQoSClientWithCredentials(credentials)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating AppQoS client", tc.testCase))
+		}
+
+		address := fmt.Sprintf("https://%s", net.JoinHostPort(tc.hostName, port))
+		if tc.resolvedHost != "" {
+			appQoSClient.SetAddressResolver(address, func() (string, error) {
+				return tc.resolvedHost, nil
+			})
+		}
+
+		receivedVersion, err := appQoSClient.GetVersion(address)
+		if (err == nil) != (tc.expectedVersion != "") || receivedVersion != tc.expectedVersion {
+			t.Errorf("%s - Failed: Expected version '%s', got '%s' (%v)", tc.testCase, tc.expectedVersion, receivedVersion, err)
+		}
+	}
+}
+
+func TestAppQoSPodIPSelection(t *testing.T) {
+	tcases := []struct {
+		testCase     string
+		podIPs       []string
+		ipFamily     corev1.IPFamily
+		probeTimeout time.Duration
+		expectedIP   string
+	}{
+		{
+			testCase:   "Test Case 1 - First Pod IP used by default",
+			podIPs:     []string{"127.0.0.2", "127.0.0.1"},
+			expectedIP: "127.0.0.2",
+		},
+		{
+			testCase:     "Test Case 2 - Probe selects the reachable Pod IP",
+			podIPs:       []string{"127.0.0.2", "127.0.0.1"},
+			probeTimeout: time.Second,
+			expectedIP:   "127.0.0.1",
+		},
+		{
+			testCase:   "Test Case 3 - IPv6 Pod IP preferred",
+			podIPs:     []string{"127.0.0.1", "fd00::1"},
+			ipFamily:   corev1.IPv6Protocol,
+			expectedIP: "fd00::1",
+		},
+		{
+			testCase:     "Test Case 4 - IPv4 Pod IPs probed in order",
+			podIPs:       []string{"fd00::1", "127.0.0.2", "127.0.0.1"},
+			ipFamily:     corev1.IPv4Protocol,
+			probeTimeout: time.Second,
+			expectedIP:   "127.0.0.1",
+		},
+		{
+			testCase:     "Test Case 5 - No Pod IP answers the probe",
+			podIPs:       []string{"127.0.0.2", "127.0.0.3"},
+			probeTimeout: time.Second,
+			expectedIP:   "127.0.0.2",
+		},
+	}
+
+	for _, tc := range tcases {
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		podIPs := make([]corev1.PodIP, 0)
+		for _, podIP := range tc.podIPs {
+			podIPs = append(podIPs, corev1.PodIP{IP: podIP})
+		}
+		appQoSPod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "appqos-pod",
+				Namespace: PowerNodeNamespace,
+				Labels:    map[string]string{"app": "appqos"},
+			},
+			Spec: corev1.PodSpec{
+				NodeName: "example-node1",
+			},
+			Status: corev1.PodStatus{
+				Phase:  corev1.PodRunning,
+				PodIP:  tc.podIPs[0],
+				PodIPs: podIPs,
+			},
+		}
+
+		r, err := createPowerNodeReconcilerObject([]runtime.Object{appQoSPod})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		server, err := createListeners([]appqos.Pool{}, []appqos.PowerProfile{}, "4.1.0")
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating Listeners", tc.testCase))
+		}
+
+		resolver := &AppQoSPodResolver{
+			Client:       r.Client,
+			NodeName:     "example-node1",
+			Namespace:    PowerNodeNamespace,
+			Selector:     "app=appqos",
+			IPFamily:     tc.ipFamily,
+			ProbeTimeout: tc.probeTimeout,
+		}
+		ip, err := resolver.Resolve()
+		server.Close()
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error resolving AppQoS Pod IP", tc.testCase))
+		}
+
+		if ip != tc.expectedIP {
+			t.Errorf("%s - Failed: Expected AppQoS Pod IP '%s', got '%s'", tc.testCase, tc.expectedIP, ip)
+		}
+	}
+}
+
+func TestAppQoSAddressAnnotationChange(t *testing.T) {
+	tcases := []struct {
+		testCase          string
+		initialAddress    string
+		updatedAddress    string
+		stopped           bool
+		expectedVersions  []string
+		expectedProfiles  []string
+		expectedWorkloads []string
+	}{
+		{
+			testCase:          "Test Case 1 - AppQoS moved to another host",
+			initialAddress:    "127.0.0.1",
+			updatedAddress:    "127.0.0.2",
+			expectedVersions:  []string{"4.0.0", "4.1.0"},
+			expectedProfiles:  []string{"performance-example-node1"},
+			expectedWorkloads: []string{"performance-example-node1-workload"},
+		},
+		{
+			testCase:          "Test Case 2 - AppQoS address unchanged",
+			initialAddress:    "127.0.0.1",
+			updatedAddress:    "127.0.0.1",
+			expectedVersions:  []string{"4.0.0", "4.0.0"},
+			expectedProfiles:  []string{},
+			expectedWorkloads: []string{},
+		},
+		{
+			testCase:          "Test Case 3 - AppQoS address annotation removed",
+			initialAddress:    "127.0.0.2",
+			updatedAddress:    "",
+			expectedVersions:  []string{"4.1.0", "4.0.0"},
+			expectedProfiles:  []string{"performance-example-node1"},
+			expectedWorkloads: []string{"performance-example-node1-workload"},
+		},
+		{
+			testCase:          "Test Case 4 - AppQoS moved while the manager is stopping",
+			initialAddress:    "127.0.0.1",
+			updatedAddress:    "127.0.0.2",
+			stopped:           true,
+			expectedVersions:  []string{"4.0.0", "4.1.0"},
+			expectedProfiles:  []string{},
+			expectedWorkloads: []string{},
+		},
+	}
+
+	for _, tc := range tcases {
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "example-node1",
+				Annotations: map[string]string{},
+			},
+		}
+		if tc.initialAddress != "" {
+			node.Annotations[AppQoSAddressAnnotation] = tc.initialAddress
+		}
+
+		objs := []runtime.Object{
+			node,
+			&powerv1alpha1.PowerProfile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "performance-example-node1",
+					Namespace: PowerNodeNamespace,
+				},
+				Spec: powerv1alpha1.PowerProfileSpec{
+					Name: "performance-example-node1",
+				},
+			},
+			&powerv1alpha1.PowerWorkload{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "performance-example-node1-workload",
+					Namespace: PowerNodeNamespace,
+				},
+				Spec: powerv1alpha1.PowerWorkloadSpec{
+					Name: "performance-example-node1-workload",
+					Node: powerv1alpha1.NodeInfo{
+						Name:   "example-node1",
+						CpuIds: []int{2, 3},
+					},
+					PowerProfile: "performance-example-node1",
+				},
+			},
+			&powerv1alpha1.PowerWorkload{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "performance-example-node2-workload",
+					Namespace: PowerNodeNamespace,
+				},
+				Spec: powerv1alpha1.PowerWorkloadSpec{
+					Name: "performance-example-node2-workload",
+					Node: powerv1alpha1.NodeInfo{
+						Name:   "example-node2",
+						CpuIds: []int{2, 3},
+					},
+					PowerProfile: "performance-example-node2",
+				},
+			},
+		}
+
+		r, err := createPowerNodeReconcilerObject(objs)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		resolver := &AppQoSNodeAddressResolver{
+			Client:   r.Client,
+			NodeName: "example-node1",
+		}
+		r.AppQoSClient.SetAddressResolver(AppQoSClientAddress, resolver.Resolve)
+
+		addressReconciler := &AppQoSAddressReconciler{
+			Client:       r.Client,
+			Log:          r.Log,
+			AppQoSClient: r.AppQoSClient,
+			NodeName:     "example-node1",
+			Profiles:     make(chan event.GenericEvent, 10),
+			Workloads:    make(chan event.GenericEvent, 10),
+		}
+		if tc.stopped {
+			// Nothing reads the channels once the manager is stopping
+			addressReconciler.Profiles = make(chan event.GenericEvent)
+			addressReconciler.Workloads = make(chan event.GenericEvent)
+			stop := make(chan struct{})
+			close(stop)
+			err = addressReconciler.InjectStopChannel(stop)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// One AppQoS instance on each host, told apart by their versions
+		server, err := createListeners([]appqos.Pool{}, []appqos.PowerProfile{}, "4.0.0")
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating Listeners", tc.testCase))
+		}
+		movedListener, err := net.Listen("tcp", "127.0.0.2:5000")
+		if err != nil {
+			server.Close()
+			t.Fatal(fmt.Sprintf("%s - error creating Listener: %v", tc.testCase, err))
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/version", (func(w http.ResponseWriter, r *http.Request) {
+			b, err := json.Marshal(appqos.Version{Version: stringPtr("4.1.0")})
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+		movedServer := httptest.NewUnstartedServer(mux)
+		movedServer.Listener.Close()
+		movedServer.Listener = movedListener
+		movedServer.Start()
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name: "example-node1",
+			},
+		}
+		for i, expectedVersion := range tc.expectedVersions {
+			if i == 1 {
+				delete(node.Annotations, AppQoSAddressAnnotation)
+				if tc.updatedAddress != "" {
+					node.Annotations[AppQoSAddressAnnotation] = tc.updatedAddress
+				}
+				err = r.Client.Update(context.TODO(), node)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err = addressReconciler.Reconcile(req)
+			if err != nil {
+				server.Close()
+				movedServer.Close()
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error reconciling object", tc.testCase))
+			}
+
+			version, err := r.AppQoSClient.GetVersion(AppQoSClientAddress)
+			if err != nil || version != expectedVersion {
+				t.Errorf("%s - Failed: Expected request %d to return version '%s', got '%s' (%v)", tc.testCase, i+1, expectedVersion, version, err)
+			}
+		}
+		server.Close()
+		movedServer.Close()
+		close(addressReconciler.Profiles)
+		close(addressReconciler.Workloads)
+
+		profiles := make([]string, 0)
+		for e := range addressReconciler.Profiles {
+			profiles = append(profiles, e.Meta.GetName())
+		}
+		if !reflect.DeepEqual(profiles, tc.expectedProfiles) {
+			t.Errorf("%s - Failed: Expected PowerProfiles %v to be re-applied, got %v", tc.testCase, tc.expectedProfiles, profiles)
+		}
+
+		workloads := make([]string, 0)
+		for e := range addressReconciler.Workloads {
+			workloads = append(workloads, e.Meta.GetName())
+		}
+		if !reflect.DeepEqual(workloads, tc.expectedWorkloads) {
+			t.Errorf("%s - Failed: Expected PowerWorkloads %v to be re-applied, got %v", tc.testCase, tc.expectedWorkloads, workloads)
+		}
+	}
+}
+
+func TestExclusiveCPUCapacity(t *testing.T) {
+	tcases := []struct {
+		testCase            string
+		allocatableCPU      string
+		guaranteedPods      []powerv1alpha1.GuaranteedPod
+		threshold           int
+		expectedAllocatable float64
+		expectedAllocated   float64
+		expectedFree        float64
+	}{
+		{
+			testCase:       "Test Case 1",
+			allocatableCPU: "8",
+			guaranteedPods: []powerv1alpha1.GuaranteedPod{
+				{
+					Name: "example-pod1",
+					Containers: []powerv1alpha1.Container{
+						{Name: "example-container1", ExclusiveCPUs: []int{1, 2}},
+						{Name: "example-container2", ExclusiveCPUs: []int{3}},
+					},
+				},
+				{
+					Name: "example-pod2",
+					Containers: []powerv1alpha1.Container{
+						{Name: "example-container1", ExclusiveCPUs: []int{4, 5}},
+					},
+				},
+			},
+			threshold:           2,
+			expectedAllocatable: 8,
+			expectedAllocated:   5,
+			expectedFree:        3,
+		},
+		{
+			testCase:       "Test Case 2",
+			allocatableCPU: "3500m",
+			guaranteedPods: []powerv1alpha1.GuaranteedPod{
+				{
+					Name: "example-pod1",
+					Containers: []powerv1alpha1.Container{
+						{Name: "example-container1", ExclusiveCPUs: []int{1, 2}},
+					},
+				},
+				{
+					Name: "example-pod2",
+					Containers: []powerv1alpha1.Container{
+						{Name: "example-container1", ExclusiveCPUs: []int{2, 3}},
+					},
+				},
+			},
+			expectedAllocatable: 3,
+			expectedAllocated:   3,
+			expectedFree:        0,
+		},
+		{
+			testCase:            "Test Case 3",
+			allocatableCPU:      "4",
+			guaranteedPods:      []powerv1alpha1.GuaranteedPod{},
+			expectedAllocatable: 4,
+			expectedAllocated:   0,
+			expectedFree:        4,
+		},
+	}
+
+	for _, tc := range tcases {
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "example-node1",
+			},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU: resource.MustParse(tc.allocatableCPU),
+				},
+			},
+		}
+
+		r, err := createPowerNodeReconcilerObject([]runtime.Object{node})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		state, err := podstate.NewState()
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating State", tc.testCase))
+		}
+		state.GuaranteedPods = tc.guaranteedPods
+		r.State = state
+		r.FreeExclusiveCPUsThreshold = tc.threshold
+
+		r.recordExclusiveCPUCapacity(r.Log, "example-node1")
+
+		for _, gauge := range []struct {
+			name     string
+			vec      *prometheus.GaugeVec
+			expected float64
+		}{
+			{"power_exclusive_cpus_allocatable", exclusiveCPUsAllocatable, tc.expectedAllocatable},
+			{"power_exclusive_cpus_allocated", exclusiveCPUsAllocated, tc.expectedAllocated},
+			{"power_exclusive_cpus_free", exclusiveCPUsFree, tc.expectedFree},
+		} {
+			metric := &dto.Metric{}
+			err = gauge.vec.WithLabelValues("example-node1").Write(metric)
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error reading %s", tc.testCase, gauge.name))
+			}
+			if metric.Gauge.GetValue() != gauge.expected {
+				t.Errorf("%s - Failed: Expected %s to be %v, got %v", tc.testCase, gauge.name, gauge.expected, metric.Gauge.GetValue())
+			}
+		}
+
+		if tc.threshold == 0 {
+			continue
+		}
+
+		metric := &dto.Metric{}
+		err = exclusiveCPUsFreeThreshold.WithLabelValues("example-node1").Write(metric)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reading power_exclusive_cpus_free_threshold", tc.testCase))
+		}
+		if metric.Gauge.GetValue() != float64(tc.threshold) {
+			t.Errorf("%s - Failed: Expected power_exclusive_cpus_free_threshold to be %v, got %v", tc.testCase, tc.threshold, metric.Gauge.GetValue())
+		}
+	}
+}
+
+func TestAppQoSCoreMismatch(t *testing.T) {
+	tcases := []struct {
+		testCase        string
+		capacityCPU     string
+		pools           []appqos.Pool
+		expectedStatus  metav1.ConditionStatus
+		expectedMessage string
+	}{
+		{
+			testCase:    "Test Case 1",
+			capacityCPU: "8",
+			pools: []appqos.Pool{
+				{Name: stringPtr("Default"), ID: intPtr(1), Cores: &[]int{0, 1, 2, 3}},
+				{Name: stringPtr("Shared"), ID: intPtr(2), Cores: &[]int{4, 5}},
+			},
+			expectedStatus:  metav1.ConditionFalse,
+			expectedMessage: "AppQoS knows of 6 of the 8 cores the Kubelet reports, missing cores [6 7]",
+		},
+		{
+			testCase:    "Test Case 2",
+			capacityCPU: "4",
+			pools: []appqos.Pool{
+				{Name: stringPtr("Default"), ID: intPtr(1), Cores: &[]int{0, 1}},
+				{Name: stringPtr("Shared"), ID: intPtr(2), Cores: &[]int{2}},
+				{Name: stringPtr("performance-example-node1"), ID: intPtr(3), Cores: &[]int{3}},
+			},
+			expectedStatus: metav1.ConditionTrue,
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		objs := []runtime.Object{
+			&powerv1alpha1.PowerNode{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "example-node1",
+					Namespace: PowerNodeNamespace,
+				},
+				Spec: powerv1alpha1.PowerNodeSpec{
+					NodeName: "example-node1",
+				},
+			},
+			&corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "example-node1",
+				},
+				Status: corev1.NodeStatus{
+					Capacity: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse(tc.capacityCPU),
+					},
+				},
+			},
+		}
+
+		r, err := createPowerNodeReconcilerObject(objs)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		server, err := createListeners(tc.pools, []appqos.PowerProfile{}, "4.0.0")
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating Listeners", tc.testCase))
+		}
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      "example-node1",
+				Namespace: PowerNodeNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		server.Close()
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling PowerNode object", tc.testCase))
+		}
+
+		powerNode := &powerv1alpha1.PowerNode{}
+		err = r.Client.Get(context.TODO(), req.NamespacedName, powerNode)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerNode object", tc.testCase))
+		}
+
+		condition := meta.FindStatusCondition(powerNode.Status.Conditions, AppQoSCoresCondition)
+		if condition == nil {
+			t.Errorf("%s - Failed: Expected PowerNode to have the '%s' condition", tc.testCase, AppQoSCoresCondition)
+			continue
+		}
+
+		if condition.Status != tc.expectedStatus {
+			t.Errorf("%s - Failed: Expected '%s' condition status to be '%s', got '%s'", tc.testCase, AppQoSCoresCondition, tc.expectedStatus, condition.Status)
+		}
+
+		if condition.Message != tc.expectedMessage {
+			t.Errorf("%s - Failed: Expected '%s' condition message to be '%s', got '%s'", tc.testCase, AppQoSCoresCondition, tc.expectedMessage, condition.Message)
+		}
+	}
+}
+
+type payloadLogger struct {
+	verbosity int
+	level     int
+	messages  *[]string
+}
+
+func (l payloadLogger) Enabled() bool {
+	return l.level <= l.verbosity
+}
+
+func (l payloadLogger) Info(msg string, keysAndValues ...interface{}) {
+	if !l.Enabled() {
+		return
+	}
+
+	*l.messages = append(*l.messages, fmt.Sprint(append([]interface{}{msg}, keysAndValues...)...))
+}
+
+func (l payloadLogger) Error(err error, msg string, keysAndValues ...interface{}) {}
+
+func (l payloadLogger) V(level int) logr.Logger {
+	l.level += level
+	return l
+}
+
+func (l payloadLogger) WithValues(keysAndValues ...interface{}) logr.Logger {
+	return l
+}
+
+func (l payloadLogger) WithName(name string) logr.Logger {
+	return l
+}
+
+func TestAppQoSPayloadLogging(t *testing.T) {
+	tcases := []struct {
+		testCase             string
+		verbosity            int
+		expectedBodiesLogged bool
+	}{
+		{
+			testCase:             "Test Case 1",
+			verbosity:            0,
+			expectedBodiesLogged: false,
+		},
+		{
+			testCase:             "Test Case 2",
+			verbosity:            1,
+			expectedBodiesLogged: false,
+		},
+		{
+			testCase:             "Test Case 3",
+			verbosity:            2,
+			expectedBodiesLogged: true,
+		},
+		{
+			testCase:             "Test Case 4",
+			verbosity:            3,
+			expectedBodiesLogged: true,
+		},
+	}
+
+	for _, tc := range tcases {
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		listener, err := net.Listen("tcp", "127.0.0.1:5000")
+		if err != nil {
+			t.Fatal(fmt.Sprintf("%s - error creating Listener: %v", tc.testCase, err))
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/pools", (func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "GET" {
+				fmt.Fprintln(w, `[{"name":"Default","id":1,"cores":[0,1,2,3],"auth_token":"pool-token"}]`)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		}))
+		server := httptest.NewUnstartedServer(mux)
+		server.Listener.Close()
+		server.Listener = listener
+		server.Start()
+
+		messages := []string{}
+		appQoSClient := appqos.NewDefaultAppQoSClient()
+		appQoSClient.SetPayloadLogger(payloadLogger{verbosity: tc.verbosity, messages: &messages})
+
+		pools, err := appQoSClient.GetPools(AppQoSClientAddress)
+		if err != nil {
+			server.Close()
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving Pools", tc.testCase))
+		}
+		_, err = appQoSClient.PostPool(&appqos.Pool{Name: stringPtr("Shared"), Cores: &[]int{4, 5}}, AppQoSClientAddress)
+		server.Close()
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating Pool", tc.testCase))
+		}
+
+		if len(pools) != 1 || len(*pools[0].Cores) != 4 {
+			t.Errorf("%s - Failed: Expected the Default Pool to be read intact, got %v", tc.testCase, pools)
+		}
+
+		logged := strings.Join(messages, "\n")
+		bodiesLogged := strings.Contains(logged, `"cores":[0,1,2,3]`) && strings.Contains(logged, `"cores":[4,5]`)
+		if bodiesLogged != tc.expectedBodiesLogged {
+			t.Errorf("%s - Failed: Expected request and response bodies logged to be %v, got %v", tc.testCase, tc.expectedBodiesLogged, logged)
+		}
+
+		if strings.Contains(logged, "pool-token") {
+			t.Errorf("%s - Failed: Expected auth_token to be redacted, got %v", tc.testCase, logged)
+		}
+		if tc.expectedBodiesLogged && !strings.Contains(logged, appqos.RedactedValue) {
+			t.Errorf("%s - Failed: Expected auth_token to be logged as %s, got %v", tc.testCase, appqos.RedactedValue, logged)
+		}
+	}
+}
+
+func TestAppQoSUnreachableTaint(t *testing.T) {
+	tcases := []struct {
+		testCase        string
+		appqosRunning   bool
+		expectedTainted bool
+	}{
+		{
+			testCase:        "Test Case 1 - AppQoS up",
+			appqosRunning:   true,
+			expectedTainted: false,
+		},
+		{
+			testCase:        "Test Case 2 - AppQoS goes down",
+			appqosRunning:   false,
+			expectedTainted: true,
+		},
+		{
+			testCase:        "Test Case 3 - AppQoS stays down",
+			appqosRunning:   false,
+			expectedTainted: true,
+		},
+		{
+			testCase:        "Test Case 4 - AppQoS recovers",
+			appqosRunning:   true,
+			expectedTainted: false,
+		},
+	}
+
+	t.Setenv("NODE_NAME", "example-node1")
+	AppQoSClientAddress = "http://127.0.0.1:5000"
+
+	powerNode := &powerv1alpha1.PowerNode{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-node1",
+			Namespace: PowerNodeNamespace,
+		},
+		Spec: powerv1alpha1.PowerNodeSpec{
+			NodeName: "example-node1",
+		},
+	}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "example-node1",
+		},
+		Spec: corev1.NodeSpec{
+			Taints: []corev1.Taint{
+				{
+					Key:    "example-taint",
+					Effect: corev1.TaintEffectNoExecute,
+				},
+			},
+		},
+	}
+	pools := []appqos.Pool{
+		{
+			Name:  stringPtr("Default"),
+			ID:    intPtr(1),
+			Cores: &[]int{0, 1, 2, 3},
+		},
+	}
+
+	r, err := createPowerNodeReconcilerObject([]runtime.Object{powerNode, node})
+	if err != nil {
+		t.Error(err)
+		t.Fatal("error creating reconcile object")
+	}
+	r.TaintAppQoSUnreachable = true
+
+	req := reconcile.Request{
+		NamespacedName: client.ObjectKey{
+			Name:      powerNode.Name,
+			Namespace: PowerNodeNamespace,
+		},
+	}
+
+	// The cases run in order against the same Node so each checks a transition from the one before
+	for _, tc := range tcases {
+		var server *httptest.Server
+		if tc.appqosRunning {
+			server, err = createListeners(pools, []appqos.PowerProfile{}, "4.1.0")
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error creating Listeners", tc.testCase))
+			}
+		}
+
+		_, err = r.Reconcile(req)
+		if server != nil {
+			server.Close()
+		}
+		if (err == nil) != tc.appqosRunning {
+			t.Errorf("%s - Failed: Expected reconcile to succeed to be %v, got error %v", tc.testCase, tc.appqosRunning, err)
+		}
+
+		updatedNode := &corev1.Node{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{Name: "example-node1"}, updatedNode)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving Node object", tc.testCase))
+		}
+
+		tainted := 0
+		otherTaintKept := false
+		for _, taint := range updatedNode.Spec.Taints {
+			if taint.Key == AppQoSUnreachableTaint && taint.Effect == corev1.TaintEffectNoSchedule {
+				tainted++
+			}
+			if taint.Key == "example-taint" {
+				otherTaintKept = true
+			}
+		}
+
+		if (tainted > 0) != tc.expectedTainted {
+			t.Errorf("%s - Failed: Expected Node to be tainted to be %v, got taints %v", tc.testCase, tc.expectedTainted, updatedNode.Spec.Taints)
+		}
+		if tainted > 1 {
+			t.Errorf("%s - Failed: Expected a single %v taint, got %d", tc.testCase, AppQoSUnreachableTaint, tainted)
+		}
+		if !otherTaintKept {
+			t.Errorf("%s - Failed: Expected Node's other taints to be kept, got %v", tc.testCase, updatedNode.Spec.Taints)
+		}
+	}
+}
+
+func TestNodeAgentToleratesAppQoSUnreachableTaint(t *testing.T) {
+	tcases := []struct {
+		testCase string
+		path     string
+	}{
+		{
+			testCase: "Test Case 1",
+			path:     "../build/manifests/power-node-agent-ds.yaml",
+		},
+	}
+
+	for _, tc := range tcases {
+		daemonSet, err := newDaemonSet(tc.path)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reading Node Agent DaemonSet", tc.testCase))
+		}
+
+		taint := &corev1.Taint{
+			Key:    AppQoSUnreachableTaint,
+			Effect: corev1.TaintEffectNoSchedule,
+		}
+		tolerated := false
+		for i := range daemonSet.Spec.Template.Spec.Tolerations {
+			if daemonSet.Spec.Template.Spec.Tolerations[i].ToleratesTaint(taint) {
+				tolerated = true
+			}
+		}
+
+		// The Node Agent is what removes the taint, so it must still be scheduled while the taint is set
+		if !tolerated {
+			t.Errorf("%s - Failed: Expected Node Agent DaemonSet to tolerate the %s taint", tc.testCase, AppQoSUnreachableTaint)
+		}
+	}
+}
@@ -24,17 +24,26 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/appqos"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/cpumanager"
 	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/podresourcesclient"
 	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/podstate"
 	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/util"
@@ -42,6 +51,8 @@ import (
 
 const (
 	PowerProfileAnnotation = "PowerProfile"
+	PodProfileAnnotation   = "power.intel.com/profile"
+	ParkSiblingsAnnotation = "power.intel.com/park-siblings"
 	ResourcePrefix         = "power.intel.com/"
 	CPUResource            = "cpu"
 )
@@ -51,19 +62,230 @@ type PowerPodReconciler struct {
 	client.Client
 	Log                logr.Logger
 	Scheme             *runtime.Scheme
-	State              podstate.State
+	State              *podstate.State
 	PodResourcesClient podresourcesclient.PodResourcesClient
+	Recorder           record.EventRecorder
+
+	// AppQoSClient is used to check requested PowerProfiles exist in AppQoS. If nil, only the CR is checked
+	AppQoSClient *appqos.AppQoSClient
+
+	// ProfileResolvers are consulted in order to find the PowerProfile a Container requests.
+	// Defaults to resolving from the Container's resource requests
+	ProfileResolvers []ProfileResolver
+
+	// StrictResourceRequests disables the annotation fallback, so Pods carrying only the PowerProfile
+	// annotation without a 'power.intel.com/' resource request are not managed
+	StrictResourceRequests bool
+
+	// AllowAppQoSOnlyProfiles accepts Pods requesting a PowerProfile that exists only in AppQoS. Otherwise a Pod
+	// requesting a PowerProfile that has no PowerProfile CR is rejected, so every applied PowerProfile is
+	// declaratively defined. Without an AppQoSClient the CR is always required
+	AllowAppQoSOnlyProfiles bool
+
+	// ManagedNodeSelector is a label selector, such as 'power.intel.com/appqos=enabled', that a Node must
+	// match for its Pods to be managed. Pods on other Nodes are skipped. Empty manages every Node
+	ManagedNodeSelector string
+
+	// ReconcileTimeout bounds how long a single reconcile may take. A reconcile that overruns it is
+	// aborted and requeued rather than holding a worker. An aborted deletion keeps the Pod in the internal
+	// state, so the release of its cores is retried. Zero disables the deadline
+	ReconcileTimeout time.Duration
+
+	// MaxConcurrentReconciles is the number of Pods reconciled in parallel. The priority buffer releases as many
+	// requests as there are workers. Zero or less uses a single worker
+	MaxConcurrentReconciles int
+
+	// CPUSetStabilizationAttempts bounds how many times a Container's cpuset is read while waiting for the CPU
+	// Manager to finalize it, stopping once two consecutive reads agree on a non-empty cpuset. A cpuset unchanged
+	// since it was last found stable is only read once. One or fewer takes the first read as is
+	CPUSetStabilizationAttempts int
+
+	// CPUSetStabilizationInterval is the delay between reads of a Container's cpuset while it stabilizes
+	CPUSetStabilizationInterval time.Duration
+
+	// VerifyCgroupCPUSet cross-checks each Container's cores from the PodResources API against its cpuset cgroup,
+	// emitting a Warning Event if they diverge. The PodResources API's cores are still used
+	VerifyCgroupCPUSet bool
+
+	// CheckCPUManagerPolicy makes Pods requesting a PowerProfile on a Node whose kubelet isn't running the static
+	// CPU Manager policy get a Warning Event instead of being quietly left without exclusive cores
+	CheckCPUManagerPolicy bool
+
+	// ReportUnprofiledContainers logs and emits a Warning Event for each Container given exclusive CPUs without a
+	// PowerProfile, whose cores are isolated but left without power management
+	ReportUnprofiledContainers bool
+
+	// ProfileChangeCooldown is how long after a core's PowerProfile is switched that further switches of it are
+	// deferred, dampening frequency flapping. Zero disables the cooldown
+	ProfileChangeCooldown time.Duration
+
+	// MaxWorkloadNodes bounds how many Nodes' Pods may have cores in a single PowerWorkload. Past it, a
+	// PowerProfile's cores are spread across '<profile>-shard-N-workload' PowerWorkloads. A PowerWorkload's NodeInfo
+	// describes a single Node, so every shard holds one Node's cores and values above 1 act as 1. Zero disables sharding
+	MaxWorkloadNodes int
+
+	// RetryBudget is how many consecutive failed reconciles a Pod is allowed before it stops being requeued and is
+	// marked with the RetriesExhaustedAnnotation. It is retried again once it changes. Zero retries indefinitely
+	RetryBudget int
+
+	// ManageEphemeralContainers also applies PowerProfiles to the cores of a Pod's running ephemeral containers,
+	// releasing them once the containers exit
+	ManageEphemeralContainers bool
+
+	// CrashLoopBackoff is how long power setup of a crash looping Pod is deferred for, and how long a Container
+	// restarted after a crash must stay running before the Pod is considered stable. Zero disables the check
+	CrashLoopBackoff time.Duration
+
+	// AnnotateAppliedCPUs sets an annotation on each managed Pod, prefixed with AppliedCPUsAnnotationPrefix, holding the
+	// cores applied for each of its Containers
+	AnnotateAppliedCPUs bool
+
+	// ReportPowerPods records each managed Pod's Node and the cores and PowerProfile of its Containers in the
+	// status of a PowerPod of the same name
+	ReportPowerPods bool
+
+	// AllocationNotifier, if set, notifies an external webhook whenever cores are allocated or released
+	AllocationNotifier *AllocationNotifier
+
+	// DecisionSink, if set, receives a DecisionRecord for every PowerWorkload write allocating or releasing cores
+	DecisionSink DecisionSink
+
+	// DeletionCoalesceWindow is how long deletion cleanups for the same PowerWorkload are collected
+	// before being written as a single update. Zero disables coalescing
+	DeletionCoalesceWindow time.Duration
+
+	// WorkloadNamespace, if set, is the namespace every PowerWorkload is kept in, instead of the namespace of the
+	// Pod that first requested its PowerProfile
+	WorkloadNamespace string
+
+	// AddCleanupFinalizer attaches the PodCleanupFinalizer to each power-managed Pod, holding back its deletion until
+	// its cores have been released from its PowerWorkloads, even if the Node Agent was down when it was deleted.
+	// A Pod carrying the finalizer is released straight away rather than through the DeletionCoalesceWindow
+	AddCleanupFinalizer bool
+
+	// MinWorkloadWriteInterval is the least time between writes to the same PowerWorkload. Pod changes arriving
+	// sooner are held per PowerWorkload and written together once the interval has passed. Zero disables the limit
+	MinWorkloadWriteInterval time.Duration
+
+	// workloadWritesForbiddenUntil is set when RBAC denies a PowerWorkload write, putting the
+	// controller into read-only reporting mode until it passes
+	workloadWritesForbiddenUntil time.Time
+	readOnlyMutex                sync.Mutex
+
+	deletions deletionCoalescer
+
+	// workloadWrites records when each PowerWorkload was last written and holds the changes waiting on
+	// MinWorkloadWriteInterval
+	workloadWrites workloadWriteQueue
+
+	// workloadLocks serializes read-modify-writes of each PowerWorkload while other PowerWorkloads proceed in parallel
+	workloadLocks keyedMutex
+
+	// queue tracks the Pod requests waiting for a worker, exposed as backpressure metrics
+	queue queueTracker
+
+	// priority orders the Pod requests waiting for a worker by PriorityClass
+	priority priorityBuffer
+
+	// memoryAlignments tracks the Containers' cores last found off their memory's NUMA nodes
+	memoryAlignments memoryAlignments
+
+	// quotaRejections tracks the Pods rejected for exceeding their namespace's core quota
+	quotaRejections quotaRejections
+
+	// cpuSetReads tracks the Containers' cpusets last found stable
+	cpuSetReads cpuSetReads
 }
 
 // +kubebuilder:rbac:groups=power.intel.com,resources=powerpods,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=power.intel.com,resources=powerpods/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;patch;update
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=power.intel.com,resources=powerconfigs,verbs=get;list;watch
 
 func (r *PowerPodReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
-	_ = context.Background()
+	r.queue.done(req)
+	r.priority.started(req)
+
+	ctx := context.Background()
+	if r.ReconcileTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.ReconcileTimeout)
+		defer cancel()
+	}
+
+	if r.retriesExhausted(ctx, req) {
+		r.Log.WithValues("powerpod", req.NamespacedName).V(1).Info("Pod has exhausted its retry budget, skipping until it changes")
+		return ctrl.Result{}, nil
+	}
+
+	previous := r.State.GetPodFromState(req.NamespacedName.Name)
+	result, err := r.reconcilePod(ctx, req)
+	if ctx.Err() == context.DeadlineExceeded {
+		// A call is stuck, so give the worker back and try again rather than surfacing the aborted call's error
+		r.Log.WithValues("powerpod", req.NamespacedName).Info("Reconcile exceeded its deadline, requeueing", "timeout", r.ReconcileTimeout.String())
+		result, err = ctrl.Result{Requeue: true}, nil
+	}
+
+	r.logReconcileSummary(req, previous, result, err)
+	return r.applyRetryBudget(ctx, req, result, err)
+}
+
+// logReconcileSummary emits a single line describing everything the reconcile decided for the Pod, found by
+// comparing its State before and after. The outcome is logged at V(0), with the per-Container detail added at V(1)
+func (r *PowerPodReconciler) logReconcileSummary(req ctrl.Request, previous powerv1alpha1.GuaranteedPod, result ctrl.Result, reconcileErr error) {
+	current := r.State.GetPodFromState(req.NamespacedName.Name)
+	previousCores := r.State.GetCPUsFromPodState(previous)
+	currentCores := r.State.GetCPUsFromPodState(current)
+
+	node := current.Node
+	if node == "" {
+		node = previous.Node
+	}
+
+	resolvedProfiles := make(map[string]bool)
+	profiles := make([]string, 0)
+	for _, container := range current.Containers {
+		if !resolvedProfiles[container.PowerProfile] {
+			resolvedProfiles[container.PowerProfile] = true
+			profiles = append(profiles, container.PowerProfile)
+		}
+	}
+	sort.Strings(profiles)
+
+	outcome := "Success"
+	if reconcileErr != nil {
+		outcome = "Error"
+	} else if result.Requeue || result.RequeueAfter > 0 {
+		outcome = "Requeue"
+	}
+
+	keysAndValues := []interface{}{
+		"node", node,
+		"profiles", profiles,
+		"coresAdded", util.CPUListDifference(previousCores, currentCores),
+		"coresRemoved", util.CPUListDifference(currentCores, previousCores),
+		"result", outcome,
+	}
+	if reconcileErr != nil {
+		keysAndValues = append(keysAndValues, "error", reconcileErr.Error())
+	}
+
+	logger := r.Log.WithValues("powerpod", req.NamespacedName)
+	if detailLogger := logger.V(1); detailLogger.Enabled() {
+		keysAndValues = append(keysAndValues, "containers", current.Containers, "requeueAfter", result.RequeueAfter.String())
+		detailLogger.Info("Reconcile summary", keysAndValues...)
+		return
+	}
+
+	logger.Info("Reconcile summary", keysAndValues...)
+}
+
+func (r *PowerPodReconciler) reconcilePod(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := r.Log.WithValues("powerpod", req.NamespacedName)
 
 	pod := &corev1.Pod{}
-	err := r.Get(context.TODO(), req.NamespacedName, pod)
+	err := r.Get(ctx, req.NamespacedName, pod)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Defeat the Pod from the internal state in case it was never deleted
@@ -71,6 +293,14 @@ func (r *PowerPodReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 			if err != nil {
 				return ctrl.Result{}, err
 			}
+			r.State.DeleteManagedCores(req.NamespacedName.Name, req.NamespacedName.Namespace)
+			r.workloadWrites.drop(req.NamespacedName)
+
+			err = r.restoreParkedSiblings(req.NamespacedName.Name)
+			if err != nil {
+				logger.Error(err, "error restoring parked sibling threads")
+				return ctrl.Result{}, err
+			}
 
 			return ctrl.Result{}, nil
 		}
@@ -92,85 +322,87 @@ func (r *PowerPodReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		return ctrl.Result{}, nil
 	}
 
+	managed, err := r.nodeIsPowerManaged(ctx, nodeName)
+	if err != nil {
+		logger.Error(err, "error checking if Node is power-managed")
+		return ctrl.Result{}, err
+	}
+	if !managed {
+		logger.Info("Node is not power-managed, skipping Pod", "selector", r.ManagedNodeSelector)
+		if !pod.ObjectMeta.DeletionTimestamp.IsZero() {
+			// A Node that stops being power-managed must not block the deletion of the Pods it used to manage
+			return ctrl.Result{}, r.removePodCleanupFinalizer(ctx, pod)
+		}
+		r.Recorder.Eventf(pod, corev1.EventTypeNormal, "NodeNotPowerManaged", "Node '%s' does not match '%s', Pod will not be power-managed", nodeName, r.ManagedNodeSelector)
+		return ctrl.Result{}, nil
+	}
+
 	if !pod.ObjectMeta.DeletionTimestamp.IsZero() {
 		// If the Pod's DeletionTimestamp is not zero then the Pod has been deleted
+		return r.releaseDeletedPod(ctx, logger, req, pod)
+	}
 
-		powerPodState := r.State.GetPodFromState(pod.GetName())
+	// If the Pod's DeletionTimestamp is equal to zero then the Pod has been created or updated
 
-		err = r.State.DeletePodFromState(pod.GetName())
-		if err != nil {
-			logger.Error(err, "error removing Pod from internal state")
-			return ctrl.Result{}, err
-		}
+	// Make sure the Pod is running, or is running its init containers
+	podNotRunningErr := errors.NewServiceUnavailable("pod not in running phase")
+	if pod.Status.Phase != corev1.PodRunning && !initContainerRunning(pod) {
+		logger.Info("Pod not running", "pod status:", pod.Status.Phase)
+		return ctrl.Result{}, podNotRunningErr
+	}
 
-		workloadToCPUsRemoved := make(map[string][]int)
-		for _, container := range powerPodState.Containers {
-			workload := fmt.Sprintf("%s%s", container.PowerProfile, WorkloadNameSuffix)
-			cpus := container.ExclusiveCPUs
-			if _, exists := workloadToCPUsRemoved[workload]; exists {
-				workloadToCPUsRemoved[workload] = append(workloadToCPUsRemoved[workload], cpus...)
-			} else {
-				workloadToCPUsRemoved[workload] = cpus
-			}
+	// A crash looping Pod is only Running in between crashes, so setting up its cores is deferred until it stabilizes
+	if r.CrashLoopBackoff > 0 {
+		if container := crashLoopingContainer(pod, r.CrashLoopBackoff, time.Now()); container != "" {
+			logger.Info("Container is crash looping, deferring power setup until the Pod stabilizes", "container", container, "requeueAfter", r.CrashLoopBackoff.String())
+			return ctrl.Result{RequeueAfter: r.CrashLoopBackoff}, nil
 		}
+	}
 
-		for workloadName, cpus := range workloadToCPUsRemoved {
-			workload := &powerv1alpha1.PowerWorkload{}
-			err = r.Get(context.TODO(), client.ObjectKey{
-				Namespace: req.NamespacedName.Namespace,
-				Name:      workloadName,
-			}, workload)
-			if err != nil {
-				if errors.IsNotFound(err) {
-					return ctrl.Result{}, nil
-				}
-				logger.Error(err, "error while trying to retrieve PowerWorkload")
-				return ctrl.Result{}, err
-			}
-
-			updatedWorkloadCPUList := getNewWorkloadCPUList(cpus, workload.Spec.Node.CpuIds)
-			if len(updatedWorkloadCPUList) == 0 {
-				// We can delete this PowerWorkload as no CPUs are utilizing it
-
-				err = r.Client.Delete(context.TODO(), workload)
-				if err != nil {
-					logger.Error(err, "error deleting PowerWorkload")
-					return ctrl.Result{}, err
-				}
-			} else {
-				workload.Spec.Node.CpuIds = updatedWorkloadCPUList
-
-				// We don't need to check if there's no containers because if there weren't, that would have been caught while checking the number of CPUs above
-				updatedWorkloadContainerList := getNewWorkloadContainerList(workload.Spec.Node.Containers, powerPodState.Containers)
-				workload.Spec.Node.Containers = updatedWorkloadContainerList
+	// Init containers that have completed no longer hold their cores, so they leave their PowerProfile's PowerWorkload
+	err = r.releaseCompletedInitContainers(ctx, logger, r.workloadNamespace(req.NamespacedName.Namespace), pod)
+	if err != nil {
+		logger.Error(err, "error releasing cores of completed init containers")
+		return ctrl.Result{}, err
+	}
 
-				err = r.Client.Update(context.TODO(), workload)
-				if err != nil {
-					logger.Error(err, "Failed updating PowerWorkload")
-					return ctrl.Result{}, err
-				}
-			}
+	if r.ManageEphemeralContainers {
+		err = r.releaseExitedEphemeralContainers(ctx, logger, r.workloadNamespace(req.NamespacedName.Namespace), pod)
+		if err != nil {
+			logger.Error(err, "error releasing cores of exited ephemeral containers")
+			return ctrl.Result{}, err
 		}
-
-		return ctrl.Result{}, nil
 	}
 
-	// If the Pod's DeletionTimestamp is equal to zero then the Pod has been created or updated
-
-	// Make sure the Pod is running
-	podNotRunningErr := errors.NewServiceUnavailable("pod not in running phase")
-	if pod.Status.Phase != corev1.PodRunning {
-		logger.Info("Pod not running", "pod status:", pod.Status.Phase)
-		return ctrl.Result{}, podNotRunningErr
+	// A resource update may have left the Pod's Containers without exclusive CPUs, so the cores they held are released
+	err = r.releaseDequalifiedContainers(ctx, logger, r.workloadNamespace(req.NamespacedName.Namespace), pod)
+	if err != nil {
+		logger.Error(err, "error releasing cores of Containers no longer given exclusive CPUs")
+		return ctrl.Result{}, err
 	}
 
-	// Get the Containers of the Pod that are requesting exclusive CPUs
+	// Get the Containers of the Pod's current phase that are requesting exclusive CPUs
 	containersRequestingExclusiveCPUs := getContainersRequestingExclusiveCPUs(pod)
+	if r.ManageEphemeralContainers {
+		containersRequestingExclusiveCPUs = append(containersRequestingExclusiveCPUs, ephemeralContainersRequestingExclusiveCPUs(pod)...)
+	}
 	if len(containersRequestingExclusiveCPUs) == 0 {
 		logger.Info("No containers are requesting exclusive CPUs")
 		return ctrl.Result{}, nil
 	}
 
+	// Without the static CPU Manager policy the containers never get exclusive cores to apply a PowerProfile to
+	if r.CheckCPUManagerPolicy && r.requestsPowerProfile(pod, containersRequestingExclusiveCPUs) {
+		policy, err := cpumanager.GetPolicy()
+		if err != nil {
+			logger.Error(err, "unable to determine the kubelet's CPU Manager policy")
+		} else if policy != cpumanager.StaticPolicy {
+			logger.Info("CPU Manager policy is not static, Pod's containers will not have exclusive CPUs", "policy", policy)
+			r.Recorder.Eventf(pod, corev1.EventTypeWarning, "CPUManagerPolicyNotStatic", "The kubelet on Node '%s' is running the '%s' CPU Manager policy, so no PowerProfile can be applied. The '%s' policy is required", pod.Spec.NodeName, policy, cpumanager.StaticPolicy)
+			return ctrl.Result{}, nil
+		}
+	}
+
 	podUID := pod.GetUID()
 	if podUID == "" {
 		logger.Info("No pod UID found")
@@ -178,92 +410,113 @@ func (r *PowerPodReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	}
 
 	powerProfileCRs := &powerv1alpha1.PowerProfileList{}
-	err = r.Client.List(context.TODO(), powerProfileCRs)
+	err = r.Client.List(ctx, powerProfileCRs)
 	if err != nil {
 		logger.Error(err, "Error retrieving Power Profiles from cluster")
 		return ctrl.Result{}, nil
 	}
 
-	powerProfilesFromContainers, powerContainers, err := r.getPowerProfileRequestsFromContainers(containersRequestingExclusiveCPUs, powerProfileCRs.Items, pod)
+	powerProfilesFromContainers, powerContainers, err := r.getPowerProfileRequestsFromContainers(ctx, containersRequestingExclusiveCPUs, powerProfileCRs.Items, pod)
 	if err != nil {
 		logger.Error(err, "Error retrieving Power Profile from Pod requests")
 		return ctrl.Result{}, err
 	}
 
-	for profile, cores := range powerProfilesFromContainers {
-		profileName := profile
-		if _, exists := extendedResourcePercentage[profile]; exists {
-			// If the PowerProfile is a base profile, we need to get the correct Profile based on the node name
+	admitted, err := r.checkNamespaceQuota(ctx, pod, powerContainers)
+	if err != nil {
+		logger.Error(err, "error checking namespace core quota")
+		return ctrl.Result{}, err
+	}
+	if !admitted {
+		logger.Info("Pod would exceed its namespace's core quota, not power-managing it", "namespace", pod.GetNamespace())
+		return ctrl.Result{}, nil
+	}
+
+	// A restarted Container may have been given a different cpuset, whose cores are swapped in when the Pod is added to its PowerWorkload
+	r.logRestartedContainers(logger, pod, powerContainers)
 
-			profileName = fmt.Sprintf("%s-%s", profile, pod.Spec.NodeName)
+	// Cores whose PowerProfile was switched recently are left on their current PowerProfile until the cooldown passes
+	changedContainers := r.changedProfileContainers(pod, powerContainers)
+	changedCPUs := make([]int, 0)
+	for _, container := range changedContainers {
+		changedCPUs = append(changedCPUs, container.ExclusiveCPUs...)
+	}
+	if r.ProfileChangeCooldown > 0 && len(changedCPUs) > 0 {
+		remaining := r.ProfileChangeCooldown - time.Since(r.State.GetLastProfileChange(changedCPUs))
+		if remaining > 0 {
+			logger.Info("PowerProfile changed within cooldown, deferring change", "cores", changedCPUs, "requeueAfter", remaining)
+			return ctrl.Result{RequeueAfter: remaining}, nil
 		}
+	}
 
-		workloadName := fmt.Sprintf("%s%s", profileName, WorkloadNameSuffix)
-		workload := &powerv1alpha1.PowerWorkload{}
-		err = r.Client.Get(context.TODO(), client.ObjectKey{
-			Namespace: req.NamespacedName.Namespace,
-			Name:      workloadName,
-		}, workload)
+	// A change held from an earlier reconcile of the Pod is superseded by this one
+	r.workloadWrites.drop(req.NamespacedName)
+
+	// If the Pod's PowerProfile has changed since it was last reconciled, its cores need moving out of the old PowerWorkload
+	err = r.releaseChangedProfiles(ctx, logger, r.workloadNamespace(req.NamespacedName.Namespace), pod, changedContainers)
+	if err != nil {
+		logger.Error(err, "error releasing cores from previous PowerWorkload")
+		return ctrl.Result{}, err
+	}
+	r.State.RecordProfileChange(changedCPUs, time.Now())
+
+	// The finalizer is attached before the Pod's cores are added, so there is never a PowerWorkload holding them
+	// that a missed deletion of the Pod would leave behind
+	if r.AddCleanupFinalizer {
+		err = r.addPodCleanupFinalizer(ctx, pod)
 		if err != nil {
-			if errors.IsNotFound(err) {
-				// This is the first Pod to request this PowerProfile, need to create corresponding PowerWorkload
-
-				containerList := make([]powerv1alpha1.Container, 0)
-				for _, container := range powerContainers {
-					workloadContainer := container
-					workloadContainer.Pod = pod.Name
-					containerList = append(containerList, workloadContainer)
-				}
+			logger.Error(err, "error adding Pod cleanup finalizer")
+			return ctrl.Result{}, err
+		}
+	}
 
-				nodeInfo := &powerv1alpha1.NodeInfo{
-					Name:       pod.Spec.NodeName,
-					Containers: containerList,
-					CpuIds:     cores,
-				}
+	// Each of the Pod's PowerProfiles has its own PowerWorkload. If the PowerProfile is a base profile, we need to
+	// get the correct Profile based on the node name, which a Container may also have requested directly
+	profileCores := make(map[string][]int)
+	for profile, cores := range powerProfilesFromContainers {
+		profileName := profileNameForNode(profile, pod.Spec.NodeName)
+		profileCores[profileName] = append(profileCores[profileName], cores...)
+	}
 
-				workloadSpec := &powerv1alpha1.PowerWorkloadSpec{
-					Name:         workloadName,
-					Node:         *nodeInfo,
-					PowerProfile: profileName,
-				}
-				workload = &powerv1alpha1.PowerWorkload{
-					ObjectMeta: metav1.ObjectMeta{
-						Namespace: req.NamespacedName.Namespace,
-						Name:      workloadName,
-					},
-				}
-				workload.Spec = *workloadSpec
-				err = r.Client.Create(context.TODO(), workload)
-				if err != nil {
-					logger.Error(err, "error while creating PowerWorkload")
-					return ctrl.Result{}, err
-				}
-			} else {
-				logger.Error(err, fmt.Sprintf("Error retrieving PowerWorkload '%s'", workloadName))
-			}
+	for profileName, cores := range profileCores {
+		err = r.addPodToWorkload(ctx, logger, r.workloadNamespace(req.NamespacedName.Namespace), pod, profileName, cores, powerContainers, powerProfileCRs.Items)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
 
-			continue
+	if pod.ObjectMeta.Annotations[ParkSiblingsAnnotation] == "true" {
+		err = r.parkSiblings(ctx, r.workloadNamespace(req.NamespacedName.Namespace), pod, powerContainers)
+		if err != nil {
+			logger.Error(err, "error parking sibling threads")
+			return ctrl.Result{}, err
 		}
+	}
+
+	// A change held back by MinWorkloadWriteInterval is only recorded once the flush has written it
+	if r.workloadWrites.holdPodState(req.NamespacedName, pod, powerContainers) {
+		return r.readOnlyResult(), nil
+	}
 
-		// PowerWorkload already exists so need to update it. If the Node already
-		// exists in the Workload, we update the Node's CPU list, if not we create
-		// the entry for the node
+	err = r.recordPodState(ctx, logger, pod, powerContainers)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
 
-		workload.Spec.Node.CpuIds = appendIfUnique(workload.Spec.Node.CpuIds, cores)
-		sort.Ints(workload.Spec.Node.CpuIds)
+	return r.readOnlyResult(), nil
+}
 
-		containerList := make([]powerv1alpha1.Container, 0)
+// recordPodState records the Pod's cores, once written to its PowerWorkloads, in the controller's State, the
+// Pod's PowerPod and its applied-cpus annotations
+func (r *PowerPodReconciler) recordPodState(ctx context.Context, logger logr.Logger, pod *corev1.Pod, powerContainers []powerv1alpha1.Container) error {
+	// The first time the Pod's cores are applied marks how long it took the Pod to be power-managed
+	if r.State.GetPodFromState(pod.GetName()).Name == "" && !r.workloadWritesReadOnly() {
+		appliedProfiles := make(map[string]bool)
 		for _, container := range powerContainers {
-			workloadContainer := container
-			workloadContainer.Pod = pod.Name
-			containerList = append(containerList, workloadContainer)
+			appliedProfiles[profileNameForNode(container.PowerProfile, pod.Spec.NodeName)] = true
 		}
-		workload.Spec.Node.Containers = append(workload.Spec.Node.Containers, containerList...)
-
-		err = r.Client.Update(context.TODO(), workload)
-		if err != nil {
-			logger.Error(err, "error while trying to update PowerWorkload")
-			return ctrl.Result{}, err
+		for profile := range appliedProfiles {
+			recordApplyDelay(pod, profile)
 		}
 	}
 
@@ -272,55 +525,264 @@ func (r *PowerPodReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	guaranteedPod := powerv1alpha1.GuaranteedPod{}
 	guaranteedPod.Node = pod.Spec.NodeName
 	guaranteedPod.Name = pod.GetName()
-	guaranteedPod.UID = string(podUID)
+	guaranteedPod.UID = string(pod.GetUID())
 	guaranteedPod.Containers = make([]powerv1alpha1.Container, 0)
 	guaranteedPod.Containers = powerContainers
-	err = r.State.UpdateStateGuaranteedPods(guaranteedPod)
+	err := r.State.UpdateStateGuaranteedPods(guaranteedPod)
 	if err != nil {
 		logger.Error(err, "error updating internal state")
-		return ctrl.Result{}, err
+		return err
+	}
+	r.State.UpdateRestartCounts(pod.GetName(), getRestartCounts(pod))
+	r.State.UpdateManagedCores(pod.GetName(), pod.GetNamespace(), containerCores(powerContainers))
+
+	if r.ReportPowerPods {
+		r.reportPowerPod(ctx, logger, pod, guaranteedPod)
 	}
 
-	return ctrl.Result{}, nil
+	if r.AnnotateAppliedCPUs {
+		r.annotateAppliedCPUs(ctx, pod, powerContainers)
+	}
+
+	return nil
+}
+
+// addPodToWorkload adds the Pod's cores and Containers to the PowerWorkload for the PowerProfile, creating the
+// PowerWorkload if this is the first Pod to request it. A shard taken by another Node's agent between choosing
+// and writing it is passed over, and another chosen
+func (r *PowerPodReconciler) addPodToWorkload(ctx context.Context, logger logr.Logger, namespace string, pod *corev1.Pod, profileName string, cores []int, powerContainers []powerv1alpha1.Container, profiles []powerv1alpha1.PowerProfile) error {
+	takenShards := make(map[string]bool)
+	for attempt := 1; ; attempt++ {
+		workloadName, err := r.selectWorkloadShard(ctx, namespace, profileName, pod.Spec.NodeName, takenShards)
+		if err != nil {
+			logger.Error(err, "error selecting PowerWorkload shard")
+			return err
+		}
+
+		taken, err := r.addPodToWorkloadShard(ctx, logger, namespace, workloadName, pod, profileName, cores, powerContainers, profiles)
+		if r.MaxWorkloadNodes <= 0 || attempt == maxShardSelectionAttempts || (!taken && !errors.IsConflict(err)) {
+			return err
+		}
+
+		// A conflicting write may only mean the shard was read before another Pod of this Node was added, so it
+		// is chosen again, while a shard now naming another Node is passed over
+		if taken {
+			takenShards[workloadName] = true
+		}
+		logger.Info("PowerWorkload shard changed while being written, choosing a shard again", "workload", workloadName, "taken", taken)
+	}
+}
+
+// addPodToWorkloadShard adds the Pod's cores and Containers to the PowerWorkload, creating it if needed. The
+// PowerWorkload stays locked for the whole read-modify-write so concurrent reconciles for the same PowerProfile
+// don't collide. With sharding, true is returned if the shard was found to hold another Node's cores
+func (r *PowerPodReconciler) addPodToWorkloadShard(ctx context.Context, logger logr.Logger, namespace string, workloadName string, pod *corev1.Pod, profileName string, cores []int, powerContainers []powerv1alpha1.Container, profiles []powerv1alpha1.PowerProfile) (bool, error) {
+	workloadKey := client.ObjectKey{Namespace: namespace, Name: workloadName}
+	r.workloadLocks.lock(workloadKey)
+	defer r.workloadLocks.unlock(workloadKey)
+
+	// Only the Containers requesting this PowerProfile belong in its PowerWorkload. They share the slice recorded
+	// in the State, so the State remembers which shard each is in
+	profileContainers := make([]powerv1alpha1.Container, 0)
+	for i := range powerContainers {
+		if profileNameForNode(powerContainers[i].PowerProfile, pod.Spec.NodeName) != profileName {
+			continue
+		}
+
+		powerContainers[i].Workload = workloadName
+		profileContainers = append(profileContainers, powerContainers[i])
+	}
+
+	podUID := pod.GetUID()
+	workload := &powerv1alpha1.PowerWorkload{}
+	err := r.Client.Get(ctx, client.ObjectKey{
+		Namespace: namespace,
+		Name:      workloadName,
+	}, workload)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// This is the first Pod to request this PowerProfile, need to create corresponding PowerWorkload
+
+			containerList := make([]powerv1alpha1.Container, 0)
+			for _, container := range profileContainers {
+				workloadContainer := container
+				workloadContainer.Pod = pod.Name
+				workloadContainer.PodUID = string(pod.GetUID())
+				containerList = append(containerList, workloadContainer)
+			}
+
+			nodeInfo := &powerv1alpha1.NodeInfo{
+				Name:       pod.Spec.NodeName,
+				Containers: containerList,
+				CpuIds:     cores,
+			}
+
+			workloadSpec := &powerv1alpha1.PowerWorkloadSpec{
+				Name:         workloadName,
+				Node:         *nodeInfo,
+				PowerProfile: profileName,
+			}
+			workload = &powerv1alpha1.PowerWorkload{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: namespace,
+					Name:      workloadName,
+				},
+			}
+			workload.Spec = *workloadSpec
+			setWorkloadNode(workload, pod.Spec.NodeName, true)
+			applyProfileFamily(workload, profiles)
+			written, err := r.writeWorkload(ctx, logger, "create", workload, func() error {
+				return r.Client.Create(ctx, workload)
+			})
+			if err != nil && !errors.IsAlreadyExists(err) {
+				logger.Error(err, "error while creating PowerWorkload")
+				return false, err
+			}
+
+			if err == nil {
+				if written {
+					recordCPUs(cpuAllocationsTotal, pod.Spec.NodeName, profileName, string(podUID), len(cores))
+					r.AllocationNotifier.notify(AllocationEventAllocated, pod.Spec.NodeName, profileName, string(podUID), cores)
+					r.recordDecision(DecisionRecord{
+						Action:         "create",
+						Workload:       workloadName,
+						Namespace:      namespace,
+						Node:           pod.Spec.NodeName,
+						Profile:        profileName,
+						PodName:        pod.Name,
+						PodUID:         string(podUID),
+						AllocatedCores: cores,
+						WorkloadCores:  workload.Spec.Node.CpuIds,
+					})
+				}
+
+				return false, nil
+			}
+
+			// A Pod on another Node created the PowerWorkload first, so re-read it and merge this Pod in as an update
+			logger.Info("PowerWorkload was created concurrently, merging into existing PowerWorkload", "workload", workloadName)
+			workload = &powerv1alpha1.PowerWorkload{}
+			err = r.Client.Get(ctx, client.ObjectKey{
+				Namespace: namespace,
+				Name:      workloadName,
+			}, workload)
+			if err != nil {
+				logger.Error(err, fmt.Sprintf("Error retrieving PowerWorkload '%s'", workloadName))
+				return false, err
+			}
+		} else {
+			logger.Error(err, fmt.Sprintf("Error retrieving PowerWorkload '%s'", workloadName))
+			return false, nil
+		}
+	}
+
+	// A shard holds a single Node's cores, as its NodeInfo can only describe one Node
+	if r.MaxWorkloadNodes > 0 && workload.Spec.Node.Name != "" && workload.Spec.Node.Name != pod.Spec.NodeName {
+		return true, nil
+	}
+
+	// PowerWorkload already exists so need to update it. A change arriving within MinWorkloadWriteInterval of the
+	// last write is held and written together with any others held for the PowerWorkload once the interval passes
+	write := pendingPodWrite{
+		pod:             pod,
+		profileName:     profileName,
+		cores:           cores,
+		powerContainers: profileContainers,
+		profiles:        profiles,
+	}
+	if r.MinWorkloadWriteInterval > 0 {
+		remaining := r.workloadWrites.remaining(workloadKey, r.MinWorkloadWriteInterval)
+		if remaining > 0 && podChangesWorkload(workload, write) {
+			logger.Info("PowerWorkload written within the minimum write interval, holding change", "workload", workloadName, "writeAfter", remaining.String())
+			write.pod = pod.DeepCopy()
+			write.powerContainers = append([]powerv1alpha1.Container{}, profileContainers...)
+			r.queueWorkloadWrite(logger, workloadKey, write, remaining)
+			return false, nil
+		}
+	}
+
+	return false, r.updateWorkloadPods(ctx, logger, workload, []pendingPodWrite{write})
+}
+
+// nodeIsPowerManaged checks the Node's labels against the ManagedNodeSelector
+func (r *PowerPodReconciler) nodeIsPowerManaged(ctx context.Context, nodeName string) (bool, error) {
+	if r.ManagedNodeSelector == "" {
+		return true, nil
+	}
+
+	selector, err := labels.Parse(r.ManagedNodeSelector)
+	if err != nil {
+		return false, err
+	}
+
+	node := &corev1.Node{}
+	err = r.Get(ctx, client.ObjectKey{Name: nodeName}, node)
+	if err != nil {
+		return false, err
+	}
+
+	return selector.Matches(labels.Set(node.GetLabels())), nil
 }
 
-func (r *PowerPodReconciler) getPowerProfileRequestsFromContainers(containers []corev1.Container, profileCRs []powerv1alpha1.PowerProfile, pod *corev1.Pod) (map[string][]int, []powerv1alpha1.Container, error) {
+func (r *PowerPodReconciler) getPowerProfileRequestsFromContainers(ctx context.Context, containers []corev1.Container, profileCRs []powerv1alpha1.PowerProfile, pod *corev1.Pod) (map[string][]int, []powerv1alpha1.Container, error) {
 	// Check for the following errors that can occur from a Pod requesting Power Profiles:
 	//	1. A Container requesting multiple Power Profiles
-	//	2. A Pod requesting multiple Power Profiles (WIP: allow for a Pod that has multiple containers to have a different Power Profile for each)
-	//	3. The requested Power Profile exists in the AppQoS instance on the node
-
-	_ = context.Background()
+	//	2. The requested Power Profile exists as a CR and in the AppQoS instance on the node
+	// Each Container may request a different Power Profile, and the Pod's cores are returned grouped by Power Profile
 
 	profiles := make(map[string][]int)
 	powerContainers := make([]powerv1alpha1.Container, 0)
 
+	allowedProfiles, restricted, err := r.allowedNamespaceProfiles(ctx, pod.GetNamespace())
+	if err != nil {
+		return map[string][]int{}, []powerv1alpha1.Container{}, err
+	}
+
 	for _, container := range containers {
-		profile, err := getContainerProfileFromRequests(container)
+		profile, err := r.resolveProfile(pod, container)
 		if err != nil {
 			return map[string][]int{}, []powerv1alpha1.Container{}, err
 		}
 
 		// If there was no Profile requested in this container we can move onto the next one
 		if profile == "" {
+			if r.ReportUnprofiledContainers {
+				cpuQuantity := container.Resources.Requests[corev1.ResourceCPU]
+				r.Log.WithValues("powerpod", client.ObjectKey{Namespace: pod.GetNamespace(), Name: pod.GetName()}).Info("Container given exclusive CPUs without a PowerProfile", "container", container.Name, "cpus", cpuQuantity.String())
+				r.Recorder.Eventf(pod, corev1.EventTypeWarning, "ExclusiveCPUsUnprofiled", "Container '%s' was given %s exclusive CPUs but requests no PowerProfile, so its cores are not power-managed", container.Name, cpuQuantity.String())
+			}
 			continue
 		}
 
-		if !profileExists(profile, profileCRs) {
-			powerProfileNotFoundError := errors.NewServiceUnavailable(fmt.Sprintf("Power Profile '%s' not found", profile))
-			return map[string][]int{}, []powerv1alpha1.Container{}, powerProfileNotFoundError
+		if restricted && !allowedProfiles[profile] {
+			r.Recorder.Eventf(pod, corev1.EventTypeWarning, "PowerProfileNotAllowed", "PowerProfile '%s' is not allowed in namespace '%s'", profile, pod.GetNamespace())
+			return map[string][]int{}, []powerv1alpha1.Container{}, errors.NewServiceUnavailable(fmt.Sprintf("Power Profile '%s' not allowed in namespace '%s'", profile, pod.GetNamespace()))
+		}
+
+		err = r.validatePowerProfile(ctx, pod, profile, profileCRs)
+		if err != nil {
+			return map[string][]int{}, []powerv1alpha1.Container{}, err
 		}
 
-		containerID := getContainerID(pod, container.Name)
-		coreIDs, err := r.PodResourcesClient.GetContainerCPUs(pod.GetName(), container.Name)
+		containerID := getContainerID(pod, container.Name, podContainerPhase(pod))
+		coreIDs, err := r.getStableContainerCPUs(ctx, pod, container.Name)
+		if err != nil {
+			return map[string][]int{}, []powerv1alpha1.Container{}, err
+		}
+		if r.VerifyCgroupCPUSet {
+			r.verifyCgroupCPUSet(pod, container.Name, containerID, getCleanCoreList(coreIDs))
+		}
+		cleanCoreList, err := r.excludeOfflineCores(pod, container.Name, getCleanCoreList(coreIDs))
 		if err != nil {
 			return map[string][]int{}, []powerv1alpha1.Container{}, err
 		}
-		cleanCoreList := getCleanCoreList(coreIDs)
+		r.checkTopologyHints(ctx, pod, container.Name, cleanCoreList)
+		r.checkMemoryNUMAAlignment(pod, container.Name, cleanCoreList)
+		r.checkProfileAffinity(pod, container.Name, profile, profileCRs, cleanCoreList)
 
 		powerContainer := &powerv1alpha1.Container{}
 		powerContainer.Name = container.Name
-		powerContainer.Id = strings.TrimPrefix(containerID, "docker://")
+		powerContainer.Runtime, powerContainer.Id = splitContainerID(containerID)
 		powerContainer.ExclusiveCPUs = cleanCoreList
 		powerContainer.PowerProfile = profile
 		powerContainers = append(powerContainers, *powerContainer)
@@ -332,63 +794,154 @@ func (r *PowerPodReconciler) getPowerProfileRequestsFromContainers(containers []
 		}
 	}
 
-	if len(reflect.ValueOf(profiles).MapKeys()) > 1 {
-		// For now we can only have one Power Profile per Pod
+	r.clearMultiProfileAnnotation(ctx, pod)
+
+	return profiles, powerContainers, nil
+}
+
+// changedProfileContainers compares the Pod's Containers against those recorded in the State and returns
+// the recorded Containers whose PowerProfile has since changed
+func (r *PowerPodReconciler) changedProfileContainers(pod *corev1.Pod, powerContainers []powerv1alpha1.Container) []powerv1alpha1.Container {
+	previousState := r.State.GetPodFromState(pod.GetName())
 
-		moreThanOneProfileError := errors.NewServiceUnavailable("Cannot have more than one Power Profile per Pod")
-		return map[string][]int{}, []powerv1alpha1.Container{}, moreThanOneProfileError
+	changed := make([]powerv1alpha1.Container, 0)
+	for _, previous := range previousState.Containers {
+		for _, current := range powerContainers {
+			if current.Name == previous.Name && current.PowerProfile != previous.PowerProfile {
+				changed = append(changed, previous)
+			}
+		}
 	}
 
-	return profiles, powerContainers, nil
+	return changed
 }
 
-func profileExists(profile string, powerProfiles []powerv1alpha1.PowerProfile) bool {
-	for _, powerProfile := range powerProfiles {
-		if powerProfile.Name == profile {
-			return true
+// releaseChangedProfiles releases the cores of the previously recorded Containers whose PowerProfile has
+// changed from the PowerWorkload of their previous PowerProfile
+func (r *PowerPodReconciler) releaseChangedProfiles(ctx context.Context, logger logr.Logger, namespace string, pod *corev1.Pod, changedContainers []powerv1alpha1.Container) error {
+	previousWorkloads := make(map[string][]powerv1alpha1.Container)
+	for _, previous := range changedContainers {
+		workloadName := containerWorkloadName(previous, profileNameForNode(previous.PowerProfile, pod.Spec.NodeName))
+		previousWorkloads[workloadName] = append(previousWorkloads[workloadName], previous)
+	}
+
+	for workloadName, containers := range previousWorkloads {
+		logger.Info("PowerProfile changed, moving cores out of previous PowerWorkload", "workload", workloadName)
+
+		err := r.releaseContainerCores(ctx, logger, namespace, pod, containers)
+		if err != nil {
+			return err
 		}
 	}
 
-	return false
+	return nil
 }
 
-func getNewWorkloadCPUList(cpuList []int, nodeCpuIds []int) []int {
-	updatedWorkloadCPUList := make([]int, 0)
+// releaseContainerCores releases the cores of the Pod's previously recorded Containers from the PowerWorkloads they were added to
+func (r *PowerPodReconciler) releaseContainerCores(ctx context.Context, logger logr.Logger, namespace string, pod *corev1.Pod, previousContainers []powerv1alpha1.Container) error {
+	previousWorkloads := make(map[string][]powerv1alpha1.Container)
+	for _, previous := range previousContainers {
+		workloadName := containerWorkloadName(previous, profileNameForNode(previous.PowerProfile, pod.Spec.NodeName))
+		previousWorkloads[workloadName] = append(previousWorkloads[workloadName], previous)
+	}
 
-	for _, cpu := range nodeCpuIds {
-		if !util.CPUInCPUList(cpu, cpuList) {
-			updatedWorkloadCPUList = append(updatedWorkloadCPUList, cpu)
+	for workloadName, containers := range previousWorkloads {
+		release := podRelease{
+			Node:       pod.Spec.NodeName,
+			Pod:        pod.GetName(),
+			UID:        string(pod.GetUID()),
+			CPUs:       make([]int, 0),
+			Containers: containers,
+		}
+		for _, container := range containers {
+			release.CPUs = append(release.CPUs, container.ExclusiveCPUs...)
+		}
+
+		err := r.releaseWorkloadCPUs(ctx, logger, client.ObjectKey{
+			Namespace: namespace,
+			Name:      workloadName,
+		}, []podRelease{release})
+		if err != nil {
+			return err
 		}
 	}
 
-	return updatedWorkloadCPUList
+	return nil
 }
 
-func appendIfUnique(cpuList []int, cpus []int) []int {
-	for _, cpu := range cpus {
-		if !util.CPUInCPUList(cpu, cpuList) {
-			cpuList = append(cpuList, cpu)
+// logRestartedContainers logs each Container whose RestartCount has increased since the Pod was last reconciled and
+// whose cores have changed. Containers whose PowerProfile also changed are left to releaseChangedProfiles
+func (r *PowerPodReconciler) logRestartedContainers(logger logr.Logger, pod *corev1.Pod, powerContainers []powerv1alpha1.Container) {
+	previousState := r.State.GetPodFromState(pod.GetName())
+	restartCounts := getRestartCounts(pod)
+
+	for _, previous := range previousState.Containers {
+		previousCount, observed := r.State.GetRestartCount(pod.GetName(), previous.Name)
+		if !observed || restartCounts[previous.Name] <= previousCount {
+			continue
+		}
+
+		for _, current := range powerContainers {
+			if current.Name != previous.Name || current.PowerProfile != previous.PowerProfile {
+				continue
+			}
+
+			if reflect.DeepEqual(current.ExclusiveCPUs, previous.ExclusiveCPUs) {
+				continue
+			}
+
+			logger.Info("Container restarted with different cores, resyncing PowerWorkload", "container", current.Name, "previousCPUs", previous.ExclusiveCPUs, "cpus", current.ExclusiveCPUs)
 		}
 	}
+}
 
-	return cpuList
+// profileNameForNode returns the name of the PowerProfile on the node, which for base profiles is suffixed with the node name
+func profileNameForNode(profile string, nodeName string) string {
+	if _, exists := extendedResourcePercentage[profile]; exists {
+		return fmt.Sprintf("%s-%s", profile, nodeName)
+	}
+
+	return profile
 }
 
-func getNewWorkloadContainerList(nodeContainers []powerv1alpha1.Container, podStateContainers []powerv1alpha1.Container) []powerv1alpha1.Container {
-	newNodeContainers := make([]powerv1alpha1.Container, 0)
+// validatePowerProfile checks the requested PowerProfile exists in the node's AppQoS instance, and as a CR unless
+// AllowAppQoSOnlyProfiles is set, emitting an Event on the Pod naming whichever one is missing
+func (r *PowerPodReconciler) validatePowerProfile(ctx context.Context, pod *corev1.Pod, profile string, profileCRs []powerv1alpha1.PowerProfile) error {
+	if (!r.AllowAppQoSOnlyProfiles || r.AppQoSClient == nil) && !profileExists(profile, profileCRs) {
+		r.Recorder.Eventf(pod, corev1.EventTypeWarning, "PowerProfileCRMissing", "PowerProfile CR '%s' not found", profile)
+		return errors.NewServiceUnavailable(fmt.Sprintf("Power Profile '%s' not found", profile))
+	}
+
+	if r.AppQoSClient == nil {
+		return nil
+	}
+
+	profileFromAppQoS, err := r.AppQoSClient.GetProfileByNameWithContext(ctx, profile, AppQoSClientAddress)
+	if err != nil {
+		return err
+	}
 
-	for _, container := range nodeContainers {
-		if !isContainerInList(container.Name, podStateContainers) {
-			newNodeContainers = append(newNodeContainers, container)
+	if reflect.DeepEqual(profileFromAppQoS, &appqos.PowerProfile{}) {
+		// An AppQoS instance with no Power Profiles at all has not been provisioned, which fails every Pod on
+		// the Node rather than just those requesting this PowerProfile
+		appQoSProfiles, err := r.AppQoSClient.GetPowerProfilesWithContext(ctx, AppQoSClientAddress)
+		if err == nil && len(appQoSProfiles) == 0 {
+			r.Recorder.Eventf(pod, corev1.EventTypeWarning, "AppQoSProfilesNotProvisioned", "AppQoS on Node '%s' has no Power Profiles provisioned, so no PowerProfile can be applied on the Node until they are created", pod.Spec.NodeName)
+			return errors.NewServiceUnavailable(fmt.Sprintf("AppQoS has no Power Profiles provisioned, Power Profile '%s' cannot be applied", profile))
 		}
+
+		r.Recorder.Eventf(pod, corev1.EventTypeWarning, "AppQoSProfileMissing", "Power Profile '%s' not found in AppQoS on Node '%s'", profile, pod.Spec.NodeName)
+		return errors.NewServiceUnavailable(fmt.Sprintf("Power Profile '%s' not found in AppQoS", profile))
 	}
 
-	return newNodeContainers
+	return nil
 }
 
-func isContainerInList(name string, containers []powerv1alpha1.Container) bool {
+// requestsPowerProfile reports whether any of the containers requests a PowerProfile
+func (r *PowerPodReconciler) requestsPowerProfile(pod *corev1.Pod, containers []corev1.Container) bool {
 	for _, container := range containers {
-		if container.Name == name {
+		profile, err := r.profileResolver().Resolve(pod, container)
+		if err == nil && profile != "" {
 			return true
 		}
 	}
@@ -396,40 +949,40 @@ func isContainerInList(name string, containers []powerv1alpha1.Container) bool {
 	return false
 }
 
-func getContainerProfileFromRequests(container corev1.Container) (string, error) {
-	profileName := ""
-	moreThanOneProfileError := errors.NewServiceUnavailable("Cannot have more than one Power Profile per Container")
-	resourceRequestsMismatchError := errors.NewServiceUnavailable("Mismatch between CPU requests and PowerProfile Requests")
-
-	for resource := range container.Resources.Requests {
-		if strings.HasPrefix(string(resource), ResourcePrefix) {
-			if profileName == "" {
-				profileName = string(resource[len(ResourcePrefix):])
-			} else {
-				// Cannot have more than one profile for a singular container
-				return "", moreThanOneProfileError
-			}
+func profileExists(profile string, powerProfiles []powerv1alpha1.PowerProfile) bool {
+	for _, powerProfile := range powerProfiles {
+		if powerProfile.Name == profile {
+			return true
+		}
+	}
+
+	return false
+}
+
+func appendIfUnique(cpuList []int, cpus []int) []int {
+	for _, cpu := range cpus {
+		if !util.CPUInCPUList(cpu, cpuList) {
+			cpuList = append(cpuList, cpu)
 		}
 	}
 
-	if profileName != "" {
-		// Check if there is a mismatch in CPU requests and PowerProfile requests
-		powerProfileResourceName := corev1.ResourceName(fmt.Sprintf("%s%s", ResourcePrefix, profileName))
-		numRequestsPowerProfile := container.Resources.Requests[powerProfileResourceName]
-		numLimitsPowerProfile := container.Resources.Limits[powerProfileResourceName]
-		numRequestsCPU := container.Resources.Requests[CPUResource]
-		numLimistCPU := container.Resources.Limits[CPUResource]
-		if numRequestsCPU != numRequestsPowerProfile || numLimistCPU != numLimitsPowerProfile {
-			return "", resourceRequestsMismatchError
+	return cpuList
+}
+
+func isContainerInList(name string, containers []powerv1alpha1.Container) bool {
+	for _, container := range containers {
+		if container.Name == name {
+			return true
 		}
 	}
 
-	return profileName, nil
+	return false
 }
 
+// getContainersRequestingExclusiveCPUs returns the Containers of the Pod's current phase that are given exclusive CPUs
 func getContainersRequestingExclusiveCPUs(pod *corev1.Pod) []corev1.Container {
 	containersRequestingExclusiveCPUs := make([]corev1.Container, 0)
-	for _, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+	for _, container := range phaseContainers(pod) {
 		if exclusiveCPUs(pod, &container) {
 			containersRequestingExclusiveCPUs = append(containersRequestingExclusiveCPUs, container)
 		}
@@ -447,8 +1000,16 @@ func exclusiveCPUs(pod *corev1.Pod, container *corev1.Container) bool {
 	return cpuQuantity.Value()*1000 == cpuQuantity.MilliValue()
 }
 
-func getContainerID(pod *corev1.Pod, containerName string) string {
-	for _, containerStatus := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+// getContainerID returns the ID of the named Container in the given phase. The statuses of that phase are searched
+// first, so a name shared by an init container and an app container resolves to the Container of the phase asked
+// for, before falling back to the statuses of the Pod's other Containers
+func getContainerID(pod *corev1.Pod, containerName string, phase containerPhase) string {
+	phaseStatuses := pod.Status.ContainerStatuses
+	if phase == initContainerPhase {
+		phaseStatuses = pod.Status.InitContainerStatuses
+	}
+
+	for _, containerStatus := range append(append([]corev1.ContainerStatus{}, phaseStatuses...), podContainerStatuses(pod)...) {
 		if containerStatus.Name == containerName {
 			return containerStatus.ContainerID
 		}
@@ -457,6 +1018,35 @@ func getContainerID(pod *corev1.Pod, containerName string) string {
 	return ""
 }
 
+// splitContainerID splits a Container ID of the form '<runtime>://<id>' into the runtime, such as 'docker',
+// 'containerd' or 'cri-o', and the ID itself. An ID without a runtime is returned as is
+func splitContainerID(containerID string) (string, string) {
+	index := strings.Index(containerID, "://")
+	if index < 0 {
+		return "", containerID
+	}
+
+	return containerID[:index], containerID[index+3:]
+}
+
+// getRestartCounts returns the RestartCount of each of the Pod's Containers
+func getRestartCounts(pod *corev1.Pod) map[string]int32 {
+	restartCounts := make(map[string]int32)
+	for _, containerStatus := range podContainerStatuses(pod) {
+		restartCounts[containerStatus.Name] = containerStatus.RestartCount
+	}
+
+	return restartCounts
+}
+
+// podContainerStatuses returns the statuses of all of the Pod's Containers, whether init, app or ephemeral
+func podContainerStatuses(pod *corev1.Pod) []corev1.ContainerStatus {
+	containerStatuses := make([]corev1.ContainerStatus, 0)
+	containerStatuses = append(containerStatuses, pod.Status.InitContainerStatuses...)
+	containerStatuses = append(containerStatuses, pod.Status.ContainerStatuses...)
+	return append(containerStatuses, pod.Status.EphemeralContainerStatuses...)
+}
+
 func getCleanCoreList(coreIDs string) []int {
 	cleanCores := make([]int, 0)
 	commaSeparated := strings.Split(coreIDs, ",")
@@ -478,7 +1068,19 @@ func getCleanCoreList(coreIDs string) []int {
 }
 
 func (r *PowerPodReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&corev1.Pod{}).
-		Complete(r)
+	// Pod requests go through the priority buffer, which also reports them as backpressure, so the
+	// builder's For() cannot be used
+	c, err := controller.New("pod", mgr, controller.Options{Reconciler: r, MaxConcurrentReconciles: r.MaxConcurrentReconciles})
+	if err != nil {
+		return err
+	}
+
+	r.priority.tracker = &r.queue
+	r.priority.workers = r.MaxConcurrentReconciles
+	err = c.Watch(&source.Kind{Type: &corev1.Pod{}}, &podPriorityHandler{buffer: &r.priority})
+	if err != nil {
+		return err
+	}
+
+	return metrics.Registry.Register(newQueueBackpressureCollector("pod", &r.queue))
 }
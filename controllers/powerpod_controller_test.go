@@ -2,20 +2,46 @@ package controllers
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/allocationapi"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/appqos"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/cgroup"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/cpuhotplug"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/cpumanager"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/memorymanager"
 	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/podresourcesclient"
 	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/podstate"
 	grpc "google.golang.org/grpc"
@@ -37,6 +63,181 @@ func (f *fakePodResourcesClient) List(ctx context.Context, in *podresourcesapi.L
 	return f.listResponse, nil
 }
 
+// sequencedPodResourcesClient simulates a CPU Manager still settling on a cpuset, answering each List with the
+// next response until the last, which it then keeps returning
+type sequencedPodResourcesClient struct {
+	listResponses []*podresourcesapi.ListPodResourcesResponse
+	calls         int
+}
+
+func (f *sequencedPodResourcesClient) List(ctx context.Context, in *podresourcesapi.ListPodResourcesRequest, opts ...grpc.CallOption) (*podresourcesapi.ListPodResourcesResponse, error) {
+	response := f.listResponses[len(f.listResponses)-1]
+	if f.calls < len(f.listResponses) {
+		response = f.listResponses[f.calls]
+	}
+	f.calls++
+
+	return response, nil
+}
+
+// blockingPodResourcesClient simulates a kubelet that never answers, returning only once the caller gives up
+type blockingPodResourcesClient struct{}
+
+func (b *blockingPodResourcesClient) List(ctx context.Context, in *podresourcesapi.ListPodResourcesRequest, opts ...grpc.CallOption) (*podresourcesapi.ListPodResourcesResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// forbiddenWorkloadClient simulates a ServiceAccount that is not permitted to write PowerWorkloads
+type forbiddenWorkloadClient struct {
+	client.Client
+	writeAttempts int
+}
+
+func (f *forbiddenWorkloadClient) forbidden(obj runtime.Object) error {
+	if workload, ok := obj.(*powerv1alpha1.PowerWorkload); ok {
+		f.writeAttempts++
+		return errors.NewForbidden(powerv1alpha1.GroupVersion.WithResource("powerworkloads").GroupResource(), workload.Name, fmt.Errorf("RBAC denied"))
+	}
+
+	return nil
+}
+
+func (f *forbiddenWorkloadClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	if err := f.forbidden(obj); err != nil {
+		return err
+	}
+
+	return f.Client.Create(ctx, obj, opts...)
+}
+
+func (f *forbiddenWorkloadClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if err := f.forbidden(obj); err != nil {
+		return err
+	}
+
+	return f.Client.Update(ctx, obj, opts...)
+}
+
+func (f *forbiddenWorkloadClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	if err := f.forbidden(obj); err != nil {
+		return err
+	}
+
+	return f.Client.Delete(ctx, obj, opts...)
+}
+
+// blockingWorkloadClient simulates an API server that never answers PowerWorkload writes, returning only once
+// the caller gives up
+type blockingWorkloadClient struct {
+	client.Client
+}
+
+func (b *blockingWorkloadClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if _, ok := obj.(*powerv1alpha1.PowerWorkload); ok {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	return b.Client.Update(ctx, obj, opts...)
+}
+
+func (b *blockingWorkloadClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	if _, ok := obj.(*powerv1alpha1.PowerWorkload); ok {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	return b.Client.Delete(ctx, obj, opts...)
+}
+
+// countingWorkloadClient counts the writes made to PowerWorkloads
+// slowReadWorkloadClient widens the window between reading and writing a PowerWorkload so unserialized
+// read-modify-writes would overlap
+type slowReadWorkloadClient struct {
+	client.Client
+}
+
+func (c *slowReadWorkloadClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	err := c.Client.Get(ctx, key, obj)
+	if _, ok := obj.(*powerv1alpha1.PowerWorkload); ok {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return err
+}
+
+// staleListWorkloadClient lists no PowerWorkloads, like a cache that has yet to see those other Nodes' agents
+// created
+type staleListWorkloadClient struct {
+	client.Client
+}
+
+func (c *staleListWorkloadClient) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	if _, ok := list.(*powerv1alpha1.PowerWorkloadList); ok {
+		return nil
+	}
+
+	return c.Client.List(ctx, list, opts...)
+}
+
+type countingWorkloadClient struct {
+	client.Client
+	mutex  sync.Mutex
+	writes int
+	// failWrites is how many of the first PowerWorkload writes fail
+	failWrites int
+}
+
+func (c *countingWorkloadClient) count(obj runtime.Object) error {
+	if _, ok := obj.(*powerv1alpha1.PowerWorkload); ok {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		c.writes++
+		if c.writes <= c.failWrites {
+			return fmt.Errorf("PowerWorkload write %d failed", c.writes)
+		}
+	}
+
+	return nil
+}
+
+func (c *countingWorkloadClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if err := c.count(obj); err != nil {
+		return err
+	}
+
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *countingWorkloadClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	if err := c.count(obj); err != nil {
+		return err
+	}
+
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+// racingCreateClient simulates a Pod on another Node creating the same PowerWorkload just before this one
+type racingCreateClient struct {
+	client.Client
+	competitor *powerv1alpha1.PowerWorkload
+}
+
+func (c *racingCreateClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	if c.competitor != nil {
+		if _, ok := obj.(*powerv1alpha1.PowerWorkload); ok {
+			err := c.Client.Create(ctx, c.competitor)
+			if err != nil {
+				return err
+			}
+			c.competitor = nil
+		}
+	}
+
+	return c.Client.Create(ctx, obj, opts...)
+}
+
 func createPowerPodReconcilerObject(objs []runtime.Object) (*PowerPodReconciler, error) {
 	s := scheme.Scheme
 
@@ -53,7 +254,7 @@ func createPowerPodReconcilerObject(objs []runtime.Object) (*PowerPodReconciler,
 		return nil, err
 	}
 
-	r := &PowerPodReconciler{Client: cl, Log: ctrl.Log.WithName("controllers").WithName("PowerWorkload"), Scheme: s, State: *state}
+	r := &PowerPodReconciler{Client: cl, Log: ctrl.Log.WithName("controllers").WithName("PowerWorkload"), Scheme: s, State: state, Recorder: record.NewFakeRecorder(100)}
 	return r, nil
 }
 
@@ -63,6 +264,48 @@ func createFakePodResourcesListerClient(listResponse *podresourcesapi.ListPodRes
 	return &podresourcesclient.PodResourcesClient{Client: podResourcesListerClient}
 }
 
+// createGuaranteedPod returns a Running Guaranteed Pod in PowerPodNamespace whose single Container requests the given
+// number of exclusive CPUs with the PowerProfile, named by the profile's resource name
+func createGuaranteedPod(name string, uid string, nodeName string, containerName string, profile string, cpus int64) *corev1.Pod {
+	resources := func() map[corev1.ResourceName]resource.Quantity {
+		return map[corev1.ResourceName]resource.Quantity{
+			corev1.ResourceName("cpu"):                    *resource.NewQuantity(cpus, resource.DecimalSI),
+			corev1.ResourceName(ResourcePrefix + profile): *resource.NewQuantity(cpus, resource.DecimalSI),
+		}
+	}
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   PowerPodNamespace,
+			UID:         types.UID(uid),
+			Annotations: map[string]string{},
+		},
+		Spec: corev1.PodSpec{
+			NodeName: nodeName,
+			Containers: []corev1.Container{
+				{
+					Name: containerName,
+					Resources: corev1.ResourceRequirements{
+						Limits:   resources(),
+						Requests: resources(),
+					},
+				},
+			},
+		},
+		Status: corev1.PodStatus{
+			Phase:    corev1.PodRunning,
+			QOSClass: corev1.PodQOSGuaranteed,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name:        containerName,
+					ContainerID: "docker://" + uid,
+				},
+			},
+		},
+	}
+}
+
 func TestPodReconcileNewWorkloadCreated(t *testing.T) {
 	tcases := []struct {
 		testCase                                string
@@ -1568,6 +1811,7 @@ func TestMultiplePowerProfilesRequested(t *testing.T) {
 		podResources                   []podresourcesapi.PodResources
 		containerResources             map[string][]podresourcesapi.ContainerResources
 		expectedNumberOfPowerWorkloads int
+		expectedWorkloadCPUs           map[string][]int
 	}{
 		{
 			testCase: "Test Case 1",
@@ -1678,7 +1922,11 @@ func TestMultiplePowerProfilesRequested(t *testing.T) {
 					},
 				},
 			},
-			expectedNumberOfPowerWorkloads: 0,
+			expectedNumberOfPowerWorkloads: 2,
+			expectedWorkloadCPUs: map[string][]int{
+				"performance-example-node1-workload":         {1, 2},
+				"balance-performance-example-node1-workload": {3, 4},
+			},
 		},
 		{
 			testCase: "Test Case 2",
@@ -1835,15 +2083,12 @@ func TestMultiplePowerProfilesRequested(t *testing.T) {
 		}
 
 		_, err = r.Reconcile(req)
-		if err != nil {
-			if !errors.IsServiceUnavailable(err) {
-				if err == nil {
-					t.Errorf("%s - Failed: Expected moreThanOneProfileError to have occured", tc.testCase)
-				} else {
-					t.Error(err)
-					t.Fatal(fmt.Sprintf("%s - error reconciling PowerWorkload object", tc.testCase))
-				}
-			}
+		if err != nil && !errors.IsServiceUnavailable(err) {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling PowerWorkload object", tc.testCase))
+		}
+		if (err != nil) != (tc.expectedNumberOfPowerWorkloads == 0) {
+			t.Errorf("%s - Failed: Expected moreThanOneProfileError to have occurred to be %v, got %v", tc.testCase, tc.expectedNumberOfPowerWorkloads == 0, err)
 		}
 
 		powerWorkloads := &powerv1alpha1.PowerWorkloadList{}
@@ -1856,6 +2101,51 @@ func TestMultiplePowerProfilesRequested(t *testing.T) {
 		if len(powerWorkloads.Items) != tc.expectedNumberOfPowerWorkloads {
 			t.Errorf("%s - Failed: Expected number of PowerWorkloads to be %v, got %v", tc.testCase, tc.expectedNumberOfPowerWorkloads, len(powerWorkloads.Items))
 		}
+
+		for workloadName, expectedCPUs := range tc.expectedWorkloadCPUs {
+			workload := &powerv1alpha1.PowerWorkload{}
+			err = r.Client.Get(context.TODO(), client.ObjectKey{Name: workloadName, Namespace: PowerPodNamespace}, workload)
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload '%s'", tc.testCase, workloadName))
+			}
+
+			cpus := append([]int{}, workload.Spec.Node.CpuIds...)
+			sort.Ints(cpus)
+			if !reflect.DeepEqual(cpus, expectedCPUs) {
+				t.Errorf("%s - Failed: Expected PowerWorkload '%s' to have CPUs %v, got %v", tc.testCase, workloadName, expectedCPUs, cpus)
+			}
+		}
+
+		if len(tc.expectedWorkloadCPUs) == 0 {
+			continue
+		}
+
+		now := metav1.Now()
+		tc.pod.DeletionTimestamp = &now
+		err = r.Client.Update(context.TODO(), tc.pod)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error updating Pod DeletionTimestamp", tc.testCase))
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod deletion", tc.testCase))
+		}
+
+		for workloadName := range tc.expectedWorkloadCPUs {
+			workload := &powerv1alpha1.PowerWorkload{}
+			err = r.Client.Get(context.TODO(), client.ObjectKey{Name: workloadName, Namespace: PowerPodNamespace}, workload)
+			if err != nil && !errors.IsNotFound(err) {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload '%s'", tc.testCase, workloadName))
+			}
+			if err == nil && len(workload.Spec.Node.CpuIds) != 0 {
+				t.Errorf("%s - Failed: Expected PowerWorkload '%s' to have no CPUs after Pod deletion, got %v", tc.testCase, workloadName, workload.Spec.Node.CpuIds)
+			}
+		}
 	}
 }
 
@@ -2684,3 +2974,8407 @@ func TestNoContainersRequestingExclusiveCPUs(t *testing.T) {
 		}
 	}
 }
+
+func createFakeCPUDevices(t *testing.T, threadSiblings map[int]string) string {
+	cpuDevicesPath := t.TempDir()
+	for cpu, siblings := range threadSiblings {
+		topologyPath := filepath.Join(cpuDevicesPath, fmt.Sprintf("cpu%d", cpu), "topology")
+		err := os.MkdirAll(topologyPath, 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = ioutil.WriteFile(filepath.Join(topologyPath, "thread_siblings_list"), []byte(siblings), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = ioutil.WriteFile(filepath.Join(cpuDevicesPath, fmt.Sprintf("cpu%d", cpu), "online"), []byte("1"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return cpuDevicesPath
+}
+
+func TestParkSiblingsRestoredOnPodDeletion(t *testing.T) {
+	tcases := []struct {
+		testCase                        string
+		pod                             *corev1.Pod
+		node                            *corev1.Node
+		powerProfile                    *powerv1alpha1.PowerProfile
+		containerResources              []podresourcesapi.ContainerResources
+		threadSiblings                  map[int]string
+		expectedParkedSiblings          []int
+		expectedOfflineCPUsBeforeDelete map[int]bool
+	}{
+		{
+			testCase: "Test Case 1",
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "example-pod",
+					Namespace: PowerPodNamespace,
+					UID:       "abcdefg",
+					Annotations: map[string]string{
+						ParkSiblingsAnnotation: "true",
+					},
+				},
+				Spec: corev1.PodSpec{
+					NodeName: "example-node1",
+					Containers: []corev1.Container{
+						{
+							Name: "example-container-1",
+							Resources: corev1.ResourceRequirements{
+								Limits: map[corev1.ResourceName]resource.Quantity{
+									corev1.ResourceName("cpu"):                                       *resource.NewQuantity(2, resource.DecimalSI),
+									corev1.ResourceName("power.intel.com/performance-example-node1"): *resource.NewQuantity(2, resource.DecimalSI),
+								},
+								Requests: map[corev1.ResourceName]resource.Quantity{
+									corev1.ResourceName("cpu"):                                       *resource.NewQuantity(2, resource.DecimalSI),
+									corev1.ResourceName("power.intel.com/performance-example-node1"): *resource.NewQuantity(2, resource.DecimalSI),
+								},
+							},
+						},
+					},
+				},
+				Status: corev1.PodStatus{
+					Phase:    corev1.PodRunning,
+					QOSClass: corev1.PodQOSGuaranteed,
+					ContainerStatuses: []corev1.ContainerStatus{
+						{
+							Name:        "example-container-1",
+							ContainerID: "docker://abcdefg",
+						},
+					},
+				},
+			},
+			node: &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "example-node1",
+				},
+			},
+			powerProfile: &powerv1alpha1.PowerProfile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "performance-example-node1",
+					Namespace: PowerPodNamespace,
+				},
+				Spec: powerv1alpha1.PowerProfileSpec{
+					Name: "performance-example-node1",
+					Epp:  "performance",
+				},
+			},
+			containerResources: []podresourcesapi.ContainerResources{
+				{
+					Name:   "example-container-1",
+					CpuIds: []int64{1, 2},
+				},
+			},
+			threadSiblings: map[int]string{
+				0: "0,4",
+				1: "1,5",
+				2: "2,6",
+				3: "3,7",
+				4: "0,4",
+				5: "1,5",
+				6: "2,6",
+				7: "3,7",
+			},
+			expectedParkedSiblings: []int{5, 6},
+			expectedOfflineCPUsBeforeDelete: map[int]bool{
+				4: false,
+				5: true,
+				6: true,
+				7: false,
+			},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", tc.node.Name)
+		cpuhotplug.CPUDevicesPath = createFakeCPUDevices(t, tc.threadSiblings)
+
+		objs := []runtime.Object{tc.pod, tc.node, tc.powerProfile}
+		r, err := createPowerPodReconcilerObject(objs)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		fakeContainers := []*podresourcesapi.ContainerResources{}
+		for i := range tc.containerResources {
+			fakeContainers = append(fakeContainers, &tc.containerResources[i])
+		}
+		fakeListResponse := &podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name:       tc.pod.Name,
+					Containers: fakeContainers,
+				},
+			},
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(fakeListResponse)
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      tc.pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+
+		parkedSiblings := r.State.GetParkedSiblings(tc.pod.Name)
+		sort.Ints(parkedSiblings)
+		if !reflect.DeepEqual(parkedSiblings, tc.expectedParkedSiblings) {
+			t.Errorf("%s - Failed: Expected parked siblings to be %v, got %v", tc.testCase, tc.expectedParkedSiblings, parkedSiblings)
+		}
+
+		for cpu, offline := range tc.expectedOfflineCPUsBeforeDelete {
+			online, _ := ioutil.ReadFile(filepath.Join(cpuhotplug.CPUDevicesPath, fmt.Sprintf("cpu%d", cpu), "online"))
+			if (string(online) == "0") != offline {
+				t.Errorf("%s - Failed: Expected CPU %d to be offline to be %v, got %v", tc.testCase, cpu, offline, string(online) == "0")
+			}
+		}
+
+		now := metav1.Now()
+		tc.pod.DeletionTimestamp = &now
+		err = r.Client.Update(context.TODO(), tc.pod)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error updating Pod DeletionTimestamp", tc.testCase))
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod deletion", tc.testCase))
+		}
+
+		for cpu := range tc.expectedOfflineCPUsBeforeDelete {
+			online, _ := ioutil.ReadFile(filepath.Join(cpuhotplug.CPUDevicesPath, fmt.Sprintf("cpu%d", cpu), "online"))
+			if string(online) != "1" {
+				t.Errorf("%s - Failed: Expected CPU %d to be restored online, got '%s'", tc.testCase, cpu, string(online))
+			}
+		}
+
+		if len(r.State.GetParkedSiblings(tc.pod.Name)) != 0 {
+			t.Errorf("%s - Failed: Expected parked siblings to be removed from State, got %v", tc.testCase, r.State.GetParkedSiblings(tc.pod.Name))
+		}
+	}
+}
+
+func TestParkSiblingsSkipsAllocatedCPUs(t *testing.T) {
+	tcases := []struct {
+		testCase               string
+		sharedCores            []int
+		expectedParkedSiblings []int
+		expectedOfflineCPUs    map[int]bool
+	}{
+		{
+			testCase:               "Test Case 1 - Sibling is another Pod's exclusive CPU",
+			sharedCores:            []int{},
+			expectedParkedSiblings: []int{6},
+			expectedOfflineCPUs: map[int]bool{
+				5: false,
+				6: true,
+			},
+		},
+		{
+			testCase:               "Test Case 2 - Sibling is in the Shared pool",
+			sharedCores:            []int{0, 3, 4, 6, 7},
+			expectedParkedSiblings: []int{},
+			expectedOfflineCPUs: map[int]bool{
+				5: false,
+				6: false,
+			},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		cpuhotplug.CPUDevicesPath = createFakeCPUDevices(t, map[int]string{
+			0: "0,4",
+			1: "1,5",
+			2: "2,6",
+			3: "3,7",
+			4: "0,4",
+			5: "1,5",
+			6: "2,6",
+			7: "3,7",
+		})
+
+		parkingPod := createGuaranteedPod("example-pod", "abcdefg", "example-node1", "example-container-1", "performance-example-node1", 2)
+		parkingPod.ObjectMeta.Annotations[ParkSiblingsAnnotation] = "true"
+		siblingPod := createGuaranteedPod("example-pod-2", "hijklmn", "example-node1", "example-container-2", "performance-example-node1", 1)
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "example-node1",
+			},
+		}
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance-example-node1",
+				Epp:  "performance",
+			},
+		}
+		sharedWorkload := &powerv1alpha1.PowerWorkload{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "shared-example-node1-workload",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerWorkloadSpec{
+				Name:     "shared-example-node1-workload",
+				AllCores: true,
+			},
+			Status: powerv1alpha1.PowerWorkloadStatus{
+				Node:        "example-node1",
+				SharedCores: tc.sharedCores,
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{parkingPod, siblingPod, node, powerProfile, sharedWorkload})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		fakeListResponse := &podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: parkingPod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{1, 2},
+						},
+					},
+				},
+				{
+					Name: siblingPod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-2",
+							CpuIds: []int64{5},
+						},
+					},
+				},
+			},
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(fakeListResponse)
+
+		for _, pod := range []*corev1.Pod{siblingPod, parkingPod} {
+			req := reconcile.Request{
+				NamespacedName: client.ObjectKey{
+					Name:      pod.Name,
+					Namespace: PowerPodNamespace,
+				},
+			}
+
+			_, err = r.Reconcile(req)
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error reconciling Pod '%s'", tc.testCase, pod.Name))
+			}
+		}
+
+		parkedSiblings := r.State.GetParkedSiblings(parkingPod.Name)
+		sort.Ints(parkedSiblings)
+		if len(parkedSiblings) != len(tc.expectedParkedSiblings) || (len(parkedSiblings) != 0 && !reflect.DeepEqual(parkedSiblings, tc.expectedParkedSiblings)) {
+			t.Errorf("%s - Failed: Expected parked siblings to be %v, got %v", tc.testCase, tc.expectedParkedSiblings, parkedSiblings)
+		}
+
+		for cpu, offline := range tc.expectedOfflineCPUs {
+			online, _ := ioutil.ReadFile(filepath.Join(cpuhotplug.CPUDevicesPath, fmt.Sprintf("cpu%d", cpu), "online"))
+			if (string(online) == "0") != offline {
+				t.Errorf("%s - Failed: Expected CPU %d to be offline to be %v, got %v", tc.testCase, cpu, offline, string(online) == "0")
+			}
+		}
+	}
+}
+
+func TestReplicaDeletionKeepsOtherReplicasContainers(t *testing.T) {
+	tcases := []struct {
+		testCase           string
+		expectedCpuIds     []int
+		expectedContainers []string
+	}{
+		{
+			testCase:           "Test Case 1 - Replicas with the same Container name",
+			expectedCpuIds:     []int{3, 4},
+			expectedContainers: []string{"example-pod-b/example-container-1"},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+
+		deletedReplica := createGuaranteedPod("example-pod-a", "abcdefg", "example-node1", "example-container-1", "performance-example-node1", 2)
+		remainingReplica := createGuaranteedPod("example-pod-b", "hijklmn", "example-node1", "example-container-1", "performance-example-node1", 2)
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "example-node1",
+			},
+		}
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance-example-node1",
+				Epp:  "performance",
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{deletedReplica, remainingReplica, node, powerProfile})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		fakeListResponse := &podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: deletedReplica.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{1, 2},
+						},
+					},
+				},
+				{
+					Name: remainingReplica.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{3, 4},
+						},
+					},
+				},
+			},
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(fakeListResponse)
+
+		for _, pod := range []*corev1.Pod{deletedReplica, remainingReplica} {
+			req := reconcile.Request{
+				NamespacedName: client.ObjectKey{
+					Name:      pod.Name,
+					Namespace: PowerPodNamespace,
+				},
+			}
+
+			_, err = r.Reconcile(req)
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error reconciling Pod '%s'", tc.testCase, pod.Name))
+			}
+		}
+
+		now := metav1.Now()
+		deletedReplica.DeletionTimestamp = &now
+		err = r.Client.Update(context.TODO(), deletedReplica)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error updating Pod DeletionTimestamp", tc.testCase))
+		}
+
+		_, err = r.Reconcile(reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      deletedReplica.Name,
+				Namespace: PowerPodNamespace,
+			},
+		})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod deletion", tc.testCase))
+		}
+
+		workload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{Name: "performance-example-node1-workload", Namespace: PowerPodNamespace}, workload)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload", tc.testCase))
+		}
+
+		cpuIds := append([]int{}, workload.Spec.Node.CpuIds...)
+		sort.Ints(cpuIds)
+		if !reflect.DeepEqual(cpuIds, tc.expectedCpuIds) {
+			t.Errorf("%s - Failed: Expected PowerWorkload CPUs to be %v, got %v", tc.testCase, tc.expectedCpuIds, cpuIds)
+		}
+
+		containers := make([]string, 0)
+		for _, container := range workload.Spec.Node.Containers {
+			containers = append(containers, container.Pod+"/"+container.Name)
+		}
+		if !reflect.DeepEqual(containers, tc.expectedContainers) {
+			t.Errorf("%s - Failed: Expected PowerWorkload Containers to be %v, got %v", tc.testCase, tc.expectedContainers, containers)
+		}
+	}
+}
+
+func TestCPUAllocationExemplarCarriesPodUID(t *testing.T) {
+	tcases := []struct {
+		testCase           string
+		pod                *corev1.Pod
+		node               *corev1.Node
+		powerProfile       *powerv1alpha1.PowerProfile
+		containerResources []podresourcesapi.ContainerResources
+		expectedProfile    string
+		expectedPodUID     string
+	}{
+		{
+			testCase: "Test Case 1",
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "example-pod",
+					Namespace: PowerPodNamespace,
+					UID:       "exemplar-pod-uid",
+				},
+				Spec: corev1.PodSpec{
+					NodeName: "example-node1",
+					Containers: []corev1.Container{
+						{
+							Name: "example-container-1",
+							Resources: corev1.ResourceRequirements{
+								Limits: map[corev1.ResourceName]resource.Quantity{
+									corev1.ResourceName("cpu"):                                       *resource.NewQuantity(2, resource.DecimalSI),
+									corev1.ResourceName("power.intel.com/performance-example-node1"): *resource.NewQuantity(2, resource.DecimalSI),
+								},
+								Requests: map[corev1.ResourceName]resource.Quantity{
+									corev1.ResourceName("cpu"):                                       *resource.NewQuantity(2, resource.DecimalSI),
+									corev1.ResourceName("power.intel.com/performance-example-node1"): *resource.NewQuantity(2, resource.DecimalSI),
+								},
+							},
+						},
+					},
+				},
+				Status: corev1.PodStatus{
+					Phase:    corev1.PodRunning,
+					QOSClass: corev1.PodQOSGuaranteed,
+					ContainerStatuses: []corev1.ContainerStatus{
+						{
+							Name:        "example-container-1",
+							ContainerID: "docker://abcdefg",
+						},
+					},
+				},
+			},
+			node: &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "example-node1",
+				},
+			},
+			powerProfile: &powerv1alpha1.PowerProfile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "performance-example-node1",
+					Namespace: PowerPodNamespace,
+				},
+				Spec: powerv1alpha1.PowerProfileSpec{
+					Name: "performance-example-node1",
+					Epp:  "performance",
+				},
+			},
+			containerResources: []podresourcesapi.ContainerResources{
+				{
+					Name:   "example-container-1",
+					CpuIds: []int64{1, 2},
+				},
+			},
+			expectedProfile: "performance-example-node1",
+			expectedPodUID:  "exemplar-pod-uid",
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", tc.node.Name)
+
+		objs := []runtime.Object{tc.pod, tc.node, tc.powerProfile}
+		r, err := createPowerPodReconcilerObject(objs)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		fakeContainers := []*podresourcesapi.ContainerResources{}
+		for i := range tc.containerResources {
+			fakeContainers = append(fakeContainers, &tc.containerResources[i])
+		}
+		fakeListResponse := &podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name:       tc.pod.Name,
+					Containers: fakeContainers,
+				},
+			},
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(fakeListResponse)
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      tc.pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+
+		metric := &dto.Metric{}
+		err = cpuAllocationsTotal.WithLabelValues(tc.node.Name, tc.expectedProfile).Write(metric)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reading allocation metric", tc.testCase))
+		}
+
+		exemplar := metric.GetCounter().GetExemplar()
+		if exemplar == nil {
+			t.Fatal(fmt.Sprintf("%s - Failed: Expected allocation metric to carry an exemplar", tc.testCase))
+		}
+
+		podUID := ""
+		for _, label := range exemplar.GetLabel() {
+			if label.GetName() == PodUIDExemplarLabel {
+				podUID = label.GetValue()
+			}
+		}
+		if podUID != tc.expectedPodUID {
+			t.Errorf("%s - Failed: Expected exemplar Pod UID to be %v, got %v", tc.testCase, tc.expectedPodUID, podUID)
+		}
+	}
+}
+
+func TestWorkloadWritesForbiddenReadOnlyMode(t *testing.T) {
+	tcases := []struct {
+		testCase                    string
+		pod                         *corev1.Pod
+		node                        *corev1.Node
+		powerProfile                *powerv1alpha1.PowerProfile
+		powerWorkload               *powerv1alpha1.PowerWorkload
+		containerResources          []podresourcesapi.ContainerResources
+		expectedCpuIds              []int
+		expectedCpuIdsAfterReadOnly []int
+	}{
+		{
+			testCase: "Test Case 1",
+			pod:      createGuaranteedPod("example-pod", "abcdefg", "example-node1", "example-container-1", "performance-example-node1", 2),
+			node: &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "example-node1",
+				},
+			},
+			powerProfile: &powerv1alpha1.PowerProfile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "performance-example-node1",
+					Namespace: PowerPodNamespace,
+				},
+				Spec: powerv1alpha1.PowerProfileSpec{
+					Name: "performance-example-node1",
+					Epp:  "performance",
+				},
+			},
+			powerWorkload: &powerv1alpha1.PowerWorkload{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "performance-example-node1-workload",
+					Namespace: PowerPodNamespace,
+				},
+				Spec: powerv1alpha1.PowerWorkloadSpec{
+					Name: "performance-example-node1-workload",
+					Node: powerv1alpha1.NodeInfo{
+						Name:   "example-node1",
+						CpuIds: []int{5, 6},
+					},
+					PowerProfile: "performance-example-node1",
+				},
+			},
+			containerResources: []podresourcesapi.ContainerResources{
+				{
+					Name:   "example-container-1",
+					CpuIds: []int64{1, 2},
+				},
+			},
+			expectedCpuIds:              []int{5, 6},
+			expectedCpuIdsAfterReadOnly: []int{1, 2, 5, 6},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", tc.node.Name)
+
+		objs := []runtime.Object{tc.pod, tc.node, tc.powerProfile, tc.powerWorkload}
+		r, err := createPowerPodReconcilerObject(objs)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		forbiddenClient := &forbiddenWorkloadClient{Client: r.Client}
+		r.Client = forbiddenClient
+
+		fakeContainers := []*podresourcesapi.ContainerResources{}
+		for i := range tc.containerResources {
+			fakeContainers = append(fakeContainers, &tc.containerResources[i])
+		}
+		fakeListResponse := &podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name:       tc.pod.Name,
+					Containers: fakeContainers,
+				},
+			},
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(fakeListResponse)
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      tc.pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		result, err := r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - Failed: Expected forbidden write not to return an error", tc.testCase))
+		}
+
+		if result.RequeueAfter <= 0 {
+			t.Errorf("%s - Failed: Expected request to be requeued while in read-only mode", tc.testCase)
+		}
+
+		workload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{
+			Name:      tc.powerWorkload.Name,
+			Namespace: PowerPodNamespace,
+		}, workload)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload object", tc.testCase))
+		}
+
+		if !reflect.DeepEqual(workload.Spec.Node.CpuIds, tc.expectedCpuIds) {
+			t.Errorf("%s - Failed: Expected PowerWorkload CPUs to be %v, got %v", tc.testCase, tc.expectedCpuIds, workload.Spec.Node.CpuIds)
+		}
+
+		if !meta.IsStatusConditionTrue(workload.Status.Conditions, WorkloadReadOnlyCondition) {
+			t.Errorf("%s - Failed: Expected PowerWorkload to have %s condition set", tc.testCase, WorkloadReadOnlyCondition)
+		}
+
+		// While in read-only mode no further writes should be attempted
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+
+		if forbiddenClient.writeAttempts != 1 {
+			t.Errorf("%s - Failed: Expected 1 PowerWorkload write attempt, got %v", tc.testCase, forbiddenClient.writeAttempts)
+		}
+
+		// Once writes are permitted again the first successful write clears the condition
+		r.Client = forbiddenClient.Client
+		r.workloadWritesForbiddenUntil = time.Time{}
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object after read-only mode", tc.testCase))
+		}
+
+		workload = &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{
+			Name:      tc.powerWorkload.Name,
+			Namespace: PowerPodNamespace,
+		}, workload)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload object", tc.testCase))
+		}
+
+		if !reflect.DeepEqual(workload.Spec.Node.CpuIds, tc.expectedCpuIdsAfterReadOnly) {
+			t.Errorf("%s - Failed: Expected PowerWorkload CPUs to be %v after read-only mode, got %v", tc.testCase, tc.expectedCpuIdsAfterReadOnly, workload.Spec.Node.CpuIds)
+		}
+
+		if !meta.IsStatusConditionFalse(workload.Status.Conditions, WorkloadReadOnlyCondition) {
+			t.Errorf("%s - Failed: Expected PowerWorkload %s condition to be cleared, got %v", tc.testCase, WorkloadReadOnlyCondition, workload.Status.Conditions)
+		}
+	}
+}
+
+func TestCoalescedPodDeletions(t *testing.T) {
+	tcases := []struct {
+		testCase               string
+		numPods                int
+		extraWorkloadCPUs      []int
+		failedWrites           int
+		expectedWorkloadExists bool
+		expectedCpuIds         []int
+		expectedMaxWrites      int
+	}{
+		{
+			testCase:               "Test Case 1",
+			numPods:                5,
+			extraWorkloadCPUs:      []int{},
+			failedWrites:           0,
+			expectedWorkloadExists: false,
+			expectedMaxWrites:      1,
+		},
+		{
+			testCase:               "Test Case 2",
+			numPods:                5,
+			extraWorkloadCPUs:      []int{20, 21},
+			failedWrites:           0,
+			expectedWorkloadExists: true,
+			expectedCpuIds:         []int{20, 21},
+			expectedMaxWrites:      1,
+		},
+		{
+			testCase:               "Test Case 3 - Failed flushes are retried",
+			numPods:                5,
+			extraWorkloadCPUs:      []int{20, 21},
+			failedWrites:           2,
+			expectedWorkloadExists: true,
+			expectedCpuIds:         []int{20, 21},
+			expectedMaxWrites:      3,
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+
+		pods := make([]corev1.Pod, 0)
+		guaranteedPods := make([]powerv1alpha1.GuaranteedPod, 0)
+		workloadContainers := make([]powerv1alpha1.Container, 0)
+		workloadCPUs := make([]int, 0)
+		for i := 0; i < tc.numPods; i++ {
+			podName := fmt.Sprintf("example-pod-%d", i)
+			container := powerv1alpha1.Container{
+				Name:          fmt.Sprintf("example-container-%d", i),
+				Id:            fmt.Sprintf("abcdefg%d", i),
+				Pod:           podName,
+				ExclusiveCPUs: []int{2 * i, 2*i + 1},
+				PowerProfile:  "performance-example-node1",
+				Workload:      "performance-example-node1-workload",
+			}
+
+			pods = append(pods, corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      podName,
+					Namespace: PowerPodNamespace,
+					UID:       types.UID(fmt.Sprintf("uid-%d", i)),
+				},
+				Spec: corev1.PodSpec{
+					NodeName: "example-node1",
+				},
+			})
+			guaranteedPods = append(guaranteedPods, powerv1alpha1.GuaranteedPod{
+				Node:       "example-node1",
+				Name:       podName,
+				UID:        fmt.Sprintf("uid-%d", i),
+				Containers: []powerv1alpha1.Container{container},
+			})
+			workloadContainers = append(workloadContainers, container)
+			workloadCPUs = append(workloadCPUs, container.ExclusiveCPUs...)
+		}
+
+		powerWorkload := &powerv1alpha1.PowerWorkload{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1-workload",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerWorkloadSpec{
+				Name: "performance-example-node1-workload",
+				Node: powerv1alpha1.NodeInfo{
+					Name:       "example-node1",
+					Containers: workloadContainers,
+					CpuIds:     append(workloadCPUs, tc.extraWorkloadCPUs...),
+				},
+				PowerProfile: "performance-example-node1",
+			},
+		}
+
+		objs := []runtime.Object{powerWorkload}
+		for i := range pods {
+			objs = append(objs, &pods[i])
+		}
+
+		r, err := createPowerPodReconcilerObject(objs)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		countingClient := &countingWorkloadClient{Client: r.Client, failWrites: tc.failedWrites}
+		r.Client = countingClient
+		r.DeletionCoalesceWindow = 100 * time.Millisecond
+
+		for _, guaranteedPod := range guaranteedPods {
+			err = r.State.UpdateStateGuaranteedPods(guaranteedPod)
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error updating internal state", tc.testCase))
+			}
+		}
+
+		for i := range pods {
+			now := metav1.Now()
+			pods[i].DeletionTimestamp = &now
+			err = countingClient.Client.Update(context.TODO(), &pods[i])
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error updating Pod '%s' DeletionTimestamp", tc.testCase, pods[i].Name))
+			}
+		}
+
+		for i := range pods {
+			req := reconcile.Request{
+				NamespacedName: client.ObjectKey{
+					Name:      pods[i].Name,
+					Namespace: PowerPodNamespace,
+				},
+			}
+
+			_, err = r.Reconcile(req)
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+			}
+		}
+
+		r.deletions.wait()
+
+		if countingClient.writes > tc.expectedMaxWrites {
+			t.Errorf("%s - Failed: Expected at most %v PowerWorkload writes, got %v", tc.testCase, tc.expectedMaxWrites, countingClient.writes)
+		}
+
+		workload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{
+			Name:      powerWorkload.Name,
+			Namespace: PowerPodNamespace,
+		}, workload)
+		if errors.IsNotFound(err) == tc.expectedWorkloadExists {
+			t.Fatal(fmt.Sprintf("%s - Failed: Expected PowerWorkload to exist to be %v, got %v", tc.testCase, tc.expectedWorkloadExists, !errors.IsNotFound(err)))
+		}
+
+		if tc.expectedWorkloadExists {
+			if !reflect.DeepEqual(workload.Spec.Node.CpuIds, tc.expectedCpuIds) {
+				t.Errorf("%s - Failed: Expected PowerWorkload CpuIds to be %v, got %v", tc.testCase, tc.expectedCpuIds, workload.Spec.Node.CpuIds)
+			}
+
+			if len(workload.Spec.Node.Containers) != 0 {
+				t.Errorf("%s - Failed: Expected PowerWorkload to have no Containers, got %v", tc.testCase, len(workload.Spec.Node.Containers))
+			}
+		}
+	}
+}
+
+func TestUnmanagedNodeSkipped(t *testing.T) {
+	tcases := []struct {
+		testCase                       string
+		nodeLabels                     map[string]string
+		managedNodeSelector            string
+		expectedNumberOfPowerWorkloads int
+		expectedEvent                  bool
+	}{
+		{
+			testCase:                       "Test Case 1",
+			nodeLabels:                     map[string]string{},
+			managedNodeSelector:            "power.intel.com/appqos=enabled",
+			expectedNumberOfPowerWorkloads: 0,
+			expectedEvent:                  true,
+		},
+		{
+			testCase: "Test Case 2",
+			nodeLabels: map[string]string{
+				"power.intel.com/appqos": "enabled",
+			},
+			managedNodeSelector:            "power.intel.com/appqos=enabled",
+			expectedNumberOfPowerWorkloads: 1,
+			expectedEvent:                  false,
+		},
+		{
+			testCase:                       "Test Case 3",
+			nodeLabels:                     map[string]string{},
+			managedNodeSelector:            "",
+			expectedNumberOfPowerWorkloads: 1,
+			expectedEvent:                  false,
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+
+		pod := createGuaranteedPod("example-pod", "abcdefg", "example-node1", "example-container-1", "performance-example-node1", 2)
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "example-node1",
+				Labels: tc.nodeLabels,
+			},
+		}
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance-example-node1",
+				Epp:  "performance",
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod, node, powerProfile})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		recorder := record.NewFakeRecorder(10)
+		r.Recorder = recorder
+		r.ManagedNodeSelector = tc.managedNodeSelector
+
+		fakeListResponse := &podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{1, 2},
+						},
+					},
+				},
+			},
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(fakeListResponse)
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+
+		powerWorkloads := &powerv1alpha1.PowerWorkloadList{}
+		err = r.Client.List(context.TODO(), powerWorkloads)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload list", tc.testCase))
+		}
+
+		if len(powerWorkloads.Items) != tc.expectedNumberOfPowerWorkloads {
+			t.Errorf("%s - Failed: Expected number of PowerWorkloads to be %v, got %v", tc.testCase, tc.expectedNumberOfPowerWorkloads, len(powerWorkloads.Items))
+		}
+
+		eventRecorded := len(recorder.Events) > 0
+		if eventRecorded != tc.expectedEvent {
+			t.Errorf("%s - Failed: Expected NodeNotPowerManaged Event to be recorded to be %v, got %v", tc.testCase, tc.expectedEvent, eventRecorded)
+		}
+	}
+}
+
+func TestConcurrentWorkloadCreation(t *testing.T) {
+	tcases := []struct {
+		testCase                string
+		pod                     *corev1.Pod
+		powerProfile            *powerv1alpha1.PowerProfile
+		competitor              *powerv1alpha1.PowerWorkload
+		containerResources      []podresourcesapi.ContainerResources
+		expectedCpuIds          []int
+		expectedNumOfContainers int
+	}{
+		{
+			testCase: "Test Case 1",
+			pod:      createGuaranteedPod("example-pod", "abcdefg", "example-node1", "example-container-1", "custom-profile", 2),
+			powerProfile: &powerv1alpha1.PowerProfile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "custom-profile",
+					Namespace: PowerPodNamespace,
+				},
+				Spec: powerv1alpha1.PowerProfileSpec{
+					Name: "custom-profile",
+					Max:  3000,
+					Min:  2800,
+					Epp:  "balance_performance",
+				},
+			},
+			competitor: &powerv1alpha1.PowerWorkload{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "custom-profile-workload",
+					Namespace: PowerPodNamespace,
+				},
+				Spec: powerv1alpha1.PowerWorkloadSpec{
+					Name: "custom-profile-workload",
+					Node: powerv1alpha1.NodeInfo{
+						Name: "example-node2",
+						Containers: []powerv1alpha1.Container{
+							{
+								Name:          "example-container-2",
+								Id:            "hijklmn",
+								Pod:           "example-pod-2",
+								ExclusiveCPUs: []int{3, 4},
+								PowerProfile:  "custom-profile",
+							},
+						},
+						CpuIds: []int{3, 4},
+					},
+					PowerProfile: "custom-profile",
+				},
+			},
+			containerResources: []podresourcesapi.ContainerResources{
+				{
+					Name:   "example-container-1",
+					CpuIds: []int64{1, 2},
+				},
+			},
+			expectedCpuIds:          []int{1, 2, 3, 4},
+			expectedNumOfContainers: 2,
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", tc.pod.Spec.NodeName)
+
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: tc.pod.Spec.NodeName,
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{tc.pod, node, tc.powerProfile})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		r.Client = &racingCreateClient{Client: r.Client, competitor: tc.competitor}
+
+		fakeContainers := []*podresourcesapi.ContainerResources{}
+		for i := range tc.containerResources {
+			fakeContainers = append(fakeContainers, &tc.containerResources[i])
+		}
+		fakeListResponse := &podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name:       tc.pod.Name,
+					Containers: fakeContainers,
+				},
+			},
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(fakeListResponse)
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      tc.pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - Failed: Expected AlreadyExists to be merged into the existing PowerWorkload", tc.testCase))
+		}
+
+		powerWorkloads := &powerv1alpha1.PowerWorkloadList{}
+		err = r.Client.List(context.TODO(), powerWorkloads)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload list", tc.testCase))
+		}
+
+		if len(powerWorkloads.Items) != 1 {
+			t.Fatal(fmt.Sprintf("%s - Failed: Expected number of PowerWorkloads to be 1, got %v", tc.testCase, len(powerWorkloads.Items)))
+		}
+
+		workload := powerWorkloads.Items[0]
+		if !reflect.DeepEqual(workload.Spec.Node.CpuIds, tc.expectedCpuIds) {
+			t.Errorf("%s - Failed: Expected PowerWorkload CpuIds to be %v, got %v", tc.testCase, tc.expectedCpuIds, workload.Spec.Node.CpuIds)
+		}
+
+		if len(workload.Spec.Node.Containers) != tc.expectedNumOfContainers {
+			t.Errorf("%s - Failed: Expected number of PowerWorkload Containers to be %v, got %v", tc.testCase, tc.expectedNumOfContainers, len(workload.Spec.Node.Containers))
+		}
+	}
+}
+
+func TestProfileResolverChain(t *testing.T) {
+	tcases := []struct {
+		testCase        string
+		annotations     map[string]string
+		requests        map[corev1.ResourceName]resource.Quantity
+		limits          map[corev1.ResourceName]resource.Quantity
+		strict          bool
+		expectedProfile string
+		expectedError   bool
+	}{
+		{
+			testCase: "Test Case 1",
+			annotations: map[string]string{
+				PowerProfileAnnotation: "balance-power",
+			},
+			requests: map[corev1.ResourceName]resource.Quantity{
+				corev1.ResourceName("cpu"):                         *resource.NewQuantity(2, resource.DecimalSI),
+				corev1.ResourceName("power.intel.com/performance"): *resource.NewQuantity(2, resource.DecimalSI),
+			},
+			limits: map[corev1.ResourceName]resource.Quantity{
+				corev1.ResourceName("cpu"):                         *resource.NewQuantity(2, resource.DecimalSI),
+				corev1.ResourceName("power.intel.com/performance"): *resource.NewQuantity(2, resource.DecimalSI),
+			},
+			expectedProfile: "performance",
+		},
+		{
+			testCase: "Test Case 2",
+			annotations: map[string]string{
+				PowerProfileAnnotation: "balance-power",
+			},
+			requests: map[corev1.ResourceName]resource.Quantity{
+				corev1.ResourceName("cpu"): *resource.NewQuantity(2, resource.DecimalSI),
+			},
+			limits: map[corev1.ResourceName]resource.Quantity{
+				corev1.ResourceName("cpu"): *resource.NewQuantity(2, resource.DecimalSI),
+			},
+			expectedProfile: "balance-power",
+		},
+		{
+			testCase:    "Test Case 3",
+			annotations: map[string]string{},
+			requests: map[corev1.ResourceName]resource.Quantity{
+				corev1.ResourceName("cpu"): *resource.NewQuantity(2, resource.DecimalSI),
+			},
+			limits: map[corev1.ResourceName]resource.Quantity{
+				corev1.ResourceName("cpu"): *resource.NewQuantity(2, resource.DecimalSI),
+			},
+			expectedProfile: "",
+		},
+		{
+			testCase: "Test Case 4",
+			annotations: map[string]string{
+				PowerProfileAnnotation: "balance-power",
+			},
+			requests: map[corev1.ResourceName]resource.Quantity{
+				corev1.ResourceName("cpu"):                         *resource.NewQuantity(2, resource.DecimalSI),
+				corev1.ResourceName("power.intel.com/performance"): *resource.NewQuantity(1, resource.DecimalSI),
+			},
+			limits: map[corev1.ResourceName]resource.Quantity{
+				corev1.ResourceName("cpu"):                         *resource.NewQuantity(2, resource.DecimalSI),
+				corev1.ResourceName("power.intel.com/performance"): *resource.NewQuantity(1, resource.DecimalSI),
+			},
+			expectedError: true,
+		},
+		{
+			testCase: "Test Case 5",
+			annotations: map[string]string{
+				PowerProfileAnnotation: "balance-power",
+			},
+			requests: map[corev1.ResourceName]resource.Quantity{
+				corev1.ResourceName("cpu"): *resource.NewQuantity(2, resource.DecimalSI),
+			},
+			limits: map[corev1.ResourceName]resource.Quantity{
+				corev1.ResourceName("cpu"): *resource.NewQuantity(2, resource.DecimalSI),
+			},
+			strict:          true,
+			expectedProfile: "",
+		},
+		{
+			testCase: "Test Case 6",
+			annotations: map[string]string{
+				PowerProfileAnnotation: "balance-power",
+			},
+			requests: map[corev1.ResourceName]resource.Quantity{
+				corev1.ResourceName("cpu"):                         *resource.NewQuantity(2, resource.DecimalSI),
+				corev1.ResourceName("power.intel.com/performance"): *resource.NewQuantity(2, resource.DecimalSI),
+			},
+			limits: map[corev1.ResourceName]resource.Quantity{
+				corev1.ResourceName("cpu"):                         *resource.NewQuantity(2, resource.DecimalSI),
+				corev1.ResourceName("power.intel.com/performance"): *resource.NewQuantity(2, resource.DecimalSI),
+			},
+			strict:          true,
+			expectedProfile: "performance",
+		},
+	}
+
+	for _, tc := range tcases {
+		r := &PowerPodReconciler{
+			ProfileResolvers: []ProfileResolver{
+				ResourceRequestResolver{},
+				AnnotationProfileResolver{},
+			},
+			StrictResourceRequests: tc.strict,
+		}
+
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "example-pod",
+				Namespace:   PowerPodNamespace,
+				Annotations: tc.annotations,
+			},
+		}
+		container := corev1.Container{
+			Name: "example-container-1",
+			Resources: corev1.ResourceRequirements{
+				Requests: tc.requests,
+				Limits:   tc.limits,
+			},
+		}
+
+		profile, err := r.profileResolver().Resolve(pod, container)
+		if (err != nil) != tc.expectedError {
+			t.Errorf("%s - Failed: Expected error to be %v, got %v", tc.testCase, tc.expectedError, err)
+		}
+
+		if profile != tc.expectedProfile {
+			t.Errorf("%s - Failed: Expected resolved PowerProfile to be '%s', got '%s'", tc.testCase, tc.expectedProfile, profile)
+		}
+	}
+}
+
+func TestPowerProfileCRAndAppQoSProfileValidation(t *testing.T) {
+	tcases := []struct {
+		testCase       string
+		profileCRs     []powerv1alpha1.PowerProfile
+		appqosProfiles []appqos.PowerProfile
+		expectedError  bool
+		expectedEvent  string
+	}{
+		{
+			testCase: "Test Case 1",
+			profileCRs: []powerv1alpha1.PowerProfile{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "performance-example-node1",
+						Namespace: PowerPodNamespace,
+					},
+				},
+			},
+			appqosProfiles: []appqos.PowerProfile{
+				{
+					ID:   intPtr(1),
+					Name: stringPtr("performance-example-node1"),
+				},
+			},
+			expectedError: false,
+			expectedEvent: "",
+		},
+		{
+			testCase:   "Test Case 2",
+			profileCRs: []powerv1alpha1.PowerProfile{},
+			appqosProfiles: []appqos.PowerProfile{
+				{
+					ID:   intPtr(1),
+					Name: stringPtr("performance-example-node1"),
+				},
+			},
+			expectedError: true,
+			expectedEvent: "PowerProfileCRMissing",
+		},
+		{
+			testCase: "Test Case 3",
+			profileCRs: []powerv1alpha1.PowerProfile{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "performance-example-node1",
+						Namespace: PowerPodNamespace,
+					},
+				},
+			},
+			appqosProfiles: []appqos.PowerProfile{
+				{
+					ID:   intPtr(1),
+					Name: stringPtr("balance-power-example-node1"),
+				},
+			},
+			expectedError: true,
+			expectedEvent: "AppQoSProfileMissing",
+		},
+		{
+			testCase: "Test Case 4 - AppQoS has no Power Profiles",
+			profileCRs: []powerv1alpha1.PowerProfile{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "performance-example-node1",
+						Namespace: PowerPodNamespace,
+					},
+				},
+			},
+			appqosProfiles: []appqos.PowerProfile{},
+			expectedError:  true,
+			expectedEvent:  "Warning AppQoSProfilesNotProvisioned AppQoS on Node 'example-node1' has no Power Profiles provisioned",
+		},
+	}
+
+	for _, tc := range tcases {
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example-pod",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: corev1.PodSpec{
+				NodeName: "example-node1",
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		recorder := record.NewFakeRecorder(10)
+		r.Recorder = recorder
+		r.AppQoSClient = appqos.NewDefaultAppQoSClient()
+
+		server, err := createListeners([]appqos.Pool{}, tc.appqosProfiles, "4.0.0")
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating Listeners", tc.testCase))
+		}
+
+		err = r.validatePowerProfile(context.Background(), pod, "performance-example-node1", tc.profileCRs)
+		server.Close()
+		if (err != nil) != tc.expectedError {
+			t.Errorf("%s - Failed: Expected error to be %v, got %v", tc.testCase, tc.expectedError, err)
+		}
+
+		event := ""
+		select {
+		case event = <-recorder.Events:
+		default:
+		}
+		if !strings.Contains(event, tc.expectedEvent) || (tc.expectedEvent == "") != (event == "") {
+			t.Errorf("%s - Failed: Expected Event '%s', got '%s'", tc.testCase, tc.expectedEvent, event)
+		}
+	}
+}
+
+func TestPowerProfileAnnotationChanged(t *testing.T) {
+	tcases := []struct {
+		testCase                   string
+		initialProfile             string
+		updatedProfile             string
+		containerResources         []podresourcesapi.ContainerResources
+		expectedWorkloadToNotExist string
+		expectedWorkload           string
+		expectedWorkloadCpuIds     []int
+	}{
+		{
+			testCase:       "Test Case 1",
+			initialProfile: "gold",
+			updatedProfile: "silver",
+			containerResources: []podresourcesapi.ContainerResources{
+				{
+					Name:   "example-container-1",
+					CpuIds: []int64{1, 2},
+				},
+			},
+			expectedWorkloadToNotExist: "gold-workload",
+			expectedWorkload:           "silver-workload",
+			expectedWorkloadCpuIds:     []int{1, 2},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example-pod",
+				Namespace: PowerPodNamespace,
+				UID:       "abcdefg",
+				Annotations: map[string]string{
+					PowerProfileAnnotation: tc.initialProfile,
+				},
+			},
+			Spec: corev1.PodSpec{
+				NodeName: "example-node1",
+				Containers: []corev1.Container{
+					{
+						Name: "example-container-1",
+						Resources: corev1.ResourceRequirements{
+							Limits: map[corev1.ResourceName]resource.Quantity{
+								corev1.ResourceName("cpu"): *resource.NewQuantity(2, resource.DecimalSI),
+							},
+							Requests: map[corev1.ResourceName]resource.Quantity{
+								corev1.ResourceName("cpu"): *resource.NewQuantity(2, resource.DecimalSI),
+							},
+						},
+					},
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase:    corev1.PodRunning,
+				QOSClass: corev1.PodQOSGuaranteed,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:        "example-container-1",
+						ContainerID: "docker://abcdefg",
+					},
+				},
+			},
+		}
+		objs := []runtime.Object{pod}
+		for _, profileName := range []string{tc.initialProfile, tc.updatedProfile} {
+			objs = append(objs, &powerv1alpha1.PowerProfile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      profileName,
+					Namespace: PowerPodNamespace,
+				},
+				Spec: powerv1alpha1.PowerProfileSpec{
+					Name: profileName,
+				},
+			})
+		}
+
+		r, err := createPowerPodReconcilerObject(objs)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		r.ProfileResolvers = []ProfileResolver{
+			ResourceRequestResolver{},
+			AnnotationProfileResolver{},
+		}
+
+		fakeContainers := []*podresourcesapi.ContainerResources{}
+		for i := range tc.containerResources {
+			fakeContainers = append(fakeContainers, &tc.containerResources[i])
+		}
+		fakeListResponse := &podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name:       pod.Name,
+					Containers: fakeContainers,
+				},
+			},
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(fakeListResponse)
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+
+		// Switch the Pod to the new PowerProfile while it is running
+		pod.Annotations[PowerProfileAnnotation] = tc.updatedProfile
+		err = r.Client.Update(context.TODO(), pod)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error updating Pod annotation", tc.testCase))
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+
+		oldWorkload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{
+			Name:      tc.expectedWorkloadToNotExist,
+			Namespace: PowerPodNamespace,
+		}, oldWorkload)
+		if !errors.IsNotFound(err) {
+			t.Errorf("%s - Failed: Expected PowerWorkload '%s' to be deleted, got %v", tc.testCase, tc.expectedWorkloadToNotExist, oldWorkload.Spec.Node.CpuIds)
+		}
+
+		newWorkload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{
+			Name:      tc.expectedWorkload,
+			Namespace: PowerPodNamespace,
+		}, newWorkload)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload '%s'", tc.testCase, tc.expectedWorkload))
+		}
+
+		if !reflect.DeepEqual(newWorkload.Spec.Node.CpuIds, tc.expectedWorkloadCpuIds) {
+			t.Errorf("%s - Failed: Expected PowerWorkload '%s' CpuIds to be %v, got %v", tc.testCase, tc.expectedWorkload, tc.expectedWorkloadCpuIds, newWorkload.Spec.Node.CpuIds)
+		}
+
+		podState := r.State.GetPodFromState(pod.Name)
+		if len(podState.Containers) != 1 || podState.Containers[0].PowerProfile != tc.updatedProfile {
+			t.Errorf("%s - Failed: Expected Pod state to record PowerProfile '%s', got %v", tc.testCase, tc.updatedProfile, podState.Containers)
+		}
+	}
+}
+
+func TestReconcileDeadlineExceeded(t *testing.T) {
+	tcases := []struct {
+		testCase                       string
+		reconcileTimeout               time.Duration
+		blockPodResources              bool
+		expectedRequeue                bool
+		expectedNumberOfPowerWorkloads int
+	}{
+		{
+			testCase:                       "Test Case 1",
+			reconcileTimeout:               50 * time.Millisecond,
+			blockPodResources:              true,
+			expectedRequeue:                true,
+			expectedNumberOfPowerWorkloads: 0,
+		},
+		{
+			testCase:                       "Test Case 2",
+			reconcileTimeout:               time.Minute,
+			blockPodResources:              false,
+			expectedRequeue:                false,
+			expectedNumberOfPowerWorkloads: 1,
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+
+		pod := createGuaranteedPod("example-pod", "abcdefg", "example-node1", "example-container-1", "performance-example-node1", 2)
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance-example-node1",
+				Epp:  "performance",
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod, powerProfile})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		r.ReconcileTimeout = tc.reconcileTimeout
+		if tc.blockPodResources {
+			r.PodResourcesClient = podresourcesclient.PodResourcesClient{Client: &blockingPodResourcesClient{}}
+		} else {
+			fakeListResponse := &podresourcesapi.ListPodResourcesResponse{
+				PodResources: []*podresourcesapi.PodResources{
+					{
+						Name: pod.Name,
+						Containers: []*podresourcesapi.ContainerResources{
+							{
+								Name:   "example-container-1",
+								CpuIds: []int64{1, 2},
+							},
+						},
+					},
+				},
+			}
+			r.PodResourcesClient = *createFakePodResourcesListerClient(fakeListResponse)
+		}
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		done := make(chan struct{})
+		var result ctrl.Result
+		go func() {
+			result, err = r.Reconcile(req)
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal(fmt.Sprintf("%s - Reconcile did not return after its deadline", tc.testCase))
+		}
+
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling object", tc.testCase))
+		}
+
+		if result.Requeue != tc.expectedRequeue {
+			t.Errorf("%s - Failed: Expected Requeue to be %v, got %v", tc.testCase, tc.expectedRequeue, result.Requeue)
+		}
+
+		workloads := &powerv1alpha1.PowerWorkloadList{}
+		err = r.Client.List(context.TODO(), workloads)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload list", tc.testCase))
+		}
+
+		if len(workloads.Items) != tc.expectedNumberOfPowerWorkloads {
+			t.Errorf("%s - Failed: Expected number of PowerWorkloads to be %v, got %v", tc.testCase, tc.expectedNumberOfPowerWorkloads, len(workloads.Items))
+		}
+	}
+}
+
+func TestListWorkloadsByFamily(t *testing.T) {
+	tcases := []struct {
+		testCase          string
+		profileLabels     map[string]string
+		family            string
+		expectedWorkloads []string
+	}{
+		{
+			testCase: "Test Case 1",
+			profileLabels: map[string]string{
+				powerv1alpha1.FamilyLabel: "perf",
+			},
+			family:            "perf",
+			expectedWorkloads: []string{"perf-med-example-node1-workload", "performance-example-node1-workload"},
+		},
+		{
+			testCase:          "Test Case 2",
+			profileLabels:     map[string]string{},
+			family:            "perf",
+			expectedWorkloads: []string{"perf-med-example-node1-workload"},
+		},
+		{
+			testCase: "Test Case 3",
+			profileLabels: map[string]string{
+				powerv1alpha1.FamilyLabel: "perf",
+			},
+			family:            "balance",
+			expectedWorkloads: []string{},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+
+		pod := createGuaranteedPod("example-pod", "abcdefg", "example-node1", "example-container-1", "performance-example-node1", 2)
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1",
+				Namespace: PowerPodNamespace,
+				Labels:    tc.profileLabels,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance-example-node1",
+				Epp:  "performance",
+			},
+		}
+		familyWorkload := &powerv1alpha1.PowerWorkload{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "perf-med-example-node1-workload",
+				Namespace: PowerPodNamespace,
+				Labels: map[string]string{
+					powerv1alpha1.FamilyLabel: "perf",
+				},
+			},
+			Spec: powerv1alpha1.PowerWorkloadSpec{
+				Name:         "perf-med-example-node1-workload",
+				PowerProfile: "perf-med-example-node1",
+			},
+		}
+		otherWorkload := &powerv1alpha1.PowerWorkload{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "balance-power-example-node1-workload",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerWorkloadSpec{
+				Name:         "balance-power-example-node1-workload",
+				PowerProfile: "balance-power-example-node1",
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod, powerProfile, familyWorkload, otherWorkload})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		fakeListResponse := &podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{1, 2},
+						},
+					},
+				},
+			},
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(fakeListResponse)
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling object", tc.testCase))
+		}
+
+		workloads, err := ListWorkloadsByFamily(context.TODO(), r.Client, PowerPodNamespace, tc.family)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error listing PowerWorkloads by family", tc.testCase))
+		}
+
+		workloadNames := make([]string, 0)
+		for _, workload := range workloads {
+			workloadNames = append(workloadNames, workload.Name)
+		}
+		sort.Strings(workloadNames)
+
+		if !reflect.DeepEqual(workloadNames, tc.expectedWorkloads) {
+			t.Errorf("%s - Failed: Expected PowerWorkloads in family '%s' to be %v, got %v", tc.testCase, tc.family, tc.expectedWorkloads, workloadNames)
+		}
+	}
+}
+
+func TestReconcileQueueBackpressureMetrics(t *testing.T) {
+	tcases := []struct {
+		testCase           string
+		queuedPods         []string
+		reconciledPods     []string
+		oldestPodAge       time.Duration
+		expectedDepth      float64
+		expectedMinimumAge float64
+	}{
+		{
+			testCase:           "Test Case 1",
+			queuedPods:         []string{"example-pod-1", "example-pod-2", "example-pod-3"},
+			reconciledPods:     []string{"example-pod-2"},
+			oldestPodAge:       30 * time.Second,
+			expectedDepth:      2,
+			expectedMinimumAge: 30,
+		},
+		{
+			testCase:           "Test Case 2",
+			queuedPods:         []string{"example-pod-1", "example-pod-1"},
+			reconciledPods:     []string{},
+			oldestPodAge:       10 * time.Second,
+			expectedDepth:      1,
+			expectedMinimumAge: 10,
+		},
+		{
+			testCase:           "Test Case 3",
+			queuedPods:         []string{"example-pod-1"},
+			reconciledPods:     []string{"example-pod-1"},
+			oldestPodAge:       0,
+			expectedDepth:      0,
+			expectedMinimumAge: 0,
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+		r.priority.tracker = &r.queue
+		podHandler := &podPriorityHandler{buffer: &r.priority}
+		for _, podName := range tc.queuedPods {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      podName,
+					Namespace: PowerPodNamespace,
+				},
+			}
+			podHandler.Create(event.CreateEvent{Meta: pod, Object: pod}, queue)
+		}
+
+		// Backdate the first queued Pod so the oldest-item age is known
+		if tc.oldestPodAge > 0 {
+			r.queue.queued[reconcile.Request{NamespacedName: client.ObjectKey{
+				Name:      tc.queuedPods[0],
+				Namespace: PowerPodNamespace,
+			}}] = time.Now().Add(-tc.oldestPodAge)
+		}
+
+		for _, podName := range tc.reconciledPods {
+			_, err = r.Reconcile(reconcile.Request{NamespacedName: client.ObjectKey{
+				Name:      podName,
+				Namespace: PowerPodNamespace,
+			}})
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error reconciling object", tc.testCase))
+			}
+		}
+
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(newQueueBackpressureCollector("pod", &r.queue))
+		families, err := registry.Gather()
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error gathering metrics", tc.testCase))
+		}
+
+		gauges := make(map[string]float64)
+		for _, family := range families {
+			gauges[family.GetName()] = family.GetMetric()[0].GetGauge().GetValue()
+		}
+
+		if gauges["power_reconcile_queue_depth"] != tc.expectedDepth {
+			t.Errorf("%s - Failed: Expected queue depth to be %v, got %v", tc.testCase, tc.expectedDepth, gauges["power_reconcile_queue_depth"])
+		}
+
+		age := gauges["power_reconcile_queue_oldest_item_age_seconds"]
+		if age < tc.expectedMinimumAge || age > tc.expectedMinimumAge+5 {
+			t.Errorf("%s - Failed: Expected oldest item age to be about %v, got %v", tc.testCase, tc.expectedMinimumAge, age)
+		}
+
+		queue.ShutDown()
+	}
+}
+
+func TestConcurrentSameProfileWorkloadUpdates(t *testing.T) {
+	tcases := []struct {
+		testCase        string
+		podProfiles     map[string]string
+		expectedCpuIds  map[string][]int
+		existingProfile string
+	}{
+		{
+			testCase: "Test Case 1",
+			podProfiles: map[string]string{
+				"example-pod-1": "performance-example-node1",
+				"example-pod-2": "performance-example-node1",
+				"example-pod-3": "performance-example-node1",
+				"example-pod-4": "performance-example-node1",
+			},
+			expectedCpuIds: map[string][]int{
+				"performance-example-node1-workload": []int{1, 2, 3, 4, 5, 6, 7, 8},
+			},
+		},
+		{
+			testCase: "Test Case 2",
+			podProfiles: map[string]string{
+				"example-pod-1": "performance-example-node1",
+				"example-pod-2": "balance-power-example-node1",
+				"example-pod-3": "performance-example-node1",
+				"example-pod-4": "balance-power-example-node1",
+			},
+			expectedCpuIds: map[string][]int{
+				"performance-example-node1-workload":   []int{1, 2, 5, 6},
+				"balance-power-example-node1-workload": []int{3, 4, 7, 8},
+			},
+		},
+		{
+			testCase: "Test Case 3",
+			podProfiles: map[string]string{
+				"example-pod-1": "performance-example-node1",
+				"example-pod-2": "performance-example-node1",
+			},
+			existingProfile: "performance-example-node1",
+			expectedCpuIds: map[string][]int{
+				"performance-example-node1-workload": []int{1, 2, 3, 4, 9},
+			},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+
+		objs := []runtime.Object{}
+		if tc.existingProfile != "" {
+			objs = append(objs, &powerv1alpha1.PowerWorkload{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      tc.existingProfile + WorkloadNameSuffix,
+					Namespace: PowerPodNamespace,
+				},
+				Spec: powerv1alpha1.PowerWorkloadSpec{
+					Name:         tc.existingProfile + WorkloadNameSuffix,
+					PowerProfile: tc.existingProfile,
+					Node: powerv1alpha1.NodeInfo{
+						Name:   "example-node1",
+						CpuIds: []int{9},
+					},
+				},
+			})
+		}
+
+		r, err := createPowerPodReconcilerObject(objs)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		r.Client = &slowReadWorkloadClient{Client: r.Client}
+
+		podNames := make([]string, 0)
+		for podName := range tc.podProfiles {
+			podNames = append(podNames, podName)
+		}
+		sort.Strings(podNames)
+
+		errs := make(chan error, len(podNames))
+		wg := sync.WaitGroup{}
+		for i, podName := range podNames {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      podName,
+					Namespace: PowerPodNamespace,
+					UID:       types.UID(podName),
+				},
+				Spec: corev1.PodSpec{
+					NodeName: "example-node1",
+				},
+			}
+			cores := []int{2*i + 1, 2*i + 2}
+			containers := []powerv1alpha1.Container{
+				{
+					Name:          "example-container-1",
+					ExclusiveCPUs: cores,
+					PowerProfile:  tc.podProfiles[podName],
+				},
+			}
+
+			wg.Add(1)
+			go func(profileName string) {
+				defer wg.Done()
+				errs <- r.addPodToWorkload(context.TODO(), r.Log, PowerPodNamespace, pod, profileName, cores, containers, []powerv1alpha1.PowerProfile{})
+			}(tc.podProfiles[podName])
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			if err != nil {
+				t.Errorf("%s - Failed: Expected concurrent PowerWorkload updates to succeed, got %v", tc.testCase, err)
+			}
+		}
+
+		for workloadName, expectedCpuIds := range tc.expectedCpuIds {
+			workload := &powerv1alpha1.PowerWorkload{}
+			err = r.Client.Get(context.TODO(), client.ObjectKey{
+				Name:      workloadName,
+				Namespace: PowerPodNamespace,
+			}, workload)
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload '%s'", tc.testCase, workloadName))
+			}
+
+			if !reflect.DeepEqual(workload.Spec.Node.CpuIds, expectedCpuIds) {
+				t.Errorf("%s - Failed: Expected PowerWorkload '%s' CpuIds to be %v, got %v", tc.testCase, workloadName, expectedCpuIds, workload.Spec.Node.CpuIds)
+			}
+		}
+	}
+}
+
+func TestPodPriorityOrdering(t *testing.T) {
+	tcases := []struct {
+		testCase       string
+		workers        int
+		podPriorities  map[string]int32
+		arrivalOrder   []string
+		expectedQueued int
+		expectedOrder  []string
+	}{
+		{
+			testCase: "Test Case 1",
+			podPriorities: map[string]int32{
+				"busy-pod":          0,
+				"best-effort-pod":   0,
+				"critical-pod":      1000000,
+				"high-priority-pod": 1000,
+			},
+			arrivalOrder:   []string{"busy-pod", "best-effort-pod", "high-priority-pod", "critical-pod"},
+			expectedQueued: 1,
+			expectedOrder:  []string{"busy-pod", "critical-pod", "high-priority-pod", "best-effort-pod"},
+		},
+		{
+			testCase: "Test Case 2",
+			podPriorities: map[string]int32{
+				"busy-pod":   0,
+				"first-pod":  100,
+				"second-pod": 100,
+				"third-pod":  100,
+			},
+			arrivalOrder:   []string{"busy-pod", "first-pod", "second-pod", "third-pod"},
+			expectedQueued: 1,
+			expectedOrder:  []string{"busy-pod", "first-pod", "second-pod", "third-pod"},
+		},
+		{
+			testCase: "Test Case 3",
+			podPriorities: map[string]int32{
+				"busy-pod":          0,
+				"high-priority-pod": 1000,
+				"low-priority-pod":  -10,
+			},
+			arrivalOrder:   []string{"busy-pod", "low-priority-pod", "high-priority-pod", "low-priority-pod"},
+			expectedQueued: 1,
+			expectedOrder:  []string{"busy-pod", "high-priority-pod", "low-priority-pod"},
+		},
+		{
+			testCase: "Test Case 4 - Two workers",
+			workers:  2,
+			podPriorities: map[string]int32{
+				"busy-pod-1":        0,
+				"busy-pod-2":        0,
+				"low-priority-pod":  -10,
+				"high-priority-pod": 1000,
+			},
+			arrivalOrder:   []string{"busy-pod-1", "busy-pod-2", "low-priority-pod", "high-priority-pod"},
+			expectedQueued: 2,
+			expectedOrder:  []string{"busy-pod-1", "busy-pod-2", "high-priority-pod", "low-priority-pod"},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		r.priority.workers = tc.workers
+
+		queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+		podHandler := &podPriorityHandler{buffer: &r.priority}
+		for _, podName := range tc.arrivalOrder {
+			priority := tc.podPriorities[podName]
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      podName,
+					Namespace: PowerPodNamespace,
+				},
+				Spec: corev1.PodSpec{
+					Priority: &priority,
+				},
+			}
+			podHandler.Create(event.CreateEvent{Meta: pod, Object: pod}, queue)
+		}
+
+		// Every worker has a request waiting for it
+		if queue.Len() != tc.expectedQueued {
+			t.Errorf("%s - Failed: Expected %d requests to be queued, got %d", tc.testCase, tc.expectedQueued, queue.Len())
+		}
+
+		// Act as the controller's workers, each picking up a request before any is reconciled, draining the
+		// queue until nothing is left
+		workers := tc.workers
+		if workers < 1 {
+			workers = 1
+		}
+		processed := make([]string, 0)
+		for queue.Len() > 0 {
+			items := make([]interface{}, 0)
+			for len(items) < workers && queue.Len() > 0 {
+				item, _ := queue.Get()
+				items = append(items, item)
+				processed = append(processed, item.(reconcile.Request).Name)
+			}
+
+			for _, item := range items {
+				_, err = r.Reconcile(item.(reconcile.Request))
+				if err != nil {
+					t.Error(err)
+					t.Fatal(fmt.Sprintf("%s - error reconciling object", tc.testCase))
+				}
+				queue.Done(item)
+			}
+		}
+		queue.ShutDown()
+
+		if !reflect.DeepEqual(processed, tc.expectedOrder) {
+			t.Errorf("%s - Failed: Expected Pods to be reconciled in order %v, got %v", tc.testCase, tc.expectedOrder, processed)
+		}
+	}
+}
+
+func createFakeNUMANodes(t *testing.T, cpuNodes map[int]int) string {
+	cpuDevicesPath := t.TempDir()
+	for cpu, node := range cpuNodes {
+		err := os.MkdirAll(filepath.Join(cpuDevicesPath, fmt.Sprintf("cpu%d", cpu), fmt.Sprintf("node%d", node)), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return cpuDevicesPath
+}
+
+func TestTopologyHintMismatch(t *testing.T) {
+	tcases := []struct {
+		testCase      string
+		cores         []int
+		devices       []*podresourcesapi.ContainerDevices
+		expectedEvent string
+	}{
+		{
+			testCase: "Test Case 1",
+			cores:    []int{1, 2},
+			devices: []*podresourcesapi.ContainerDevices{
+				{
+					ResourceName: "intel.com/sriov",
+					DeviceIds:    []string{"0000:18:02.1"},
+					Topology: &podresourcesapi.TopologyInfo{
+						Nodes: []*podresourcesapi.NUMANode{{ID: 0}},
+					},
+				},
+			},
+			expectedEvent: "",
+		},
+		{
+			testCase: "Test Case 2",
+			cores:    []int{1, 5},
+			devices: []*podresourcesapi.ContainerDevices{
+				{
+					ResourceName: "intel.com/sriov",
+					DeviceIds:    []string{"0000:18:02.1"},
+					Topology: &podresourcesapi.TopologyInfo{
+						Nodes: []*podresourcesapi.NUMANode{{ID: 0}},
+					},
+				},
+			},
+			expectedEvent: "TopologyHintMismatch",
+		},
+		{
+			testCase: "Test Case 3",
+			cores:    []int{5, 6},
+			devices: []*podresourcesapi.ContainerDevices{
+				{
+					ResourceName: "intel.com/sriov",
+					DeviceIds:    []string{"0000:18:02.1"},
+				},
+			},
+			expectedEvent: "",
+		},
+	}
+
+	for _, tc := range tcases {
+		cpuhotplug.CPUDevicesPath = createFakeNUMANodes(t, map[int]int{1: 0, 2: 0, 5: 1, 6: 1})
+
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example-pod",
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		recorder := record.NewFakeRecorder(10)
+		r.Recorder = recorder
+
+		fakeListResponse := &podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:    "example-container-1",
+							Devices: tc.devices,
+						},
+					},
+				},
+			},
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(fakeListResponse)
+
+		r.checkTopologyHints(context.Background(), pod, "example-container-1", tc.cores)
+
+		event := ""
+		select {
+		case event = <-recorder.Events:
+		default:
+		}
+		if !strings.Contains(event, tc.expectedEvent) || (tc.expectedEvent == "") != (event == "") {
+			t.Errorf("%s - Failed: Expected Event '%s', got '%s'", tc.testCase, tc.expectedEvent, event)
+		}
+	}
+}
+
+func TestContainerRestartResyncsCores(t *testing.T) {
+	tcases := []struct {
+		testCase             string
+		restartCount         int32
+		restartedCPUs        []int64
+		expectedWorkloadCPUs []int
+	}{
+		{
+			testCase:             "Test Case 1",
+			restartCount:         1,
+			restartedCPUs:        []int64{3, 4},
+			expectedWorkloadCPUs: []int{3, 4},
+		},
+		{
+			testCase:             "Test Case 2",
+			restartCount:         0,
+			restartedCPUs:        []int64{1, 2},
+			expectedWorkloadCPUs: []int{1, 2},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+
+		pod := createGuaranteedPod("example-pod", "abcdefg", "example-node1", "example-container-1", "performance-example-node1", 2)
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance-example-node1",
+				Epp:  "performance",
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod, powerProfile})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		fakeContainer := &podresourcesapi.ContainerResources{
+			Name:   "example-container-1",
+			CpuIds: []int64{1, 2},
+		}
+		fakeListResponse := &podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name:       pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{fakeContainer},
+				},
+			},
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(fakeListResponse)
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+
+		// The container restarts and the kubelet hands it a new cpuset, but the Pod stays Running
+		err = r.Client.Get(context.TODO(), req.NamespacedName, pod)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving Pod object", tc.testCase))
+		}
+		pod.Status.ContainerStatuses[0].RestartCount = tc.restartCount
+		err = r.Client.Update(context.TODO(), pod)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error updating Pod RestartCount", tc.testCase))
+		}
+		fakeContainer.CpuIds = tc.restartedCPUs
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling restarted Pod object", tc.testCase))
+		}
+
+		workload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{
+			Name:      "performance-example-node1-workload",
+			Namespace: PowerPodNamespace,
+		}, workload)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload", tc.testCase))
+		}
+
+		if !reflect.DeepEqual(workload.Spec.Node.CpuIds, tc.expectedWorkloadCPUs) {
+			t.Errorf("%s - Failed: Expected PowerWorkload CPUs to be %v, got %v", tc.testCase, tc.expectedWorkloadCPUs, workload.Spec.Node.CpuIds)
+		}
+
+		state := r.State.GetPodFromState(pod.Name)
+		if len(state.Containers) != 1 || !reflect.DeepEqual(state.Containers[0].ExclusiveCPUs, tc.expectedWorkloadCPUs) {
+			t.Errorf("%s - Failed: Expected State CPUs to be %v, got %v", tc.testCase, tc.expectedWorkloadCPUs, state.Containers)
+		}
+	}
+}
+
+func TestPodUpdateCPUSetReconciliation(t *testing.T) {
+	tcases := []struct {
+		testCase             string
+		initialCPUs          []int64
+		updatedCPUs          []int64
+		expectedWorkloadCPUs []int
+	}{
+		{
+			testCase:             "Test Case 1",
+			initialCPUs:          []int64{1, 2, 3, 4},
+			updatedCPUs:          []int64{1, 2},
+			expectedWorkloadCPUs: []int{1, 2},
+		},
+		{
+			testCase:             "Test Case 2",
+			initialCPUs:          []int64{1, 2},
+			updatedCPUs:          []int64{2, 5},
+			expectedWorkloadCPUs: []int{2, 5},
+		},
+		{
+			testCase:             "Test Case 3",
+			initialCPUs:          []int64{1, 2},
+			updatedCPUs:          []int64{1, 2},
+			expectedWorkloadCPUs: []int{1, 2},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+
+		pod := createGuaranteedPod("example-pod", "abcdefg", "example-node1", "example-container-1", "performance-example-node1", 2)
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance-example-node1",
+				Epp:  "performance",
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod, powerProfile})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		fakeContainer := &podresourcesapi.ContainerResources{
+			Name:   "example-container-1",
+			CpuIds: tc.initialCPUs,
+		}
+		fakeListResponse := &podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name:       pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{fakeContainer},
+				},
+			},
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(fakeListResponse)
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+
+		// The Pod is updated with a new cpuset without its container restarting
+		fakeContainer.CpuIds = tc.updatedCPUs
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling updated Pod object", tc.testCase))
+		}
+
+		workload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{
+			Name:      "performance-example-node1-workload",
+			Namespace: PowerPodNamespace,
+		}, workload)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload", tc.testCase))
+		}
+
+		if !reflect.DeepEqual(workload.Spec.Node.CpuIds, tc.expectedWorkloadCPUs) {
+			t.Errorf("%s - Failed: Expected PowerWorkload CPUs to be %v, got %v", tc.testCase, tc.expectedWorkloadCPUs, workload.Spec.Node.CpuIds)
+		}
+
+		if len(workload.Spec.Node.Containers) != 1 || !reflect.DeepEqual(workload.Spec.Node.Containers[0].ExclusiveCPUs, tc.expectedWorkloadCPUs) {
+			t.Errorf("%s - Failed: Expected one PowerWorkload Container with CPUs %v, got %v", tc.testCase, tc.expectedWorkloadCPUs, workload.Spec.Node.Containers)
+		}
+	}
+}
+
+func TestProfileApplyDelayObserved(t *testing.T) {
+	tcases := []struct {
+		testCase             string
+		nodeName             string
+		runningFor           time.Duration
+		reconciles           int
+		expectedSampleCount  uint64
+		expectedMinimumDelay float64
+		expectedMaximumDelay float64
+	}{
+		{
+			testCase:             "Test Case 1",
+			nodeName:             "apply-delay-node1",
+			runningFor:           30 * time.Second,
+			reconciles:           1,
+			expectedSampleCount:  1,
+			expectedMinimumDelay: 30,
+			expectedMaximumDelay: 60,
+		},
+		{
+			testCase:             "Test Case 2",
+			nodeName:             "apply-delay-node2",
+			runningFor:           5 * time.Second,
+			reconciles:           2,
+			expectedSampleCount:  1,
+			expectedMinimumDelay: 5,
+			expectedMaximumDelay: 35,
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", tc.nodeName)
+		profileName := fmt.Sprintf("performance-%s", tc.nodeName)
+
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example-pod",
+				Namespace: PowerPodNamespace,
+				UID:       "abcdefg",
+			},
+			Spec: corev1.PodSpec{
+				NodeName: tc.nodeName,
+				Containers: []corev1.Container{
+					{
+						Name: "example-container-1",
+						Resources: corev1.ResourceRequirements{
+							Limits: map[corev1.ResourceName]resource.Quantity{
+								corev1.ResourceName("cpu"): *resource.NewQuantity(2, resource.DecimalSI),
+								corev1.ResourceName(fmt.Sprintf("power.intel.com/%s", profileName)): *resource.NewQuantity(2, resource.DecimalSI),
+							},
+							Requests: map[corev1.ResourceName]resource.Quantity{
+								corev1.ResourceName("cpu"): *resource.NewQuantity(2, resource.DecimalSI),
+								corev1.ResourceName(fmt.Sprintf("power.intel.com/%s", profileName)): *resource.NewQuantity(2, resource.DecimalSI),
+							},
+						},
+					},
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase:    corev1.PodRunning,
+				QOSClass: corev1.PodQOSGuaranteed,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:        "example-container-1",
+						ContainerID: "docker://abcdefg",
+						State: corev1.ContainerState{
+							Running: &corev1.ContainerStateRunning{
+								StartedAt: metav1.NewTime(time.Now().Add(-tc.runningFor)),
+							},
+						},
+					},
+				},
+			},
+		}
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      profileName,
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: profileName,
+				Epp:  "performance",
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod, powerProfile})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		fakeListResponse := &podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{1, 2},
+						},
+					},
+				},
+			},
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(fakeListResponse)
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		for i := 0; i < tc.reconciles; i++ {
+			_, err = r.Reconcile(req)
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+			}
+		}
+
+		metric := &dto.Metric{}
+		err = profileApplyDelaySeconds.WithLabelValues(tc.nodeName, profileName).(prometheus.Metric).Write(metric)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reading apply delay metric", tc.testCase))
+		}
+
+		if metric.GetHistogram().GetSampleCount() != tc.expectedSampleCount {
+			t.Errorf("%s - Failed: Expected apply delay sample count to be %v, got %v", tc.testCase, tc.expectedSampleCount, metric.GetHistogram().GetSampleCount())
+		}
+
+		delay := metric.GetHistogram().GetSampleSum()
+		if delay < tc.expectedMinimumDelay || delay > tc.expectedMaximumDelay {
+			t.Errorf("%s - Failed: Expected apply delay to be between %v and %v, got %v", tc.testCase, tc.expectedMinimumDelay, tc.expectedMaximumDelay, delay)
+		}
+	}
+}
+
+func TestOfflineCoresExcluded(t *testing.T) {
+	tcases := []struct {
+		testCase             string
+		offlineCPUs          []int
+		expectedWorkloadCPUs []int
+		expectedEvent        string
+	}{
+		{
+			testCase:             "Test Case 1",
+			offlineCPUs:          []int{2},
+			expectedWorkloadCPUs: []int{1},
+			expectedEvent:        "OfflineCPUsExcluded",
+		},
+		{
+			testCase:             "Test Case 2",
+			offlineCPUs:          []int{},
+			expectedWorkloadCPUs: []int{1, 2},
+			expectedEvent:        "",
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		cpuhotplug.CPUDevicesPath = createFakeCPUDevices(t, map[int]string{1: "1", 2: "2"})
+		for _, cpu := range tc.offlineCPUs {
+			err := cpuhotplug.SetCPUOnline(cpu, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		pod := createGuaranteedPod("example-pod", "abcdefg", "example-node1", "example-container-1", "performance-example-node1", 2)
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance-example-node1",
+				Epp:  "performance",
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod, powerProfile})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		recorder := record.NewFakeRecorder(10)
+		r.Recorder = recorder
+
+		fakeListResponse := &podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{1, 2},
+						},
+					},
+				},
+			},
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(fakeListResponse)
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+
+		workload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{
+			Name:      "performance-example-node1-workload",
+			Namespace: PowerPodNamespace,
+		}, workload)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload", tc.testCase))
+		}
+
+		if !reflect.DeepEqual(workload.Spec.Node.CpuIds, tc.expectedWorkloadCPUs) {
+			t.Errorf("%s - Failed: Expected PowerWorkload CPUs to be %v, got %v", tc.testCase, tc.expectedWorkloadCPUs, workload.Spec.Node.CpuIds)
+		}
+
+		event := ""
+		select {
+		case event = <-recorder.Events:
+		default:
+		}
+		if !strings.Contains(event, tc.expectedEvent) || (tc.expectedEvent == "") != (event == "") {
+			t.Errorf("%s - Failed: Expected Event '%s', got '%s'", tc.testCase, tc.expectedEvent, event)
+		}
+	}
+}
+
+func TestNamespaceProfileAllowlist(t *testing.T) {
+	tcases := []struct {
+		testCase          string
+		namespaceProfiles map[string][]string
+		expectedAllocated bool
+		expectedEvent     string
+	}{
+		{
+			testCase: "Test Case 1",
+			namespaceProfiles: map[string][]string{
+				PowerPodNamespace: {"performance-example-node1"},
+			},
+			expectedAllocated: true,
+			expectedEvent:     "",
+		},
+		{
+			testCase: "Test Case 2",
+			namespaceProfiles: map[string][]string{
+				PowerPodNamespace: {"balance-power-example-node1"},
+			},
+			expectedAllocated: false,
+			expectedEvent:     "PowerProfileNotAllowed",
+		},
+		{
+			testCase: "Test Case 3",
+			namespaceProfiles: map[string][]string{
+				"*": {"balance-power-example-node1"},
+			},
+			expectedAllocated: false,
+			expectedEvent:     "PowerProfileNotAllowed",
+		},
+		{
+			testCase: "Test Case 4",
+			namespaceProfiles: map[string][]string{
+				"other-namespace": {"balance-power-example-node1"},
+			},
+			expectedAllocated: true,
+			expectedEvent:     "",
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		cpuhotplug.CPUDevicesPath = createFakeCPUDevices(t, map[int]string{1: "1", 2: "2"})
+
+		pod := createGuaranteedPod("example-pod", "abcdefg", "example-node1", "example-container-1", "performance-example-node1", 2)
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance-example-node1",
+				Epp:  "performance",
+			},
+		}
+		powerConfig := &powerv1alpha1.PowerConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "power-config",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerConfigSpec{
+				NamespaceProfiles: tc.namespaceProfiles,
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod, powerProfile, powerConfig})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		recorder := record.NewFakeRecorder(10)
+		r.Recorder = recorder
+
+		fakeListResponse := &podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{1, 2},
+						},
+					},
+				},
+			},
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(fakeListResponse)
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if (err == nil) != tc.expectedAllocated {
+			t.Errorf("%s - Failed: Expected reconcile error to be %v, got %v", tc.testCase, !tc.expectedAllocated, err)
+		}
+
+		workload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{
+			Name:      "performance-example-node1-workload",
+			Namespace: PowerPodNamespace,
+		}, workload)
+		if err != nil && !errors.IsNotFound(err) {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload", tc.testCase))
+		}
+
+		if (err == nil) != tc.expectedAllocated {
+			t.Errorf("%s - Failed: Expected PowerWorkload to exist to be %v, got %v", tc.testCase, tc.expectedAllocated, err == nil)
+		}
+
+		event := ""
+		select {
+		case event = <-recorder.Events:
+		default:
+		}
+		if !strings.Contains(event, tc.expectedEvent) || (tc.expectedEvent == "") != (event == "") {
+			t.Errorf("%s - Failed: Expected Event '%s', got '%s'", tc.testCase, tc.expectedEvent, event)
+		}
+	}
+}
+
+// summaryLogger records the key/value pairs of each 'Reconcile summary' line logged at or below its verbosity
+type summaryLogger struct {
+	verbosity int
+	level     int
+	values    []interface{}
+	summaries *[]map[string]interface{}
+}
+
+func (l summaryLogger) Enabled() bool {
+	return l.level <= l.verbosity
+}
+
+func (l summaryLogger) Info(msg string, keysAndValues ...interface{}) {
+	if !l.Enabled() || msg != "Reconcile summary" {
+		return
+	}
+
+	summary := make(map[string]interface{})
+	values := append(append([]interface{}{}, l.values...), keysAndValues...)
+	for i := 0; i+1 < len(values); i += 2 {
+		summary[values[i].(string)] = values[i+1]
+	}
+	*l.summaries = append(*l.summaries, summary)
+}
+
+func (l summaryLogger) Error(err error, msg string, keysAndValues ...interface{}) {}
+
+func (l summaryLogger) V(level int) logr.Logger {
+	l.level += level
+	return l
+}
+
+func (l summaryLogger) WithValues(keysAndValues ...interface{}) logr.Logger {
+	l.values = append(append([]interface{}{}, l.values...), keysAndValues...)
+	return l
+}
+
+func (l summaryLogger) WithName(name string) logr.Logger {
+	return l
+}
+
+func TestReconcileSummaryLog(t *testing.T) {
+	tcases := []struct {
+		testCase       string
+		verbosity      int
+		expectedFields []string
+	}{
+		{
+			testCase:       "Test Case 1",
+			verbosity:      0,
+			expectedFields: []string{"powerpod", "node", "profiles", "coresAdded", "coresRemoved", "result"},
+		},
+		{
+			testCase:       "Test Case 2",
+			verbosity:      1,
+			expectedFields: []string{"powerpod", "node", "profiles", "coresAdded", "coresRemoved", "result", "containers", "requeueAfter"},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		cpuhotplug.CPUDevicesPath = createFakeCPUDevices(t, map[int]string{1: "1", 2: "2"})
+
+		pod := createGuaranteedPod("example-pod", "abcdefg", "example-node1", "example-container-1", "performance-example-node1", 2)
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance-example-node1",
+				Epp:  "performance",
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod, powerProfile})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		summaries := make([]map[string]interface{}, 0)
+		r.Log = summaryLogger{verbosity: tc.verbosity, summaries: &summaries}
+
+		fakeListResponse := &podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{1, 2},
+						},
+					},
+				},
+			},
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(fakeListResponse)
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+
+		err = r.Client.Delete(context.TODO(), pod)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error deleting Pod object", tc.testCase))
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling deleted Pod object", tc.testCase))
+		}
+
+		if len(summaries) != 2 {
+			t.Fatal(fmt.Sprintf("%s - Failed: Expected 2 summary lines, got %v", tc.testCase, len(summaries)))
+		}
+
+		for _, field := range tc.expectedFields {
+			if _, exists := summaries[0][field]; !exists {
+				t.Errorf("%s - Failed: Expected summary to have field '%s', got %v", tc.testCase, field, summaries[0])
+			}
+		}
+		if len(summaries[0]) != len(tc.expectedFields) {
+			t.Errorf("%s - Failed: Expected summary to have %v fields, got %v", tc.testCase, len(tc.expectedFields), summaries[0])
+		}
+
+		expectedAdded := map[string]interface{}{
+			"node":         "example-node1",
+			"profiles":     []string{"performance-example-node1"},
+			"coresAdded":   []int{1, 2},
+			"coresRemoved": []int{},
+			"result":       "Success",
+		}
+		expectedRemoved := map[string]interface{}{
+			"node":         "example-node1",
+			"profiles":     []string{},
+			"coresAdded":   []int{},
+			"coresRemoved": []int{1, 2},
+			"result":       "Success",
+		}
+		for i, expected := range []map[string]interface{}{expectedAdded, expectedRemoved} {
+			for field, value := range expected {
+				if !reflect.DeepEqual(summaries[i][field], value) {
+					t.Errorf("%s - Failed: Expected summary %d field '%s' to be %v, got %v", tc.testCase, i, field, value, summaries[i][field])
+				}
+			}
+		}
+	}
+}
+
+func TestCPUSetStabilization(t *testing.T) {
+	tcases := []struct {
+		testCase             string
+		cpuSets              [][]int64
+		attempts             int
+		expectedError        bool
+		expectedWorkloadCPUs []int
+		expectedResyncReads  int
+	}{
+		{
+			testCase:             "Test Case 1",
+			cpuSets:              [][]int64{{}, {1, 2}},
+			attempts:             5,
+			expectedWorkloadCPUs: []int{1, 2},
+			expectedResyncReads:  2,
+		},
+		{
+			testCase:             "Test Case 2",
+			cpuSets:              [][]int64{{}, {1}, {1, 2}},
+			attempts:             5,
+			expectedWorkloadCPUs: []int{1, 2},
+			expectedResyncReads:  2,
+		},
+		{
+			testCase:      "Test Case 3",
+			cpuSets:       [][]int64{{}},
+			attempts:      3,
+			expectedError: true,
+		},
+		{
+			testCase:             "Test Case 4",
+			cpuSets:              [][]int64{{1}, {1, 2}},
+			attempts:             1,
+			expectedWorkloadCPUs: []int{1},
+			expectedResyncReads:  2,
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		cpuhotplug.CPUDevicesPath = createFakeCPUDevices(t, map[int]string{1: "1", 2: "2"})
+
+		pod := createGuaranteedPod("example-pod", "abcdefg", "example-node1", "example-container-1", "performance-example-node1", 2)
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance-example-node1",
+				Epp:  "performance",
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod, powerProfile})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		r.CPUSetStabilizationAttempts = tc.attempts
+		r.CPUSetStabilizationInterval = time.Millisecond
+
+		podResourcesListerClient := &sequencedPodResourcesClient{}
+		for _, cpuSet := range tc.cpuSets {
+			podResourcesListerClient.listResponses = append(podResourcesListerClient.listResponses, &podresourcesapi.ListPodResourcesResponse{
+				PodResources: []*podresourcesapi.PodResources{
+					{
+						Name: pod.Name,
+						Containers: []*podresourcesapi.ContainerResources{
+							{
+								Name:   "example-container-1",
+								CpuIds: cpuSet,
+							},
+						},
+					},
+				},
+			})
+		}
+		r.PodResourcesClient = podresourcesclient.PodResourcesClient{Client: podResourcesListerClient}
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if (err != nil) != tc.expectedError {
+			t.Errorf("%s - Failed: Expected reconcile error to be %v, got %v", tc.testCase, tc.expectedError, err)
+		}
+
+		workload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{
+			Name:      "performance-example-node1-workload",
+			Namespace: PowerPodNamespace,
+		}, workload)
+		if tc.expectedError {
+			if !errors.IsNotFound(err) {
+				t.Errorf("%s - Failed: Expected no PowerWorkload to be created, got %v", tc.testCase, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload", tc.testCase))
+		}
+
+		if !reflect.DeepEqual(workload.Spec.Node.CpuIds, tc.expectedWorkloadCPUs) {
+			t.Errorf("%s - Failed: Expected PowerWorkload CPUs to be %v, got %v", tc.testCase, tc.expectedWorkloadCPUs, workload.Spec.Node.CpuIds)
+		}
+
+		// Reconciling the Pod again with its cpuset unchanged reads it only once, alongside the read of the
+		// Container's devices for the topology check
+		reads := podResourcesListerClient.calls
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+		if podResourcesListerClient.calls-reads != tc.expectedResyncReads {
+			t.Errorf("%s - Failed: Expected the unchanged cpuset to be read %d times, got %d", tc.testCase, tc.expectedResyncReads, podResourcesListerClient.calls-reads)
+		}
+	}
+}
+
+func TestAllocationWebhookNotification(t *testing.T) {
+	tcases := []struct {
+		testCase          string
+		failures          int
+		retries           int
+		expectedDelivered bool
+		expectedAttempts  int
+	}{
+		{
+			testCase:          "Test Case 1",
+			failures:          0,
+			retries:           2,
+			expectedDelivered: true,
+			expectedAttempts:  1,
+		},
+		{
+			testCase:          "Test Case 2",
+			failures:          2,
+			retries:           2,
+			expectedDelivered: true,
+			expectedAttempts:  3,
+		},
+		{
+			testCase:          "Test Case 3",
+			failures:          5,
+			retries:           1,
+			expectedDelivered: false,
+			expectedAttempts:  2,
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		cpuhotplug.CPUDevicesPath = createFakeCPUDevices(t, map[int]string{1: "1", 2: "2"})
+
+		var attemptsMutex sync.Mutex
+		attempts := 0
+		delivered := make(chan AllocationEvent, 1)
+		finished := make(chan struct{}, 10)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() { finished <- struct{}{} }()
+
+			attemptsMutex.Lock()
+			attempts++
+			attempt := attempts
+			attemptsMutex.Unlock()
+
+			if attempt <= tc.failures {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			event := AllocationEvent{}
+			_ = json.NewDecoder(r.Body).Decode(&event)
+			delivered <- event
+		}))
+
+		pod := createGuaranteedPod("example-pod", "abcdefg", "example-node1", "example-container-1", "performance-example-node1", 2)
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance-example-node1",
+				Epp:  "performance",
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod, powerProfile})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		r.AllocationNotifier = NewAllocationNotifier(server.URL, time.Second, tc.retries, time.Millisecond, ctrl.Log.WithName("allocation-notifier"))
+
+		fakeListResponse := &podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{1, 2},
+						},
+					},
+				},
+			},
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(fakeListResponse)
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			server.Close()
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+
+		// The notification is sent in the background, so wait for every expected attempt to be answered
+		for i := 0; i < tc.expectedAttempts; i++ {
+			select {
+			case <-finished:
+			case <-time.After(5 * time.Second):
+				t.Errorf("%s - Failed: Timed out waiting for notification attempt %d", tc.testCase, i+1)
+			}
+		}
+
+		event := AllocationEvent{}
+		gotEvent := false
+		select {
+		case event = <-delivered:
+			gotEvent = true
+		case <-time.After(50 * time.Millisecond):
+		}
+		server.Close()
+
+		attemptsMutex.Lock()
+		if attempts != tc.expectedAttempts {
+			t.Errorf("%s - Failed: Expected %v notification attempts, got %v", tc.testCase, tc.expectedAttempts, attempts)
+		}
+		attemptsMutex.Unlock()
+
+		if gotEvent != tc.expectedDelivered {
+			t.Fatal(fmt.Sprintf("%s - Failed: Expected notification delivered to be %v, got %v", tc.testCase, tc.expectedDelivered, gotEvent))
+		}
+		if !gotEvent {
+			continue
+		}
+
+		expectedEvent := AllocationEvent{
+			Type:      AllocationEventAllocated,
+			Node:      "example-node1",
+			Profile:   "performance-example-node1",
+			Cores:     []int{1, 2},
+			PodUID:    "abcdefg",
+			Timestamp: event.Timestamp,
+		}
+		if !reflect.DeepEqual(event, expectedEvent) {
+			t.Errorf("%s - Failed: Expected notification to be %v, got %v", tc.testCase, expectedEvent, event)
+		}
+	}
+}
+
+func TestCPUManagerPolicyCheck(t *testing.T) {
+	tcases := []struct {
+		testCase          string
+		checkPolicy       bool
+		policy            string
+		expectedAllocated bool
+		expectedEvent     string
+	}{
+		{
+			testCase:          "Test Case 1",
+			checkPolicy:       true,
+			policy:            "none",
+			expectedAllocated: false,
+			expectedEvent:     "CPUManagerPolicyNotStatic",
+		},
+		{
+			testCase:          "Test Case 2",
+			checkPolicy:       true,
+			policy:            "static",
+			expectedAllocated: true,
+			expectedEvent:     "",
+		},
+		{
+			testCase:          "Test Case 3",
+			checkPolicy:       false,
+			policy:            "none",
+			expectedAllocated: true,
+			expectedEvent:     "",
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		cpuhotplug.CPUDevicesPath = createFakeCPUDevices(t, map[int]string{1: "1", 2: "2"})
+
+		cpumanager.StatePath = filepath.Join(t.TempDir(), "cpu_manager_state")
+		state := fmt.Sprintf(`{"policyName":"%s","defaultCpuSet":"0-3","checksum":1}`, tc.policy)
+		err := ioutil.WriteFile(cpumanager.StatePath, []byte(state), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pod := createGuaranteedPod("example-pod", "abcdefg", "example-node1", "example-container-1", "performance-example-node1", 2)
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance-example-node1",
+				Epp:  "performance",
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod, powerProfile})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		r.CheckCPUManagerPolicy = tc.checkPolicy
+
+		recorder := record.NewFakeRecorder(10)
+		r.Recorder = recorder
+
+		fakeListResponse := &podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{1, 2},
+						},
+					},
+				},
+			},
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(fakeListResponse)
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+
+		workload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{
+			Name:      "performance-example-node1-workload",
+			Namespace: PowerPodNamespace,
+		}, workload)
+		if err != nil && !errors.IsNotFound(err) {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload", tc.testCase))
+		}
+
+		if (err == nil) != tc.expectedAllocated {
+			t.Errorf("%s - Failed: Expected PowerWorkload to exist to be %v, got %v", tc.testCase, tc.expectedAllocated, err == nil)
+		}
+
+		event := ""
+		select {
+		case event = <-recorder.Events:
+		default:
+		}
+		if !strings.Contains(event, tc.expectedEvent) || (tc.expectedEvent == "") != (event == "") {
+			t.Errorf("%s - Failed: Expected Event '%s', got '%s'", tc.testCase, tc.expectedEvent, event)
+		}
+	}
+}
+
+func TestProfileChangeCooldown(t *testing.T) {
+	tcases := []struct {
+		testCase         string
+		cooldown         time.Duration
+		expectedDeferred bool
+		expectedWorkload string
+	}{
+		{
+			testCase:         "Test Case 1",
+			cooldown:         time.Minute,
+			expectedDeferred: true,
+			expectedWorkload: "silver-workload",
+		},
+		{
+			testCase:         "Test Case 2",
+			cooldown:         0,
+			expectedDeferred: false,
+			expectedWorkload: "gold-workload",
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example-pod",
+				Namespace: PowerPodNamespace,
+				UID:       "abcdefg",
+				Annotations: map[string]string{
+					PowerProfileAnnotation: "gold",
+				},
+			},
+			Spec: corev1.PodSpec{
+				NodeName: "example-node1",
+				Containers: []corev1.Container{
+					{
+						Name: "example-container-1",
+						Resources: corev1.ResourceRequirements{
+							Limits: map[corev1.ResourceName]resource.Quantity{
+								corev1.ResourceName("cpu"): *resource.NewQuantity(2, resource.DecimalSI),
+							},
+							Requests: map[corev1.ResourceName]resource.Quantity{
+								corev1.ResourceName("cpu"): *resource.NewQuantity(2, resource.DecimalSI),
+							},
+						},
+					},
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase:    corev1.PodRunning,
+				QOSClass: corev1.PodQOSGuaranteed,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:        "example-container-1",
+						ContainerID: "docker://abcdefg",
+					},
+				},
+			},
+		}
+		objs := []runtime.Object{pod}
+		for _, profileName := range []string{"gold", "silver"} {
+			objs = append(objs, &powerv1alpha1.PowerProfile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      profileName,
+					Namespace: PowerPodNamespace,
+				},
+				Spec: powerv1alpha1.PowerProfileSpec{
+					Name: profileName,
+				},
+			})
+		}
+
+		r, err := createPowerPodReconcilerObject(objs)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		r.ProfileChangeCooldown = tc.cooldown
+		r.ProfileResolvers = []ProfileResolver{
+			ResourceRequestResolver{},
+			AnnotationProfileResolver{},
+		}
+
+		fakeListResponse := &podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{1, 2},
+						},
+					},
+				},
+			},
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(fakeListResponse)
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+
+		// The first switch is applied straight away, the second comes within the cooldown of the first
+		var result ctrl.Result
+		for _, profileName := range []string{"silver", "gold"} {
+			currentPod := &corev1.Pod{}
+			err = r.Client.Get(context.TODO(), req.NamespacedName, currentPod)
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error retrieving Pod object", tc.testCase))
+			}
+
+			currentPod.Annotations[PowerProfileAnnotation] = profileName
+			err = r.Client.Update(context.TODO(), currentPod)
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error updating Pod annotation", tc.testCase))
+			}
+
+			result, err = r.Reconcile(req)
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+			}
+		}
+
+		if (result.RequeueAfter > 0) != tc.expectedDeferred {
+			t.Errorf("%s - Failed: Expected second change to be deferred to be %v, got requeue after %v", tc.testCase, tc.expectedDeferred, result.RequeueAfter)
+		}
+		if tc.expectedDeferred && result.RequeueAfter > tc.cooldown {
+			t.Errorf("%s - Failed: Expected requeue within the %v cooldown, got %v", tc.testCase, tc.cooldown, result.RequeueAfter)
+		}
+
+		workload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{
+			Name:      tc.expectedWorkload,
+			Namespace: PowerPodNamespace,
+		}, workload)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload '%s'", tc.testCase, tc.expectedWorkload))
+		}
+
+		if !reflect.DeepEqual(workload.Spec.Node.CpuIds, []int{1, 2}) {
+			t.Errorf("%s - Failed: Expected PowerWorkload '%s' CpuIds to be %v, got %v", tc.testCase, tc.expectedWorkload, []int{1, 2}, workload.Spec.Node.CpuIds)
+		}
+	}
+}
+
+func TestAllocationServiceQuery(t *testing.T) {
+	tcases := []struct {
+		testCase            string
+		node                string
+		profile             string
+		expectedAllocations []*allocationapi.CoreAllocation
+	}{
+		{
+			testCase: "Test Case 1",
+			node:     "example-node1",
+			profile:  "",
+			expectedAllocations: []*allocationapi.CoreAllocation{
+				{Node: "example-node1", Core: 1, Profile: "performance-example-node1", Pod: "example-pod-1", PodUid: "abcdefg", Container: "example-container-1"},
+				{Node: "example-node1", Core: 2, Profile: "performance-example-node1", Pod: "example-pod-1", PodUid: "abcdefg", Container: "example-container-1"},
+				{Node: "example-node1", Core: 5, Profile: "balance-power-example-node1", Pod: "example-pod-1", PodUid: "abcdefg", Container: "example-container-2"},
+			},
+		},
+		{
+			testCase: "Test Case 2",
+			node:     "",
+			profile:  "performance-example-node1",
+			expectedAllocations: []*allocationapi.CoreAllocation{
+				{Node: "example-node1", Core: 1, Profile: "performance-example-node1", Pod: "example-pod-1", PodUid: "abcdefg", Container: "example-container-1"},
+				{Node: "example-node1", Core: 2, Profile: "performance-example-node1", Pod: "example-pod-1", PodUid: "abcdefg", Container: "example-container-1"},
+				{Node: "example-node2", Core: 3, Profile: "performance-example-node1", Pod: "example-pod-2", PodUid: "hijklmn", Container: "example-container-1"},
+			},
+		},
+		{
+			testCase:            "Test Case 3",
+			node:                "example-node3",
+			profile:             "",
+			expectedAllocations: []*allocationapi.CoreAllocation{},
+		},
+	}
+
+	for _, tc := range tcases {
+		state, err := podstate.NewState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		state.GuaranteedPods = []powerv1alpha1.GuaranteedPod{
+			{
+				Node: "example-node2",
+				Name: "example-pod-2",
+				UID:  "hijklmn",
+				Containers: []powerv1alpha1.Container{
+					{Name: "example-container-1", ExclusiveCPUs: []int{3}, PowerProfile: "performance-example-node1"},
+				},
+			},
+			{
+				Node: "example-node1",
+				Name: "example-pod-1",
+				UID:  "abcdefg",
+				Containers: []powerv1alpha1.Container{
+					{Name: "example-container-1", ExclusiveCPUs: []int{2, 1}, PowerProfile: "performance-example-node1"},
+					{Name: "example-container-2", ExclusiveCPUs: []int{5}, PowerProfile: "balance-power-example-node1"},
+				},
+			},
+		}
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		server := &AllocationServer{
+			State: state,
+			Log:   ctrl.Log.WithName("testing"),
+		}
+		stop := make(chan struct{})
+		go server.Serve(listener, stop)
+
+		conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+		if err != nil {
+			close(stop)
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error dialing AllocationService", tc.testCase))
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		response, err := allocationapi.NewAllocationServiceClient(conn).GetAllocations(ctx, &allocationapi.GetAllocationsRequest{
+			Node:    tc.node,
+			Profile: tc.profile,
+		})
+		cancel()
+		conn.Close()
+		close(stop)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error querying AllocationService", tc.testCase))
+		}
+
+		if len(response.GetAllocations()) != len(tc.expectedAllocations) {
+			t.Fatal(fmt.Sprintf("%s - Failed: Expected %v allocations, got %v", tc.testCase, tc.expectedAllocations, response.GetAllocations()))
+		}
+		for i, allocation := range response.GetAllocations() {
+			if !reflect.DeepEqual(*allocation, *tc.expectedAllocations[i]) {
+				t.Errorf("%s - Failed: Expected allocation %v to be %v, got %v", tc.testCase, i, tc.expectedAllocations[i], allocation)
+			}
+		}
+	}
+}
+
+func TestConflictingAnnotationAndResourceRequest(t *testing.T) {
+	tcases := []struct {
+		testCase         string
+		annotation       string
+		resolvers        []ProfileResolver
+		expectedWorkload string
+		expectedEvent    string
+	}{
+		{
+			testCase:         "Test Case 1",
+			annotation:       "balance-power-example-node1",
+			resolvers:        []ProfileResolver{ResourceRequestResolver{}, AnnotationProfileResolver{}},
+			expectedWorkload: "performance-example-node1-workload",
+			expectedEvent:    "PowerProfileConflict",
+		},
+		{
+			testCase:         "Test Case 2",
+			annotation:       "balance-power-example-node1",
+			resolvers:        []ProfileResolver{AnnotationProfileResolver{}, ResourceRequestResolver{}},
+			expectedWorkload: "performance-example-node1-workload",
+			expectedEvent:    "PowerProfileConflict",
+		},
+		{
+			testCase:         "Test Case 3",
+			annotation:       "performance-example-node1",
+			resolvers:        []ProfileResolver{ResourceRequestResolver{}, AnnotationProfileResolver{}},
+			expectedWorkload: "performance-example-node1-workload",
+			expectedEvent:    "",
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		cpuhotplug.CPUDevicesPath = createFakeCPUDevices(t, map[int]string{1: "1", 2: "2"})
+
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example-pod",
+				Namespace: PowerPodNamespace,
+				UID:       "abcdefg",
+				Annotations: map[string]string{
+					PowerProfileAnnotation: tc.annotation,
+				},
+			},
+			Spec: corev1.PodSpec{
+				NodeName: "example-node1",
+				Containers: []corev1.Container{
+					{
+						Name: "example-container-1",
+						Resources: corev1.ResourceRequirements{
+							Limits: map[corev1.ResourceName]resource.Quantity{
+								corev1.ResourceName("cpu"):                                       *resource.NewQuantity(2, resource.DecimalSI),
+								corev1.ResourceName("power.intel.com/performance-example-node1"): *resource.NewQuantity(2, resource.DecimalSI),
+							},
+							Requests: map[corev1.ResourceName]resource.Quantity{
+								corev1.ResourceName("cpu"):                                       *resource.NewQuantity(2, resource.DecimalSI),
+								corev1.ResourceName("power.intel.com/performance-example-node1"): *resource.NewQuantity(2, resource.DecimalSI),
+							},
+						},
+					},
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase:    corev1.PodRunning,
+				QOSClass: corev1.PodQOSGuaranteed,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:        "example-container-1",
+						ContainerID: "docker://abcdefg",
+					},
+				},
+			},
+		}
+		objs := []runtime.Object{pod}
+		for _, profileName := range []string{"performance-example-node1", "balance-power-example-node1"} {
+			objs = append(objs, &powerv1alpha1.PowerProfile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      profileName,
+					Namespace: PowerPodNamespace,
+				},
+				Spec: powerv1alpha1.PowerProfileSpec{
+					Name: profileName,
+				},
+			})
+		}
+
+		r, err := createPowerPodReconcilerObject(objs)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		r.ProfileResolvers = tc.resolvers
+
+		recorder := record.NewFakeRecorder(10)
+		r.Recorder = recorder
+
+		fakeListResponse := &podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{1, 2},
+						},
+					},
+				},
+			},
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(fakeListResponse)
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+
+		workloads := &powerv1alpha1.PowerWorkloadList{}
+		err = r.Client.List(context.TODO(), workloads)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkloads", tc.testCase))
+		}
+
+		if len(workloads.Items) != 1 || workloads.Items[0].Name != tc.expectedWorkload {
+			t.Errorf("%s - Failed: Expected only PowerWorkload '%s', got %v", tc.testCase, tc.expectedWorkload, workloads.Items)
+		}
+
+		event := ""
+		select {
+		case event = <-recorder.Events:
+		default:
+		}
+		if !strings.Contains(event, tc.expectedEvent) || (tc.expectedEvent == "") != (event == "") {
+			t.Errorf("%s - Failed: Expected Event '%s', got '%s'", tc.testCase, tc.expectedEvent, event)
+		}
+	}
+}
+
+func TestPodDeletionStateNodeMismatch(t *testing.T) {
+	tcases := []struct {
+		testCase               string
+		stateNode              string
+		expectedWorkloadCpuIds []int
+	}{
+		{
+			testCase:               "Test Case 1",
+			stateNode:              "example-node2",
+			expectedWorkloadCpuIds: []int{1, 2, 3},
+		},
+		{
+			testCase:               "Test Case 2",
+			stateNode:              "example-node1",
+			expectedWorkloadCpuIds: []int{3},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+
+		now := metav1.Now()
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "example-pod",
+				Namespace:         PowerPodNamespace,
+				UID:               "abcdefg",
+				DeletionTimestamp: &now,
+			},
+			Spec: corev1.PodSpec{
+				NodeName: "example-node1",
+			},
+		}
+		workload := &powerv1alpha1.PowerWorkload{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1-workload",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerWorkloadSpec{
+				Name:         "performance-example-node1-workload",
+				PowerProfile: "performance-example-node1",
+				Node: powerv1alpha1.NodeInfo{
+					Name: "example-node1",
+					Containers: []powerv1alpha1.Container{
+						{
+							Name:          "example-container-1",
+							Pod:           "example-pod",
+							ExclusiveCPUs: []int{1, 2},
+							PowerProfile:  "performance-example-node1",
+						},
+						{
+							Name:          "example-container-1",
+							Pod:           "other-pod",
+							ExclusiveCPUs: []int{3},
+							PowerProfile:  "performance-example-node1",
+						},
+					},
+					CpuIds: []int{1, 2, 3},
+				},
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod, workload})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		err = r.State.UpdateStateGuaranteedPods(powerv1alpha1.GuaranteedPod{
+			Node: tc.stateNode,
+			Name: "example-pod",
+			UID:  "abcdefg",
+			Containers: []powerv1alpha1.Container{
+				{
+					Name:          "example-container-1",
+					ExclusiveCPUs: []int{1, 2},
+					PowerProfile:  "performance-example-node1",
+				},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+
+		updatedWorkload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{
+			Name:      "performance-example-node1-workload",
+			Namespace: PowerPodNamespace,
+		}, updatedWorkload)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload", tc.testCase))
+		}
+
+		if !reflect.DeepEqual(updatedWorkload.Spec.Node.CpuIds, tc.expectedWorkloadCpuIds) {
+			t.Errorf("%s - Failed: Expected PowerWorkload CpuIds to be %v, got %v", tc.testCase, tc.expectedWorkloadCpuIds, updatedWorkload.Spec.Node.CpuIds)
+		}
+
+		if r.State.GetPodFromState(pod.Name).Name != "" {
+			t.Errorf("%s - Failed: Expected Pod to be removed from internal state", tc.testCase)
+		}
+	}
+}
+
+func TestWorkloadSharding(t *testing.T) {
+	tcases := []struct {
+		testCase         string
+		maxWorkloadNodes int
+		staleList        bool
+		expectedShards   map[string]string
+	}{
+		{
+			testCase:         "Test Case 1",
+			maxWorkloadNodes: 2,
+			expectedShards: map[string]string{
+				"gold-workload":         "example-node1",
+				"gold-shard-1-workload": "example-node2",
+				"gold-shard-2-workload": "example-node3",
+			},
+		},
+		{
+			testCase:         "Test Case 2",
+			maxWorkloadNodes: 1,
+			expectedShards: map[string]string{
+				"gold-workload":         "example-node1",
+				"gold-shard-1-workload": "example-node2",
+				"gold-shard-2-workload": "example-node3",
+			},
+		},
+		{
+			testCase:         "Test Case 3",
+			maxWorkloadNodes: 0,
+			expectedShards: map[string]string{
+				"gold-workload": "example-node1,example-node2,example-node3",
+			},
+		},
+		{
+			testCase:         "Test Case 4 - Shards taken since they were listed are passed over",
+			maxWorkloadNodes: 1,
+			staleList:        true,
+			expectedShards: map[string]string{
+				"gold-workload":         "example-node1",
+				"gold-shard-1-workload": "example-node2",
+				"gold-shard-2-workload": "example-node3",
+			},
+		},
+	}
+
+	nodes := []string{"example-node1", "example-node2", "example-node3"}
+	for _, tc := range tcases {
+		objs := []runtime.Object{
+			&powerv1alpha1.PowerProfile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "gold",
+					Namespace: PowerPodNamespace,
+				},
+				Spec: powerv1alpha1.PowerProfileSpec{
+					Name: "gold",
+					Epp:  "performance",
+				},
+			},
+		}
+		podResources := make([]*podresourcesapi.PodResources, 0)
+		for i, node := range nodes {
+			podName := fmt.Sprintf("example-pod-%d", i+1)
+			objs = append(objs, &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      podName,
+					Namespace: PowerPodNamespace,
+					UID:       types.UID(podName),
+				},
+				Spec: corev1.PodSpec{
+					NodeName: node,
+					Containers: []corev1.Container{
+						{
+							Name: "example-container-1",
+							Resources: corev1.ResourceRequirements{
+								Limits: map[corev1.ResourceName]resource.Quantity{
+									corev1.ResourceName("cpu"):                  *resource.NewQuantity(2, resource.DecimalSI),
+									corev1.ResourceName("power.intel.com/gold"): *resource.NewQuantity(2, resource.DecimalSI),
+								},
+								Requests: map[corev1.ResourceName]resource.Quantity{
+									corev1.ResourceName("cpu"):                  *resource.NewQuantity(2, resource.DecimalSI),
+									corev1.ResourceName("power.intel.com/gold"): *resource.NewQuantity(2, resource.DecimalSI),
+								},
+							},
+						},
+					},
+				},
+				Status: corev1.PodStatus{
+					Phase:    corev1.PodRunning,
+					QOSClass: corev1.PodQOSGuaranteed,
+					ContainerStatuses: []corev1.ContainerStatus{
+						{
+							Name:        "example-container-1",
+							ContainerID: "docker://abcdefg",
+						},
+					},
+				},
+			})
+			podResources = append(podResources, &podresourcesapi.PodResources{
+				Name: podName,
+				Containers: []*podresourcesapi.ContainerResources{
+					{
+						Name:   "example-container-1",
+						CpuIds: []int64{int64(2*i + 1), int64(2*i + 2)},
+					},
+				},
+			})
+		}
+
+		r, err := createPowerPodReconcilerObject(objs)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		r.MaxWorkloadNodes = tc.maxWorkloadNodes
+		workloadClient := r.Client
+		if tc.staleList {
+			r.Client = &staleListWorkloadClient{Client: workloadClient}
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(&podresourcesapi.ListPodResourcesResponse{PodResources: podResources})
+
+		// Each Node's agent reconciles its own Pod with its own State. The first Node reconciles again at the
+		// end to check it stays in the shard it was first given
+		for _, i := range []int{0, 1, 2, 0} {
+			t.Setenv("NODE_NAME", nodes[i])
+			state, err := podstate.NewState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.State = state
+
+			req := reconcile.Request{
+				NamespacedName: client.ObjectKey{
+					Name:      fmt.Sprintf("example-pod-%d", i+1),
+					Namespace: PowerPodNamespace,
+				},
+			}
+
+			_, err = r.Reconcile(req)
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+			}
+		}
+
+		workloads := &powerv1alpha1.PowerWorkloadList{}
+		err = workloadClient.List(context.TODO(), workloads)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkloads", tc.testCase))
+		}
+
+		shards := make(map[string]string)
+		for _, workload := range workloads.Items {
+			shards[workload.Name] = workload.Annotations[WorkloadNodesAnnotation]
+		}
+		if !reflect.DeepEqual(shards, tc.expectedShards) {
+			t.Errorf("%s - Failed: Expected PowerWorkload shards to be %v, got %v", tc.testCase, tc.expectedShards, shards)
+		}
+	}
+}
+
+func TestCgroupCPUSetVerification(t *testing.T) {
+	tcases := []struct {
+		testCase      string
+		verifyCPUSet  bool
+		cgroupCPUs    string
+		expectedEvent string
+	}{
+		{
+			testCase:      "Test Case 1",
+			verifyCPUSet:  true,
+			cgroupCPUs:    "1-2",
+			expectedEvent: "",
+		},
+		{
+			testCase:      "Test Case 2",
+			verifyCPUSet:  true,
+			cgroupCPUs:    "3-4",
+			expectedEvent: "CPUSetMismatch",
+		},
+		{
+			testCase:      "Test Case 3",
+			verifyCPUSet:  false,
+			cgroupCPUs:    "3-4",
+			expectedEvent: "",
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		cpuhotplug.CPUDevicesPath = createFakeCPUDevices(t, map[int]string{1: "1", 2: "2"})
+
+		cgroup.CgroupPath = t.TempDir()
+		cpusetDir := filepath.Join(cgroup.CgroupPath, "cpuset", "kubepods", "podabcdefg", "abcdefg")
+		err := os.MkdirAll(cpusetDir, 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(filepath.Join(cpusetDir, "cpuset.cpus"), []byte(tc.cgroupCPUs+"\n"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pod := createGuaranteedPod("example-pod", "abcdefg", "example-node1", "example-container-1", "performance-example-node1", 2)
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance-example-node1",
+				Epp:  "performance",
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod, powerProfile})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		r.VerifyCgroupCPUSet = tc.verifyCPUSet
+
+		recorder := record.NewFakeRecorder(10)
+		r.Recorder = recorder
+
+		fakeListResponse := &podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{1, 2},
+						},
+					},
+				},
+			},
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(fakeListResponse)
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+
+		workload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{
+			Name:      "performance-example-node1-workload",
+			Namespace: PowerPodNamespace,
+		}, workload)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload", tc.testCase))
+		}
+
+		if !reflect.DeepEqual(workload.Spec.Node.CpuIds, []int{1, 2}) {
+			t.Errorf("%s - Failed: Expected PowerWorkload CpuIds to be %v, got %v", tc.testCase, []int{1, 2}, workload.Spec.Node.CpuIds)
+		}
+
+		event := ""
+		select {
+		case event = <-recorder.Events:
+		default:
+		}
+		if !strings.Contains(event, tc.expectedEvent) || (tc.expectedEvent == "") != (event == "") {
+			t.Errorf("%s - Failed: Expected Event '%s', got '%s'", tc.testCase, tc.expectedEvent, event)
+		}
+	}
+}
+
+func TestInitContainerProfile(t *testing.T) {
+	tcases := []struct {
+		testCase                  string
+		initProfile               string
+		mainProfile               string
+		initCPUs                  []int64
+		mainCPUs                  []int64
+		expectedInitWorkloadCPUs  map[string][]int
+		expectedFinalWorkloadCPUs map[string][]int
+	}{
+		{
+			testCase:    "Test Case 1",
+			initProfile: "performance-example-node1",
+			mainProfile: "balance-power-example-node1",
+			initCPUs:    []int64{1, 2},
+			mainCPUs:    []int64{3, 4},
+			expectedInitWorkloadCPUs: map[string][]int{
+				"performance-example-node1-workload": {1, 2},
+			},
+			expectedFinalWorkloadCPUs: map[string][]int{
+				"performance-example-node1-workload":   nil,
+				"balance-power-example-node1-workload": {3, 4},
+			},
+		},
+		{
+			testCase:    "Test Case 2",
+			initProfile: "performance-example-node1",
+			mainProfile: "performance-example-node1",
+			initCPUs:    []int64{1, 2},
+			mainCPUs:    []int64{1, 2},
+			expectedInitWorkloadCPUs: map[string][]int{
+				"performance-example-node1-workload": {1, 2},
+			},
+			expectedFinalWorkloadCPUs: map[string][]int{
+				"performance-example-node1-workload": {1, 2},
+			},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		cpuhotplug.CPUDevicesPath = createFakeCPUDevices(t, map[int]string{1: "1", 2: "2", 3: "3", 4: "4"})
+
+		containerResources := func(profile string) corev1.ResourceRequirements {
+			return corev1.ResourceRequirements{
+				Limits: map[corev1.ResourceName]resource.Quantity{
+					corev1.ResourceName("cpu"):                    *resource.NewQuantity(2, resource.DecimalSI),
+					corev1.ResourceName(ResourcePrefix + profile): *resource.NewQuantity(2, resource.DecimalSI),
+				},
+				Requests: map[corev1.ResourceName]resource.Quantity{
+					corev1.ResourceName("cpu"):                    *resource.NewQuantity(2, resource.DecimalSI),
+					corev1.ResourceName(ResourcePrefix + profile): *resource.NewQuantity(2, resource.DecimalSI),
+				},
+			}
+		}
+
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example-pod",
+				Namespace: PowerPodNamespace,
+				UID:       "abcdefg",
+			},
+			Spec: corev1.PodSpec{
+				NodeName: "example-node1",
+				InitContainers: []corev1.Container{
+					{
+						Name:      "example-init-container",
+						Resources: containerResources(tc.initProfile),
+					},
+				},
+				Containers: []corev1.Container{
+					{
+						Name:      "example-container-1",
+						Resources: containerResources(tc.mainProfile),
+					},
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase:    corev1.PodPending,
+				QOSClass: corev1.PodQOSGuaranteed,
+				InitContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:        "example-init-container",
+						ContainerID: "docker://init",
+						State: corev1.ContainerState{
+							Running: &corev1.ContainerStateRunning{},
+						},
+					},
+				},
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name: "example-container-1",
+						State: corev1.ContainerState{
+							Waiting: &corev1.ContainerStateWaiting{Reason: "PodInitializing"},
+						},
+					},
+				},
+			},
+		}
+		objs := []runtime.Object{pod}
+		for profile := range map[string]bool{tc.initProfile: true, tc.mainProfile: true} {
+			objs = append(objs, &powerv1alpha1.PowerProfile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      profile,
+					Namespace: PowerPodNamespace,
+				},
+				Spec: powerv1alpha1.PowerProfileSpec{
+					Name: profile,
+				},
+			})
+		}
+
+		r, err := createPowerPodReconcilerObject(objs)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		checkWorkloads := func(phase string, expectedWorkloadCPUs map[string][]int) {
+			for workloadName, expectedCPUs := range expectedWorkloadCPUs {
+				workload := &powerv1alpha1.PowerWorkload{}
+				err = r.Client.Get(context.TODO(), client.ObjectKey{
+					Name:      workloadName,
+					Namespace: PowerPodNamespace,
+				}, workload)
+				if expectedCPUs == nil {
+					if !errors.IsNotFound(err) {
+						t.Errorf("%s - Failed: Expected PowerWorkload '%s' to be deleted after %s, got %v", tc.testCase, workloadName, phase, workload.Spec.Node.CpuIds)
+					}
+					continue
+				}
+				if err != nil {
+					t.Error(err)
+					t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload", tc.testCase))
+				}
+
+				if !reflect.DeepEqual(workload.Spec.Node.CpuIds, expectedCPUs) {
+					t.Errorf("%s - Failed: Expected PowerWorkload '%s' CpuIds after %s to be %v, got %v", tc.testCase, workloadName, phase, expectedCPUs, workload.Spec.Node.CpuIds)
+				}
+			}
+		}
+
+		// Init phase: only the init container has cores
+		r.PodResourcesClient = *createFakePodResourcesListerClient(&podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-init-container",
+							CpuIds: tc.initCPUs,
+						},
+					},
+				},
+			},
+		})
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object during init", tc.testCase))
+		}
+		checkWorkloads("init", tc.expectedInitWorkloadCPUs)
+
+		// Init completes and the app container starts
+		pod.Status.Phase = corev1.PodRunning
+		pod.Status.InitContainerStatuses[0].State = corev1.ContainerState{
+			Terminated: &corev1.ContainerStateTerminated{Reason: "Completed"},
+		}
+		pod.Status.ContainerStatuses[0].ContainerID = "docker://main"
+		pod.Status.ContainerStatuses[0].State = corev1.ContainerState{
+			Running: &corev1.ContainerStateRunning{},
+		}
+		err = r.Client.Update(context.TODO(), pod)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r.PodResourcesClient = *createFakePodResourcesListerClient(&podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: tc.mainCPUs,
+						},
+					},
+				},
+			},
+		})
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object after init", tc.testCase))
+		}
+		checkWorkloads("init completed", tc.expectedFinalWorkloadCPUs)
+
+		powerPodState := r.State.GetPodFromState(pod.Name)
+		if len(powerPodState.Containers) != 1 || powerPodState.Containers[0].Name != "example-container-1" {
+			t.Errorf("%s - Failed: Expected only the app container in the internal state, got %v", tc.testCase, powerPodState.Containers)
+		}
+	}
+}
+
+func TestStaleMultiProfileAnnotationRemoved(t *testing.T) {
+	tcases := []struct {
+		testCase    string
+		annotations map[string]string
+	}{
+		{
+			testCase: "Test Case 1 - Annotated by an earlier Node Agent",
+			annotations: map[string]string{
+				MultiProfileAnnotation: "balance-performance-example-node1,performance-example-node1",
+			},
+		},
+		{
+			testCase:    "Test Case 2 - Not annotated",
+			annotations: map[string]string{},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		cpuhotplug.CPUDevicesPath = createFakeCPUDevices(t, map[int]string{1: "1", 2: "2", 3: "3", 4: "4"})
+
+		containerResources := func(profile string) corev1.ResourceRequirements {
+			return corev1.ResourceRequirements{
+				Limits: map[corev1.ResourceName]resource.Quantity{
+					corev1.ResourceName("cpu"):                    *resource.NewQuantity(2, resource.DecimalSI),
+					corev1.ResourceName(ResourcePrefix + profile): *resource.NewQuantity(2, resource.DecimalSI),
+				},
+				Requests: map[corev1.ResourceName]resource.Quantity{
+					corev1.ResourceName("cpu"):                    *resource.NewQuantity(2, resource.DecimalSI),
+					corev1.ResourceName(ResourcePrefix + profile): *resource.NewQuantity(2, resource.DecimalSI),
+				},
+			}
+		}
+
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "example-pod",
+				Namespace:   PowerPodNamespace,
+				UID:         "abcdefg",
+				Annotations: tc.annotations,
+			},
+			Spec: corev1.PodSpec{
+				NodeName: "example-node1",
+				Containers: []corev1.Container{
+					{
+						Name:      "example-container-1",
+						Resources: containerResources("performance-example-node1"),
+					},
+					{
+						Name:      "example-container-2",
+						Resources: containerResources("balance-performance-example-node1"),
+					},
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase:    corev1.PodRunning,
+				QOSClass: corev1.PodQOSGuaranteed,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:        "example-container-1",
+						ContainerID: "docker://abcdefg",
+					},
+					{
+						Name:        "example-container-2",
+						ContainerID: "docker://hijklmn",
+					},
+				},
+			},
+		}
+		objs := []runtime.Object{pod}
+		for _, profile := range []string{"performance-example-node1", "balance-performance-example-node1"} {
+			objs = append(objs, &powerv1alpha1.PowerProfile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      profile,
+					Namespace: PowerPodNamespace,
+				},
+				Spec: powerv1alpha1.PowerProfileSpec{
+					Name: profile,
+				},
+			})
+		}
+
+		r, err := createPowerPodReconcilerObject(objs)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(&podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{1, 2},
+						},
+						{
+							Name:   "example-container-2",
+							CpuIds: []int64{3, 4},
+						},
+					},
+				},
+			},
+		})
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+
+		updatedPod := &corev1.Pod{}
+		err = r.Client.Get(context.TODO(), req.NamespacedName, updatedPod)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if annotation, exists := updatedPod.GetAnnotations()[MultiProfileAnnotation]; exists {
+			t.Errorf("%s - Failed: Expected annotation to be removed, got '%s'", tc.testCase, annotation)
+		}
+
+		powerWorkloads := &powerv1alpha1.PowerWorkloadList{}
+		err = r.Client.List(context.TODO(), powerWorkloads)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(powerWorkloads.Items) != 2 {
+			t.Errorf("%s - Failed: Expected a PowerWorkload for each PowerProfile, got %v", tc.testCase, len(powerWorkloads.Items))
+		}
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	tcases := []struct {
+		testCase       string
+		retryBudget    int
+		changePod      func(pod *corev1.Pod)
+		expectedErrors []bool
+	}{
+		{
+			testCase:    "Test Case 1",
+			retryBudget: 3,
+			changePod: func(pod *corev1.Pod) {
+				pod.Annotations["example-annotation"] = "changed"
+			},
+			expectedErrors: []bool{true, true, false, false},
+		},
+		{
+			testCase:    "Test Case 2",
+			retryBudget: 3,
+			changePod: func(pod *corev1.Pod) {
+				delete(pod.Annotations, RetriesExhaustedAnnotation)
+			},
+			expectedErrors: []bool{true, true, false, false},
+		},
+		{
+			testCase:       "Test Case 3",
+			retryBudget:    0,
+			changePod:      func(pod *corev1.Pod) {},
+			expectedErrors: []bool{true, true, true, true},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		cpuhotplug.CPUDevicesPath = createFakeCPUDevices(t, map[int]string{1: "1", 2: "2"})
+
+		pod := createGuaranteedPod("example-pod", "abcdefg", "example-node1", "example-container-1", "performance-example-node1", 2)
+
+		// The PowerProfile doesn't exist yet, so every reconcile fails
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		r.RetryBudget = tc.retryBudget
+
+		recorder := record.NewFakeRecorder(20)
+		r.Recorder = recorder
+
+		r.PodResourcesClient = *createFakePodResourcesListerClient(&podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{1, 2},
+						},
+					},
+				},
+			},
+		})
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		for i, expectedError := range tc.expectedErrors {
+			_, err = r.Reconcile(req)
+			if (err != nil) != expectedError {
+				t.Errorf("%s - Failed: Expected reconcile %d to return an error to be %v, got %v", tc.testCase, i+1, expectedError, err)
+			}
+		}
+
+		exhaustedEvents := 0
+		profileMissingEvents := 0
+		for len(recorder.Events) > 0 {
+			event := <-recorder.Events
+			if strings.Contains(event, "RetryBudgetExhausted") {
+				exhaustedEvents++
+			}
+			if strings.Contains(event, "PowerProfileCRMissing") {
+				profileMissingEvents++
+			}
+		}
+		expectedExhaustedEvents := 0
+		expectedProfileMissingEvents := len(tc.expectedErrors)
+		if tc.retryBudget > 0 {
+			expectedExhaustedEvents = 1
+			expectedProfileMissingEvents = tc.retryBudget
+		}
+		if exhaustedEvents != expectedExhaustedEvents {
+			t.Errorf("%s - Failed: Expected %d RetryBudgetExhausted Events, got %d", tc.testCase, expectedExhaustedEvents, exhaustedEvents)
+		}
+		if profileMissingEvents != expectedProfileMissingEvents {
+			t.Errorf("%s - Failed: Expected %d reconcile attempts, got %d", tc.testCase, expectedProfileMissingEvents, profileMissingEvents)
+		}
+
+		err = r.Client.Get(context.TODO(), req.NamespacedName, pod)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, annotated := pod.Annotations[RetriesExhaustedAnnotation]
+		if annotated != (tc.retryBudget > 0) {
+			t.Errorf("%s - Failed: Expected Pod to be annotated as exhausted to be %v, got %v", tc.testCase, tc.retryBudget > 0, annotated)
+		}
+
+		// Creating the PowerProfile and changing the Pod lets it be reconciled again
+		err = r.Client.Create(context.TODO(), &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance-example-node1",
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tc.changePod(pod)
+		err = r.Client.Update(context.TODO(), pod)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object after it changed", tc.testCase))
+		}
+
+		workload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{
+			Name:      "performance-example-node1-workload",
+			Namespace: PowerPodNamespace,
+		}, workload)
+		if err != nil {
+			t.Errorf("%s - Failed: Expected PowerWorkload to be created once the Pod changed, got %v", tc.testCase, err)
+		}
+
+		updatedPod := &corev1.Pod{}
+		err = r.Client.Get(context.TODO(), req.NamespacedName, updatedPod)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, annotated := updatedPod.Annotations[RetriesExhaustedAnnotation]; annotated {
+			t.Errorf("%s - Failed: Expected '%s' annotation to be removed once the Pod changed", tc.testCase, RetriesExhaustedAnnotation)
+		}
+	}
+}
+
+func TestAllocationsDebugHandler(t *testing.T) {
+	tcases := []struct {
+		testCase       string
+		guaranteedPods []powerv1alpha1.GuaranteedPod
+		parkedSiblings map[string][]int
+	}{
+		{
+			testCase:       "Test Case 1",
+			guaranteedPods: []powerv1alpha1.GuaranteedPod{},
+			parkedSiblings: map[string][]int{},
+		},
+		{
+			testCase: "Test Case 2",
+			guaranteedPods: []powerv1alpha1.GuaranteedPod{
+				{
+					Node: "example-node1",
+					Name: "example-pod",
+					UID:  "abcdefg",
+					Containers: []powerv1alpha1.Container{
+						{
+							Name:          "example-container-1",
+							Id:            "abcdefg",
+							Pod:           "example-pod",
+							ExclusiveCPUs: []int{1, 2},
+							PowerProfile:  "performance-example-node1",
+							Workload:      "performance-example-node1-workload",
+						},
+						{
+							Name:          "example-container-2",
+							Id:            "hijklmn",
+							Pod:           "example-pod",
+							ExclusiveCPUs: []int{3, 4},
+							PowerProfile:  "performance-example-node1",
+							Workload:      "performance-example-node1-workload",
+						},
+					},
+				},
+			},
+			parkedSiblings: map[string][]int{
+				"example-pod": {5, 6},
+			},
+		},
+	}
+
+	for _, tc := range tcases {
+		state, err := podstate.NewState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, pod := range tc.guaranteedPods {
+			err = state.UpdateStateGuaranteedPods(pod)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		for pod, cpus := range tc.parkedSiblings {
+			state.UpdateParkedSiblings(pod, cpus)
+		}
+
+		recorder := httptest.NewRecorder()
+		NewAllocationsDebugHandler(state).ServeHTTP(recorder, httptest.NewRequest("GET", AllocationsDebugPath, nil))
+
+		if recorder.Code != http.StatusOK {
+			t.Errorf("%s - Failed: Expected status %d, got %d", tc.testCase, http.StatusOK, recorder.Code)
+		}
+		if recorder.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("%s - Failed: Expected JSON content type, got '%s'", tc.testCase, recorder.Header().Get("Content-Type"))
+		}
+
+		snapshot := &podstate.State{}
+		err = json.Unmarshal(recorder.Body.Bytes(), snapshot)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error decoding allocations snapshot", tc.testCase))
+		}
+
+		if !reflect.DeepEqual(snapshot.GuaranteedPods, tc.guaranteedPods) {
+			t.Errorf("%s - Failed: Expected GuaranteedPods to be %v, got %v", tc.testCase, tc.guaranteedPods, snapshot.GuaranteedPods)
+		}
+		if !reflect.DeepEqual(snapshot.ParkedSiblings, tc.parkedSiblings) {
+			t.Errorf("%s - Failed: Expected ParkedSiblings to be %v, got %v", tc.testCase, tc.parkedSiblings, snapshot.ParkedSiblings)
+		}
+	}
+}
+
+func TestPowerProfileQuantityMismatch(t *testing.T) {
+	tcases := []struct {
+		testCase          string
+		cpuQuantity       int64
+		profileQuantity   int64
+		expectedAllocated bool
+		expectedEvent     string
+	}{
+		{
+			testCase:          "Test Case 1",
+			cpuQuantity:       4,
+			profileQuantity:   2,
+			expectedAllocated: false,
+			expectedEvent:     "PowerProfileQuantityMismatch",
+		},
+		{
+			testCase:          "Test Case 2",
+			cpuQuantity:       1,
+			profileQuantity:   2,
+			expectedAllocated: false,
+			expectedEvent:     "PowerProfileQuantityMismatch",
+		},
+		{
+			testCase:          "Test Case 3",
+			cpuQuantity:       2,
+			profileQuantity:   2,
+			expectedAllocated: true,
+			expectedEvent:     "",
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		cpuhotplug.CPUDevicesPath = createFakeCPUDevices(t, map[int]string{1: "1", 2: "2"})
+
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example-pod",
+				Namespace: PowerPodNamespace,
+				UID:       "abcdefg",
+			},
+			Spec: corev1.PodSpec{
+				NodeName: "example-node1",
+				Containers: []corev1.Container{
+					{
+						Name: "example-container-1",
+						Resources: corev1.ResourceRequirements{
+							Limits: map[corev1.ResourceName]resource.Quantity{
+								corev1.ResourceName("cpu"):                                       *resource.NewQuantity(tc.cpuQuantity, resource.DecimalSI),
+								corev1.ResourceName("power.intel.com/performance-example-node1"): *resource.NewQuantity(tc.profileQuantity, resource.DecimalSI),
+							},
+							Requests: map[corev1.ResourceName]resource.Quantity{
+								corev1.ResourceName("cpu"):                                       *resource.NewQuantity(tc.cpuQuantity, resource.DecimalSI),
+								corev1.ResourceName("power.intel.com/performance-example-node1"): *resource.NewQuantity(tc.profileQuantity, resource.DecimalSI),
+							},
+						},
+					},
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase:    corev1.PodRunning,
+				QOSClass: corev1.PodQOSGuaranteed,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:        "example-container-1",
+						ContainerID: "docker://abcdefg",
+					},
+				},
+			},
+		}
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance-example-node1",
+				Epp:  "performance",
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod, powerProfile})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		recorder := record.NewFakeRecorder(10)
+		r.Recorder = recorder
+
+		r.PodResourcesClient = *createFakePodResourcesListerClient(&podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{1, 2},
+						},
+					},
+				},
+			},
+		})
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if (err == nil) != tc.expectedAllocated {
+			t.Errorf("%s - Failed: Expected reconcile to succeed to be %v, got %v", tc.testCase, tc.expectedAllocated, err)
+		}
+		if err != nil && !errors.IsServiceUnavailable(err) {
+			t.Errorf("%s - Failed: Expected ServiceUnavailable error, got %v", tc.testCase, err)
+		}
+
+		workload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{
+			Name:      "performance-example-node1-workload",
+			Namespace: PowerPodNamespace,
+		}, workload)
+		if err != nil && !errors.IsNotFound(err) {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload", tc.testCase))
+		}
+		if (err == nil) != tc.expectedAllocated {
+			t.Errorf("%s - Failed: Expected PowerWorkload to exist to be %v, got %v", tc.testCase, tc.expectedAllocated, err == nil)
+		}
+
+		event := ""
+		select {
+		case event = <-recorder.Events:
+		default:
+		}
+		if !strings.Contains(event, tc.expectedEvent) || (tc.expectedEvent == "") != (event == "") {
+			t.Errorf("%s - Failed: Expected Event '%s', got '%s'", tc.testCase, tc.expectedEvent, event)
+		}
+	}
+}
+
+func TestEphemeralContainerProfile(t *testing.T) {
+	tcases := []struct {
+		testCase                string
+		manageEphemeral         bool
+		expectedRunningCPUs     []int
+		expectedStateContainers int
+	}{
+		{
+			testCase:                "Test Case 1",
+			manageEphemeral:         true,
+			expectedRunningCPUs:     []int{1, 2},
+			expectedStateContainers: 1,
+		},
+		{
+			testCase:                "Test Case 2",
+			manageEphemeral:         false,
+			expectedRunningCPUs:     nil,
+			expectedStateContainers: 0,
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		cpuhotplug.CPUDevicesPath = createFakeCPUDevices(t, map[int]string{1: "1", 2: "2", 3: "3", 4: "4"})
+
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example-pod",
+				Namespace: PowerPodNamespace,
+				UID:       "abcdefg",
+			},
+			Spec: corev1.PodSpec{
+				NodeName: "example-node1",
+				Containers: []corev1.Container{
+					{
+						Name: "example-container-1",
+						Resources: corev1.ResourceRequirements{
+							Limits: map[corev1.ResourceName]resource.Quantity{
+								corev1.ResourceName("cpu"): resource.MustParse("500m"),
+							},
+							Requests: map[corev1.ResourceName]resource.Quantity{
+								corev1.ResourceName("cpu"): resource.MustParse("500m"),
+							},
+						},
+					},
+				},
+				EphemeralContainers: []corev1.EphemeralContainer{
+					{
+						EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+							Name: "example-debug-container",
+							Resources: corev1.ResourceRequirements{
+								Limits: map[corev1.ResourceName]resource.Quantity{
+									corev1.ResourceName("cpu"): *resource.NewQuantity(2, resource.DecimalSI),
+									corev1.ResourceName(ResourcePrefix + "performance-example-node1"): *resource.NewQuantity(2, resource.DecimalSI),
+								},
+								Requests: map[corev1.ResourceName]resource.Quantity{
+									corev1.ResourceName("cpu"): *resource.NewQuantity(2, resource.DecimalSI),
+									corev1.ResourceName(ResourcePrefix + "performance-example-node1"): *resource.NewQuantity(2, resource.DecimalSI),
+								},
+							},
+						},
+					},
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase:    corev1.PodRunning,
+				QOSClass: corev1.PodQOSGuaranteed,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:        "example-container-1",
+						ContainerID: "docker://main",
+						State: corev1.ContainerState{
+							Running: &corev1.ContainerStateRunning{},
+						},
+					},
+				},
+				EphemeralContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:        "example-debug-container",
+						ContainerID: "docker://debug",
+						State: corev1.ContainerState{
+							Running: &corev1.ContainerStateRunning{},
+						},
+					},
+				},
+			},
+		}
+		objs := []runtime.Object{
+			pod,
+			&powerv1alpha1.PowerProfile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "performance-example-node1",
+					Namespace: PowerPodNamespace,
+				},
+				Spec: powerv1alpha1.PowerProfileSpec{
+					Name: "performance-example-node1",
+				},
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject(objs)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		r.ManageEphemeralContainers = tc.manageEphemeral
+		r.PodResourcesClient = *createFakePodResourcesListerClient(&podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-debug-container",
+							CpuIds: []int64{1, 2},
+						},
+					},
+				},
+			},
+		})
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		checkWorkload := func(phase string, expectedCPUs []int) {
+			workload := &powerv1alpha1.PowerWorkload{}
+			err = r.Client.Get(context.TODO(), client.ObjectKey{
+				Name:      "performance-example-node1-workload",
+				Namespace: PowerPodNamespace,
+			}, workload)
+			if expectedCPUs == nil {
+				if !errors.IsNotFound(err) {
+					t.Errorf("%s - Failed: Expected no PowerWorkload %s, got %v", tc.testCase, phase, workload.Spec.Node.CpuIds)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload", tc.testCase))
+			}
+
+			if !reflect.DeepEqual(workload.Spec.Node.CpuIds, expectedCPUs) {
+				t.Errorf("%s - Failed: Expected PowerWorkload CpuIds %s to be %v, got %v", tc.testCase, phase, expectedCPUs, workload.Spec.Node.CpuIds)
+			}
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+		checkWorkload("while the ephemeral container runs", tc.expectedRunningCPUs)
+
+		powerPodState := r.State.GetPodFromState(pod.Name)
+		if len(powerPodState.Containers) != tc.expectedStateContainers {
+			t.Errorf("%s - Failed: Expected %v Containers in the internal state, got %v", tc.testCase, tc.expectedStateContainers, powerPodState.Containers)
+		}
+
+		// The ephemeral container exits, handing its cores back
+		pod.Status.EphemeralContainerStatuses[0].State = corev1.ContainerState{
+			Terminated: &corev1.ContainerStateTerminated{Reason: "Completed"},
+		}
+		err = r.Client.Update(context.TODO(), pod)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object after the ephemeral container exited", tc.testCase))
+		}
+		checkWorkload("after the ephemeral container exited", nil)
+
+		powerPodState = r.State.GetPodFromState(pod.Name)
+		if len(powerPodState.Containers) != 0 {
+			t.Errorf("%s - Failed: Expected no Containers in the internal state after exit, got %v", tc.testCase, powerPodState.Containers)
+		}
+	}
+}
+
+func TestCrashLoopBackoff(t *testing.T) {
+	tcases := []struct {
+		testCase         string
+		containerState   corev1.ContainerState
+		lastExitCode     int32
+		expectedRequeue  time.Duration
+		expectedWorkload bool
+	}{
+		{
+			testCase: "Test Case 1",
+			containerState: corev1.ContainerState{
+				Waiting: &corev1.ContainerStateWaiting{
+					Reason: CrashLoopBackOffReason,
+				},
+			},
+			lastExitCode:     1,
+			expectedRequeue:  time.Minute,
+			expectedWorkload: false,
+		},
+		{
+			testCase: "Test Case 2",
+			containerState: corev1.ContainerState{
+				Running: &corev1.ContainerStateRunning{
+					StartedAt: metav1.NewTime(time.Now().Add(-10 * time.Second)),
+				},
+			},
+			lastExitCode:     1,
+			expectedRequeue:  time.Minute,
+			expectedWorkload: false,
+		},
+		{
+			testCase: "Test Case 3",
+			containerState: corev1.ContainerState{
+				Running: &corev1.ContainerStateRunning{
+					StartedAt: metav1.NewTime(time.Now().Add(-5 * time.Minute)),
+				},
+			},
+			lastExitCode:     1,
+			expectedWorkload: true,
+		},
+		{
+			testCase: "Test Case 4",
+			containerState: corev1.ContainerState{
+				Running: &corev1.ContainerStateRunning{
+					StartedAt: metav1.NewTime(time.Now().Add(-10 * time.Second)),
+				},
+			},
+			lastExitCode:     0,
+			expectedWorkload: true,
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example-pod",
+				Namespace: PowerPodNamespace,
+				UID:       "abcdefg",
+			},
+			Spec: corev1.PodSpec{
+				NodeName: "example-node1",
+				Containers: []corev1.Container{
+					{
+						Name: "example-container-1",
+						Resources: corev1.ResourceRequirements{
+							Limits: map[corev1.ResourceName]resource.Quantity{
+								corev1.ResourceName("cpu"):                                       *resource.NewQuantity(2, resource.DecimalSI),
+								corev1.ResourceName("power.intel.com/performance-example-node1"): *resource.NewQuantity(2, resource.DecimalSI),
+							},
+							Requests: map[corev1.ResourceName]resource.Quantity{
+								corev1.ResourceName("cpu"):                                       *resource.NewQuantity(2, resource.DecimalSI),
+								corev1.ResourceName("power.intel.com/performance-example-node1"): *resource.NewQuantity(2, resource.DecimalSI),
+							},
+						},
+					},
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase:    corev1.PodRunning,
+				QOSClass: corev1.PodQOSGuaranteed,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:         "example-container-1",
+						ContainerID:  "docker://abcdefg",
+						State:        tc.containerState,
+						RestartCount: 3,
+						LastTerminationState: corev1.ContainerState{
+							Terminated: &corev1.ContainerStateTerminated{
+								ExitCode: tc.lastExitCode,
+							},
+						},
+					},
+				},
+			},
+		}
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance-example-node1",
+				Epp:  "performance",
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod, powerProfile})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		r.CrashLoopBackoff = time.Minute
+
+		fakeListResponse := &podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{1, 2},
+						},
+					},
+				},
+			},
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(fakeListResponse)
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		result, err := r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+
+		if result.RequeueAfter != tc.expectedRequeue {
+			t.Errorf("%s - Failed: Expected Pod to be requeued after %v, got %v", tc.testCase, tc.expectedRequeue, result.RequeueAfter)
+		}
+
+		workload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{
+			Name:      "performance-example-node1-workload",
+			Namespace: PowerPodNamespace,
+		}, workload)
+		if err != nil && !errors.IsNotFound(err) {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload", tc.testCase))
+		}
+
+		if (err == nil) != tc.expectedWorkload {
+			t.Errorf("%s - Failed: Expected PowerWorkload to exist to be %v, got %v", tc.testCase, tc.expectedWorkload, err == nil)
+		}
+	}
+}
+
+func TestPowerPodStatus(t *testing.T) {
+	tcases := []struct {
+		testCase           string
+		reportPowerPods    bool
+		expectedPowerPod   bool
+		expectedContainers []powerv1alpha1.Container
+	}{
+		{
+			testCase:         "Test Case 1",
+			reportPowerPods:  true,
+			expectedPowerPod: true,
+			expectedContainers: []powerv1alpha1.Container{
+				{
+					Name:          "example-container-1",
+					Id:            "abcdefg",
+					Runtime:       "docker",
+					ExclusiveCPUs: []int{1, 2},
+					PowerProfile:  "performance-example-node1",
+					Workload:      "performance-example-node1-workload",
+				},
+			},
+		},
+		{
+			testCase:         "Test Case 2",
+			reportPowerPods:  false,
+			expectedPowerPod: false,
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+
+		pod := createGuaranteedPod("example-pod", "abcdefg", "example-node1", "example-container-1", "performance-example-node1", 2)
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance-example-node1",
+				Epp:  "performance",
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod, powerProfile})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		r.ReportPowerPods = tc.reportPowerPods
+
+		fakeListResponse := &podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{1, 2},
+						},
+					},
+				},
+			},
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(fakeListResponse)
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+
+		powerPod := &powerv1alpha1.PowerPod{}
+		err = r.Client.Get(context.TODO(), req.NamespacedName, powerPod)
+		if err != nil && !errors.IsNotFound(err) {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerPod", tc.testCase))
+		}
+
+		if (err == nil) != tc.expectedPowerPod {
+			t.Errorf("%s - Failed: Expected PowerPod to exist to be %v, got %v", tc.testCase, tc.expectedPowerPod, err == nil)
+			continue
+		}
+		if !tc.expectedPowerPod {
+			continue
+		}
+
+		if powerPod.Status.Node != "example-node1" {
+			t.Errorf("%s - Failed: Expected PowerPod Node to be 'example-node1', got '%s'", tc.testCase, powerPod.Status.Node)
+		}
+
+		if !reflect.DeepEqual(powerPod.Status.Containers, tc.expectedContainers) {
+			t.Errorf("%s - Failed: Expected PowerPod Containers to be %v, got %v", tc.testCase, tc.expectedContainers, powerPod.Status.Containers)
+		}
+
+		if len(powerPod.OwnerReferences) != 1 || powerPod.OwnerReferences[0].UID != pod.GetUID() {
+			t.Errorf("%s - Failed: Expected PowerPod to be owned by the Pod, got %v", tc.testCase, powerPod.OwnerReferences)
+		}
+	}
+}
+
+func TestMemoryNUMAMismatch(t *testing.T) {
+	tcases := []struct {
+		testCase      string
+		cores         []int
+		memoryState   string
+		expectedEvent string
+	}{
+		{
+			testCase:      "Test Case 1",
+			cores:         []int{1, 2},
+			memoryState:   `{"policyName":"Static","entries":{"abcdefg":{"example-container-1":[{"numaAffinity":[0],"type":"memory","size":1073741824}]}},"checksum":1}`,
+			expectedEvent: "",
+		},
+		{
+			testCase:      "Test Case 2",
+			cores:         []int{1, 5},
+			memoryState:   `{"policyName":"Static","entries":{"abcdefg":{"example-container-1":[{"numaAffinity":[0],"type":"memory","size":1073741824}]}},"checksum":1}`,
+			expectedEvent: "MemoryNUMAMismatch",
+		},
+		{
+			testCase:      "Test Case 3",
+			cores:         []int{1, 5},
+			memoryState:   `{"policyName":"Static","entries":{"abcdefg":{"example-container-1":[{"numaAffinity":[0,1],"type":"memory","size":1073741824}]}},"checksum":1}`,
+			expectedEvent: "",
+		},
+		{
+			testCase:      "Test Case 4",
+			cores:         []int{5, 6},
+			memoryState:   `{"policyName":"Static","entries":{"abcdefg":{"example-container-1":[{"numaAffinity":[0],"type":"memory","size":1073741824},{"numaAffinity":[0],"type":"hugepages-1Gi","size":1073741824}]}},"checksum":1}`,
+			expectedEvent: "MemoryNUMAMismatch",
+		},
+		{
+			testCase:      "Test Case 5",
+			cores:         []int{5, 6},
+			memoryState:   `{"policyName":"None","entries":{},"checksum":1}`,
+			expectedEvent: "",
+		},
+		{
+			testCase:      "Test Case 6",
+			cores:         []int{5, 6},
+			memoryState:   "",
+			expectedEvent: "",
+		},
+	}
+
+	for _, tc := range tcases {
+		cpuhotplug.CPUDevicesPath = createFakeNUMANodes(t, map[int]int{1: 0, 2: 0, 5: 1, 6: 1})
+
+		memorymanager.StatePath = filepath.Join(t.TempDir(), "memory_manager_state")
+		if tc.memoryState != "" {
+			err := ioutil.WriteFile(memorymanager.StatePath, []byte(tc.memoryState), 0644)
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error writing Memory Manager state", tc.testCase))
+			}
+		}
+
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example-pod",
+				Namespace: PowerPodNamespace,
+				UID:       "abcdefg",
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		recorder := record.NewFakeRecorder(10)
+		r.Recorder = recorder
+
+		r.checkMemoryNUMAAlignment(pod, "example-container-1", tc.cores)
+
+		event := ""
+		select {
+		case event = <-recorder.Events:
+		default:
+		}
+		if !strings.Contains(event, tc.expectedEvent) || (tc.expectedEvent == "") != (event == "") {
+			t.Errorf("%s - Failed: Expected Event '%s', got '%s'", tc.testCase, tc.expectedEvent, event)
+		}
+	}
+}
+
+func TestMemoryNUMAMismatchReportedOnChange(t *testing.T) {
+	cpuhotplug.CPUDevicesPath = createFakeNUMANodes(t, map[int]int{1: 0, 2: 0, 5: 1, 6: 1})
+	memorymanager.StatePath = filepath.Join(t.TempDir(), "memory_manager_state")
+	err := ioutil.WriteFile(memorymanager.StatePath, []byte(`{"policyName":"Static","entries":{"abcdefg":{"example-container-1":[{"numaAffinity":[0],"type":"memory","size":1073741824}]}},"checksum":1}`), 0644)
+	if err != nil {
+		t.Error(err)
+		t.Fatal("error writing Memory Manager state")
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-pod",
+			Namespace: PowerPodNamespace,
+			UID:       "abcdefg",
+		},
+	}
+
+	r, err := createPowerPodReconcilerObject([]runtime.Object{pod})
+	if err != nil {
+		t.Error(err)
+		t.Fatal("error creating reconciler object")
+	}
+
+	recorder := record.NewFakeRecorder(10)
+	r.Recorder = recorder
+
+	tcases := []struct {
+		testCase      string
+		cores         []int
+		expectedEvent bool
+	}{
+		{
+			testCase:      "Test Case 1 - first mismatch reported",
+			cores:         []int{1, 5},
+			expectedEvent: true,
+		},
+		{
+			testCase:      "Test Case 2 - unchanged mismatch not reported again",
+			cores:         []int{5, 1},
+			expectedEvent: false,
+		},
+		{
+			testCase:      "Test Case 3 - aligned cores not reported",
+			cores:         []int{1, 2},
+			expectedEvent: false,
+		},
+		{
+			testCase:      "Test Case 4 - returning mismatch reported",
+			cores:         []int{1, 5},
+			expectedEvent: true,
+		},
+		{
+			testCase:      "Test Case 5 - changed mismatch reported",
+			cores:         []int{5, 6},
+			expectedEvent: true,
+		},
+	}
+
+	for _, tc := range tcases {
+		r.checkMemoryNUMAAlignment(pod, "example-container-1", tc.cores)
+
+		event := ""
+		select {
+		case event = <-recorder.Events:
+		default:
+		}
+		if (event != "") != tc.expectedEvent {
+			t.Errorf("%s - Failed: Expected an Event to be %v, got '%s'", tc.testCase, tc.expectedEvent, event)
+		}
+	}
+}
+
+func TestPodResourcesContainerMismatch(t *testing.T) {
+	tcases := []struct {
+		testCase      string
+		podResources  []*podresourcesapi.PodResources
+		expectedCPUs  string
+		expectedError string
+	}{
+		{
+			testCase: "Test Case 1 - Requested Container listed",
+			podResources: []*podresourcesapi.PodResources{
+				{
+					Name: "example-pod",
+					Containers: []*podresourcesapi.ContainerResources{
+						{Name: "example-container-1", CpuIds: []int64{1, 2}},
+					},
+				},
+			},
+			expectedCPUs: "1-2",
+		},
+		{
+			testCase: "Test Case 2 - Pod name reused by a Pod with another Container",
+			podResources: []*podresourcesapi.PodResources{
+				{
+					Name: "example-pod",
+					Containers: []*podresourcesapi.ContainerResources{
+						{Name: "other-container", CpuIds: []int64{5, 6}},
+					},
+				},
+			},
+			expectedError: "listed Containers [other-container] for Pod:example-pod but not the requested Container:example-container-1",
+		},
+		{
+			testCase: "Test Case 3 - Requested Container listed under a reused Pod name",
+			podResources: []*podresourcesapi.PodResources{
+				{
+					Name: "example-pod",
+					Containers: []*podresourcesapi.ContainerResources{
+						{Name: "other-container", CpuIds: []int64{5, 6}},
+					},
+				},
+				{
+					Name: "example-pod",
+					Containers: []*podresourcesapi.ContainerResources{
+						{Name: "example-container-1", CpuIds: []int64{3, 4}},
+					},
+				},
+			},
+			expectedCPUs: "3-4",
+		},
+		{
+			testCase:      "Test Case 4 - Pod not listed",
+			podResources:  []*podresourcesapi.PodResources{},
+			expectedError: "cpus for Pod:example-pod Container:example-container-1 not found",
+		},
+	}
+
+	for _, tc := range tcases {
+		fakeListResponse := &podresourcesapi.ListPodResourcesResponse{
+			PodResources: tc.podResources,
+		}
+		podResourcesClient := createFakePodResourcesListerClient(fakeListResponse)
+
+		cpus, err := podResourcesClient.GetContainerCPUs(context.Background(), "example-pod", "example-container-1")
+		if tc.expectedError != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+				t.Errorf("%s - Failed: Expected error '%s', got '%v'", tc.testCase, tc.expectedError, err)
+			}
+			if cpus != "" {
+				t.Errorf("%s - Failed: Expected no CPUs, got '%s'", tc.testCase, cpus)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving Container CPUs", tc.testCase))
+		}
+		if cpus != tc.expectedCPUs {
+			t.Errorf("%s - Failed: Expected CPUs '%s', got '%s'", tc.testCase, tc.expectedCPUs, cpus)
+		}
+	}
+}
+
+func TestProfileAffinityMismatch(t *testing.T) {
+	tcases := []struct {
+		testCase       string
+		cores          []int
+		profileSpec    powerv1alpha1.PowerProfileSpec
+		expectedEvents []string
+	}{
+		{
+			testCase: "Test Case 1 - Cores on the preferred socket",
+			cores:    []int{1, 2},
+			profileSpec: powerv1alpha1.PowerProfileSpec{
+				Name:            "performance-example-node1",
+				Epp:             "performance",
+				PreferredSocket: intPtr(0),
+			},
+			expectedEvents: []string{},
+		},
+		{
+			testCase: "Test Case 2 - Cores span two sockets",
+			cores:    []int{1, 5},
+			profileSpec: powerv1alpha1.PowerProfileSpec{
+				Name:            "performance-example-node1",
+				Epp:             "performance",
+				PreferredSocket: intPtr(0),
+			},
+			expectedEvents: []string{"CPUs [5] of Container 'example-container-1' are not on socket 0"},
+		},
+		{
+			testCase: "Test Case 3 - Cores span two NUMA nodes",
+			cores:    []int{1, 5},
+			profileSpec: powerv1alpha1.PowerProfileSpec{
+				Name:          "performance-example-node1",
+				Epp:           "performance",
+				PreferredNUMA: intPtr(1),
+			},
+			expectedEvents: []string{"CPUs [1] of Container 'example-container-1' are not on NUMA node 1"},
+		},
+		{
+			testCase: "Test Case 4 - Cores off both the preferred socket and NUMA node",
+			cores:    []int{5, 6},
+			profileSpec: powerv1alpha1.PowerProfileSpec{
+				Name:            "performance-example-node1",
+				Epp:             "performance",
+				PreferredSocket: intPtr(0),
+				PreferredNUMA:   intPtr(0),
+			},
+			expectedEvents: []string{
+				"CPUs [5 6] of Container 'example-container-1' are not on socket 0",
+				"CPUs [5 6] of Container 'example-container-1' are not on NUMA node 0",
+			},
+		},
+		{
+			testCase: "Test Case 5 - No affinity",
+			cores:    []int{1, 5},
+			profileSpec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance-example-node1",
+				Epp:  "performance",
+			},
+			expectedEvents: []string{},
+		},
+	}
+
+	for _, tc := range tcases {
+		// CPUs 1 and 2 are on socket and NUMA node 0, CPUs 5 and 6 on socket and NUMA node 1
+		cpuhotplug.CPUDevicesPath = createFakeNUMANodes(t, map[int]int{1: 0, 2: 0, 5: 1, 6: 1})
+		for cpu, socket := range map[int]int{1: 0, 2: 0, 5: 1, 6: 1} {
+			topologyPath := filepath.Join(cpuhotplug.CPUDevicesPath, fmt.Sprintf("cpu%d", cpu), "topology")
+			err := os.MkdirAll(topologyPath, 0755)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = ioutil.WriteFile(filepath.Join(topologyPath, "physical_package_id"), []byte(strconv.Itoa(socket)), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example-pod",
+				Namespace: PowerPodNamespace,
+			},
+		}
+		profiles := []powerv1alpha1.PowerProfile{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "performance-example-node1",
+					Namespace: PowerPodNamespace,
+				},
+				Spec: tc.profileSpec,
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		recorder := record.NewFakeRecorder(10)
+		r.Recorder = recorder
+
+		r.checkProfileAffinity(pod, "example-container-1", "performance-example-node1", profiles, tc.cores)
+
+		events := make([]string, 0)
+		for len(recorder.Events) > 0 {
+			events = append(events, <-recorder.Events)
+		}
+		if len(events) != len(tc.expectedEvents) {
+			t.Errorf("%s - Failed: Expected Events %v, got %v", tc.testCase, tc.expectedEvents, events)
+			continue
+		}
+		for i, expectedEvent := range tc.expectedEvents {
+			if !strings.Contains(events[i], "ProfileAffinityMismatch") || !strings.Contains(events[i], expectedEvent) {
+				t.Errorf("%s - Failed: Expected Event '%s', got '%s'", tc.testCase, expectedEvent, events[i])
+			}
+		}
+	}
+}
+
+func TestPodQoSDequalification(t *testing.T) {
+	tcases := []struct {
+		testCase                string
+		updatedQOSClass         corev1.PodQOSClass
+		updatedContainer2CPU    resource.Quantity
+		expectedWorkloadCPUs    []int
+		expectedStateContainers []string
+	}{
+		{
+			testCase:                "Test Case 1 - Pod loses Guaranteed QoS",
+			updatedQOSClass:         corev1.PodQOSBurstable,
+			updatedContainer2CPU:    *resource.NewQuantity(2, resource.DecimalSI),
+			expectedWorkloadCPUs:    nil,
+			expectedStateContainers: []string{},
+		},
+		{
+			testCase:                "Test Case 2 - Container loses whole CPUs",
+			updatedQOSClass:         corev1.PodQOSGuaranteed,
+			updatedContainer2CPU:    *resource.NewMilliQuantity(1500, resource.DecimalSI),
+			expectedWorkloadCPUs:    []int{1, 2},
+			expectedStateContainers: []string{"example-container-1"},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		cpuhotplug.CPUDevicesPath = createFakeCPUDevices(t, map[int]string{1: "1", 2: "2", 3: "3", 4: "4"})
+
+		containerResources := func(cpu resource.Quantity) corev1.ResourceRequirements {
+			return corev1.ResourceRequirements{
+				Limits: map[corev1.ResourceName]resource.Quantity{
+					corev1.ResourceName("cpu"): cpu,
+					corev1.ResourceName(ResourcePrefix + "performance-example-node1"): *resource.NewQuantity(2, resource.DecimalSI),
+				},
+				Requests: map[corev1.ResourceName]resource.Quantity{
+					corev1.ResourceName("cpu"): cpu,
+					corev1.ResourceName(ResourcePrefix + "performance-example-node1"): *resource.NewQuantity(2, resource.DecimalSI),
+				},
+			}
+		}
+
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example-pod",
+				Namespace: PowerPodNamespace,
+				UID:       "abcdefg",
+			},
+			Spec: corev1.PodSpec{
+				NodeName: "example-node1",
+				Containers: []corev1.Container{
+					{
+						Name:      "example-container-1",
+						Resources: containerResources(*resource.NewQuantity(2, resource.DecimalSI)),
+					},
+					{
+						Name:      "example-container-2",
+						Resources: containerResources(*resource.NewQuantity(2, resource.DecimalSI)),
+					},
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase:    corev1.PodRunning,
+				QOSClass: corev1.PodQOSGuaranteed,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:        "example-container-1",
+						ContainerID: "docker://abcdefg",
+					},
+					{
+						Name:        "example-container-2",
+						ContainerID: "docker://hijklmn",
+					},
+				},
+			},
+		}
+		profile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance-example-node1",
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod, profile})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(&podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{1, 2},
+						},
+						{
+							Name:   "example-container-2",
+							CpuIds: []int64{3, 4},
+						},
+					},
+				},
+			},
+		})
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+
+		// The Pod's resources are updated in place
+		pod.Status.QOSClass = tc.updatedQOSClass
+		pod.Spec.Containers[1].Resources = containerResources(tc.updatedContainer2CPU)
+		err = r.Client.Update(context.TODO(), pod)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling updated Pod object", tc.testCase))
+		}
+
+		workload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{
+			Name:      "performance-example-node1-workload",
+			Namespace: PowerPodNamespace,
+		}, workload)
+		if tc.expectedWorkloadCPUs == nil {
+			if !errors.IsNotFound(err) {
+				t.Errorf("%s - Failed: Expected PowerWorkload to be deleted, got %v", tc.testCase, workload.Spec.Node.CpuIds)
+			}
+		} else {
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload", tc.testCase))
+			}
+
+			if !reflect.DeepEqual(workload.Spec.Node.CpuIds, tc.expectedWorkloadCPUs) {
+				t.Errorf("%s - Failed: Expected PowerWorkload CpuIds to be %v, got %v", tc.testCase, tc.expectedWorkloadCPUs, workload.Spec.Node.CpuIds)
+			}
+		}
+
+		stateContainers := make([]string, 0)
+		for _, container := range r.State.GetPodFromState(pod.Name).Containers {
+			stateContainers = append(stateContainers, container.Name)
+		}
+		if !reflect.DeepEqual(stateContainers, tc.expectedStateContainers) {
+			t.Errorf("%s - Failed: Expected internal state Containers to be %v, got %v", tc.testCase, tc.expectedStateContainers, stateContainers)
+		}
+	}
+}
+
+func TestAppliedCPUsAnnotation(t *testing.T) {
+	tcases := []struct {
+		testCase            string
+		annotateAppliedCPUs bool
+		readOnly            bool
+		secondContainer     string
+		expectedAnnotations map[string]string
+	}{
+		{
+			testCase:            "Test Case 1 - Annotations set",
+			annotateAppliedCPUs: true,
+			secondContainer:     "example-container-2",
+			expectedAnnotations: map[string]string{
+				"example-annotation": "example-value",
+				AppliedCPUsAnnotationPrefix + "example-container-1": "1-3",
+				AppliedCPUsAnnotationPrefix + "example-container-2": "5,7",
+			},
+		},
+		{
+			testCase:            "Test Case 2 - Annotations disabled",
+			annotateAppliedCPUs: false,
+			secondContainer:     "example-container-2",
+			expectedAnnotations: map[string]string{
+				"example-annotation":                              "example-value",
+				AppliedCPUsAnnotationPrefix + "removed-container": "4",
+			},
+		},
+		{
+			testCase:            "Test Case 3 - Container name too long for the annotation key",
+			annotateAppliedCPUs: true,
+			secondContainer:     "example-container-with-a-name-long-enough-to-overflow-the-key",
+			expectedAnnotations: map[string]string{
+				"example-annotation": "example-value",
+				AppliedCPUsAnnotationPrefix + "example-container-1":                               "1-3",
+				"power.intel.com/applied-cpus.example-container-with-a-name-long-enou-9b83f1a70d": "5,7",
+			},
+		},
+		{
+			testCase:            "Test Case 4 - Workload writes read-only",
+			annotateAppliedCPUs: true,
+			readOnly:            true,
+			secondContainer:     "example-container-2",
+			expectedAnnotations: map[string]string{
+				"example-annotation":                              "example-value",
+				AppliedCPUsAnnotationPrefix + "removed-container": "4",
+			},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		cpuhotplug.CPUDevicesPath = createFakeCPUDevices(t, map[int]string{1: "1", 2: "2", 3: "3", 5: "5", 7: "7"})
+
+		containerResources := func(cpus int64) corev1.ResourceRequirements {
+			return corev1.ResourceRequirements{
+				Limits: map[corev1.ResourceName]resource.Quantity{
+					corev1.ResourceName("cpu"): *resource.NewQuantity(cpus, resource.DecimalSI),
+					corev1.ResourceName(ResourcePrefix + "performance-example-node1"): *resource.NewQuantity(cpus, resource.DecimalSI),
+				},
+				Requests: map[corev1.ResourceName]resource.Quantity{
+					corev1.ResourceName("cpu"): *resource.NewQuantity(cpus, resource.DecimalSI),
+					corev1.ResourceName(ResourcePrefix + "performance-example-node1"): *resource.NewQuantity(cpus, resource.DecimalSI),
+				},
+			}
+		}
+
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example-pod",
+				Namespace: PowerPodNamespace,
+				UID:       "abcdefg",
+				Annotations: map[string]string{
+					"example-annotation":                              "example-value",
+					AppliedCPUsAnnotationPrefix + "removed-container": "4",
+				},
+			},
+			Spec: corev1.PodSpec{
+				NodeName: "example-node1",
+				Containers: []corev1.Container{
+					{
+						Name:      "example-container-1",
+						Resources: containerResources(3),
+					},
+					{
+						Name:      tc.secondContainer,
+						Resources: containerResources(2),
+					},
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase:    corev1.PodRunning,
+				QOSClass: corev1.PodQOSGuaranteed,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:        "example-container-1",
+						ContainerID: "docker://abcdefg",
+					},
+					{
+						Name:        tc.secondContainer,
+						ContainerID: "docker://hijklmn",
+					},
+				},
+			},
+		}
+		profile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance-example-node1",
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod, profile})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		r.AnnotateAppliedCPUs = tc.annotateAppliedCPUs
+		if tc.readOnly {
+			r.workloadWritesForbiddenUntil = time.Now().Add(time.Minute)
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(&podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{3, 1, 2},
+						},
+						{
+							Name:   tc.secondContainer,
+							CpuIds: []int64{5, 7},
+						},
+					},
+				},
+			},
+		})
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+
+		updatedPod := &corev1.Pod{}
+		err = r.Client.Get(context.TODO(), req.NamespacedName, updatedPod)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving Pod object", tc.testCase))
+		}
+
+		if !reflect.DeepEqual(updatedPod.GetAnnotations(), tc.expectedAnnotations) {
+			t.Errorf("%s - Failed: Expected Pod annotations to be %v, got %v", tc.testCase, tc.expectedAnnotations, updatedPod.GetAnnotations())
+		}
+	}
+}
+
+func TestStateConcurrentAccess(t *testing.T) {
+	tcases := []struct {
+		testCase      string
+		numPods       int
+		numDeleted    int
+		numIterations int
+	}{
+		{
+			testCase:      "Test Case 1",
+			numPods:       20,
+			numDeleted:    0,
+			numIterations: 50,
+		},
+		{
+			testCase:      "Test Case 2",
+			numPods:       20,
+			numDeleted:    10,
+			numIterations: 50,
+		},
+	}
+
+	for _, tc := range tcases {
+		state, err := podstate.NewState()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Each Pod is reconciled by its own worker while readers walk the State, as they would with
+		// MaxConcurrentReconciles raised. Run with -race to detect unsynchronized access
+		wg := sync.WaitGroup{}
+		errs := make(chan error, tc.numPods*tc.numIterations)
+		for i := 0; i < tc.numPods; i++ {
+			wg.Add(1)
+			go func(podNum int) {
+				defer wg.Done()
+
+				podName := fmt.Sprintf("example-pod-%d", podNum)
+				for j := 0; j < tc.numIterations; j++ {
+					err := state.UpdateStateGuaranteedPods(powerv1alpha1.GuaranteedPod{
+						Name: podName,
+						Node: "example-node1",
+						Containers: []powerv1alpha1.Container{
+							{Name: "example-container", ExclusiveCPUs: []int{podNum}},
+						},
+					})
+					if err != nil {
+						errs <- err
+					}
+					state.UpdateParkedSiblings(podName, []int{podNum + tc.numPods})
+					state.UpdateRestartCounts(podName, map[string]int32{"example-container": int32(j)})
+					state.RecordProfileChange([]int{podNum}, time.Now())
+					state.RecordReconcileFailure(podName, "fingerprint")
+
+					state.GetPodFromState(podName)
+					state.GetParkedSiblings(podName)
+					state.GetRestartCount(podName, "example-container")
+					state.GetLastProfileChange([]int{podNum})
+					state.GetRetryBudget(podName)
+				}
+
+				if podNum < tc.numDeleted {
+					err := state.DeletePodFromState(podName)
+					if err != nil {
+						errs <- err
+					}
+					state.DeleteParkedSiblings(podName)
+					state.DeleteRestartCounts(podName)
+					state.DeleteRetryBudget(podName)
+				}
+			}(i)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < tc.numIterations; j++ {
+				exclusiveCPUCapacity(tc.numPods, state)
+				_, err := json.Marshal(state)
+				if err != nil {
+					errs <- err
+				}
+			}
+		}()
+
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error updating State concurrently", tc.testCase))
+		}
+
+		guaranteedPods := state.GetGuaranteedPods()
+		if len(guaranteedPods) != tc.numPods-tc.numDeleted {
+			t.Errorf("%s - Failed: Expected %d Guaranteed Pods in the State, got %d", tc.testCase, tc.numPods-tc.numDeleted, len(guaranteedPods))
+		}
+
+		for i := 0; i < tc.numPods; i++ {
+			podName := fmt.Sprintf("example-pod-%d", i)
+			deleted := i < tc.numDeleted
+			if (state.GetPodFromState(podName).Name == "") != deleted {
+				t.Errorf("%s - Failed: Expected '%s' to be deleted from the State to be %v", tc.testCase, podName, deleted)
+			}
+			if !deleted && len(state.GetParkedSiblings(podName)) != 1 {
+				t.Errorf("%s - Failed: Expected '%s' to have one parked sibling, got %v", tc.testCase, podName, state.GetParkedSiblings(podName))
+			}
+			if budget, _ := state.GetRetryBudget(podName); !deleted && budget.Failures != tc.numIterations {
+				t.Errorf("%s - Failed: Expected '%s' to have %d failures recorded, got %d", tc.testCase, podName, tc.numIterations, budget.Failures)
+			}
+		}
+	}
+}
+
+func TestPodProfileAnnotation(t *testing.T) {
+	tcases := []struct {
+		testCase               string
+		annotations            map[string]string
+		container2Profile      string
+		expectedWorkload       string
+		expectedWorkloadCpuIds []int
+	}{
+		{
+			testCase: "Test Case 1 - Annotation applied to every exclusive Container",
+			annotations: map[string]string{
+				PodProfileAnnotation: "gold",
+			},
+			expectedWorkload:       "gold-workload",
+			expectedWorkloadCpuIds: []int{1, 2, 3, 4},
+		},
+		{
+			testCase: "Test Case 2 - Annotation takes precedence over the PowerProfile annotation",
+			annotations: map[string]string{
+				PodProfileAnnotation:   "gold",
+				PowerProfileAnnotation: "silver",
+			},
+			expectedWorkload:       "gold-workload",
+			expectedWorkloadCpuIds: []int{1, 2, 3, 4},
+		},
+		{
+			testCase: "Test Case 3 - Annotation applied alongside a Container's own PowerProfile",
+			annotations: map[string]string{
+				PodProfileAnnotation: "gold",
+			},
+			container2Profile:      "gold",
+			expectedWorkload:       "gold-workload",
+			expectedWorkloadCpuIds: []int{1, 2, 3, 4},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+
+		cpuResources := func(cpu resource.Quantity, profile string) corev1.ResourceRequirements {
+			resources := corev1.ResourceRequirements{
+				Limits: map[corev1.ResourceName]resource.Quantity{
+					corev1.ResourceName("cpu"): cpu,
+				},
+				Requests: map[corev1.ResourceName]resource.Quantity{
+					corev1.ResourceName("cpu"): cpu,
+				},
+			}
+			if profile != "" {
+				resources.Limits[corev1.ResourceName(ResourcePrefix+profile)] = cpu
+				resources.Requests[corev1.ResourceName(ResourcePrefix+profile)] = cpu
+			}
+
+			return resources
+		}
+
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "example-pod",
+				Namespace:   PowerPodNamespace,
+				UID:         "abcdefg",
+				Annotations: tc.annotations,
+			},
+			Spec: corev1.PodSpec{
+				NodeName: "example-node1",
+				Containers: []corev1.Container{
+					{
+						Name:      "example-container-1",
+						Resources: cpuResources(*resource.NewQuantity(2, resource.DecimalSI), ""),
+					},
+					{
+						Name:      "example-container-2",
+						Resources: cpuResources(*resource.NewQuantity(2, resource.DecimalSI), tc.container2Profile),
+					},
+					{
+						Name:      "example-container-3",
+						Resources: cpuResources(*resource.NewMilliQuantity(500, resource.DecimalSI), ""),
+					},
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase:    corev1.PodRunning,
+				QOSClass: corev1.PodQOSGuaranteed,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:        "example-container-1",
+						ContainerID: "docker://abcdefg",
+					},
+					{
+						Name:        "example-container-2",
+						ContainerID: "docker://hijklmn",
+					},
+					{
+						Name:        "example-container-3",
+						ContainerID: "docker://opqrstu",
+					},
+				},
+			},
+		}
+		objs := []runtime.Object{pod}
+		for _, profileName := range []string{"gold", "silver"} {
+			objs = append(objs, &powerv1alpha1.PowerProfile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      profileName,
+					Namespace: PowerPodNamespace,
+				},
+				Spec: powerv1alpha1.PowerProfileSpec{
+					Name: profileName,
+				},
+			})
+		}
+
+		r, err := createPowerPodReconcilerObject(objs)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		r.ProfileResolvers = []ProfileResolver{
+			ResourceRequestResolver{},
+			AnnotationProfileResolver{},
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(&podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{1, 2},
+						},
+						{
+							Name:   "example-container-2",
+							CpuIds: []int64{3, 4},
+						},
+						{
+							Name:   "example-container-3",
+							CpuIds: []int64{5, 6, 7},
+						},
+					},
+				},
+			},
+		})
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+
+		workload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{
+			Name:      tc.expectedWorkload,
+			Namespace: PowerPodNamespace,
+		}, workload)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload '%s'", tc.testCase, tc.expectedWorkload))
+		}
+
+		if !reflect.DeepEqual(workload.Spec.Node.CpuIds, tc.expectedWorkloadCpuIds) {
+			t.Errorf("%s - Failed: Expected PowerWorkload '%s' CpuIds to be %v, got %v", tc.testCase, tc.expectedWorkload, tc.expectedWorkloadCpuIds, workload.Spec.Node.CpuIds)
+		}
+
+		podState := r.State.GetPodFromState(pod.Name)
+		if len(podState.Containers) != 2 {
+			t.Errorf("%s - Failed: Expected 2 Containers in the Pod state, got %v", tc.testCase, podState.Containers)
+		}
+		for _, container := range podState.Containers {
+			if container.PowerProfile != "gold" {
+				t.Errorf("%s - Failed: Expected Container '%s' to be given PowerProfile 'gold', got '%s'", tc.testCase, container.Name, container.PowerProfile)
+			}
+		}
+	}
+}
+
+func TestUnprofiledContainerReport(t *testing.T) {
+	tcases := []struct {
+		testCase                   string
+		reportUnprofiledContainers bool
+		expectedEvents             []string
+	}{
+		{
+			testCase:                   "Test Case 1 - Reporting enabled",
+			reportUnprofiledContainers: true,
+			expectedEvents: []string{
+				"Warning ExclusiveCPUsUnprofiled Container 'example-container-2' was given 2 exclusive CPUs but requests no PowerProfile",
+			},
+		},
+		{
+			testCase:                   "Test Case 2 - Reporting disabled",
+			reportUnprofiledContainers: false,
+			expectedEvents:             []string{},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+
+		cpuResources := func(cpu resource.Quantity, profile string) corev1.ResourceRequirements {
+			resources := corev1.ResourceRequirements{
+				Limits: map[corev1.ResourceName]resource.Quantity{
+					corev1.ResourceName("cpu"): cpu,
+				},
+				Requests: map[corev1.ResourceName]resource.Quantity{
+					corev1.ResourceName("cpu"): cpu,
+				},
+			}
+			if profile != "" {
+				resources.Limits[corev1.ResourceName(ResourcePrefix+profile)] = cpu
+				resources.Requests[corev1.ResourceName(ResourcePrefix+profile)] = cpu
+			}
+
+			return resources
+		}
+
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example-pod",
+				Namespace: PowerPodNamespace,
+				UID:       "abcdefg",
+			},
+			Spec: corev1.PodSpec{
+				NodeName: "example-node1",
+				Containers: []corev1.Container{
+					{
+						Name:      "example-container-1",
+						Resources: cpuResources(*resource.NewQuantity(2, resource.DecimalSI), "gold"),
+					},
+					{
+						Name:      "example-container-2",
+						Resources: cpuResources(*resource.NewQuantity(2, resource.DecimalSI), ""),
+					},
+					{
+						Name:      "example-container-3",
+						Resources: cpuResources(*resource.NewMilliQuantity(500, resource.DecimalSI), ""),
+					},
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase:    corev1.PodRunning,
+				QOSClass: corev1.PodQOSGuaranteed,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:        "example-container-1",
+						ContainerID: "docker://abcdefg",
+					},
+					{
+						Name:        "example-container-2",
+						ContainerID: "docker://hijklmn",
+					},
+					{
+						Name:        "example-container-3",
+						ContainerID: "docker://opqrstu",
+					},
+				},
+			},
+		}
+		profile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "gold",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "gold",
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod, profile})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		recorder := record.NewFakeRecorder(10)
+		r.Recorder = recorder
+		r.ReportUnprofiledContainers = tc.reportUnprofiledContainers
+		r.PodResourcesClient = *createFakePodResourcesListerClient(&podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{1, 2},
+						},
+						{
+							Name:   "example-container-2",
+							CpuIds: []int64{3, 4},
+						},
+						{
+							Name:   "example-container-3",
+							CpuIds: []int64{5, 6, 7},
+						},
+					},
+				},
+			},
+		})
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+
+		events := make([]string, 0)
+		for len(recorder.Events) > 0 {
+			event := <-recorder.Events
+			if strings.Contains(event, "ExclusiveCPUsUnprofiled") {
+				events = append(events, event)
+			}
+		}
+		if len(events) != len(tc.expectedEvents) {
+			t.Errorf("%s - Failed: Expected %d ExclusiveCPUsUnprofiled Events, got %v", tc.testCase, len(tc.expectedEvents), events)
+			continue
+		}
+		for i := range events {
+			if !strings.HasPrefix(events[i], tc.expectedEvents[i]) {
+				t.Errorf("%s - Failed: Expected Event '%s', got '%s'", tc.testCase, tc.expectedEvents[i], events[i])
+			}
+		}
+
+		// The unprofiled Container is reported but otherwise left alone
+		workload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{
+			Name:      "gold-workload",
+			Namespace: PowerPodNamespace,
+		}, workload)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload", tc.testCase))
+		}
+		if !reflect.DeepEqual(workload.Spec.Node.CpuIds, []int{1, 2}) {
+			t.Errorf("%s - Failed: Expected PowerWorkload CpuIds to be %v, got %v", tc.testCase, []int{1, 2}, workload.Spec.Node.CpuIds)
+		}
+	}
+}
+
+func TestPodCPUSetAddAndRemove(t *testing.T) {
+	tcases := []struct {
+		testCase             string
+		podInState           bool
+		expectedWorkloadCPUs []int
+		expectedContainers   []string
+	}{
+		{
+			testCase:             "Test Case 1 - Pod recorded in internal state",
+			podInState:           true,
+			expectedWorkloadCPUs: []int{1, 3, 5, 7, 8, 9},
+			expectedContainers:   []string{"example-pod-2/example-container-1", "example-pod/example-container-1", "example-pod/example-container-2"},
+		},
+		{
+			testCase:             "Test Case 2 - Node Agent restarted without internal state",
+			podInState:           false,
+			expectedWorkloadCPUs: []int{1, 3, 5, 7, 8, 9},
+			expectedContainers:   []string{"example-pod-2/example-container-1", "example-pod/example-container-1", "example-pod/example-container-2"},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+
+		containerResources := corev1.ResourceRequirements{
+			Limits: map[corev1.ResourceName]resource.Quantity{
+				corev1.ResourceName("cpu"):                                       *resource.NewQuantity(2, resource.DecimalSI),
+				corev1.ResourceName("power.intel.com/performance-example-node1"): *resource.NewQuantity(2, resource.DecimalSI),
+			},
+			Requests: map[corev1.ResourceName]resource.Quantity{
+				corev1.ResourceName("cpu"):                                       *resource.NewQuantity(2, resource.DecimalSI),
+				corev1.ResourceName("power.intel.com/performance-example-node1"): *resource.NewQuantity(2, resource.DecimalSI),
+			},
+		}
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example-pod",
+				Namespace: PowerPodNamespace,
+				UID:       "abcdefg",
+			},
+			Spec: corev1.PodSpec{
+				NodeName: "example-node1",
+				Containers: []corev1.Container{
+					{
+						Name:      "example-container-1",
+						Resources: containerResources,
+					},
+					{
+						Name:      "example-container-2",
+						Resources: containerResources,
+					},
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase:    corev1.PodRunning,
+				QOSClass: corev1.PodQOSGuaranteed,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:        "example-container-1",
+						ContainerID: "docker://abcdefg",
+					},
+					{
+						Name:        "example-container-2",
+						ContainerID: "docker://hijklmn",
+					},
+				},
+			},
+		}
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance-example-node1",
+			},
+		}
+		previousContainers := []powerv1alpha1.Container{
+			{Name: "example-container-1", Pod: "example-pod", ExclusiveCPUs: []int{1, 2}, PowerProfile: "performance-example-node1", Workload: "performance-example-node1-workload"},
+			{Name: "example-container-2", Pod: "example-pod", ExclusiveCPUs: []int{3, 4}, PowerProfile: "performance-example-node1", Workload: "performance-example-node1-workload"},
+		}
+		workload := &powerv1alpha1.PowerWorkload{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1-workload",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerWorkloadSpec{
+				Name: "performance-example-node1-workload",
+				Node: powerv1alpha1.NodeInfo{
+					Name:   "example-node1",
+					CpuIds: []int{1, 2, 3, 4, 6, 8, 9},
+					Containers: append([]powerv1alpha1.Container{
+						{Name: "example-container-1", Pod: "example-pod-2", ExclusiveCPUs: []int{8, 9}, PowerProfile: "performance-example-node1"},
+						{Name: "example-container-old", Pod: "example-pod", ExclusiveCPUs: []int{6}, PowerProfile: "performance-example-node1"},
+					}, previousContainers...),
+				},
+				PowerProfile: "performance-example-node1",
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod, powerProfile, workload})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		countingClient := &countingWorkloadClient{Client: r.Client}
+		r.Client = countingClient
+
+		if tc.podInState {
+			err = r.State.UpdateStateGuaranteedPods(powerv1alpha1.GuaranteedPod{
+				Node:       "example-node1",
+				Name:       pod.Name,
+				UID:        string(pod.UID),
+				Containers: previousContainers,
+			})
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error updating internal state", tc.testCase))
+			}
+		}
+
+		// Each Container both loses a core and gains one
+		r.PodResourcesClient = *createFakePodResourcesListerClient(&podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{1, 5},
+						},
+						{
+							Name:   "example-container-2",
+							CpuIds: []int64{3, 7},
+						},
+					},
+				},
+			},
+		})
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+
+		if countingClient.writes != 1 {
+			t.Errorf("%s - Failed: Expected the PowerWorkload to be written once, got %d writes", tc.testCase, countingClient.writes)
+		}
+
+		updatedWorkload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{
+			Name:      "performance-example-node1-workload",
+			Namespace: PowerPodNamespace,
+		}, updatedWorkload)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload", tc.testCase))
+		}
+
+		if !reflect.DeepEqual(updatedWorkload.Spec.Node.CpuIds, tc.expectedWorkloadCPUs) {
+			t.Errorf("%s - Failed: Expected PowerWorkload CpuIds to be %v, got %v", tc.testCase, tc.expectedWorkloadCPUs, updatedWorkload.Spec.Node.CpuIds)
+		}
+
+		containers := make([]string, 0)
+		for _, container := range updatedWorkload.Spec.Node.Containers {
+			containers = append(containers, container.Pod+"/"+container.Name)
+		}
+		if !reflect.DeepEqual(containers, tc.expectedContainers) {
+			t.Errorf("%s - Failed: Expected PowerWorkload Containers to be %v, got %v", tc.testCase, tc.expectedContainers, containers)
+		}
+	}
+}
+
+func TestWorkloadWriteThrottle(t *testing.T) {
+	tcases := []struct {
+		testCase             string
+		minWriteInterval     time.Duration
+		cpuSets              [][]int64
+		secondPodCPUs        []int64
+		expectedWrites       int
+		expectedWorkloadCPUs []int
+		expectedHeldCPUs     []int
+	}{
+		{
+			testCase:             "Test Case 1 - Rapid cpuset changes coalesced into one write",
+			minWriteInterval:     200 * time.Millisecond,
+			cpuSets:              [][]int64{{1, 2}, {1, 3}, {1, 4}, {1, 5}},
+			expectedWrites:       1,
+			expectedWorkloadCPUs: []int{1, 5},
+			expectedHeldCPUs:     []int{1, 2},
+		},
+		{
+			testCase:             "Test Case 2 - Write throttling disabled",
+			minWriteInterval:     0,
+			cpuSets:              [][]int64{{1, 2}, {1, 3}, {1, 4}, {1, 5}},
+			expectedWrites:       3,
+			expectedWorkloadCPUs: []int{1, 5},
+			expectedHeldCPUs:     []int{1, 5},
+		},
+		{
+			testCase:             "Test Case 3 - Unchanged Pod not held",
+			minWriteInterval:     200 * time.Millisecond,
+			cpuSets:              [][]int64{{1, 2}, {1, 2}, {1, 2}},
+			expectedWrites:       2,
+			expectedWorkloadCPUs: []int{1, 2},
+			expectedHeldCPUs:     []int{1, 2},
+		},
+		{
+			testCase:             "Test Case 4 - Changes of two Pods written together",
+			minWriteInterval:     200 * time.Millisecond,
+			cpuSets:              [][]int64{{1, 2}, {1, 3}},
+			secondPodCPUs:        []int64{6, 7},
+			expectedWrites:       1,
+			expectedWorkloadCPUs: []int{1, 3, 6, 7},
+			expectedHeldCPUs:     []int{1, 2},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+
+		pod := createGuaranteedPod("example-pod", "abcdefg", "example-node1", "example-container-1", "performance-example-node1", 2)
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance-example-node1",
+			},
+		}
+
+		secondPod := pod.DeepCopy()
+		secondPod.Name = "example-pod-2"
+		secondPod.UID = "hijklmn"
+		secondPod.Spec.Containers[0].Name = "example-container-2"
+		secondPod.Status.ContainerStatuses[0].Name = "example-container-2"
+		secondPod.Status.ContainerStatuses[0].ContainerID = "docker://hijklmn"
+
+		objs := []runtime.Object{pod, powerProfile}
+		if tc.secondPodCPUs != nil {
+			objs = append(objs, secondPod)
+		}
+		r, err := createPowerPodReconcilerObject(objs)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		countingClient := &countingWorkloadClient{Client: r.Client}
+		r.Client = countingClient
+		r.MinWorkloadWriteInterval = tc.minWriteInterval
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		for _, cpuSet := range tc.cpuSets {
+			podResources := []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: cpuSet,
+						},
+					},
+				},
+			}
+			if tc.secondPodCPUs != nil {
+				podResources = append(podResources, &podresourcesapi.PodResources{
+					Name: secondPod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-2",
+							CpuIds: tc.secondPodCPUs,
+						},
+					},
+				})
+			}
+			r.PodResourcesClient = *createFakePodResourcesListerClient(&podresourcesapi.ListPodResourcesResponse{
+				PodResources: podResources,
+			})
+
+			_, err = r.Reconcile(req)
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+			}
+
+			if tc.secondPodCPUs != nil {
+				_, err = r.Reconcile(reconcile.Request{
+					NamespacedName: client.ObjectKey{
+						Name:      secondPod.Name,
+						Namespace: PowerPodNamespace,
+					},
+				})
+				if err != nil {
+					t.Error(err)
+					t.Fatal(fmt.Sprintf("%s - error reconciling second Pod object", tc.testCase))
+				}
+			}
+		}
+
+		// A held change isn't recorded until it has been written
+		heldCPUs := r.State.GetCPUsFromPodState(r.State.GetPodFromState(pod.Name))
+		if !reflect.DeepEqual(heldCPUs, tc.expectedHeldCPUs) {
+			t.Errorf("%s - Failed: Expected the State to hold CPUs %v before the held changes are written, got %v", tc.testCase, tc.expectedHeldCPUs, heldCPUs)
+		}
+		if tc.secondPodCPUs != nil && r.State.GetPodFromState(secondPod.Name).Name != "" {
+			t.Errorf("%s - Failed: Expected the second Pod not to be in the State before its held change is written", tc.testCase)
+		}
+
+		// The held changes are written once the interval since the PowerWorkload was created has passed
+		r.workloadWrites.wait()
+
+		if countingClient.writes != tc.expectedWrites {
+			t.Errorf("%s - Failed: Expected the PowerWorkload to be updated %d times, got %d", tc.testCase, tc.expectedWrites, countingClient.writes)
+		}
+
+		workload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{
+			Name:      "performance-example-node1-workload",
+			Namespace: PowerPodNamespace,
+		}, workload)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload", tc.testCase))
+		}
+
+		if !reflect.DeepEqual(workload.Spec.Node.CpuIds, tc.expectedWorkloadCPUs) {
+			t.Errorf("%s - Failed: Expected PowerWorkload CpuIds to be %v, got %v", tc.testCase, tc.expectedWorkloadCPUs, workload.Spec.Node.CpuIds)
+		}
+
+		lastCPUs := make([]int, 0)
+		for _, cpu := range tc.cpuSets[len(tc.cpuSets)-1] {
+			lastCPUs = append(lastCPUs, int(cpu))
+		}
+		stateCPUs := r.State.GetCPUsFromPodState(r.State.GetPodFromState(pod.Name))
+		if !reflect.DeepEqual(stateCPUs, lastCPUs) {
+			t.Errorf("%s - Failed: Expected the State to hold CPUs %v once the held changes are written, got %v", tc.testCase, lastCPUs, stateCPUs)
+		}
+		if tc.secondPodCPUs != nil && r.State.GetPodFromState(secondPod.Name).Name == "" {
+			t.Errorf("%s - Failed: Expected the second Pod to be in the State once its held change is written", tc.testCase)
+		}
+
+		// Deleting the PowerWorkload drops the record of its last write
+		deletedPods := []*corev1.Pod{pod}
+		if tc.secondPodCPUs != nil {
+			deletedPods = append(deletedPods, secondPod)
+		}
+		for _, deletedPod := range deletedPods {
+			err = r.Client.Get(context.TODO(), client.ObjectKey{Name: deletedPod.Name, Namespace: PowerPodNamespace}, deletedPod)
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error retrieving Pod", tc.testCase))
+			}
+			now := metav1.Now()
+			deletedPod.DeletionTimestamp = &now
+			err = r.Client.Update(context.TODO(), deletedPod)
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error updating Pod DeletionTimestamp", tc.testCase))
+			}
+
+			_, err = r.Reconcile(reconcile.Request{
+				NamespacedName: client.ObjectKey{
+					Name:      deletedPod.Name,
+					Namespace: PowerPodNamespace,
+				},
+			})
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error reconciling Pod deletion", tc.testCase))
+			}
+		}
+
+		workloadKey := client.ObjectKey{Name: "performance-example-node1-workload", Namespace: PowerPodNamespace}
+		err = r.Client.Get(context.TODO(), workloadKey, &powerv1alpha1.PowerWorkload{})
+		if !errors.IsNotFound(err) {
+			t.Errorf("%s - Failed: Expected the PowerWorkload to be deleted", tc.testCase)
+		}
+		if _, written := r.workloadWrites.written[workloadKey]; written {
+			t.Errorf("%s - Failed: Expected the deleted PowerWorkload's last write to be forgotten", tc.testCase)
+		}
+	}
+}
+
+func TestDecisionSinkRecord(t *testing.T) {
+	tcases := []struct {
+		testCase        string
+		configureSink   bool
+		expectedRecords []DecisionRecord
+	}{
+		{
+			testCase:      "Test Case 1 - HTTP sink receives a record for each decision",
+			configureSink: true,
+			expectedRecords: []DecisionRecord{
+				{
+					Action:         "create",
+					Workload:       "performance-example-node1-workload",
+					Namespace:      PowerPodNamespace,
+					Node:           "example-node1",
+					Profile:        "performance-example-node1",
+					PodName:        "example-pod",
+					PodUID:         "abcdefg",
+					AllocatedCores: []int{1, 2},
+					WorkloadCores:  []int{1, 2},
+				},
+				{
+					Action:         "update",
+					Workload:       "performance-example-node1-workload",
+					Namespace:      PowerPodNamespace,
+					Node:           "example-node1",
+					Profile:        "performance-example-node1",
+					PodName:        "example-pod",
+					PodUID:         "abcdefg",
+					AllocatedCores: []int{3},
+					ReleasedCores:  []int{2},
+					WorkloadCores:  []int{1, 3},
+				},
+				{
+					Action:        "delete",
+					Workload:      "performance-example-node1-workload",
+					Namespace:     PowerPodNamespace,
+					Node:          "example-node1",
+					Profile:       "performance-example-node1",
+					PodName:       "example-pod",
+					PodUID:        "abcdefg",
+					ReleasedCores: []int{1, 3},
+					WorkloadCores: []int{},
+				},
+			},
+		},
+		{
+			testCase:        "Test Case 2 - No sink configured",
+			configureSink:   false,
+			expectedRecords: []DecisionRecord{},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+
+		received := make(chan DecisionRecord, 10)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			record := DecisionRecord{}
+			_ = json.NewDecoder(r.Body).Decode(&record)
+			received <- record
+		}))
+
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "example-pod",
+				Namespace:  PowerPodNamespace,
+				UID:        "abcdefg",
+				Finalizers: []string{"example-finalizer"},
+			},
+			Spec: corev1.PodSpec{
+				NodeName: "example-node1",
+				Containers: []corev1.Container{
+					{
+						Name: "example-container-1",
+						Resources: corev1.ResourceRequirements{
+							Limits: map[corev1.ResourceName]resource.Quantity{
+								corev1.ResourceName("cpu"):                                       *resource.NewQuantity(2, resource.DecimalSI),
+								corev1.ResourceName("power.intel.com/performance-example-node1"): *resource.NewQuantity(2, resource.DecimalSI),
+							},
+							Requests: map[corev1.ResourceName]resource.Quantity{
+								corev1.ResourceName("cpu"):                                       *resource.NewQuantity(2, resource.DecimalSI),
+								corev1.ResourceName("power.intel.com/performance-example-node1"): *resource.NewQuantity(2, resource.DecimalSI),
+							},
+						},
+					},
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase:    corev1.PodRunning,
+				QOSClass: corev1.PodQOSGuaranteed,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:        "example-container-1",
+						ContainerID: "docker://abcdefg",
+					},
+				},
+			},
+		}
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance-example-node1",
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod, powerProfile})
+		if err != nil {
+			server.Close()
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		if tc.configureSink {
+			r.DecisionSink = NewHTTPDecisionSink(server.URL, time.Second, ctrl.Log.WithName("decision-sink"))
+		}
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		// The Pod is created, has its cpuset changed and is then deleted
+		records := make([]DecisionRecord, 0)
+		for step, cpuSet := range [][]int64{{1, 2}, {1, 3}, {1, 3}} {
+			if step == 2 {
+				now := metav1.Now()
+				pod.DeletionTimestamp = &now
+				err = r.Client.Update(context.TODO(), pod)
+				if err != nil {
+					server.Close()
+					t.Fatal(fmt.Sprintf("%s - error updating Pod DeletionTimestamp", tc.testCase))
+				}
+			}
+
+			r.PodResourcesClient = *createFakePodResourcesListerClient(&podresourcesapi.ListPodResourcesResponse{
+				PodResources: []*podresourcesapi.PodResources{
+					{
+						Name: pod.Name,
+						Containers: []*podresourcesapi.ContainerResources{
+							{
+								Name:   "example-container-1",
+								CpuIds: cpuSet,
+							},
+						},
+					},
+				},
+			})
+
+			_, err = r.Reconcile(req)
+			if err != nil {
+				server.Close()
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+			}
+
+			// Records are sent in the background, so wait for each before the next decision is made
+			wait := 50 * time.Millisecond
+			if tc.configureSink {
+				wait = time.Second
+			}
+			select {
+			case record := <-received:
+				record.Timestamp = time.Time{}
+				records = append(records, record)
+			case <-time.After(wait):
+			}
+		}
+		server.Close()
+
+		if !reflect.DeepEqual(records, tc.expectedRecords) {
+			t.Errorf("%s - Failed: Expected decision records %+v, got %+v", tc.testCase, tc.expectedRecords, records)
+		}
+	}
+}
+
+func TestGetContainerIDByPhase(t *testing.T) {
+	tcases := []struct {
+		testCase      string
+		containerName string
+		phase         containerPhase
+		expectedID    string
+	}{
+		{
+			testCase:      "Test Case 1 - Name shared by init and app container, app phase",
+			containerName: "shared-name",
+			phase:         appContainerPhase,
+			expectedID:    "docker://app-shared",
+		},
+		{
+			testCase:      "Test Case 2 - Name shared by init and app container, init phase",
+			containerName: "shared-name",
+			phase:         initContainerPhase,
+			expectedID:    "docker://init-shared",
+		},
+		{
+			testCase:      "Test Case 3 - Init container only, app phase falls back to init statuses",
+			containerName: "init-only",
+			phase:         appContainerPhase,
+			expectedID:    "docker://init-only",
+		},
+		{
+			testCase:      "Test Case 4 - App container only, init phase falls back to app statuses",
+			containerName: "app-only",
+			phase:         initContainerPhase,
+			expectedID:    "docker://app-only",
+		},
+		{
+			testCase:      "Test Case 5 - Unknown container",
+			containerName: "missing",
+			phase:         appContainerPhase,
+			expectedID:    "",
+		},
+	}
+
+	pod := &corev1.Pod{
+		Status: corev1.PodStatus{
+			InitContainerStatuses: []corev1.ContainerStatus{
+				{Name: "shared-name", ContainerID: "docker://init-shared"},
+				{Name: "init-only", ContainerID: "docker://init-only"},
+			},
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "shared-name", ContainerID: "docker://app-shared"},
+				{Name: "app-only", ContainerID: "docker://app-only"},
+			},
+		},
+	}
+
+	for _, tc := range tcases {
+		containerID := getContainerID(pod, tc.containerName, tc.phase)
+		if containerID != tc.expectedID {
+			t.Errorf("%s - Failed: Expected container ID '%s', got '%s'", tc.testCase, tc.expectedID, containerID)
+		}
+	}
+}
+
+func TestWorkloadCoresHistogram(t *testing.T) {
+	tcases := []struct {
+		testCase       string
+		nodeName       string
+		seededCPUs     []int
+		podCPUs        []int64
+		expectedBucket float64
+		expectedCores  float64
+	}{
+		{
+			testCase:       "Test Case 1 - New PowerWorkload",
+			nodeName:       "workload-cores-node1",
+			podCPUs:        []int64{1, 2, 3},
+			expectedBucket: 4,
+			expectedCores:  3,
+		},
+		{
+			testCase:       "Test Case 2 - Seeded PowerWorkload",
+			nodeName:       "workload-cores-node2",
+			seededCPUs:     []int{10, 11, 12, 13},
+			podCPUs:        []int64{1, 2},
+			expectedBucket: 8,
+			expectedCores:  6,
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", tc.nodeName)
+		profileName := fmt.Sprintf("performance-%s", tc.nodeName)
+		numCPUs := int64(len(tc.podCPUs))
+
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example-pod",
+				Namespace: PowerPodNamespace,
+				UID:       "abcdefg",
+			},
+			Spec: corev1.PodSpec{
+				NodeName: tc.nodeName,
+				Containers: []corev1.Container{
+					{
+						Name: "example-container-1",
+						Resources: corev1.ResourceRequirements{
+							Limits: map[corev1.ResourceName]resource.Quantity{
+								corev1.ResourceName("cpu"): *resource.NewQuantity(numCPUs, resource.DecimalSI),
+								corev1.ResourceName(fmt.Sprintf("power.intel.com/%s", profileName)): *resource.NewQuantity(numCPUs, resource.DecimalSI),
+							},
+							Requests: map[corev1.ResourceName]resource.Quantity{
+								corev1.ResourceName("cpu"): *resource.NewQuantity(numCPUs, resource.DecimalSI),
+								corev1.ResourceName(fmt.Sprintf("power.intel.com/%s", profileName)): *resource.NewQuantity(numCPUs, resource.DecimalSI),
+							},
+						},
+					},
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase:    corev1.PodRunning,
+				QOSClass: corev1.PodQOSGuaranteed,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:        "example-container-1",
+						ContainerID: "docker://abcdefg",
+					},
+				},
+			},
+		}
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      profileName,
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: profileName,
+				Epp:  "performance",
+			},
+		}
+
+		objs := []runtime.Object{pod, powerProfile}
+		if tc.seededCPUs != nil {
+			objs = append(objs, &powerv1alpha1.PowerWorkload{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      profileName + WorkloadNameSuffix,
+					Namespace: PowerPodNamespace,
+				},
+				Spec: powerv1alpha1.PowerWorkloadSpec{
+					Name:         profileName + WorkloadNameSuffix,
+					PowerProfile: profileName,
+					Node: powerv1alpha1.NodeInfo{
+						Name:   tc.nodeName,
+						CpuIds: tc.seededCPUs,
+					},
+				},
+			})
+		}
+
+		r, err := createPowerPodReconcilerObject(objs)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		r.PodResourcesClient = *createFakePodResourcesListerClient(&podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: tc.podCPUs,
+						},
+					},
+				},
+			},
+		})
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+
+		metric := &dto.Metric{}
+		err = workloadCores.WithLabelValues(tc.nodeName).(prometheus.Metric).Write(metric)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reading workload cores metric", tc.testCase))
+		}
+
+		if metric.GetHistogram().GetSampleCount() != 1 {
+			t.Errorf("%s - Failed: Expected workload cores sample count to be 1, got %v", tc.testCase, metric.GetHistogram().GetSampleCount())
+		}
+		if metric.GetHistogram().GetSampleSum() != tc.expectedCores {
+			t.Errorf("%s - Failed: Expected workload cores sample to be %v, got %v", tc.testCase, tc.expectedCores, metric.GetHistogram().GetSampleSum())
+		}
+
+		// The sample should fall in the expected bucket and none below it
+		for _, bucket := range metric.GetHistogram().GetBucket() {
+			expectedCount := uint64(0)
+			if bucket.GetUpperBound() >= tc.expectedBucket {
+				expectedCount = 1
+			}
+			if bucket.GetCumulativeCount() != expectedCount {
+				t.Errorf("%s - Failed: Expected bucket le=%v to have count %v, got %v", tc.testCase, bucket.GetUpperBound(), expectedCount, bucket.GetCumulativeCount())
+			}
+		}
+	}
+}
+
+func TestAppQoSOnlyProfileRejected(t *testing.T) {
+	tcases := []struct {
+		testCase                string
+		allowAppQoSOnlyProfiles bool
+		profileCRs              []runtime.Object
+		expectedError           bool
+		expectedEvent           string
+		expectedWorkload        bool
+	}{
+		{
+			testCase:                "Test Case 1 - Profile only in AppQoS",
+			allowAppQoSOnlyProfiles: false,
+			profileCRs:              []runtime.Object{},
+			expectedError:           true,
+			expectedEvent:           "PowerProfileCRMissing",
+			expectedWorkload:        false,
+		},
+		{
+			testCase:                "Test Case 2 - Profile in AppQoS and as a CR",
+			allowAppQoSOnlyProfiles: false,
+			profileCRs: []runtime.Object{
+				&powerv1alpha1.PowerProfile{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "performance-example-node1",
+						Namespace: PowerPodNamespace,
+					},
+					Spec: powerv1alpha1.PowerProfileSpec{
+						Name: "performance-example-node1",
+						Epp:  "performance",
+					},
+				},
+			},
+			expectedError:    false,
+			expectedEvent:    "",
+			expectedWorkload: true,
+		},
+		{
+			testCase:                "Test Case 3 - Profile only in AppQoS when allowed",
+			allowAppQoSOnlyProfiles: true,
+			profileCRs:              []runtime.Object{},
+			expectedError:           false,
+			expectedEvent:           "",
+			expectedWorkload:        true,
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		pod := createGuaranteedPod("example-pod", "abcdefg", "example-node1", "example-container-1", "performance-example-node1", 2)
+
+		r, err := createPowerPodReconcilerObject(append([]runtime.Object{pod}, tc.profileCRs...))
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		recorder := record.NewFakeRecorder(10)
+		r.Recorder = recorder
+		r.AllowAppQoSOnlyProfiles = tc.allowAppQoSOnlyProfiles
+		r.AppQoSClient = appqos.NewDefaultAppQoSClient()
+		r.PodResourcesClient = *createFakePodResourcesListerClient(&podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{1, 2},
+						},
+					},
+				},
+			},
+		})
+
+		server, err := createListeners([]appqos.Pool{}, []appqos.PowerProfile{
+			{
+				ID:   intPtr(1),
+				Name: stringPtr("performance-example-node1"),
+			},
+		}, "4.0.0")
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating Listeners", tc.testCase))
+		}
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		server.Close()
+		if (err != nil) != tc.expectedError {
+			t.Errorf("%s - Failed: Expected error to be %v, got %v", tc.testCase, tc.expectedError, err)
+		}
+
+		event := ""
+		select {
+		case event = <-recorder.Events:
+		default:
+		}
+		if !strings.Contains(event, tc.expectedEvent) || (tc.expectedEvent == "") != (event == "") {
+			t.Errorf("%s - Failed: Expected Event '%s', got '%s'", tc.testCase, tc.expectedEvent, event)
+		}
+
+		workload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{
+			Name:      "performance-example-node1-workload",
+			Namespace: PowerPodNamespace,
+		}, workload)
+		if tc.expectedWorkload != (err == nil) {
+			t.Errorf("%s - Failed: Expected PowerWorkload to exist to be %v, got error %v", tc.testCase, tc.expectedWorkload, err)
+		}
+	}
+}
+
+func TestEmptiedNodeRepopulated(t *testing.T) {
+	tcases := []struct {
+		testCase       string
+		seededWorkload bool
+		expectedCores  []int
+	}{
+		{
+			testCase:       "Test Case 1 - PowerWorkload's NodeInfo emptied",
+			seededWorkload: true,
+			expectedCores:  []int{3, 4},
+		},
+		{
+			testCase:       "Test Case 2 - No PowerWorkload",
+			seededWorkload: false,
+			expectedCores:  []int{3, 4},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		pod := createGuaranteedPod("example-pod", "abcdefg", "example-node1", "example-container-1", "performance-example-node1", 2)
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance-example-node1",
+				Epp:  "performance",
+			},
+		}
+
+		objs := []runtime.Object{pod, powerProfile}
+		if tc.seededWorkload {
+			// The last Pod on the Node left, and its NodeInfo was cleared along with its Pool in AppQoS
+			objs = append(objs, &powerv1alpha1.PowerWorkload{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "performance-example-node1-workload",
+					Namespace: PowerPodNamespace,
+				},
+				Spec: powerv1alpha1.PowerWorkloadSpec{
+					Name:         "performance-example-node1-workload",
+					PowerProfile: "performance-example-node1",
+				},
+			})
+		}
+
+		r, err := createPowerPodReconcilerObject(objs)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(&podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{3, 4},
+						},
+					},
+				},
+			},
+		})
+
+		_, err = r.Reconcile(reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+
+		appqosPools := []appqos.Pool{
+			{
+				Name:  stringPtr("Default"),
+				ID:    intPtr(1),
+				Cores: &[]int{3, 4, 5, 6, 7},
+			},
+		}
+		listener, err := net.Listen("tcp", "127.0.0.1:5000")
+		if err != nil {
+			t.Fatal(fmt.Sprintf("%s - error creating Listener: %v", tc.testCase, err))
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/pools/", (func(w http.ResponseWriter, r *http.Request) {
+			path := strings.Split(r.URL.Path, "/")
+			id, _ := strconv.Atoi(path[len(path)-1])
+			p := appqos.Pool{}
+			_ = json.NewDecoder(r.Body).Decode(&p)
+			for i := range appqosPools {
+				if *appqosPools[i].ID == id {
+					appqosPools[i].Cores = p.Cores
+				}
+			}
+		}))
+		mux.HandleFunc("/pools", (func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "POST" {
+				p := appqos.Pool{}
+				_ = json.NewDecoder(r.Body).Decode(&p)
+				p.ID = intPtr(len(appqosPools) + 10)
+				appqosPools = append(appqosPools, p)
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprintln(w, "\"okay\"")
+				return
+			}
+
+			b, err := json.Marshal(appqosPools)
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+		mux.HandleFunc("/power_profiles", (func(w http.ResponseWriter, r *http.Request) {
+			b, err := json.Marshal([]appqos.PowerProfile{
+				{
+					Name: stringPtr("performance-example-node1"),
+					ID:   intPtr(1),
+				},
+			})
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+		server := httptest.NewUnstartedServer(mux)
+		server.Listener.Close()
+		server.Listener = listener
+		server.Start()
+
+		// The PowerWorkload controller on the Node applies the re-added cores to AppQoS
+		workloadReconciler := &PowerWorkloadReconciler{
+			Client:       r.Client,
+			Log:          ctrl.Log.WithName("controllers").WithName("PowerWorkload"),
+			Scheme:       scheme.Scheme,
+			AppQoSClient: appqos.NewDefaultAppQoSClient(),
+		}
+		_, err = workloadReconciler.Reconcile(reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      "performance-example-node1-workload",
+				Namespace: PowerPodNamespace,
+			},
+		})
+		server.Close()
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling PowerWorkload object", tc.testCase))
+		}
+
+		var pool *appqos.Pool
+		for i := range appqosPools {
+			if *appqosPools[i].Name == "performance-example-node1-workload" {
+				pool = &appqosPools[i]
+			}
+		}
+		if pool == nil {
+			t.Errorf("%s - Failed: Expected Pool 'performance-example-node1-workload' to be applied to AppQoS, got none", tc.testCase)
+			continue
+		}
+		if !reflect.DeepEqual(*pool.Cores, tc.expectedCores) {
+			t.Errorf("%s - Failed: Expected Pool cores to be %v, got %v", tc.testCase, tc.expectedCores, *pool.Cores)
+		}
+		if pool.PowerProfile == nil || *pool.PowerProfile != 1 {
+			t.Errorf("%s - Failed: Expected Pool to have PowerProfile 1, got %v", tc.testCase, pool.PowerProfile)
+		}
+	}
+}
+
+func TestNamespaceCoreQuota(t *testing.T) {
+	tcases := []struct {
+		testCase            string
+		namespaceCoreQuotas map[string]int
+		managedPod          string
+		managedNamespace    string
+		managedCores        int
+		expectedAllocated   bool
+		expectedEvent       string
+	}{
+		{
+			testCase:   "Test Case 1 - Quota reached exactly",
+			managedPod: "other-pod",
+			namespaceCoreQuotas: map[string]int{
+				PowerPodNamespace: 4,
+			},
+			managedNamespace:  PowerPodNamespace,
+			managedCores:      2,
+			expectedAllocated: true,
+			expectedEvent:     "",
+		},
+		{
+			testCase:   "Test Case 2 - Quota exceeded by one core",
+			managedPod: "other-pod",
+			namespaceCoreQuotas: map[string]int{
+				PowerPodNamespace: 3,
+			},
+			managedNamespace:  PowerPodNamespace,
+			managedCores:      2,
+			expectedAllocated: false,
+			expectedEvent:     "NamespaceCoreQuotaExceeded",
+		},
+		{
+			testCase:   "Test Case 3 - Wildcard quota exceeded",
+			managedPod: "other-pod",
+			namespaceCoreQuotas: map[string]int{
+				"*": 3,
+			},
+			managedNamespace:  PowerPodNamespace,
+			managedCores:      2,
+			expectedAllocated: false,
+			expectedEvent:     "NamespaceCoreQuotaExceeded",
+		},
+		{
+			testCase:   "Test Case 4 - Cores managed in another namespace",
+			managedPod: "other-pod",
+			namespaceCoreQuotas: map[string]int{
+				PowerPodNamespace: 3,
+			},
+			managedNamespace:  "other-namespace",
+			managedCores:      2,
+			expectedAllocated: true,
+			expectedEvent:     "",
+		},
+		{
+			testCase:   "Test Case 5 - Namespace's own entry overrides wildcard",
+			managedPod: "other-pod",
+			namespaceCoreQuotas: map[string]int{
+				PowerPodNamespace: 4,
+				"*":               1,
+			},
+			managedNamespace:  PowerPodNamespace,
+			managedCores:      2,
+			expectedAllocated: true,
+			expectedEvent:     "",
+		},
+		{
+			testCase:   "Test Case 6 - Same-named Pod managed in another namespace",
+			managedPod: "example-pod",
+			namespaceCoreQuotas: map[string]int{
+				PowerPodNamespace: 2,
+			},
+			managedNamespace:  "other-namespace",
+			managedCores:      2,
+			expectedAllocated: true,
+			expectedEvent:     "",
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		cpuhotplug.CPUDevicesPath = createFakeCPUDevices(t, map[int]string{1: "1", 2: "2"})
+
+		pod := createGuaranteedPod("example-pod", "abcdefg", "example-node1", "example-container-1", "performance-example-node1", 2)
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance-example-node1",
+				Epp:  "performance",
+			},
+		}
+		powerConfig := &powerv1alpha1.PowerConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "power-config",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerConfigSpec{
+				NamespaceCoreQuotas: tc.namespaceCoreQuotas,
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod, powerProfile, powerConfig})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		recorder := record.NewFakeRecorder(10)
+		r.Recorder = recorder
+		r.State.UpdateManagedCores(tc.managedPod, tc.managedNamespace, tc.managedCores)
+
+		r.PodResourcesClient = *createFakePodResourcesListerClient(&podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{1, 2},
+						},
+					},
+				},
+			},
+		})
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		// A rejected Pod is reported once however often it is reconciled
+		for i := 0; i < 2; i++ {
+			_, err = r.Reconcile(req)
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+			}
+		}
+
+		workload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{
+			Name:      "performance-example-node1-workload",
+			Namespace: PowerPodNamespace,
+		}, workload)
+		if err != nil && !errors.IsNotFound(err) {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload", tc.testCase))
+		}
+
+		if (err == nil) != tc.expectedAllocated {
+			t.Errorf("%s - Failed: Expected PowerWorkload to exist to be %v, got %v", tc.testCase, tc.expectedAllocated, err == nil)
+		}
+
+		// An allocated Pod's cores count against its namespace's quota from then on
+		expectedManaged := 0
+		if tc.managedNamespace == PowerPodNamespace {
+			expectedManaged = tc.managedCores
+		}
+		if tc.expectedAllocated {
+			expectedManaged += 2
+		}
+		managed := r.State.GetNamespaceManagedCores(PowerPodNamespace, "")
+		if managed != expectedManaged {
+			t.Errorf("%s - Failed: Expected namespace to have %d managed cores, got %d", tc.testCase, expectedManaged, managed)
+		}
+
+		if managed := r.State.GetNamespaceManagedCores("other-namespace", ""); tc.managedNamespace == "other-namespace" && managed != tc.managedCores {
+			t.Errorf("%s - Failed: Expected other namespace to keep %d managed cores, got %d", tc.testCase, tc.managedCores, managed)
+		}
+
+		event := ""
+		select {
+		case event = <-recorder.Events:
+		default:
+		}
+		if !strings.Contains(event, tc.expectedEvent) || (tc.expectedEvent == "") != (event == "") {
+			t.Errorf("%s - Failed: Expected Event '%s', got '%s'", tc.testCase, tc.expectedEvent, event)
+		}
+		if len(recorder.Events) != 0 {
+			t.Errorf("%s - Failed: Expected a single Event, got %d more", tc.testCase, len(recorder.Events))
+		}
+	}
+}
+
+func TestPodCleanupFinalizer(t *testing.T) {
+	tcases := []struct {
+		testCase               string
+		existingFinalizers     []string
+		restartBeforeDelete    bool
+		deletionCoalesceWindow time.Duration
+		otherNamespaceCPUs     []int
+	}{
+		{
+			testCase:               "Test Case 1 - Finalizer removed once cores are released",
+			existingFinalizers:     nil,
+			restartBeforeDelete:    false,
+			deletionCoalesceWindow: 0,
+			otherNamespaceCPUs:     nil,
+		},
+		{
+			testCase:               "Test Case 2 - Cores released from PowerWorkloads after internal state is lost",
+			existingFinalizers:     []string{"example-finalizer"},
+			restartBeforeDelete:    true,
+			deletionCoalesceWindow: 0,
+			otherNamespaceCPUs:     nil,
+		},
+		{
+			testCase:               "Test Case 3 - Finalized Pod released without deletion coalescing",
+			existingFinalizers:     nil,
+			restartBeforeDelete:    false,
+			deletionCoalesceWindow: time.Hour,
+			otherNamespaceCPUs:     nil,
+		},
+		{
+			testCase:               "Test Case 4 - Same-named Pod in another namespace keeps its cores",
+			existingFinalizers:     nil,
+			restartBeforeDelete:    true,
+			deletionCoalesceWindow: 0,
+			otherNamespaceCPUs:     []int{5, 6},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "example-pod",
+				Namespace:  PowerPodNamespace,
+				UID:        "abcdefg",
+				Finalizers: tc.existingFinalizers,
+			},
+			Spec: corev1.PodSpec{
+				NodeName: "example-node1",
+				Containers: []corev1.Container{
+					{
+						Name: "example-container-1",
+						Resources: corev1.ResourceRequirements{
+							Limits: map[corev1.ResourceName]resource.Quantity{
+								corev1.ResourceName("cpu"):                                       *resource.NewQuantity(2, resource.DecimalSI),
+								corev1.ResourceName("power.intel.com/performance-example-node1"): *resource.NewQuantity(2, resource.DecimalSI),
+							},
+							Requests: map[corev1.ResourceName]resource.Quantity{
+								corev1.ResourceName("cpu"):                                       *resource.NewQuantity(2, resource.DecimalSI),
+								corev1.ResourceName("power.intel.com/performance-example-node1"): *resource.NewQuantity(2, resource.DecimalSI),
+							},
+						},
+					},
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase:    corev1.PodRunning,
+				QOSClass: corev1.PodQOSGuaranteed,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:        "example-container-1",
+						ContainerID: "docker://abcdefg",
+					},
+				},
+			},
+		}
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "example-node1",
+			},
+		}
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance-example-node1",
+				Epp:  "performance",
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod, node, powerProfile})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		r.AddCleanupFinalizer = true
+		r.DeletionCoalesceWindow = tc.deletionCoalesceWindow
+
+		fakeListResponse := &podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{1, 2},
+						},
+					},
+				},
+			},
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(fakeListResponse)
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		// Reconciling more than once must not attach the finalizer more than once
+		for i := 0; i < 2; i++ {
+			_, err = r.Reconcile(req)
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+			}
+		}
+
+		updatedPod := &corev1.Pod{}
+		err = r.Client.Get(context.TODO(), req.NamespacedName, updatedPod)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving Pod", tc.testCase))
+		}
+
+		// A Pod of the same name in another namespace shares the PowerWorkload
+		if tc.otherNamespaceCPUs != nil {
+			sharedWorkload := &powerv1alpha1.PowerWorkload{}
+			err = r.Client.Get(context.TODO(), client.ObjectKey{Name: "performance-example-node1-workload", Namespace: PowerPodNamespace}, sharedWorkload)
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload", tc.testCase))
+			}
+
+			sharedWorkload.Spec.Node.CpuIds = append(sharedWorkload.Spec.Node.CpuIds, tc.otherNamespaceCPUs...)
+			sharedWorkload.Spec.Node.Containers = append(sharedWorkload.Spec.Node.Containers, powerv1alpha1.Container{
+				Name:          "example-container-1",
+				Id:            "hijklmn",
+				Pod:           pod.Name,
+				PodUID:        "other-namespace-pod-uid",
+				ExclusiveCPUs: tc.otherNamespaceCPUs,
+				PowerProfile:  "performance-example-node1",
+			})
+			err = r.Client.Update(context.TODO(), sharedWorkload)
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error updating PowerWorkload", tc.testCase))
+			}
+		}
+
+		expectedFinalizers := append(append([]string{}, tc.existingFinalizers...), PodCleanupFinalizer)
+		if !reflect.DeepEqual(updatedPod.GetFinalizers(), expectedFinalizers) {
+			t.Errorf("%s - Failed: Expected Pod finalizers to be %v, got %v", tc.testCase, expectedFinalizers, updatedPod.GetFinalizers())
+		}
+
+		if tc.restartBeforeDelete {
+			r.State, err = podstate.NewState()
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error creating internal state", tc.testCase))
+			}
+		}
+
+		now := metav1.Now()
+		updatedPod.DeletionTimestamp = &now
+		err = r.Client.Update(context.TODO(), updatedPod)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error updating Pod DeletionTimestamp", tc.testCase))
+		}
+
+		// Reconciling the deletion again once the finalizer is gone must be a no-op
+		for i := 0; i < 2; i++ {
+			_, err = r.Reconcile(req)
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error reconciling Pod deletion", tc.testCase))
+			}
+		}
+
+		workload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{Name: "performance-example-node1-workload", Namespace: PowerPodNamespace}, workload)
+		if tc.otherNamespaceCPUs == nil && !errors.IsNotFound(err) {
+			t.Errorf("%s - Failed: Expected PowerWorkload to be deleted once the Pod's cores were released, got %v", tc.testCase, workload.Spec.Node.CpuIds)
+		}
+		if tc.otherNamespaceCPUs != nil && !reflect.DeepEqual(workload.Spec.Node.CpuIds, tc.otherNamespaceCPUs) {
+			t.Errorf("%s - Failed: Expected PowerWorkload to keep only the other Pod's CPUs %v, got %v", tc.testCase, tc.otherNamespaceCPUs, workload.Spec.Node.CpuIds)
+		}
+
+		deletedPod := &corev1.Pod{}
+		err = r.Client.Get(context.TODO(), req.NamespacedName, deletedPod)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving Pod", tc.testCase))
+		}
+
+		if !reflect.DeepEqual(deletedPod.GetFinalizers(), tc.existingFinalizers) {
+			t.Errorf("%s - Failed: Expected Pod finalizers to be %v, got %v", tc.testCase, tc.existingFinalizers, deletedPod.GetFinalizers())
+		}
+	}
+}
+
+func TestReadOnlyDeletionKeepsPodInState(t *testing.T) {
+	tcases := []struct {
+		testCase       string
+		expectedCpuIds []int
+	}{
+		{
+			testCase:       "Test Case 1",
+			expectedCpuIds: []int{1, 2},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+
+		pod := createGuaranteedPod("example-pod", "abcdefg", "example-node1", "example-container-1", "performance-example-node1", 2)
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "example-node1",
+			},
+		}
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance-example-node1",
+				Epp:  "performance",
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod, node, powerProfile})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		fakeListResponse := &podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{1, 2},
+						},
+					},
+				},
+			},
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(fakeListResponse)
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+
+		now := metav1.Now()
+		pod.DeletionTimestamp = &now
+		err = r.Client.Update(context.TODO(), pod)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error updating Pod DeletionTimestamp", tc.testCase))
+		}
+
+		// The release is denied, so the Pod must stay in the State for the requeue to release its cores
+		writableClient := r.Client
+		r.Client = &forbiddenWorkloadClient{Client: writableClient}
+		result, err := r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod deletion", tc.testCase))
+		}
+		if result.RequeueAfter <= 0 {
+			t.Errorf("%s - Failed: Expected deletion to be requeued while in read-only mode", tc.testCase)
+		}
+
+		if r.State.GetPodFromState(pod.Name).Name == "" {
+			t.Errorf("%s - Failed: Expected Pod to be kept in the internal state while in read-only mode", tc.testCase)
+		}
+
+		workload := &powerv1alpha1.PowerWorkload{}
+		err = writableClient.Get(context.TODO(), client.ObjectKey{Name: "performance-example-node1-workload", Namespace: PowerPodNamespace}, workload)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload", tc.testCase))
+		}
+		if !reflect.DeepEqual(workload.Spec.Node.CpuIds, tc.expectedCpuIds) {
+			t.Errorf("%s - Failed: Expected PowerWorkload CPUs to be %v while in read-only mode, got %v", tc.testCase, tc.expectedCpuIds, workload.Spec.Node.CpuIds)
+		}
+
+		// Once writes are permitted again the requeued deletion releases the cores
+		r.Client = writableClient
+		r.workloadWritesForbiddenUntil = time.Time{}
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod deletion", tc.testCase))
+		}
+
+		err = r.Client.Get(context.TODO(), client.ObjectKey{Name: "performance-example-node1-workload", Namespace: PowerPodNamespace}, workload)
+		if !errors.IsNotFound(err) {
+			t.Errorf("%s - Failed: Expected PowerWorkload to be deleted once writes were permitted, got %v", tc.testCase, err)
+		}
+
+		if r.State.GetPodFromState(pod.Name).Name != "" {
+			t.Errorf("%s - Failed: Expected Pod to be removed from the internal state once its cores were released", tc.testCase)
+		}
+	}
+}
+
+func TestTimedOutDeletionRetried(t *testing.T) {
+	tcases := []struct {
+		testCase         string
+		reconcileTimeout time.Duration
+		expectedCpuIds   []int
+	}{
+		{
+			testCase:         "Test Case 1",
+			reconcileTimeout: 50 * time.Millisecond,
+			expectedCpuIds:   []int{1, 2},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+
+		pod := createGuaranteedPod("example-pod", "abcdefg", "example-node1", "example-container-1", "performance-example-node1", 2)
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "example-node1",
+			},
+		}
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance-example-node1",
+				Epp:  "performance",
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod, node, powerProfile})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		r.ReconcileTimeout = tc.reconcileTimeout
+
+		fakeListResponse := &podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{1, 2},
+						},
+					},
+				},
+			},
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(fakeListResponse)
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+
+		now := metav1.Now()
+		pod.DeletionTimestamp = &now
+		err = r.Client.Update(context.TODO(), pod)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error updating Pod DeletionTimestamp", tc.testCase))
+		}
+
+		// The release overruns the deadline, so the Pod must stay in the State for the requeue to release its cores
+		responsiveClient := r.Client
+		r.Client = &blockingWorkloadClient{Client: responsiveClient}
+		result, err := r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod deletion", tc.testCase))
+		}
+		if !result.Requeue {
+			t.Errorf("%s - Failed: Expected timed out deletion to be requeued", tc.testCase)
+		}
+
+		if r.State.GetPodFromState(pod.Name).Name == "" {
+			t.Errorf("%s - Failed: Expected Pod to be kept in the internal state after the deletion timed out", tc.testCase)
+		}
+
+		workload := &powerv1alpha1.PowerWorkload{}
+		err = responsiveClient.Get(context.TODO(), client.ObjectKey{Name: "performance-example-node1-workload", Namespace: PowerPodNamespace}, workload)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload", tc.testCase))
+		}
+		if !reflect.DeepEqual(workload.Spec.Node.CpuIds, tc.expectedCpuIds) {
+			t.Errorf("%s - Failed: Expected PowerWorkload CPUs to be %v after the deletion timed out, got %v", tc.testCase, tc.expectedCpuIds, workload.Spec.Node.CpuIds)
+		}
+
+		r.Client = responsiveClient
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod deletion", tc.testCase))
+		}
+
+		err = r.Client.Get(context.TODO(), client.ObjectKey{Name: "performance-example-node1-workload", Namespace: PowerPodNamespace}, workload)
+		if !errors.IsNotFound(err) {
+			t.Errorf("%s - Failed: Expected PowerWorkload to be deleted by the retried deletion, got %v", tc.testCase, err)
+		}
+
+		if r.State.GetPodFromState(pod.Name).Name != "" {
+			t.Errorf("%s - Failed: Expected Pod to be removed from the internal state once its cores were released", tc.testCase)
+		}
+	}
+}
+
+func TestContainerRuntimeRecorded(t *testing.T) {
+	tcases := []struct {
+		testCase        string
+		containerID     string
+		expectedId      string
+		expectedRuntime string
+	}{
+		{
+			testCase:        "Test Case 1 - docker",
+			containerID:     "docker://abcdefg",
+			expectedId:      "abcdefg",
+			expectedRuntime: "docker",
+		},
+		{
+			testCase:        "Test Case 2 - containerd",
+			containerID:     "containerd://hijklmn",
+			expectedId:      "hijklmn",
+			expectedRuntime: "containerd",
+		},
+		{
+			testCase:        "Test Case 3 - cri-o",
+			containerID:     "cri-o://opqrstu",
+			expectedId:      "opqrstu",
+			expectedRuntime: "cri-o",
+		},
+		{
+			testCase:        "Test Case 4 - No runtime",
+			containerID:     "vwxyz",
+			expectedId:      "vwxyz",
+			expectedRuntime: "",
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example-pod",
+				Namespace: PowerPodNamespace,
+				UID:       "abcdefg",
+			},
+			Spec: corev1.PodSpec{
+				NodeName: "example-node1",
+				Containers: []corev1.Container{
+					{
+						Name: "example-container-1",
+						Resources: corev1.ResourceRequirements{
+							Limits: map[corev1.ResourceName]resource.Quantity{
+								corev1.ResourceName("cpu"):                                       *resource.NewQuantity(2, resource.DecimalSI),
+								corev1.ResourceName("power.intel.com/performance-example-node1"): *resource.NewQuantity(2, resource.DecimalSI),
+							},
+							Requests: map[corev1.ResourceName]resource.Quantity{
+								corev1.ResourceName("cpu"):                                       *resource.NewQuantity(2, resource.DecimalSI),
+								corev1.ResourceName("power.intel.com/performance-example-node1"): *resource.NewQuantity(2, resource.DecimalSI),
+							},
+						},
+					},
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase:    corev1.PodRunning,
+				QOSClass: corev1.PodQOSGuaranteed,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:        "example-container-1",
+						ContainerID: tc.containerID,
+					},
+				},
+			},
+		}
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "example-node1",
+			},
+		}
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1",
+				Namespace: PowerPodNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance-example-node1",
+				Epp:  "performance",
+			},
+		}
+
+		r, err := createPowerPodReconcilerObject([]runtime.Object{pod, node, powerProfile})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		fakeListResponse := &podresourcesapi.ListPodResourcesResponse{
+			PodResources: []*podresourcesapi.PodResources{
+				{
+					Name: pod.Name,
+					Containers: []*podresourcesapi.ContainerResources{
+						{
+							Name:   "example-container-1",
+							CpuIds: []int64{1, 2},
+						},
+					},
+				},
+			},
+		}
+		r.PodResourcesClient = *createFakePodResourcesListerClient(fakeListResponse)
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      pod.Name,
+				Namespace: PowerPodNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling Pod object", tc.testCase))
+		}
+
+		powerPodState := r.State.GetPodFromState(pod.Name)
+		if len(powerPodState.Containers) != 1 {
+			t.Fatal(fmt.Sprintf("%s - Failed: Expected one Container in the internal state, got %v", tc.testCase, powerPodState.Containers))
+		}
+		if powerPodState.Containers[0].Id != tc.expectedId {
+			t.Errorf("%s - Failed: Expected Container ID to be '%s', got '%s'", tc.testCase, tc.expectedId, powerPodState.Containers[0].Id)
+		}
+		if powerPodState.Containers[0].Runtime != tc.expectedRuntime {
+			t.Errorf("%s - Failed: Expected Container runtime to be '%s', got '%s'", tc.testCase, tc.expectedRuntime, powerPodState.Containers[0].Runtime)
+		}
+
+		workload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{Name: "performance-example-node1-workload", Namespace: PowerPodNamespace}, workload)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload", tc.testCase))
+		}
+		if len(workload.Spec.Node.Containers) != 1 || workload.Spec.Node.Containers[0].Runtime != tc.expectedRuntime {
+			t.Errorf("%s - Failed: Expected PowerWorkload Container runtime to be '%s', got %v", tc.testCase, tc.expectedRuntime, workload.Spec.Node.Containers)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
+)
+
+// reportPowerPod records the Pod's allocation from the State in the status of a PowerPod of the same name, so
+// the cores and PowerProfile applied to each of its Containers can be inspected without reading the PowerWorkloads.
+// The PowerPod is owned by the Pod so it is garbage collected along with it
+func (r *PowerPodReconciler) reportPowerPod(ctx context.Context, logger logr.Logger, pod *corev1.Pod, guaranteedPod powerv1alpha1.GuaranteedPod) {
+	status := powerv1alpha1.PowerPodStatus{
+		Node:       guaranteedPod.Node,
+		Containers: guaranteedPod.Containers,
+	}
+
+	powerPod := &powerv1alpha1.PowerPod{}
+	err := r.Client.Get(ctx, client.ObjectKey{
+		Namespace: pod.GetNamespace(),
+		Name:      pod.GetName(),
+	}, powerPod)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			logger.Error(err, "error retrieving PowerPod")
+			return
+		}
+
+		powerPod = &powerv1alpha1.PowerPod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: pod.GetNamespace(),
+				Name:      pod.GetName(),
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion: "v1",
+						Kind:       "Pod",
+						Name:       pod.GetName(),
+						UID:        pod.GetUID(),
+					},
+				},
+			},
+		}
+		err = r.Client.Create(ctx, powerPod)
+		if err != nil {
+			logger.Error(err, "error creating PowerPod")
+			return
+		}
+	}
+
+	if reflect.DeepEqual(powerPod.Status, status) {
+		return
+	}
+
+	powerPod.Status = status
+	err = r.Client.Status().Update(ctx, powerPod)
+	if err != nil {
+		logger.Error(err, "error updating PowerPod status")
+	}
+}
+
+// deletePowerPod removes the Pod's PowerPod once the Pod is deleted, rather than waiting for garbage collection
+func (r *PowerPodReconciler) deletePowerPod(ctx context.Context, logger logr.Logger, namespace string, name string) {
+	powerPod := &powerv1alpha1.PowerPod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+	}
+	err := r.Client.Delete(ctx, powerPod)
+	if err != nil && !errors.IsNotFound(err) {
+		logger.Error(err, "error deleting PowerPod")
+	}
+}
@@ -29,9 +29,14 @@ import (
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
 	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/appqos"
@@ -41,6 +46,10 @@ import (
 
 const (
 	MaxFrequencyFile = "/sys/devices/system/cpu/cpu0/cpufreq/scaling_max_freq"
+
+	// ProfileCreatedByLabel marks the extended PowerProfiles the operator creates for each node from a base profile
+	ProfileCreatedByLabel    = "power.intel.com/created-by"
+	ProfileCreatedByOperator = "power-operator"
 )
 
 var AppQoSClientAddress = "https://localhost:5000"
@@ -87,10 +96,20 @@ type PowerProfileReconciler struct {
 	Log          logr.Logger
 	Scheme       *runtime.Scheme
 	AppQoSClient *appqos.AppQoSClient
+	Recorder     record.EventRecorder
+
+	// Reapply, if set, receives PowerProfiles to reconcile again after AppQoS has lost its state or moved
+	Reapply <-chan event.GenericEvent
+
+	// WorkloadNamespace, if set, is the namespace every PowerWorkload is kept in, instead of the PowerProfile's
+	WorkloadNamespace string
+
+	invalidCeilings invalidCeilings
 }
 
 // +kubebuilder:rbac:groups=power.intel.com,resources=powerprofiles,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=power.intel.com,resources=powerprofiles/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile method that implements the reconcile loop
 func (r *PowerProfileReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
@@ -118,6 +137,11 @@ func (r *PowerProfileReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error
 
 			// Make sure the profile existed in AppQoS, if not we don't have to delete it
 			if !reflect.DeepEqual(*profileFromAppQoS, appqos.PowerProfile{}) {
+				if delay := r.AppQoSClient.ReserveWrite(AppQoSClientAddress); delay > 0 {
+					logger.Info("AppQoS write rate limit reached, requeueing", "requeueAfter", delay.String())
+					return ctrl.Result{RequeueAfter: delay}, nil
+				}
+
 				err = r.AppQoSClient.DeletePowerProfile(AppQoSClientAddress, *profileFromAppQoS.ID)
 				if err != nil {
 					logger.Error(err, "error deleting PowerProfile from AppQoS instance")
@@ -160,7 +184,7 @@ func (r *PowerProfileReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error
 				powerWorkload := &powerv1alpha1.PowerWorkload{}
 				err = r.Client.Get(context.TODO(), client.ObjectKey{
 					Name:      workloadName,
-					Namespace: req.NamespacedName.Namespace,
+					Namespace: r.workloadNamespace(req.NamespacedName.Namespace),
 				}, powerWorkload)
 				if err != nil {
 					if errors.IsNotFound(err) {
@@ -199,6 +223,39 @@ func (r *PowerProfileReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error
 		return ctrl.Result{}, nil
 	}
 
+	// Profiles can be created without the admission webhook, so the EPP must be checked against the governor here too
+	err = profile.ValidateGovernorEpp()
+	if err != nil {
+		logger.Error(err, "error reconciling PowerProfile")
+		r.Recorder.Eventf(profile, corev1.EventTypeWarning, "IncoherentGovernorEpp", "Deleting PowerProfile: %v", err)
+
+		err = r.Client.Delete(context.TODO(), profile)
+		if err != nil {
+			logger.Error(err, fmt.Sprintf("error deleting PowerProfile %s with incoherent EPP value %s", profile.Spec.Name, profile.Spec.Epp))
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	// A PowerProfile whose nodeSelector doesn't match this Node gives up the Node, which is gained back through
+	// the usual creation below once the nodeSelector matches again
+	eligible, err := r.profileEligibleOnNode(profile, nodeName)
+	if err != nil {
+		logger.Error(err, "error checking PowerProfile nodeSelector against Node")
+		return ctrl.Result{}, err
+	}
+	if !eligible {
+		logger.Info("Node does not match PowerProfile's nodeSelector, removing PowerProfile from Node", "nodeSelector", profile.Spec.NodeSelector)
+		err = r.removeProfileFromNode(profile, nodeName)
+		if err != nil {
+			logger.Error(err, "error removing PowerProfile from Node")
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, nil
+	}
+
 	if _, exists := extendedResourcePercentage[profile.Spec.Name]; !exists && profile.Spec.Epp != "power" {
 		logger.Info("PowerProfile is not a base profile or designated as a Shared Profile, skipping...")
 		return ctrl.Result{}, nil
@@ -220,7 +277,8 @@ func (r *PowerProfileReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error
 		return ctrl.Result{}, err
 	}
 
-	maximumFrequency = (maximumFrequency / 1000) - 400
+	nodeMaximumFrequency := maximumFrequency / 1000
+	maximumFrequency = nodeMaximumFrequency - 400
 	minimumFrequency := maximumFrequency - 400
 
 	maximumValueForProfile := maximumFrequency - extendedPowerProfileMaxMinDifference[profile.Spec.Name]
@@ -240,14 +298,21 @@ func (r *PowerProfileReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error
 					ObjectMeta: metav1.ObjectMeta{
 						Namespace: req.NamespacedName.Namespace,
 						Name:      profileName,
+						Labels: map[string]string{
+							ProfileCreatedByLabel: ProfileCreatedByOperator,
+						},
 					},
 				}
 
 				powerProfileSpec := &powerv1alpha1.PowerProfileSpec{
-					Name: profileName,
-					Max:  maximumValueForProfile,
-					Min:  minimumValueForProfile,
-					Epp:  profile.Spec.Epp,
+					Name:            profileName,
+					Max:             maximumValueForProfile,
+					Min:             minimumValueForProfile,
+					Epp:             profile.Spec.Epp,
+					MaxLifetime:     profile.Spec.MaxLifetime,
+					Baseline:        profile.Spec.Baseline,
+					PreferredSocket: profile.Spec.PreferredSocket,
+					PreferredNUMA:   profile.Spec.PreferredNUMA,
 				}
 
 				powerProfile.Spec = *powerProfileSpec
@@ -284,23 +349,179 @@ func (r *PowerProfileReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error
 		}
 		powerProfile.Epp = &profile.Spec.Epp
 		if profile.Spec.Epp == "power" {
-			powerProfile.MinFreq = &profile.Spec.Min
-			powerProfile.MaxFreq = &profile.Spec.Max
+			minimumValueForProfile, maximumValueForProfile = resolveFrequencies(profile.Spec, nodeMaximumFrequency)
+			powerProfile.MinFreq = &minimumValueForProfile
+			powerProfile.MaxFreq = &maximumValueForProfile
 		} else {
 			powerProfile.MinFreq = &minimumValueForProfile
 			powerProfile.MaxFreq = &maximumValueForProfile
 		}
 
+		err = r.applyFrequencyCeiling(profile, powerProfile, nodeName)
+		if err != nil {
+			logger.Error(err, "error applying the Node's maximum frequency ceiling")
+			return ctrl.Result{}, err
+		}
+
+		if delay := r.AppQoSClient.ReserveWrite(AppQoSClientAddress); delay > 0 {
+			logger.Info("AppQoS write rate limit reached, requeueing", "requeueAfter", delay.String())
+			return ctrl.Result{RequeueAfter: delay}, nil
+		}
+
+		err = r.syncAppQoSPowerProfile(profile, powerProfile)
+		if err != nil {
+			logger.Error(err, "error syncing PowerProfile with AppQoS instance")
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// resolveFrequencies returns the minimum and maximum frequencies of the PowerProfile on a Node whose maximum
+// frequency is nodeMaximumFrequency, taking any frequency deltas off the Node's maximum in place of the absolute values
+func resolveFrequencies(spec powerv1alpha1.PowerProfileSpec, nodeMaximumFrequency int) (int, int) {
+	minimum, maximum := spec.Min, spec.Max
+	if spec.MaxDelta != nil {
+		maximum = nodeMaximumFrequency - *spec.MaxDelta
+	}
+	if spec.MinDelta != nil {
+		minimum = nodeMaximumFrequency - *spec.MinDelta
+	}
+
+	return minimum, maximum
+}
+
+// syncAppQoSPowerProfile creates the Power Profile in the AppQoS instance if it does not exist. If a Power Profile
+// with the same name already exists but its values differ from what the PowerProfile CRD requires, the AppQoS
+// instance is updated to match, as the CRD is the source of truth
+func (r *PowerProfileReconciler) syncAppQoSPowerProfile(profile *powerv1alpha1.PowerProfile, powerProfile *appqos.PowerProfile) error {
+	logger := r.Log.WithName("syncAppQoSPowerProfile")
+
+	profileFromAppQoS, err := r.AppQoSClient.GetProfileByName(*powerProfile.Name, AppQoSClientAddress)
+	if err != nil {
+		return err
+	}
+
+	if reflect.DeepEqual(profileFromAppQoS, &appqos.PowerProfile{}) {
 		// Create PowerProfile
 
 		appqosPostResp, err := r.AppQoSClient.PostPowerProfile(powerProfile, AppQoSClientAddress)
 		if err != nil {
 			logger.Error(err, appqosPostResp)
-			return ctrl.Result{}, err
+			return err
 		}
+
+		return nil
 	}
 
-	return ctrl.Result{}, nil
+	if !appQoSPowerProfileMismatch(profileFromAppQoS, powerProfile) {
+		return nil
+	}
+
+	appqosPutResp, err := r.AppQoSClient.PutPowerProfile(powerProfile, AppQoSClientAddress, *profileFromAppQoS.ID)
+	if err != nil {
+		logger.Error(err, appqosPutResp)
+		return err
+	}
+
+	r.Recorder.Eventf(profile, corev1.EventTypeNormal, "AppQoSProfileCorrected",
+		"Power Profile '%s' in AppQoS instance on node '%s' updated from min %d/max %d/epp %s to min %d/max %d/epp %s",
+		*powerProfile.Name, os.Getenv("NODE_NAME"),
+		intValue(profileFromAppQoS.MinFreq), intValue(profileFromAppQoS.MaxFreq), stringValue(profileFromAppQoS.Epp),
+		*powerProfile.MinFreq, *powerProfile.MaxFreq, *powerProfile.Epp)
+
+	return nil
+}
+
+func appQoSPowerProfileMismatch(profileFromAppQoS *appqos.PowerProfile, powerProfile *appqos.PowerProfile) bool {
+	return intValue(profileFromAppQoS.MinFreq) != intValue(powerProfile.MinFreq) ||
+		intValue(profileFromAppQoS.MaxFreq) != intValue(powerProfile.MaxFreq) ||
+		stringValue(profileFromAppQoS.Epp) != stringValue(powerProfile.Epp)
+}
+
+func intValue(value *int) int {
+	if value == nil {
+		return 0
+	}
+
+	return *value
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+
+	return *value
+}
+
+// profileEligibleOnNode reports whether the Node's labels match the PowerProfile's nodeSelector
+func (r *PowerProfileReconciler) profileEligibleOnNode(profile *powerv1alpha1.PowerProfile, nodeName string) (bool, error) {
+	if len(profile.Spec.NodeSelector) == 0 {
+		return true, nil
+	}
+
+	node := &corev1.Node{}
+	err := r.Client.Get(context.TODO(), client.ObjectKey{
+		Name: nodeName,
+	}, node)
+	if err != nil {
+		return false, err
+	}
+
+	return labels.SelectorFromSet(profile.Spec.NodeSelector).Matches(labels.Set(node.GetLabels())), nil
+}
+
+// removeProfileFromNode removes the PowerProfile's Extended Resources from the Node and deletes the Node's
+// PowerWorkloads for it. For a base profile the Node's extended PowerProfile is deleted too, so it is created
+// afresh if the Node becomes eligible again
+func (r *PowerProfileReconciler) removeProfileFromNode(profile *powerv1alpha1.PowerProfile, nodeName string) error {
+	profileNames := map[string]bool{profile.Name: true}
+
+	if _, exists := extendedResourcePercentage[profile.Spec.Name]; exists && profile.Spec.Epp != "power" {
+		profileName := fmt.Sprintf("%s-%s", profile.Spec.Name, nodeName)
+		profileNames[profileName] = true
+
+		profileForNode := &powerv1alpha1.PowerProfile{}
+		err := r.Client.Get(context.TODO(), client.ObjectKey{
+			Namespace: profile.Namespace,
+			Name:      profileName,
+		}, profileForNode)
+		if err == nil {
+			err = r.Client.Delete(context.TODO(), profileForNode)
+		}
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	for profileName := range profileNames {
+		err := r.removeExtendedResources(nodeName, profileName)
+		if err != nil {
+			return err
+		}
+	}
+
+	workloads := &powerv1alpha1.PowerWorkloadList{}
+	err := r.Client.List(context.TODO(), workloads, client.InNamespace(r.workloadNamespace(profile.Namespace)))
+	if err != nil {
+		return err
+	}
+
+	for i := range workloads.Items {
+		workload := &workloads.Items[i]
+		if workload.Spec.Node.Name != nodeName || !profileNames[workload.Spec.PowerProfile] {
+			continue
+		}
+
+		err = r.Client.Delete(context.TODO(), workload)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (r *PowerProfileReconciler) createExtendedResources(nodeName string, profileName string, baseProfile string) error {
@@ -355,7 +576,11 @@ func (r *PowerProfileReconciler) removeExtendedResources(nodeName string, profil
 
 // SetupWithManager specifies how the controller is built and watch a CR and other resources that are owned and managed by the controller
 func (r *PowerProfileReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&powerv1alpha1.PowerProfile{}).
-		Complete(r)
+	builder := ctrl.NewControllerManagedBy(mgr).
+		For(&powerv1alpha1.PowerProfile{})
+	if r.Reapply != nil {
+		builder = builder.Watches(&source.Channel{Source: r.Reapply}, &handler.EnqueueRequestForObject{})
+	}
+
+	return builder.Complete(r)
 }
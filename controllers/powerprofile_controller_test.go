@@ -7,6 +7,8 @@ import (
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"testing"
@@ -15,6 +17,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -46,7 +49,7 @@ func createPowerProfileReconcileObject(powerProfile *powerv1alpha1.PowerProfile)
 
 	appqosCl := appqos.NewDefaultAppQoSClient()
 
-	r := &PowerProfileReconciler{Client: cl, Log: ctrl.Log.WithName("controllers").WithName("PowerProfile"), Scheme: s, AppQoSClient: appqosCl}
+	r := &PowerProfileReconciler{Client: cl, Log: ctrl.Log.WithName("controllers").WithName("PowerProfile"), Scheme: s, AppQoSClient: appqosCl, Recorder: record.NewFakeRecorder(100)}
 
 	return r, nil
 }
@@ -69,6 +72,21 @@ func createPowerProfileListeners(appqosPowerProfiles []appqos.PowerProfile) (*ht
 					appqosPowerProfiles = append(appqosPowerProfiles[:i], appqosPowerProfiles[i+1:]...)
 				}
 			}
+		} else if r.Method == "PUT" {
+			path := strings.Split(r.URL.Path, "/")
+			id, _ := strconv.Atoi(path[len(path)-1])
+			for i, profile := range appqosPowerProfiles {
+				if *profile.ID == id {
+					updatedProfile := appqos.PowerProfile{}
+					err := json.NewDecoder(r.Body).Decode(&updatedProfile)
+					if err != nil {
+						w.WriteHeader(http.StatusBadRequest)
+						return
+					}
+					updatedProfile.ID = profile.ID
+					appqosPowerProfiles[i] = updatedProfile
+				}
+			}
 		}
 	}))
 	mux.HandleFunc("/power_profiles", (func(w http.ResponseWriter, r *http.Request) {
@@ -1404,6 +1422,7 @@ func TestExtendedPowerProfileDeletion(t *testing.T) {
 		basePowerProfile                 *powerv1alpha1.PowerProfile
 		node                             *corev1.Node
 		powerWorkload                    *powerv1alpha1.PowerWorkload
+		workloadNamespace                string
 		expectedExtendedResourcesToExist map[string]bool
 	}{
 		{
@@ -1577,6 +1596,61 @@ func TestExtendedPowerProfileDeletion(t *testing.T) {
 				"balance-power-example-node1": false,
 			},
 		},
+		{
+			testCase: "Test Case 4 - PowerWorkload kept in the workload namespace",
+			extendedPowerProfile: &powerv1alpha1.PowerProfile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "performance-example-node1",
+					Namespace: PowerProfileNamespace,
+				},
+				Spec: powerv1alpha1.PowerProfileSpec{
+					Name: "performance-example-node1",
+					Max:  3700,
+					Min:  3400,
+					Epp:  "performance",
+				},
+			},
+			basePowerProfile: &powerv1alpha1.PowerProfile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "performance",
+					Namespace: PowerProfileNamespace,
+				},
+				Spec: powerv1alpha1.PowerProfileSpec{
+					Name: "performance",
+					Epp:  "performance",
+				},
+			},
+			node: &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "example-node1",
+				},
+				Status: corev1.NodeStatus{
+					Capacity: map[corev1.ResourceName]resource.Quantity{
+						"cpu:":                        *resource.NewQuantity(42, resource.DecimalSI),
+						"power.intel.com/performance": *resource.NewQuantity(42, resource.DecimalSI),
+						"power.intel.com/performance-example-node1": *resource.NewQuantity(42, resource.DecimalSI),
+					},
+				},
+			},
+			powerWorkload: &powerv1alpha1.PowerWorkload{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "performance-example-node1-workload",
+					Namespace: "power-workloads",
+				},
+				Spec: powerv1alpha1.PowerWorkloadSpec{
+					Name: "performance-example-node1-workload",
+					Node: powerv1alpha1.NodeInfo{
+						Name: "example-node1",
+					},
+					PowerProfile: "performance-example-node1",
+				},
+			},
+			workloadNamespace: "power-workloads",
+			expectedExtendedResourcesToExist: map[string]bool{
+				"performance":               true,
+				"performance-example-node1": false,
+			},
+		},
 	}
 
 	for _, tc := range tcases {
@@ -1587,6 +1661,7 @@ func TestExtendedPowerProfileDeletion(t *testing.T) {
 		if err != nil {
 			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
 		}
+		r.WorkloadNamespace = tc.workloadNamespace
 
 		server, err := createPowerProfileListeners([]appqos.PowerProfile{})
 		if err != nil {
@@ -1675,7 +1750,7 @@ func TestExtendedPowerProfileDeletion(t *testing.T) {
 		powerWorkload := &powerv1alpha1.PowerWorkload{}
 		err = r.Client.Get(context.TODO(), client.ObjectKey{
 			Name:      tc.powerWorkload.Name,
-			Namespace: PowerProfileNamespace,
+			Namespace: tc.powerWorkload.Namespace,
 		}, powerWorkload)
 		if !errors.IsNotFound(err) {
 			t.Errorf("%s - Failed: Expected PowerWorkload '%s' to not exist", tc.testCase, tc.powerWorkload.Name)
@@ -2087,3 +2162,666 @@ func TestPowerProfileMinMaxValues(t *testing.T) {
 		}
 	}
 }
+
+func TestAppQoSPowerProfileFrequencyMismatch(t *testing.T) {
+	tcases := []struct {
+		testCase               string
+		powerProfile           *powerv1alpha1.PowerProfile
+		appqosPowerProfiles    []appqos.PowerProfile
+		requestedProfileName   string
+		requestedMin           int
+		requestedMax           int
+		requestedEpp           string
+		expectedNumberOfEvents int
+	}{
+		{
+			testCase: "Test Case 1 - Frequencies mismatch",
+			powerProfile: &powerv1alpha1.PowerProfile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "performance",
+					Namespace: PowerProfileNamespace,
+				},
+				Spec: powerv1alpha1.PowerProfileSpec{
+					Name: "performance",
+					Epp:  "performance",
+				},
+			},
+			appqosPowerProfiles: []appqos.PowerProfile{
+				{
+					ID:      intPtr(1),
+					Name:    stringPtr("performance-example-node1"),
+					MinFreq: intPtr(1600),
+					MaxFreq: intPtr(2000),
+					Epp:     stringPtr("performance"),
+				},
+			},
+			requestedProfileName:   "performance-example-node1",
+			requestedMin:           2000,
+			requestedMax:           2400,
+			requestedEpp:           "performance",
+			expectedNumberOfEvents: 1,
+		},
+		{
+			testCase: "Test Case 2 - Frequencies match",
+			powerProfile: &powerv1alpha1.PowerProfile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "performance",
+					Namespace: PowerProfileNamespace,
+				},
+				Spec: powerv1alpha1.PowerProfileSpec{
+					Name: "performance",
+					Epp:  "performance",
+				},
+			},
+			appqosPowerProfiles: []appqos.PowerProfile{
+				{
+					ID:      intPtr(1),
+					Name:    stringPtr("performance-example-node1"),
+					MinFreq: intPtr(2000),
+					MaxFreq: intPtr(2400),
+					Epp:     stringPtr("performance"),
+				},
+			},
+			requestedProfileName:   "performance-example-node1",
+			requestedMin:           2000,
+			requestedMax:           2400,
+			requestedEpp:           "performance",
+			expectedNumberOfEvents: 0,
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		AppQoSClientAddress = "http://localhost:5000"
+
+		r, err := createPowerProfileReconcileObject(tc.powerProfile)
+		if err != nil {
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		server, err := createPowerProfileListeners(tc.appqosPowerProfiles)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating Listener", tc.testCase))
+		}
+
+		powerProfile := &appqos.PowerProfile{
+			Name:    &tc.requestedProfileName,
+			MinFreq: &tc.requestedMin,
+			MaxFreq: &tc.requestedMax,
+			Epp:     &tc.requestedEpp,
+		}
+
+		err = r.syncAppQoSPowerProfile(tc.powerProfile, powerProfile)
+		if err != nil {
+			server.Close()
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error syncing AppQoS Power Profile", tc.testCase))
+		}
+
+		profileFromAppQoS, err := r.AppQoSClient.GetProfileByName(tc.requestedProfileName, AppQoSClientAddress)
+		server.Close()
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving AppQoS Power Profile", tc.testCase))
+		}
+
+		if *profileFromAppQoS.MinFreq != tc.requestedMin || *profileFromAppQoS.MaxFreq != tc.requestedMax {
+			t.Errorf("%s - Failed: Expected AppQoS Power Profile frequencies to be %v/%v, got %v/%v", tc.testCase, tc.requestedMin, tc.requestedMax, *profileFromAppQoS.MinFreq, *profileFromAppQoS.MaxFreq)
+		}
+
+		recorder := r.Recorder.(*record.FakeRecorder)
+		if len(recorder.Events) != tc.expectedNumberOfEvents {
+			t.Errorf("%s - Failed: Expected number of Events to be %v, got %v", tc.testCase, tc.expectedNumberOfEvents, len(recorder.Events))
+		}
+	}
+}
+
+func TestGovernorEppCoherence(t *testing.T) {
+	tcases := []struct {
+		testCase               string
+		governor               string
+		epp                    string
+		expectedProfileExists  bool
+		expectedNumberOfEvents int
+	}{
+		{
+			testCase:               "Test Case 1 - Coherent pair",
+			governor:               "performance",
+			epp:                    "performance",
+			expectedProfileExists:  true,
+			expectedNumberOfEvents: 0,
+		},
+		{
+			testCase:               "Test Case 2 - Incoherent pair",
+			governor:               "performance",
+			epp:                    "balance_power",
+			expectedProfileExists:  false,
+			expectedNumberOfEvents: 1,
+		},
+		{
+			testCase:               "Test Case 3 - Coherent pair",
+			governor:               "powersave",
+			epp:                    "balance_performance",
+			expectedProfileExists:  true,
+			expectedNumberOfEvents: 0,
+		},
+		{
+			testCase:               "Test Case 4 - Unknown governor",
+			governor:               "ondemand",
+			epp:                    "performance",
+			expectedProfileExists:  false,
+			expectedNumberOfEvents: 1,
+		},
+		{
+			testCase:               "Test Case 5 - No governor",
+			governor:               "",
+			epp:                    "balance_power",
+			expectedProfileExists:  true,
+			expectedNumberOfEvents: 0,
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		AppQoSClientAddress = "http://localhost:5000"
+
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example-profile",
+				Namespace: PowerProfileNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name:     "example-profile",
+				Epp:      tc.epp,
+				Governor: tc.governor,
+			},
+		}
+
+		// The admission webhook and the reconciler share the same validation
+		err := powerProfile.ValidateCreate()
+		if (err == nil) != tc.expectedProfileExists {
+			t.Errorf("%s - Failed: Expected admission to be allowed to be %v, got error %v", tc.testCase, tc.expectedProfileExists, err)
+		}
+
+		r, err := createPowerProfileReconcileObject(powerProfile)
+		if err != nil {
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      powerProfile.Name,
+				Namespace: PowerProfileNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling object", tc.testCase))
+		}
+
+		err = r.Client.Get(context.TODO(), req.NamespacedName, &powerv1alpha1.PowerProfile{})
+		if exists := !errors.IsNotFound(err); exists != tc.expectedProfileExists {
+			t.Errorf("%s - Failed: Expected PowerProfile to exist to be %v, got %v", tc.testCase, tc.expectedProfileExists, exists)
+		}
+
+		recorder := r.Recorder.(*record.FakeRecorder)
+		if len(recorder.Events) != tc.expectedNumberOfEvents {
+			t.Errorf("%s - Failed: Expected number of Events to be %v, got %v", tc.testCase, tc.expectedNumberOfEvents, len(recorder.Events))
+		}
+	}
+}
+
+func TestPowerProfileNodeSelectorChange(t *testing.T) {
+	tcases := []struct {
+		testCase                  string
+		profileName               string
+		nodeSelector              map[string]string
+		workloadName              string
+		extendedProfileName       string
+		workloadNamespace         string
+		expectedWorkloadExists    bool
+		expectedRemainingResource []string
+	}{
+		{
+			testCase:                  "Test Case 1",
+			profileName:               "gold",
+			nodeSelector:              map[string]string{"power-tier": "gold"},
+			workloadName:              "gold-workload",
+			expectedWorkloadExists:    false,
+			expectedRemainingResource: []string{"power.intel.com/performance", "power.intel.com/performance-example-node1"},
+		},
+		{
+			testCase:                  "Test Case 2",
+			profileName:               "gold",
+			nodeSelector:              map[string]string{"power-tier": "silver"},
+			workloadName:              "gold-workload",
+			expectedWorkloadExists:    true,
+			expectedRemainingResource: []string{"power.intel.com/gold", "power.intel.com/performance", "power.intel.com/performance-example-node1"},
+		},
+		{
+			testCase:                  "Test Case 3",
+			profileName:               "performance",
+			nodeSelector:              map[string]string{"power-tier": "gold"},
+			workloadName:              "performance-example-node1-workload",
+			extendedProfileName:       "performance-example-node1",
+			expectedWorkloadExists:    false,
+			expectedRemainingResource: []string{"power.intel.com/gold"},
+		},
+		{
+			testCase:                  "Test Case 4 - PowerWorkloads kept in the workload namespace",
+			profileName:               "gold",
+			nodeSelector:              map[string]string{"power-tier": "gold"},
+			workloadName:              "gold-workload",
+			workloadNamespace:         "power-workloads",
+			expectedWorkloadExists:    false,
+			expectedRemainingResource: []string{"power.intel.com/performance", "power.intel.com/performance-example-node1"},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		AppQoSClientAddress = "http://localhost:5000"
+
+		powerProfile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      tc.profileName,
+				Namespace: PowerProfileNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: tc.profileName,
+				Epp:  "performance",
+			},
+		}
+
+		r, err := createPowerProfileReconcileObject(powerProfile)
+		if err != nil {
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		r.WorkloadNamespace = tc.workloadNamespace
+		workloadNamespace := PowerProfileNamespace
+		if tc.workloadNamespace != "" {
+			workloadNamespace = tc.workloadNamespace
+		}
+
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "example-node1",
+				Labels: map[string]string{
+					"power-tier": "silver",
+				},
+			},
+			Status: corev1.NodeStatus{
+				Capacity: map[corev1.ResourceName]resource.Quantity{
+					corev1.ResourceName("power.intel.com/gold"):                      *resource.NewQuantity(4, resource.DecimalSI),
+					corev1.ResourceName("power.intel.com/performance"):               *resource.NewQuantity(4, resource.DecimalSI),
+					corev1.ResourceName("power.intel.com/performance-example-node1"): *resource.NewQuantity(4, resource.DecimalSI),
+				},
+			},
+		}
+		workload := &powerv1alpha1.PowerWorkload{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      tc.workloadName,
+				Namespace: workloadNamespace,
+			},
+			Spec: powerv1alpha1.PowerWorkloadSpec{
+				Name:         tc.workloadName,
+				PowerProfile: strings.TrimSuffix(tc.workloadName, WorkloadNameSuffix),
+				Node: powerv1alpha1.NodeInfo{
+					Name:   "example-node1",
+					CpuIds: []int{1, 2},
+				},
+			},
+		}
+		objs := []runtime.Object{node, workload}
+		if tc.extendedProfileName != "" {
+			objs = append(objs, &powerv1alpha1.PowerProfile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      tc.extendedProfileName,
+					Namespace: PowerProfileNamespace,
+				},
+				Spec: powerv1alpha1.PowerProfileSpec{
+					Name: tc.extendedProfileName,
+					Epp:  "performance",
+				},
+			})
+		}
+		for _, obj := range objs {
+			err = r.Client.Create(context.TODO(), obj)
+			if err != nil {
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error creating object", tc.testCase))
+			}
+		}
+
+		// Edit the PowerProfile's nodeSelector
+		profile := &powerv1alpha1.PowerProfile{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{Name: tc.profileName, Namespace: PowerProfileNamespace}, profile)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerProfile", tc.testCase))
+		}
+		profile.Spec.NodeSelector = tc.nodeSelector
+		err = r.Client.Update(context.TODO(), profile)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error updating PowerProfile nodeSelector", tc.testCase))
+		}
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      tc.profileName,
+				Namespace: PowerProfileNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling object", tc.testCase))
+		}
+
+		err = r.Client.Get(context.TODO(), client.ObjectKey{Name: tc.workloadName, Namespace: workloadNamespace}, &powerv1alpha1.PowerWorkload{})
+		if exists := !errors.IsNotFound(err); exists != tc.expectedWorkloadExists {
+			t.Errorf("%s - Failed: Expected PowerWorkload to exist to be %v, got %v", tc.testCase, tc.expectedWorkloadExists, exists)
+		}
+
+		if tc.extendedProfileName != "" {
+			err = r.Client.Get(context.TODO(), client.ObjectKey{Name: tc.extendedProfileName, Namespace: PowerProfileNamespace}, &powerv1alpha1.PowerProfile{})
+			if !errors.IsNotFound(err) {
+				t.Errorf("%s - Failed: Expected extended PowerProfile '%s' to be deleted", tc.testCase, tc.extendedProfileName)
+			}
+		}
+
+		updatedNode := &corev1.Node{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{Name: "example-node1"}, updatedNode)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving Node", tc.testCase))
+		}
+
+		remainingResources := make([]string, 0)
+		for resourceName := range updatedNode.Status.Capacity {
+			remainingResources = append(remainingResources, string(resourceName))
+		}
+		sort.Strings(remainingResources)
+		if !reflect.DeepEqual(remainingResources, tc.expectedRemainingResource) {
+			t.Errorf("%s - Failed: Expected Node Extended Resources to be %v, got %v", tc.testCase, tc.expectedRemainingResource, remainingResources)
+		}
+	}
+}
+
+func intPtr(value int) *int {
+	return &value
+}
+
+func stringPtr(value string) *string {
+	return &value
+}
+
+func TestPowerProfileFrequencyDeltas(t *testing.T) {
+	tcases := []struct {
+		testCase             string
+		spec                 powerv1alpha1.PowerProfileSpec
+		nodeMaximumFrequency int
+		expectedMin          int
+		expectedMax          int
+	}{
+		{
+			testCase: "Test Case 1 - Deltas on a 3700MHz Node",
+			spec: powerv1alpha1.PowerProfileSpec{
+				Name:     "shared",
+				Epp:      "power",
+				MaxDelta: intPtr(1500),
+				MinDelta: intPtr(2000),
+			},
+			nodeMaximumFrequency: 3700,
+			expectedMin:          1700,
+			expectedMax:          2200,
+		},
+		{
+			testCase: "Test Case 2 - Deltas on a 3000MHz Node",
+			spec: powerv1alpha1.PowerProfileSpec{
+				Name:     "shared",
+				Epp:      "power",
+				MaxDelta: intPtr(1500),
+				MinDelta: intPtr(2000),
+			},
+			nodeMaximumFrequency: 3000,
+			expectedMin:          1000,
+			expectedMax:          1500,
+		},
+		{
+			testCase: "Test Case 3 - Absolute values without deltas",
+			spec: powerv1alpha1.PowerProfileSpec{
+				Name: "shared",
+				Epp:  "power",
+				Max:  1500,
+				Min:  1000,
+			},
+			nodeMaximumFrequency: 3700,
+			expectedMin:          1000,
+			expectedMax:          1500,
+		},
+		{
+			testCase: "Test Case 4 - Delta overrides only the maximum",
+			spec: powerv1alpha1.PowerProfileSpec{
+				Name:     "shared",
+				Epp:      "power",
+				Max:      1500,
+				Min:      1000,
+				MaxDelta: intPtr(1000),
+			},
+			nodeMaximumFrequency: 3700,
+			expectedMin:          1000,
+			expectedMax:          2700,
+		},
+	}
+
+	for _, tc := range tcases {
+		minimum, maximum := resolveFrequencies(tc.spec, tc.nodeMaximumFrequency)
+		if minimum != tc.expectedMin || maximum != tc.expectedMax {
+			t.Errorf("%s - Failed: Expected frequencies to be %v/%v, got %v/%v", tc.testCase, tc.expectedMin, tc.expectedMax, minimum, maximum)
+		}
+	}
+}
+
+func TestNodeFrequencyCeiling(t *testing.T) {
+	tcases := []struct {
+		testCase      string
+		annotations   map[string]string
+		minFreq       int
+		maxFreq       int
+		expectedMin   int
+		expectedMax   int
+		expectedEvent string
+	}{
+		{
+			testCase:    "Test Case 1 - No ceiling",
+			annotations: map[string]string{},
+			minFreq:     2900,
+			maxFreq:     3300,
+			expectedMin: 2900,
+			expectedMax: 3300,
+		},
+		{
+			testCase:      "Test Case 2 - Maximum above the ceiling",
+			annotations:   map[string]string{MaxFrequencyAnnotation: "3000"},
+			minFreq:       2900,
+			maxFreq:       3300,
+			expectedMin:   2900,
+			expectedMax:   3000,
+			expectedEvent: "FrequencyClamped",
+		},
+		{
+			testCase:      "Test Case 3 - Minimum and maximum above the ceiling",
+			annotations:   map[string]string{MaxFrequencyAnnotation: "2500"},
+			minFreq:       2900,
+			maxFreq:       3300,
+			expectedMin:   2500,
+			expectedMax:   2500,
+			expectedEvent: "FrequencyClamped",
+		},
+		{
+			testCase:    "Test Case 4 - Maximum below the ceiling",
+			annotations: map[string]string{MaxFrequencyAnnotation: "3500"},
+			minFreq:     2900,
+			maxFreq:     3300,
+			expectedMin: 2900,
+			expectedMax: 3300,
+		},
+		{
+			testCase:      "Test Case 5 - Invalid ceiling",
+			annotations:   map[string]string{MaxFrequencyAnnotation: "3GHz"},
+			minFreq:       2900,
+			maxFreq:       3300,
+			expectedMin:   2900,
+			expectedMax:   3300,
+			expectedEvent: "InvalidMaxFrequency",
+		},
+	}
+
+	for _, tc := range tcases {
+		profile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance",
+				Namespace: PowerProfileNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name: "performance",
+				Epp:  "performance",
+			},
+		}
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "example-node1",
+				Annotations: tc.annotations,
+			},
+		}
+
+		r, err := createPowerProfileReconcileObject(profile)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		err = r.Client.Create(context.TODO(), node)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating Node object", tc.testCase))
+		}
+		recorder := record.NewFakeRecorder(10)
+		r.Recorder = recorder
+
+		name := "performance-example-node1"
+		epp := "performance"
+		powerProfile := &appqos.PowerProfile{
+			Name:    &name,
+			Epp:     &epp,
+			MinFreq: intPtr(tc.minFreq),
+			MaxFreq: intPtr(tc.maxFreq),
+		}
+
+		err = r.applyFrequencyCeiling(profile, powerProfile, "example-node1")
+		if err != nil {
+			t.Errorf("%s - Failed: Expected no error, got %v", tc.testCase, err)
+		}
+
+		if *powerProfile.MinFreq != tc.expectedMin || *powerProfile.MaxFreq != tc.expectedMax {
+			t.Errorf("%s - Failed: Expected frequencies to be %v/%v, got %v/%v", tc.testCase, tc.expectedMin, tc.expectedMax, *powerProfile.MinFreq, *powerProfile.MaxFreq)
+		}
+
+		event := ""
+		select {
+		case event = <-recorder.Events:
+		default:
+		}
+		if !strings.Contains(event, tc.expectedEvent) || (tc.expectedEvent == "") != (event == "") {
+			t.Errorf("%s - Failed: Expected Event '%s', got '%s'", tc.testCase, tc.expectedEvent, event)
+		}
+
+		// The next reconcile of the PowerProfile is not reported again
+		err = r.applyFrequencyCeiling(profile, powerProfile, "example-node1")
+		if err != nil {
+			t.Errorf("%s - Failed: Expected no error reapplying the ceiling, got %v", tc.testCase, err)
+		}
+		if len(recorder.Events) != 0 {
+			t.Errorf("%s - Failed: Expected no further Events, got %v", tc.testCase, <-recorder.Events)
+		}
+	}
+}
+
+func TestAppQoSProfileNameMatch(t *testing.T) {
+	tcases := []struct {
+		testCase       string
+		appqosProfiles []appqos.PowerProfile
+		profileName    string
+		expectedID     int
+		expectedErr    bool
+	}{
+		{
+			testCase: "Test Case 1 - Exact match among prefixed names",
+			appqosProfiles: []appqos.PowerProfile{
+				{ID: intPtr(1), Name: stringPtr("performance-example-node1")},
+				{ID: intPtr(2), Name: stringPtr("performance")},
+				{ID: intPtr(3), Name: stringPtr("performance-example-node10")},
+			},
+			profileName: "performance",
+			expectedID:  2,
+		},
+		{
+			testCase: "Test Case 2 - No exact match",
+			appqosProfiles: []appqos.PowerProfile{
+				{ID: intPtr(1), Name: stringPtr("performance-example-node1")},
+				{ID: intPtr(2), Name: stringPtr("Performance")},
+			},
+			profileName: "performance",
+			expectedID:  0,
+		},
+		{
+			testCase: "Test Case 3 - Ambiguous match",
+			appqosProfiles: []appqos.PowerProfile{
+				{ID: intPtr(1), Name: stringPtr("performance")},
+				{ID: intPtr(2), Name: stringPtr("balance-power")},
+				{ID: intPtr(3), Name: stringPtr("performance")},
+			},
+			profileName: "performance",
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range tcases {
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		server, err := createPowerProfileListeners(tc.appqosProfiles)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating Listener", tc.testCase))
+		}
+
+		profile, err := appqos.NewDefaultAppQoSClient().GetProfileByName(tc.profileName, AppQoSClientAddress)
+		server.Close()
+
+		if (err != nil) != tc.expectedErr {
+			t.Errorf("%s - Failed: Expected error to be %v, got %v", tc.testCase, tc.expectedErr, err)
+		}
+		if tc.expectedErr {
+			if err != nil && !strings.Contains(err.Error(), "[1 3]") {
+				t.Errorf("%s - Failed: Expected error to name the IDs of the matching Power Profiles, got '%v'", tc.testCase, err)
+			}
+			continue
+		}
+
+		if tc.expectedID == 0 {
+			if !reflect.DeepEqual(profile, &appqos.PowerProfile{}) {
+				t.Errorf("%s - Failed: Expected no Power Profile, got %v", tc.testCase, *profile.Name)
+			}
+			continue
+		}
+
+		if profile.ID == nil || *profile.ID != tc.expectedID {
+			t.Errorf("%s - Failed: Expected Power Profile with ID %d, got %v", tc.testCase, tc.expectedID, profile.ID)
+		}
+	}
+}
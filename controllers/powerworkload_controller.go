@@ -23,12 +23,18 @@ import (
 	"reflect"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
 	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/appqos"
@@ -42,12 +48,101 @@ type PowerWorkloadReconciler struct {
 	Log          logr.Logger
 	Scheme       *runtime.Scheme
 	AppQoSClient *appqos.AppQoSClient
+
+	// ProfileCleanup decides what happens to a PowerProfile once the last PowerWorkload using it is deleted
+	ProfileCleanup ProfileCleanupPolicy
+
+	// DefaultReleaseProfile is the PowerProfile given to freed cores that would return to the Default pool, which
+	// otherwise leaves them at AppQoS's defaults. They are held in the Release pool so the rest of the Default pool
+	// keeps its tuning. Cores returned to a Shared pool take the Shared pool's profile
+	DefaultReleaseProfile string
+
+	// ScopeMode is the granularity at which the node's AppQoS instance applies frequencies. Defaults to Core
+	ScopeMode ScopeMode
+
+	// Reapply, if set, receives PowerWorkloads to reconcile again after AppQoS has lost its state or moved
+	Reapply <-chan event.GenericEvent
+
+	// WorkloadNamespace, if set, is the namespace every PowerWorkload is kept in. The Node's PowerWorkloads found in
+	// other namespaces, left there from before it was set, are moved into it
+	WorkloadNamespace string
+
+	// RolloutWindow is how long a PowerProfile change raising a Pool's maximum frequency is deferred while other
+	// PowerWorkloads on the Node have a reduction to apply, so reductions land first. Zero disables the ordering
+	RolloutWindow time.Duration
+
+	// ReassertOnStartup re-applies each PowerWorkload to AppQoS the first time it is reconciled after the Node Agent
+	// starts, even if its generation was already applied
+	ReassertOnStartup bool
+
+	rollout rolloutSequencer
+
+	reassertions workloadReassertions
+
+	// capabilities caches the features the node's AppQoS instance advertises once they have been discovered
+	capabilities []string
+}
+
+// ScopeMode is the granularity at which AppQoS applies a PowerProfile's frequencies
+type ScopeMode string
+
+const (
+	// ScopeCore applies each PowerWorkload's PowerProfile to its own cores
+	ScopeCore ScopeMode = "Core"
+
+	// ScopePackage applies frequencies to whole packages, so every PowerWorkload with cores on a package is
+	// given the single PowerProfile in effect for the package, which is never below the Shared pool's there
+	ScopePackage ScopeMode = "Package"
+)
+
+// ParseScopeMode returns the ScopeMode with the given name, or an error if there is none
+func ParseScopeMode(name string) (ScopeMode, error) {
+	switch mode := ScopeMode(name); mode {
+	case ScopeCore, ScopePackage:
+		return mode, nil
+	}
+
+	return "", fmt.Errorf("unknown scope mode '%s', must be '%s' or '%s'", name, ScopeCore, ScopePackage)
+}
+
+// ProfileCleanupPolicy is what to do with a PowerProfile no PowerWorkload uses any more
+type ProfileCleanupPolicy string
+
+const (
+	// ProfileCleanupRetain leaves the profile in AppQoS for reuse
+	ProfileCleanupRetain ProfileCleanupPolicy = "Retain"
+
+	// ProfileCleanupDeleteAppQoSProfile deletes the profile from AppQoS once its PowerProfile is gone too. While the
+	// PowerProfile exists a new PowerWorkload may use it, so its profile is kept in AppQoS
+	ProfileCleanupDeleteAppQoSProfile ProfileCleanupPolicy = "DeleteAppQoSProfile"
+
+	// ProfileCleanupDeleteProfile first deletes the PowerProfile if it was created by the operator, then the profile
+	// in AppQoS
+	ProfileCleanupDeleteProfile ProfileCleanupPolicy = "DeleteProfile"
+)
+
+// ParseProfileCleanupPolicy returns the ProfileCleanupPolicy with the given name, or an error if there is none
+func ParseProfileCleanupPolicy(name string) (ProfileCleanupPolicy, error) {
+	switch policy := ProfileCleanupPolicy(name); policy {
+	case ProfileCleanupRetain, ProfileCleanupDeleteAppQoSProfile, ProfileCleanupDeleteProfile:
+		return policy, nil
+	}
+
+	return "", fmt.Errorf("unknown profile cleanup policy '%s', must be one of '%s', '%s' or '%s'", name,
+		ProfileCleanupRetain, ProfileCleanupDeleteAppQoSProfile, ProfileCleanupDeleteProfile)
 }
 
 const (
 	SharedWorkloadName string = "shared-workload"
 	WorkloadNameSuffix string = "-workload"
 	DefaultPool        string = "Default"
+
+	// ReleasePool holds the cores freed back to the Default pool while they carry the --default-release-profile
+	ReleasePool string = "Release"
+
+	// ExternalDriftCondition is set on a PowerWorkload while its Pool in AppQoS has been changed outside the
+	// operator, and cleared once the PowerWorkload has been re-applied
+	ExternalDriftCondition = "ExternalDriftDetected"
 )
 
 var sharedPowerWorkloadName = ""
@@ -66,6 +161,19 @@ func (r *PowerWorkloadReconciler) Reconcile(req ctrl.Request) (ctrl.Result, erro
 		if errors.IsNotFound(err) {
 			// Assume PowerWorkload has been deleted. Check each Power Node to delete from each AppQoS instance
 
+			// A PowerWorkload moved into the WorkloadNamespace keeps the Pool of the one deleted here
+			if r.WorkloadNamespace != "" && req.NamespacedName.Namespace != r.WorkloadNamespace {
+				migrated, err := r.workloadMigrated(req.NamespacedName.Name)
+				if err != nil {
+					logger.Error(err, "error checking for PowerWorkload in the workload namespace")
+					return ctrl.Result{}, err
+				}
+				if migrated {
+					logger.Info("PowerWorkload was moved to the workload namespace, leaving its Pool in place", "workloadNamespace", r.WorkloadNamespace)
+					return ctrl.Result{}, nil
+				}
+			}
+
 			var pool *appqos.Pool
 
 			if strings.HasPrefix(req.NamespacedName.Name, "shared-") {
@@ -112,6 +220,11 @@ func (r *PowerWorkloadReconciler) Reconcile(req ctrl.Request) (ctrl.Result, erro
 				}
 			}
 
+			if delay := r.AppQoSClient.ReserveWrite(AppQoSClientAddress); delay > 0 {
+				logger.Info("AppQoS write rate limit reached, requeueing", "requeueAfter", delay.String())
+				return ctrl.Result{RequeueAfter: delay}, nil
+			}
+
 			err = r.AppQoSClient.DeletePool(AppQoSClientAddress, *pool.ID)
 			if err != nil {
 				logger.Error(err, "error deleting Pool from AppQoS instance")
@@ -124,35 +237,53 @@ func (r *PowerWorkloadReconciler) Reconcile(req ctrl.Request) (ctrl.Result, erro
 				return ctrl.Result{}, err
 			}
 
-			appqosPutResponse, err := r.AppQoSClient.PutPool(updatedSharedPool, AppQoSClientAddress, id)
-			if err != nil {
-				logger.Error(err, appqosPutResponse)
-				return ctrl.Result{}, err
-			}
+			// The cores went to the Release pool rather than the Shared pool if the Shared pool comes back empty
+			if !reflect.DeepEqual(updatedSharedPool, &appqos.Pool{}) {
+				appqosPutResponse, err := r.AppQoSClient.PutPool(updatedSharedPool, AppQoSClientAddress, id)
+				if err != nil {
+					logger.Error(err, appqosPutResponse)
+					return ctrl.Result{}, err
+				}
 
-			sharedWorkload := powerv1alpha1.PowerWorkload{}
-			powerWorkloads := &powerv1alpha1.PowerWorkloadList{}
-			err = r.Client.List(context.TODO(), powerWorkloads)
-			if err != nil {
-				logger.Error(err, "error retrieving PowerWorkload list")
-				return ctrl.Result{}, err
-			} else if len(powerWorkloads.Items) > 0 {
-				for _, powerWorkload := range powerWorkloads.Items {
-					if powerWorkload.Status.Node == nodeName {
-						sharedWorkload = powerWorkload
+				sharedWorkload := powerv1alpha1.PowerWorkload{}
+				powerWorkloads := &powerv1alpha1.PowerWorkloadList{}
+				err = r.Client.List(context.TODO(), powerWorkloads)
+				if err != nil {
+					logger.Error(err, "error retrieving PowerWorkload list")
+					return ctrl.Result{}, err
+				} else if len(powerWorkloads.Items) > 0 {
+					for _, powerWorkload := range powerWorkloads.Items {
+						if powerWorkload.Status.Node == nodeName {
+							sharedWorkload = powerWorkload
+						}
 					}
-				}
 
-				if !reflect.DeepEqual(sharedWorkload, powerv1alpha1.PowerWorkload{}) {
-					sharedWorkload.Status.SharedCores = *updatedSharedPool.Cores
-					err = r.Client.Status().Update(context.TODO(), &sharedWorkload)
-					if err != nil {
-						logger.Error(err, "error updating SharedWorkload")
-						return ctrl.Result{}, err
+					if !reflect.DeepEqual(sharedWorkload, powerv1alpha1.PowerWorkload{}) {
+						sharedWorkload.Status.SharedCores = *updatedSharedPool.Cores
+						err = r.Client.Status().Update(context.TODO(), &sharedWorkload)
+						if err != nil {
+							logger.Error(err, "error updating SharedWorkload")
+							return ctrl.Result{}, err
+						}
 					}
 				}
 			}
 
+			if *pool.Name != "Shared" {
+				err = r.reconcilePackageScope(logger, req.NamespacedName.Namespace, nodeName, *pool.Cores)
+				if err != nil {
+					return ctrl.Result{}, err
+				}
+			}
+
+			if r.ProfileCleanup != "" && r.ProfileCleanup != ProfileCleanupRetain && *pool.Name != "Shared" && pool.PowerProfile != nil {
+				err = r.cleanupUnusedProfile(logger, req.NamespacedName.Namespace, *pool.PowerProfile)
+				if err != nil {
+					logger.Error(err, "error cleaning up unused PowerProfile")
+					return ctrl.Result{}, err
+				}
+			}
+
 			return ctrl.Result{}, nil
 		}
 
@@ -160,6 +291,16 @@ func (r *PowerWorkloadReconciler) Reconcile(req ctrl.Request) (ctrl.Result, erro
 		return ctrl.Result{}, err
 	}
 
+	if r.WorkloadNamespace != "" && workload.Namespace != r.WorkloadNamespace && !workload.Spec.AllCores && workload.Spec.Node.Name == nodeName {
+		err = r.migrateWorkloadNamespace(logger, workload)
+		if err != nil {
+			logger.Error(err, "error moving PowerWorkload into the workload namespace", "workloadNamespace", r.WorkloadNamespace)
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, nil
+	}
+
 	// If there are multiple nodes that the Shared PowerWorkload's Node Selector satisfies we need to fail here before anything is done
 	if workload.Spec.AllCores {
 		if !strings.HasPrefix(workload.Name, "shared-") {
@@ -211,7 +352,16 @@ func (r *PowerWorkloadReconciler) Reconcile(req ctrl.Request) (ctrl.Result, erro
 	} else {
 		// Check to make sure this PowerWorkload is meant for this Node
 		if workload.Spec.Node.Name != nodeName {
-			// Not meant for this Node, continue
+			// Not meant for this Node, but if the Node it is meant for has been removed from the cluster
+			// its NodeInfo is pruned. There is nothing to reset in AppQoS as the Node is gone
+
+			if workload.Spec.Node.Name != "" {
+				err = r.pruneDeletedNode(logger, workload)
+				if err != nil {
+					logger.Error(err, "error pruning NodeInfo of deleted Node")
+					return ctrl.Result{}, err
+				}
+			}
 
 			return ctrl.Result{}, nil
 		}
@@ -229,6 +379,61 @@ func (r *PowerWorkloadReconciler) Reconcile(req ctrl.Request) (ctrl.Result, erro
 			logger.Error(powerWorkloadIncorrectBeginning, "error creating Shared PowerWorkload")
 			return ctrl.Result{}, nil
 		}
+
+		// Cores recorded against a Node that has since been replaced by another of the same name belong to
+		// different hardware, so they are reset. The update triggers another reconcile to apply the change
+		updated, err := r.resetReplacedNode(logger, workload)
+		if err != nil {
+			logger.Error(err, "error checking PowerWorkload's Node for replacement")
+			return ctrl.Result{}, err
+		}
+		if updated {
+			return ctrl.Result{}, nil
+		}
+	}
+
+	// A PowerProfile with a maxLifetime is only applied for that long before the Pool reverts to the baseline
+	profile, err := r.workloadProfile(workload)
+	if err != nil {
+		logger.Error(err, "error retrieving PowerProfile of PowerWorkload")
+		return ctrl.Result{}, err
+	}
+	expired := profileLifetimeExpired(workload, profile) && !workload.Status.ProfileExpired
+
+	// If this generation of the PowerWorkload has already been applied there is nothing to do, unless the
+	// Pool has drifted from what was applied since, e.g. after AppQoS was reconfigured by hand, or the
+	// PowerProfile's lifetime has passed, or it is still to be re-applied since the Node Agent started
+	if !workload.Spec.AllCores && workload.Generation != 0 && workload.Status.ObservedGeneration == workload.Generation && !r.reassertionPending(workload) {
+		drift, err := r.poolDrift(workload)
+		if err != nil {
+			logger.Error(err, "error retrieving Pool from AppQoS")
+			return ctrl.Result{}, err
+		}
+		if drift == "" && !expired {
+			logger.Info("PowerWorkload generation already applied to AppQoS, nothing to update", "generation", workload.Generation)
+			return lifetimeResult(workload, profile), nil
+		}
+
+		if drift != "" {
+			logger.Info("Pool has drifted from the applied PowerWorkload, re-applying", "generation", workload.Generation, "drift", drift)
+
+			meta.SetStatusCondition(&workload.Status.Conditions, metav1.Condition{
+				Type:    ExternalDriftCondition,
+				Status:  metav1.ConditionTrue,
+				Reason:  "PoolChanged",
+				Message: drift,
+			})
+			err = r.Client.Status().Update(context.TODO(), workload)
+			if err != nil {
+				logger.Error(err, "error reporting drift condition on PowerWorkload")
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	if delay := r.AppQoSClient.ReserveWrite(AppQoSClientAddress); delay > 0 {
+		logger.Info("AppQoS write rate limit reached, requeueing", "requeueAfter", delay.String())
+		return ctrl.Result{RequeueAfter: delay}, nil
 	}
 
 	// Get the PowerProfile from the AppQoS instance
@@ -245,6 +450,34 @@ func (r *PowerWorkloadReconciler) Reconcile(req ctrl.Request) (ctrl.Result, erro
 		return ctrl.Result{}, err
 	}
 
+	// When frequencies are set per package, the Pool takes the PowerProfile in effect for its packages instead
+	if r.ScopeMode == ScopePackage && !workload.Spec.AllCores {
+		packageWorkloads, err := r.packageWorkloads(req.NamespacedName.Namespace, nodeName, workload.Spec.Node.CpuIds)
+		if err != nil {
+			logger.Error(err, "error retrieving PowerWorkloads sharing a package")
+			return ctrl.Result{}, err
+		}
+
+		powerProfileFromAppQoS, err = r.effectivePackageProfile(packageWorkloads, powerProfileFromAppQoS)
+		if err != nil {
+			logger.Error(err, "error resolving the PowerProfile in effect for the PowerWorkload's packages")
+			return ctrl.Result{}, err
+		}
+	}
+
+	if (expired || workload.Status.ProfileExpired) && profile != nil {
+		baseline, err := r.baselineProfile(profile, nodeName)
+		if err != nil {
+			logger.Error(err, "error resolving the baseline PowerProfile of an expired PowerProfile")
+			return ctrl.Result{}, err
+		}
+		if expired {
+			logger.Info("PowerProfile's maxLifetime has passed, reverting the Pool to the baseline", "profile", workload.Spec.PowerProfile, "baseline", *baseline.Name)
+		}
+		powerProfileFromAppQoS = baseline
+		workload.Status.ProfileExpired = true
+	}
+
 	// Get the Pool associated with this PowerWorkload
 	poolFromAppQoS, err := r.AppQoSClient.GetPoolByName(AppQoSClientAddress, req.NamespacedName.Name)
 	if err != nil {
@@ -297,7 +530,15 @@ func (r *PowerWorkloadReconciler) Reconcile(req ctrl.Request) (ctrl.Result, erro
 					return ctrl.Result{}, err
 				}
 			} else {
-				coresRemovedFromDefaultPool = util.CPUListDifference(workload.Spec.ReservedCPUs, *sharedPool.Cores)
+				// Cores freed into the Release pool are shared again now that there is a Shared pool to take them
+				releasedCPUs, err := r.deleteReleasePool(AppQoSClientAddress)
+				if err != nil {
+					logger.Error(err, "error removing Release Pool from AppQoS")
+					return ctrl.Result{}, err
+				}
+
+				defaultCPUs := append(append([]int{}, *sharedPool.Cores...), releasedCPUs...)
+				coresRemovedFromDefaultPool = util.CPUListDifference(workload.Spec.ReservedCPUs, defaultCPUs)
 
 				updatedSharedPool, id := updatePoolWithoutPowerProfile(workload.Spec.ReservedCPUs, sharedPool)
 				appqosPutResponse, err := r.AppQoSClient.PutPool(updatedSharedPool, AppQoSClientAddress, id)
@@ -335,6 +576,7 @@ func (r *PowerWorkloadReconciler) Reconcile(req ctrl.Request) (ctrl.Result, erro
 
 			// Update the Status of the Shared PowerWorkload with the current Shared pool cores
 			workload.Status.Node = nodeName
+			workload.Status.ObservedGeneration = workload.Generation
 			err = r.Client.Status().Update(context.TODO(), workload)
 			if err != nil {
 				logger.Error(err, "error updating Shared PowerWorkload status")
@@ -353,31 +595,10 @@ func (r *PowerWorkloadReconciler) Reconcile(req ctrl.Request) (ctrl.Result, erro
 			// Only update the AppQoS instance if there were any cores removed
 			// In this instance where the Pool is being created, there will always be a removal of cores from the Shared pool
 			if !reflect.DeepEqual(updatedSharedPool, &appqos.Pool{}) {
-				appqosPutResponse, err := r.AppQoSClient.PutPool(updatedSharedPool, AppQoSClientAddress, id)
+				err = r.applySharedPool(logger, req.NamespacedName.Namespace, workload.Spec.Node.Name, updatedSharedPool, id)
 				if err != nil {
-					logger.Error(err, appqosPutResponse)
 					return ctrl.Result{}, err
 				}
-
-				sharedWorkload := &powerv1alpha1.PowerWorkload{}
-				err = r.Client.Get(context.TODO(), client.ObjectKey{
-					Name:      fmt.Sprintf("shared-%s-workload", workload.Spec.Node.Name),
-					Namespace: req.NamespacedName.Namespace,
-				}, sharedWorkload)
-				if err != nil {
-					// If the Shared Workload is not found, we don't need to update the Status
-					if !errors.IsNotFound(err) {
-						logger.Error(err, "error retrieving Shared PowerWorkload")
-						return ctrl.Result{}, err
-					}
-				} else {
-					sharedWorkload.Status.SharedCores = *updatedSharedPool.Cores
-					err = r.Client.Status().Update(context.TODO(), sharedWorkload)
-					if err != nil {
-						logger.Error(err, "error updating status of Shared PowerWorkload")
-						return ctrl.Result{}, err
-					}
-				}
 			}
 
 			cbmDefault := 1
@@ -393,6 +614,17 @@ func (r *PowerWorkloadReconciler) Reconcile(req ctrl.Request) (ctrl.Result, erro
 				logger.Error(err, appqosPostResponse)
 				return ctrl.Result{}, err
 			}
+
+			err = r.reconcilePackageScope(logger, req.NamespacedName.Namespace, nodeName, workload.Spec.Node.CpuIds)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+
+			err = r.recordAppliedCPUs(workload)
+			if err != nil {
+				logger.Error(err, "error updating PowerWorkload status")
+				return ctrl.Result{}, err
+			}
 		}
 	} else {
 		// The pool already exists in AppQoS, so need to retrieve and update it
@@ -403,82 +635,316 @@ func (r *PowerWorkloadReconciler) Reconcile(req ctrl.Request) (ctrl.Result, erro
 		// back into the Default pool. It has to be done in this order as AppQoS will fail
 		// if you try and assign CPUs to a new pool when they exist in another one
 
-		updatedSharedPool, id, err := r.removeCoresFromSharedPool(workload.Spec.Node.CpuIds, AppQoSClientAddress)
-		if err != nil {
-			logger.Error(err, "error updating Shared pool")
-			return ctrl.Result{}, err
+		// Only the cores that changed since the PowerWorkload was last applied need moving. If it has never been
+		// applied, the Pool's current cores in AppQoS are the baseline
+		appliedCPUs := workload.Status.AppliedCpuIds
+		if appliedCPUs == nil || !sameCPUs(appliedCPUs, *poolFromAppQoS.Cores) {
+			appliedCPUs = *poolFromAppQoS.Cores
+		}
+		addedCPUs := util.CPUListDifference(appliedCPUs, workload.Spec.Node.CpuIds)
+		returnedCPUs := util.CPUListDifference(workload.Spec.Node.CpuIds, appliedCPUs)
+		profileChanged := poolFromAppQoS.PowerProfile == nil || *poolFromAppQoS.PowerProfile != *powerProfileFromAppQoS.ID
+
+		if len(addedCPUs) == 0 && len(returnedCPUs) == 0 && !profileChanged {
+			logger.Info("PowerWorkload is already applied to AppQoS, nothing to update")
+			r.recordReassertion(workload)
+			if workload.Status.ObservedGeneration != workload.Generation || meta.IsStatusConditionTrue(workload.Status.Conditions, ExternalDriftCondition) || expired {
+				err = r.recordAppliedCPUs(workload)
+				if err != nil {
+					logger.Error(err, "error updating PowerWorkload status")
+					return ctrl.Result{}, err
+				}
+			}
+			return lifetimeResult(workload, profile), nil
 		}
 
-		// Only update the Shared Pool if there were cores removed
-		if !reflect.DeepEqual(updatedSharedPool, &appqos.Pool{}) {
-			appqosPutResponse, err := r.AppQoSClient.PutPool(updatedSharedPool, AppQoSClientAddress, id)
+		if r.RolloutWindow > 0 && profileChanged && !workload.Spec.AllCores {
+			delay, err := r.sequenceIncrease(logger, workload, poolFromAppQoS, powerProfileFromAppQoS)
 			if err != nil {
-				logger.Error(err, appqosPutResponse)
+				logger.Error(err, "error ordering the PowerWorkload's frequency change against the Node's rollout")
 				return ctrl.Result{}, err
 			}
-
-			sharedWorkload := &powerv1alpha1.PowerWorkload{}
-			err = r.Client.Get(context.TODO(), client.ObjectKey{
-				Name:      fmt.Sprintf("shared-%s-workload", workload.Spec.Node.Name),
-				Namespace: req.NamespacedName.Namespace,
-			}, sharedWorkload)
-			if err != nil {
-				// The Shared Workload is not found, we don't need to update the Status
-				if !errors.IsNotFound(err) {
-					logger.Error(err, "error retrieving Shared PowerWorkload")
-					return ctrl.Result{}, err
-				}
-			} else {
-				sharedWorkload.Status.SharedCores = *updatedSharedPool.Cores
-				err = r.Client.Status().Update(context.TODO(), sharedWorkload)
-				if err != nil {
-					logger.Error(err, "error updating Shared PowerWorkload Status")
-					return ctrl.Result{}, err
-				}
+			if delay > 0 {
+				return ctrl.Result{RequeueAfter: delay}, nil
 			}
 		}
 
-		returnedCPUs := util.CPUListDifference(workload.Spec.Node.CpuIds, *poolFromAppQoS.Cores)
+		// The Shared pool gives up the added cores before the Pool takes them, and takes back the returned ones
+		// after the Pool gives them up, so that applied one at a time no core is ever in two Pools
+		pools := make([]appqos.Pool, 0)
+		updatedSharedPool, id, err := r.removeCoresFromSharedPool(addedCPUs, AppQoSClientAddress)
+		if err != nil {
+			logger.Error(err, "error updating Shared pool")
+			return ctrl.Result{}, err
+		}
+
+		// Only update the Shared Pool if there were cores removed
+		sharedPoolChanged := !reflect.DeepEqual(updatedSharedPool, &appqos.Pool{})
+		sharedCPUs := make([]int, 0)
+		if sharedPoolChanged {
+			updatedSharedPool.ID = &id
+			pools = append(pools, *updatedSharedPool)
+			sharedCPUs = *updatedSharedPool.Cores
+		}
 
 		// Update the Workload's Pool (length of Core List in a Pool cannot be zero)
 		updatedPool := &appqos.Pool{}
 		updatedPool.Name = &req.NamespacedName.Name
+		updatedPool.ID = poolFromAppQoS.ID
 		updatedPool.Cores = &workload.Spec.Node.CpuIds
 		updatedPool.PowerProfile = powerProfileFromAppQoS.ID
+		pools = append(pools, *updatedPool)
 
-		appqosPutResponse, err := r.AppQoSClient.PutPool(updatedPool, AppQoSClientAddress, *poolFromAppQoS.ID)
+		releasedCPUs := make([]int, 0)
+		if len(returnedCPUs) > 0 {
+			if !sharedPoolChanged {
+				updatedSharedPool, err = r.AppQoSClient.GetSharedPool(AppQoSClientAddress)
+				if err != nil {
+					logger.Error(err, "error retrieving Shared pool")
+					return ctrl.Result{}, err
+				}
+			}
+
+			// Giving the Default pool the release profile would re-tune every core left in it, so the freed cores
+			// go to the Release pool once the Pool has given them up
+			if updatedSharedPool.PowerProfile == nil && r.DefaultReleaseProfile != "" {
+				releasedCPUs = returnedCPUs
+			} else {
+				sharedCPUs = append(append([]int{}, *updatedSharedPool.Cores...), returnedCPUs...)
+				sort.Ints(sharedCPUs)
+				pools = append(pools, appqos.Pool{
+					Name:         updatedSharedPool.Name,
+					ID:           updatedSharedPool.ID,
+					Cores:        &sharedCPUs,
+					PowerProfile: updatedSharedPool.PowerProfile,
+				})
+				sharedPoolChanged = true
+			}
+		}
+
+		err = r.applyPools(logger, pools)
 		if err != nil {
-			logger.Error(err, appqosPutResponse)
 			return ctrl.Result{}, err
 		}
 
-		if len(returnedCPUs) > 0 {
-			updatedSharedPool, id, err := r.returnCoresToSharedPool(returnedCPUs, AppQoSClientAddress)
+		if len(releasedCPUs) > 0 {
+			err = r.addCoresToReleasePool(releasedCPUs, AppQoSClientAddress)
 			if err != nil {
-				logger.Error(err, "error updating Shared Pool")
+				logger.Error(err, "error giving freed cores the release profile")
 				return ctrl.Result{}, err
 			}
+		}
 
-			appqosPutResponse, err := r.AppQoSClient.PutPool(updatedSharedPool, AppQoSClientAddress, id)
+		if sharedPoolChanged {
+			err = r.recordSharedCores(logger, req.NamespacedName.Namespace, workload.Spec.Node.Name, sharedCPUs)
 			if err != nil {
-				logger.Error(err, appqosPutResponse)
 				return ctrl.Result{}, err
 			}
 		}
+
+		err = r.reconcilePackageScope(logger, req.NamespacedName.Namespace, nodeName, appendIfUnique(append([]int{}, appliedCPUs...), workload.Spec.Node.CpuIds))
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		err = r.recordAppliedCPUs(workload)
+		if err != nil {
+			logger.Error(err, "error updating PowerWorkload status")
+			return ctrl.Result{}, err
+		}
+	}
+
+	return lifetimeResult(workload, profile), nil
+}
+
+// applySharedPool writes the Shared pool recomputed after exclusive cores were carved out of or returned to it,
+// re-applying its Power Profile to the new Core List, and records the cores on the Node's Shared PowerWorkload
+func (r *PowerWorkloadReconciler) applySharedPool(logger logr.Logger, namespace string, nodeName string, sharedPool *appqos.Pool, id int) error {
+	appqosPutResponse, err := r.AppQoSClient.PutPool(sharedPool, AppQoSClientAddress, id)
+	if err != nil {
+		logger.Error(err, appqosPutResponse)
+		return err
+	}
+
+	return r.recordSharedCores(logger, namespace, nodeName, *sharedPool.Cores)
+}
+
+// recordSharedCores records the Shared pool's cores on the Node's Shared PowerWorkload
+func (r *PowerWorkloadReconciler) recordSharedCores(logger logr.Logger, namespace string, nodeName string, sharedCores []int) error {
+	sharedWorkload := &powerv1alpha1.PowerWorkload{}
+	err := r.Client.Get(context.TODO(), client.ObjectKey{
+		Name:      fmt.Sprintf("shared-%s-workload", nodeName),
+		Namespace: namespace,
+	}, sharedWorkload)
+	if err != nil {
+		// If the Shared Workload is not found, we don't need to update the Status
+		if errors.IsNotFound(err) {
+			return nil
+		}
+
+		logger.Error(err, "error retrieving Shared PowerWorkload")
+		return err
+	}
+
+	sharedWorkload.Status.SharedCores = sharedCores
+	err = r.Client.Status().Update(context.TODO(), sharedWorkload)
+	if err != nil {
+		logger.Error(err, "error updating status of Shared PowerWorkload")
+		return err
+	}
+
+	return nil
+}
+
+// recordAppliedCPUs stores the PowerWorkload's Core List and generation in its status as the last applied to
+// AppQoS, so later updates only need to move the cores that changed and unchanged generations can be skipped.
+// Any drift reported before is cleared, as the Pool now matches the PowerWorkload again
+func (r *PowerWorkloadReconciler) recordAppliedCPUs(workload *powerv1alpha1.PowerWorkload) error {
+	workload.Status.AppliedCpuIds = append([]int{}, workload.Spec.Node.CpuIds...)
+	workload.Status.ObservedGeneration = workload.Generation
+	if workload.Status.AppliedTime == nil {
+		now := metav1.Now()
+		workload.Status.AppliedTime = &now
 	}
+	if meta.IsStatusConditionTrue(workload.Status.Conditions, ExternalDriftCondition) {
+		meta.SetStatusCondition(&workload.Status.Conditions, metav1.Condition{
+			Type:    ExternalDriftCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  "Reconciled",
+			Message: "Pool re-applied to AppQoS",
+		})
+	}
+	err := r.Client.Status().Update(context.TODO(), workload)
+	if err != nil {
+		return err
+	}
+
+	r.recordReassertion(workload)
+	return nil
+}
+
+// pruneDeletedNode clears the PowerWorkload's NodeInfo if the Node it names no longer exists
+func (r *PowerWorkloadReconciler) pruneDeletedNode(logger logr.Logger, workload *powerv1alpha1.PowerWorkload) error {
+	node := &corev1.Node{}
+	err := r.Client.Get(context.TODO(), client.ObjectKey{Name: workload.Spec.Node.Name}, node)
+	if err == nil || !errors.IsNotFound(err) {
+		return err
+	}
+
+	logger.Info("Node no longer exists, pruning it from the PowerWorkload", "node", workload.Spec.Node.Name)
+	workload.Spec.Node = powerv1alpha1.NodeInfo{}
+	return r.Client.Update(context.TODO(), workload)
+}
+
+// poolDrift describes how the PowerWorkload's Pool in AppQoS differs from the cores last applied to it, or
+// returns an empty string if it still holds them
+func (r *PowerWorkloadReconciler) poolDrift(workload *powerv1alpha1.PowerWorkload) (string, error) {
+	pool, err := r.AppQoSClient.GetPoolByName(AppQoSClientAddress, workload.Name)
+	if err != nil {
+		return "", err
+	}
+
+	if reflect.DeepEqual(pool, &appqos.Pool{}) || pool.Cores == nil {
+		return fmt.Sprintf("Pool '%s' no longer exists in AppQoS", workload.Name), nil
+	}
+
+	if sameCPUs(workload.Status.AppliedCpuIds, *pool.Cores) {
+		return "", nil
+	}
+
+	return fmt.Sprintf("Pool '%s' holds cores %v in AppQoS, expected %v", workload.Name, *pool.Cores, workload.Status.AppliedCpuIds), nil
+}
 
-	return ctrl.Result{}, nil
+// sameCPUs reports whether the two Core Lists hold the same cores, regardless of order
+func sameCPUs(cpuListOne []int, cpuListTwo []int) bool {
+	return len(util.CPUListDifference(cpuListOne, cpuListTwo)) == 0 && len(util.CPUListDifference(cpuListTwo, cpuListOne)) == 0
+}
+
+// cleanupUnusedProfile deletes the AppQoS profile with the given ID if no remaining PowerWorkload uses it and its
+// PowerProfile is gone, deleting the PowerProfile first if the policy allows and the operator created it
+func (r *PowerWorkloadReconciler) cleanupUnusedProfile(logger logr.Logger, namespace string, profileID int) error {
+	profiles, err := r.AppQoSClient.GetPowerProfiles(AppQoSClientAddress)
+	if err != nil {
+		return err
+	}
+
+	profileName := ""
+	for _, profile := range profiles {
+		if profile.ID != nil && *profile.ID == profileID && profile.Name != nil {
+			profileName = *profile.Name
+		}
+	}
+	if profileName == "" {
+		return nil
+	}
+
+	workloads := &powerv1alpha1.PowerWorkloadList{}
+	err = r.Client.List(context.TODO(), workloads, client.InNamespace(namespace))
+	if err != nil {
+		return err
+	}
+
+	for _, workload := range workloads.Items {
+		if workload.Spec.PowerProfile == profileName {
+			return nil
+		}
+	}
+
+	profile := &powerv1alpha1.PowerProfile{}
+	profileExists := true
+	err = r.Client.Get(context.TODO(), client.ObjectKey{
+		Name:      profileName,
+		Namespace: namespace,
+	}, profile)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+
+		profileExists = false
+	}
+
+	// PowerProfiles created by users are theirs to remove
+	if profileExists && r.ProfileCleanup == ProfileCleanupDeleteProfile && profile.Labels[ProfileCreatedByLabel] == ProfileCreatedByOperator {
+		err = r.Client.Delete(context.TODO(), profile)
+		if err != nil {
+			return err
+		}
+
+		profileExists = false
+	}
+
+	// Nothing would put the profile back in AppQoS for the next PowerWorkload to use the PowerProfile
+	if profileExists {
+		logger.Info("Last PowerWorkload using PowerProfile deleted, keeping it in AppQoS while the PowerProfile exists", "profile", profileName, "policy", r.ProfileCleanup)
+		return nil
+	}
+
+	logger.Info("Last PowerWorkload using PowerProfile deleted, removing it from AppQoS", "profile", profileName, "policy", r.ProfileCleanup)
+	return r.AppQoSClient.DeletePowerProfile(AppQoSClientAddress, profileID)
 }
 
 func (r *PowerWorkloadReconciler) removeCoresFromSharedPool(workloadCPUList []int, nodeAddress string) (*appqos.Pool, int, error) {
 	// Removes the CPUs in workloadCPUList from the Shared Pool if they exist. Returns an empty Pool if
 	// no cores have been removed
 
+	// Cores freed with the release profile are claimed from the Release pool directly
+	err := r.removeCoresFromReleasePool(workloadCPUList, nodeAddress)
+	if err != nil {
+		return &appqos.Pool{}, 0, err
+	}
+
 	sharedPool, err := r.AppQoSClient.GetSharedPool(nodeAddress)
 	if err != nil {
 		return &appqos.Pool{}, 0, err
 	}
 
+	if len(util.CommonCPUs(workloadCPUList, *sharedPool.Cores)) == 0 {
+		// None of the cores are in the Shared Pool, so its Core List is unchanged
+
+		return &appqos.Pool{}, 0, nil
+	}
+
 	updatedSharedCoreList := util.CPUListDifference(workloadCPUList, *sharedPool.Cores)
 	if len(updatedSharedCoreList) == 0 {
 		// Return empty Pool so the calling function knows there's no need to update the AppQoS instance
@@ -500,13 +966,19 @@ func (r *PowerWorkloadReconciler) removeCoresFromSharedPool(workloadCPUList []in
 }
 
 func (r *PowerWorkloadReconciler) returnCoresToSharedPool(returnedCPUs []int, nodeAddress string) (*appqos.Pool, int, error) {
-	// Returns the cores that are no longer used by a Power Workload to the Shared Pool
+	// Returns the cores that are no longer used by a Power Workload to the Shared Pool. Returns an empty Pool if
+	// the cores were given the release profile in the Release pool instead
 
 	sharedPool, err := r.AppQoSClient.GetSharedPool(nodeAddress)
 	if err != nil {
 		return &appqos.Pool{}, 0, err
 	}
 
+	// Giving the Default pool the release profile would re-tune every core left in it, not just the freed ones
+	if sharedPool.PowerProfile == nil && r.DefaultReleaseProfile != "" {
+		return &appqos.Pool{}, 0, r.addCoresToReleasePool(returnedCPUs, nodeAddress)
+	}
+
 	var updatedSharedPool *appqos.Pool
 	var sharedPoolID int
 	newSharedCPUList := append(*sharedPool.Cores, returnedCPUs...)
@@ -522,6 +994,91 @@ func (r *PowerWorkloadReconciler) returnCoresToSharedPool(returnedCPUs []int, no
 	return updatedSharedPool, sharedPoolID, nil
 }
 
+// addCoresToReleasePool gives freed cores the release profile by moving them into the Release pool, creating it
+// when the first cores are freed
+func (r *PowerWorkloadReconciler) addCoresToReleasePool(returnedCPUs []int, nodeAddress string) error {
+	releaseProfile, err := r.AppQoSClient.GetProfileByName(r.DefaultReleaseProfile, nodeAddress)
+	if err != nil {
+		return err
+	}
+	if reflect.DeepEqual(releaseProfile, &appqos.PowerProfile{}) {
+		return errors.NewServiceUnavailable(fmt.Sprintf("release PowerProfile '%s' not found in AppQoS instance", r.DefaultReleaseProfile))
+	}
+
+	releasePool, err := r.AppQoSClient.GetPoolByName(nodeAddress, ReleasePool)
+	if err != nil {
+		return err
+	}
+
+	if reflect.DeepEqual(releasePool, &appqos.Pool{}) {
+		cbmDefault := 1
+		releasePoolName := ReleasePool
+		releasedCPUs := append([]int{}, returnedCPUs...)
+		sort.Ints(releasedCPUs)
+
+		pool := &appqos.Pool{}
+		pool.Name = &releasePoolName
+		pool.Cores = &releasedCPUs
+		pool.PowerProfile = releaseProfile.ID
+		pool.Cbm = &cbmDefault
+
+		_, err = r.AppQoSClient.PostPool(pool, nodeAddress)
+		return err
+	}
+
+	releasedCPUs := append(append([]int{}, *releasePool.Cores...), returnedCPUs...)
+	sort.Ints(releasedCPUs)
+
+	updatedReleasePool, id := updatePool(releasedCPUs, *releaseProfile.ID, releasePool)
+	_, err = r.AppQoSClient.PutPool(updatedReleasePool, nodeAddress, id)
+	return err
+}
+
+// removeCoresFromReleasePool takes the CPUs in workloadCPUList out of the Release pool if they are in it,
+// deleting the Pool once its last core is claimed as its Core List cannot be empty
+func (r *PowerWorkloadReconciler) removeCoresFromReleasePool(workloadCPUList []int, nodeAddress string) error {
+	releasePool, err := r.AppQoSClient.GetPoolByName(nodeAddress, ReleasePool)
+	if err != nil {
+		return err
+	}
+
+	if reflect.DeepEqual(releasePool, &appqos.Pool{}) || len(util.CommonCPUs(workloadCPUList, *releasePool.Cores)) == 0 {
+		return nil
+	}
+
+	remainingCPUs := util.CPUListDifference(workloadCPUList, *releasePool.Cores)
+	if len(remainingCPUs) == 0 {
+		return r.AppQoSClient.DeletePool(nodeAddress, *releasePool.ID)
+	}
+
+	updatedReleasePool := &appqos.Pool{}
+	updatedReleasePool.Name = releasePool.Name
+	updatedReleasePool.Cores = &remainingCPUs
+	updatedReleasePool.PowerProfile = releasePool.PowerProfile
+
+	_, err = r.AppQoSClient.PutPool(updatedReleasePool, nodeAddress, *releasePool.ID)
+	return err
+}
+
+// deleteReleasePool removes the Release pool, if there is one, returning the cores that were in it
+func (r *PowerWorkloadReconciler) deleteReleasePool(nodeAddress string) ([]int, error) {
+	releasePool, err := r.AppQoSClient.GetPoolByName(nodeAddress, ReleasePool)
+	if err != nil {
+		return []int{}, err
+	}
+
+	if reflect.DeepEqual(releasePool, &appqos.Pool{}) {
+		return []int{}, nil
+	}
+
+	err = r.AppQoSClient.DeletePool(nodeAddress, *releasePool.ID)
+	if err != nil {
+		return []int{}, err
+	}
+
+	return *releasePool.Cores, nil
+}
+
 func updatePool(newCPUList []int, newPowerProfile int, pool *appqos.Pool) (*appqos.Pool, int) {
 	updatedPool := &appqos.Pool{}
 	updatedPool.Name = pool.Name
@@ -540,7 +1097,11 @@ func updatePoolWithoutPowerProfile(newCPUList []int, pool *appqos.Pool) (*appqos
 }
 
 func (r *PowerWorkloadReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&powerv1alpha1.PowerWorkload{}).
-		Complete(r)
+	builder := ctrl.NewControllerManagedBy(mgr).
+		For(&powerv1alpha1.PowerWorkload{})
+	if r.Reapply != nil {
+		builder = builder.Watches(&source.Channel{Source: r.Reapply}, &handler.EnqueueRequestForObject{})
+	}
+
+	return builder.Complete(r)
 }
@@ -4,24 +4,32 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
 	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/appqos"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/cpuhotplug"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -4166,3 +4174,3426 @@ func TestNonSharedWorkloadUpdate(t *testing.T) {
 		server.Close()
 	}
 }
+
+func TestAppQoSWriteRateLimit(t *testing.T) {
+	tcases := []struct {
+		testCase           string
+		nodeName           string
+		deletedWorkloads   []string
+		writesPerSecond    float64
+		burst              int
+		expectedRemaining  []string
+		expectedMinRequeue int
+	}{
+		{
+			testCase: "Test Case 1",
+			nodeName: "example-node1",
+			deletedWorkloads: []string{
+				"performance-example-node1-workload",
+				"balance-performance-example-node1-workload",
+				"balance-power-example-node1-workload",
+			},
+			writesPerSecond:    20,
+			burst:              1,
+			expectedRemaining:  []string{"Default", "Shared"},
+			expectedMinRequeue: 1,
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", tc.nodeName)
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		appqosPools := []appqos.Pool{
+			{
+				Name:  stringPtr("Default"),
+				ID:    intPtr(1),
+				Cores: &[]int{0},
+			},
+			{
+				Name:  stringPtr("Shared"),
+				ID:    intPtr(2),
+				Cores: &[]int{1, 2, 3},
+			},
+		}
+		for i, workloadName := range tc.deletedWorkloads {
+			appqosPools = append(appqosPools, appqos.Pool{
+				Name:  stringPtr(workloadName),
+				ID:    intPtr(i + 3),
+				Cores: &[]int{i + 4},
+			})
+		}
+
+		r, err := createPowerWorkloadReconcilerObject([]runtime.Object{})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		r.AppQoSClient.SetWriteRateLimit(tc.writesPerSecond, tc.burst)
+
+		server, err := createPowerWorkloadListeners(appqosPools, []appqos.PowerProfile{})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating Listeners", tc.testCase))
+		}
+
+		// Deliver the deletions as a burst, honouring any requeue until every one has completed
+		numRequeues := 0
+		pending := tc.deletedWorkloads
+		for attempt := 0; len(pending) > 0 && attempt < 50; attempt++ {
+			requeued := make([]string, 0)
+			var requeueAfter time.Duration
+			for _, workloadName := range pending {
+				req := reconcile.Request{
+					NamespacedName: client.ObjectKey{
+						Name:      workloadName,
+						Namespace: PowerWorkloadNamespace,
+					},
+				}
+
+				result, err := r.Reconcile(req)
+				if err != nil {
+					server.Close()
+					t.Error(err)
+					t.Fatal(fmt.Sprintf("%s - error reconciling PowerWorkload object '%s'", tc.testCase, workloadName))
+				}
+
+				if result.RequeueAfter > 0 {
+					numRequeues++
+					requeued = append(requeued, workloadName)
+					requeueAfter = result.RequeueAfter
+				}
+			}
+
+			pending = requeued
+			time.Sleep(requeueAfter)
+		}
+
+		pools, err := r.AppQoSClient.GetPools(AppQoSClientAddress)
+		server.Close()
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving Pools from AppQoS", tc.testCase))
+		}
+
+		if numRequeues < tc.expectedMinRequeue {
+			t.Errorf("%s - Failed: Expected burst to be paced with at least %v requeues, got %v", tc.testCase, tc.expectedMinRequeue, numRequeues)
+		}
+
+		remaining := make([]string, 0)
+		for _, pool := range pools {
+			remaining = append(remaining, *pool.Name)
+		}
+		if !reflect.DeepEqual(remaining, tc.expectedRemaining) {
+			t.Errorf("%s - Failed: Expected remaining Pools to be %v, got %v", tc.testCase, tc.expectedRemaining, remaining)
+		}
+	}
+}
+
+func TestNonSharedWorkloadMinimalUpdate(t *testing.T) {
+	tcases := []struct {
+		testCase             string
+		appliedCpuIds        []int
+		poolCores            []int
+		updatedCpuIds        []int
+		expectedPutPools     map[string][]int
+		expectedAppliedCores []int
+	}{
+		{
+			testCase:      "Test Case 1",
+			appliedCpuIds: []int{2, 3},
+			poolCores:     []int{2, 3},
+			updatedCpuIds: []int{2, 3, 4},
+			expectedPutPools: map[string][]int{
+				"Default":                            []int{5, 6, 7},
+				"performance-example-node1-workload": []int{2, 3, 4},
+			},
+			expectedAppliedCores: []int{2, 3, 4},
+		},
+		{
+			testCase:             "Test Case 2",
+			appliedCpuIds:        []int{2, 3},
+			poolCores:            []int{2, 3},
+			updatedCpuIds:        []int{2, 3},
+			expectedPutPools:     map[string][]int{},
+			expectedAppliedCores: []int{2, 3},
+		},
+		{
+			testCase:      "Test Case 3",
+			appliedCpuIds: []int{2, 3},
+			poolCores:     []int{2, 3},
+			updatedCpuIds: []int{2},
+			expectedPutPools: map[string][]int{
+				"Default":                            []int{3, 4, 5, 6, 7},
+				"performance-example-node1-workload": []int{2},
+			},
+			expectedAppliedCores: []int{2},
+		},
+		{
+			testCase:      "Test Case 4",
+			appliedCpuIds: nil,
+			poolCores:     []int{2, 3},
+			updatedCpuIds: []int{2, 3, 4},
+			expectedPutPools: map[string][]int{
+				"Default":                            []int{5, 6, 7},
+				"performance-example-node1-workload": []int{2, 3, 4},
+			},
+			expectedAppliedCores: []int{2, 3, 4},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		appqosPools := []appqos.Pool{
+			{
+				Name:  stringPtr("Default"),
+				ID:    intPtr(1),
+				Cores: &[]int{4, 5, 6, 7},
+			},
+			{
+				Name:         stringPtr("performance-example-node1-workload"),
+				ID:           intPtr(2),
+				Cores:        &tc.poolCores,
+				PowerProfile: intPtr(1),
+			},
+		}
+		appqosPowerProfiles := []appqos.PowerProfile{
+			{
+				Name: stringPtr("performance-example-node1"),
+				ID:   intPtr(1),
+			},
+		}
+
+		workload := &powerv1alpha1.PowerWorkload{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1-workload",
+				Namespace: PowerWorkloadNamespace,
+			},
+			Spec: powerv1alpha1.PowerWorkloadSpec{
+				Name: "performance-example-node1-workload",
+				Node: powerv1alpha1.NodeInfo{
+					Name:   "example-node1",
+					CpuIds: tc.updatedCpuIds,
+				},
+				PowerProfile: "performance-example-node1",
+			},
+			Status: powerv1alpha1.PowerWorkloadStatus{
+				AppliedCpuIds: tc.appliedCpuIds,
+			},
+		}
+
+		r, err := createPowerWorkloadReconcilerObject([]runtime.Object{workload})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		// Record every Pool written so the number and content of AppQoS updates can be checked
+		putPools := make(map[string][]int)
+		listener, err := net.Listen("tcp", "127.0.0.1:5000")
+		if err != nil {
+			t.Fatal(fmt.Sprintf("%s - error creating Listener: %v", tc.testCase, err))
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/pools/", (func(w http.ResponseWriter, r *http.Request) {
+			p := appqos.Pool{}
+			_ = json.NewDecoder(r.Body).Decode(&p)
+			putPools[*p.Name] = *p.Cores
+		}))
+		mux.HandleFunc("/pools", (func(w http.ResponseWriter, r *http.Request) {
+			b, err := json.Marshal(appqosPools)
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+		mux.HandleFunc("/power_profiles", (func(w http.ResponseWriter, r *http.Request) {
+			b, err := json.Marshal(appqosPowerProfiles)
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+		server := httptest.NewUnstartedServer(mux)
+		server.Listener.Close()
+		server.Listener = listener
+		server.Start()
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      "performance-example-node1-workload",
+				Namespace: PowerWorkloadNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		server.Close()
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling PowerWorkload object", tc.testCase))
+		}
+
+		if !reflect.DeepEqual(putPools, tc.expectedPutPools) {
+			t.Errorf("%s - Failed: Expected AppQoS Pool updates to be %v, got %v", tc.testCase, tc.expectedPutPools, putPools)
+		}
+
+		err = r.Client.Get(context.TODO(), req.NamespacedName, workload)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload object", tc.testCase))
+		}
+
+		if !reflect.DeepEqual(workload.Status.AppliedCpuIds, tc.expectedAppliedCores) {
+			t.Errorf("%s - Failed: Expected applied Core List to be %v, got %v", tc.testCase, tc.expectedAppliedCores, workload.Status.AppliedCpuIds)
+		}
+	}
+}
+
+func TestUnusedProfileCleanup(t *testing.T) {
+	tcases := []struct {
+		testCase                     string
+		cleanupPolicy                ProfileCleanupPolicy
+		profileLabels                map[string]string
+		profileGone                  bool
+		remainingWorkloads           []string
+		expectedAppQoSProfileDeleted bool
+		expectedProfileDeleted       bool
+	}{
+		{
+			testCase:      "Test Case 1",
+			cleanupPolicy: ProfileCleanupRetain,
+			profileLabels: map[string]string{
+				ProfileCreatedByLabel: ProfileCreatedByOperator,
+			},
+			expectedAppQoSProfileDeleted: false,
+			expectedProfileDeleted:       false,
+		},
+		{
+			testCase:      "Test Case 2",
+			cleanupPolicy: ProfileCleanupDeleteAppQoSProfile,
+			profileLabels: map[string]string{
+				ProfileCreatedByLabel: ProfileCreatedByOperator,
+			},
+			expectedAppQoSProfileDeleted: false,
+			expectedProfileDeleted:       false,
+		},
+		{
+			testCase:      "Test Case 3",
+			cleanupPolicy: ProfileCleanupDeleteProfile,
+			profileLabels: map[string]string{
+				ProfileCreatedByLabel: ProfileCreatedByOperator,
+			},
+			expectedAppQoSProfileDeleted: true,
+			expectedProfileDeleted:       true,
+		},
+		{
+			testCase:      "Test Case 4",
+			cleanupPolicy: ProfileCleanupDeleteProfile,
+			profileLabels: map[string]string{
+				ProfileCreatedByLabel: ProfileCreatedByOperator,
+			},
+			remainingWorkloads:           []string{"performance-example-node1-workload-2"},
+			expectedAppQoSProfileDeleted: false,
+			expectedProfileDeleted:       false,
+		},
+		{
+			testCase:                     "Test Case 5",
+			cleanupPolicy:                ProfileCleanupDeleteProfile,
+			profileLabels:                map[string]string{},
+			expectedAppQoSProfileDeleted: false,
+			expectedProfileDeleted:       false,
+		},
+		{
+			testCase:                     "Test Case 6",
+			cleanupPolicy:                ProfileCleanupDeleteAppQoSProfile,
+			profileGone:                  true,
+			expectedAppQoSProfileDeleted: true,
+			expectedProfileDeleted:       true,
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		appqosPools := []appqos.Pool{
+			{
+				Name:  stringPtr("Default"),
+				ID:    intPtr(1),
+				Cores: &[]int{0, 1},
+			},
+			{
+				Name:         stringPtr("performance-example-node1-workload"),
+				ID:           intPtr(2),
+				Cores:        &[]int{2, 3},
+				PowerProfile: intPtr(1),
+			},
+		}
+		appqosPowerProfiles := []appqos.PowerProfile{
+			{
+				Name: stringPtr("performance-example-node1"),
+				ID:   intPtr(1),
+			},
+		}
+
+		objs := []runtime.Object{}
+		if !tc.profileGone {
+			objs = append(objs, &powerv1alpha1.PowerProfile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "performance-example-node1",
+					Namespace: PowerWorkloadNamespace,
+					Labels:    tc.profileLabels,
+				},
+				Spec: powerv1alpha1.PowerProfileSpec{
+					Name: "performance-example-node1",
+					Epp:  "performance",
+				},
+			})
+		}
+		for _, workloadName := range tc.remainingWorkloads {
+			objs = append(objs, &powerv1alpha1.PowerWorkload{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      workloadName,
+					Namespace: PowerWorkloadNamespace,
+				},
+				Spec: powerv1alpha1.PowerWorkloadSpec{
+					Name:         workloadName,
+					PowerProfile: "performance-example-node1",
+				},
+			})
+		}
+
+		r, err := createPowerWorkloadReconcilerObject(objs)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		r.ProfileCleanup = tc.cleanupPolicy
+
+		appqosProfileDeleted := false
+		listener, err := net.Listen("tcp", "127.0.0.1:5000")
+		if err != nil {
+			t.Fatal(fmt.Sprintf("%s - error creating Listener: %v", tc.testCase, err))
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/pools/", (func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "DELETE" {
+				appqosPools = appqosPools[:1]
+			}
+		}))
+		mux.HandleFunc("/pools", (func(w http.ResponseWriter, r *http.Request) {
+			b, err := json.Marshal(appqosPools)
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+		mux.HandleFunc("/power_profiles/", (func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "DELETE" {
+				appqosProfileDeleted = true
+			}
+		}))
+		mux.HandleFunc("/power_profiles", (func(w http.ResponseWriter, r *http.Request) {
+			b, err := json.Marshal(appqosPowerProfiles)
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+		server := httptest.NewUnstartedServer(mux)
+		server.Listener.Close()
+		server.Listener = listener
+		server.Start()
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      "performance-example-node1-workload",
+				Namespace: PowerWorkloadNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		server.Close()
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling PowerWorkload object", tc.testCase))
+		}
+
+		if appqosProfileDeleted != tc.expectedAppQoSProfileDeleted {
+			t.Errorf("%s - Failed: Expected AppQoS profile to be deleted to be %v, got %v", tc.testCase, tc.expectedAppQoSProfileDeleted, appqosProfileDeleted)
+		}
+
+		err = r.Client.Get(context.TODO(), client.ObjectKey{
+			Name:      "performance-example-node1",
+			Namespace: PowerWorkloadNamespace,
+		}, &powerv1alpha1.PowerProfile{})
+		if profileDeleted := errors.IsNotFound(err); profileDeleted != tc.expectedProfileDeleted {
+			t.Errorf("%s - Failed: Expected PowerProfile to be deleted to be %v, got %v", tc.testCase, tc.expectedProfileDeleted, profileDeleted)
+		}
+	}
+}
+
+func TestParseProfileCleanupPolicy(t *testing.T) {
+	tcases := []struct {
+		testCase       string
+		name           string
+		expectedPolicy ProfileCleanupPolicy
+		expectedError  bool
+	}{
+		{
+			testCase:       "Test Case 1 - Retain",
+			name:           "Retain",
+			expectedPolicy: ProfileCleanupRetain,
+		},
+		{
+			testCase:       "Test Case 2 - DeleteProfile",
+			name:           "DeleteProfile",
+			expectedPolicy: ProfileCleanupDeleteProfile,
+		},
+		{
+			testCase:      "Test Case 3 - Misspelt policy",
+			name:          "Delete",
+			expectedError: true,
+		},
+		{
+			testCase:      "Test Case 4 - Empty policy",
+			name:          "",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range tcases {
+		policy, err := ParseProfileCleanupPolicy(tc.name)
+		if (err != nil) != tc.expectedError {
+			t.Errorf("%s - Failed: Expected error to be %v, got %v", tc.testCase, tc.expectedError, err)
+		}
+
+		if policy != tc.expectedPolicy {
+			t.Errorf("%s - Failed: Expected policy '%s', got '%s'", tc.testCase, tc.expectedPolicy, policy)
+		}
+	}
+}
+
+func TestParseScopeMode(t *testing.T) {
+	tcases := []struct {
+		testCase      string
+		name          string
+		expectedMode  ScopeMode
+		expectedError bool
+	}{
+		{
+			testCase:     "Test Case 1 - Core",
+			name:         "Core",
+			expectedMode: ScopeCore,
+		},
+		{
+			testCase:     "Test Case 2 - Package",
+			name:         "Package",
+			expectedMode: ScopePackage,
+		},
+		{
+			testCase:      "Test Case 3 - Misspelt mode",
+			name:          "package",
+			expectedError: true,
+		},
+		{
+			testCase:      "Test Case 4 - Empty mode",
+			name:          "",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range tcases {
+		mode, err := ParseScopeMode(tc.name)
+		if (err != nil) != tc.expectedError {
+			t.Errorf("%s - Failed: Expected error to be %v, got %v", tc.testCase, tc.expectedError, err)
+		}
+
+		if mode != tc.expectedMode {
+			t.Errorf("%s - Failed: Expected mode '%s', got '%s'", tc.testCase, tc.expectedMode, mode)
+		}
+	}
+}
+
+func TestAdoptAppQoSAllocations(t *testing.T) {
+	tcases := []struct {
+		testCase                       string
+		dryRun                         bool
+		expectedAdopted                []string
+		expectedNumberOfPowerWorkloads int
+	}{
+		{
+			testCase:                       "Test Case 1",
+			dryRun:                         true,
+			expectedAdopted:                []string{"legacy-pool"},
+			expectedNumberOfPowerWorkloads: 1,
+		},
+		{
+			testCase:                       "Test Case 2",
+			dryRun:                         false,
+			expectedAdopted:                []string{"legacy-pool"},
+			expectedNumberOfPowerWorkloads: 2,
+		},
+	}
+
+	for _, tc := range tcases {
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		existingWorkload := &powerv1alpha1.PowerWorkload{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "managed-pool",
+				Namespace: PowerWorkloadNamespace,
+			},
+			Spec: powerv1alpha1.PowerWorkloadSpec{
+				Name:         "managed-pool",
+				PowerProfile: "performance",
+			},
+		}
+
+		r, err := createPowerWorkloadReconcilerObject([]runtime.Object{existingWorkload})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		pools := []appqos.Pool{
+			{Name: stringPtr("Default"), ID: intPtr(1), Cores: &[]int{0, 1, 2, 3}, PowerProfile: intPtr(1)},
+			{Name: stringPtr("Shared"), ID: intPtr(2), Cores: &[]int{6, 7}, PowerProfile: intPtr(1)},
+			{Name: stringPtr("legacy-pool"), ID: intPtr(3), Cores: &[]int{4, 5}, PowerProfile: intPtr(2)},
+			{Name: stringPtr("managed-pool"), ID: intPtr(4), Cores: &[]int{8, 9}, PowerProfile: intPtr(2)},
+			{Name: stringPtr("no-profile-pool"), ID: intPtr(5), Cores: &[]int{10}},
+		}
+		profiles := []appqos.PowerProfile{
+			{Name: stringPtr("shared"), ID: intPtr(1)},
+			{Name: stringPtr("performance"), ID: intPtr(2)},
+		}
+
+		server, err := createPowerWorkloadListeners(pools, profiles)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating Listeners", tc.testCase))
+		}
+
+		adopted, err := AdoptAppQoSAllocations(context.TODO(), r.Client, r.AppQoSClient, r.Log, PowerWorkloadNamespace, "example-node1", tc.dryRun)
+		server.Close()
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error adopting AppQoS allocations", tc.testCase))
+		}
+
+		adoptedNames := make([]string, 0)
+		for _, workload := range adopted {
+			adoptedNames = append(adoptedNames, workload.Name)
+		}
+		if !reflect.DeepEqual(adoptedNames, tc.expectedAdopted) {
+			t.Errorf("%s - Failed: Expected adopted PowerWorkloads to be %v, got %v", tc.testCase, tc.expectedAdopted, adoptedNames)
+		}
+
+		workloads := &powerv1alpha1.PowerWorkloadList{}
+		err = r.Client.List(context.TODO(), workloads)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload list", tc.testCase))
+		}
+
+		if len(workloads.Items) != tc.expectedNumberOfPowerWorkloads {
+			t.Errorf("%s - Failed: Expected number of PowerWorkloads to be %v, got %v", tc.testCase, tc.expectedNumberOfPowerWorkloads, len(workloads.Items))
+		}
+
+		if tc.dryRun {
+			continue
+		}
+
+		workload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{Name: "legacy-pool", Namespace: PowerWorkloadNamespace}, workload)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving adopted PowerWorkload", tc.testCase))
+		}
+
+		if workload.Spec.PowerProfile != "performance" {
+			t.Errorf("%s - Failed: Expected adopted PowerWorkload PowerProfile to be %v, got %v", tc.testCase, "performance", workload.Spec.PowerProfile)
+		}
+
+		if !reflect.DeepEqual(workload.Spec.Node.CpuIds, []int{4, 5}) {
+			t.Errorf("%s - Failed: Expected adopted PowerWorkload CPUs to be %v, got %v", tc.testCase, []int{4, 5}, workload.Spec.Node.CpuIds)
+		}
+	}
+}
+
+func TestWorkloadObservedGeneration(t *testing.T) {
+	tcases := []struct {
+		testCase                   string
+		generation                 int64
+		observedGeneration         int64
+		poolCores                  []int
+		cpuIds                     []int
+		expectedPutPools           map[string][]int
+		expectedProfileLookups     int
+		expectedObservedGeneration int64
+	}{
+		{
+			testCase:                   "Test Case 1",
+			generation:                 2,
+			observedGeneration:         2,
+			poolCores:                  []int{2, 3},
+			cpuIds:                     []int{2, 3},
+			expectedPutPools:           map[string][]int{},
+			expectedProfileLookups:     0,
+			expectedObservedGeneration: 2,
+		},
+		{
+			testCase:           "Test Case 2",
+			generation:         3,
+			observedGeneration: 2,
+			poolCores:          []int{2, 3},
+			cpuIds:             []int{2, 3, 4},
+			expectedPutPools: map[string][]int{
+				"Default":                            []int{5, 6, 7},
+				"performance-example-node1-workload": []int{2, 3, 4},
+			},
+			expectedProfileLookups:     1,
+			expectedObservedGeneration: 3,
+		},
+		{
+			testCase:           "Test Case 3",
+			generation:         2,
+			observedGeneration: 2,
+			poolCores:          []int{2},
+			cpuIds:             []int{2, 3},
+			expectedPutPools: map[string][]int{
+				"performance-example-node1-workload": []int{2, 3},
+			},
+			expectedProfileLookups:     1,
+			expectedObservedGeneration: 2,
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		appqosPools := []appqos.Pool{
+			{
+				Name:  stringPtr("Default"),
+				ID:    intPtr(1),
+				Cores: &[]int{4, 5, 6, 7},
+			},
+			{
+				Name:         stringPtr("performance-example-node1-workload"),
+				ID:           intPtr(2),
+				Cores:        &tc.poolCores,
+				PowerProfile: intPtr(1),
+			},
+		}
+		appqosPowerProfiles := []appqos.PowerProfile{
+			{
+				Name: stringPtr("performance-example-node1"),
+				ID:   intPtr(1),
+			},
+		}
+
+		workload := &powerv1alpha1.PowerWorkload{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "performance-example-node1-workload",
+				Namespace:  PowerWorkloadNamespace,
+				Generation: tc.generation,
+			},
+			Spec: powerv1alpha1.PowerWorkloadSpec{
+				Name: "performance-example-node1-workload",
+				Node: powerv1alpha1.NodeInfo{
+					Name:   "example-node1",
+					CpuIds: tc.cpuIds,
+				},
+				PowerProfile: "performance-example-node1",
+			},
+			Status: powerv1alpha1.PowerWorkloadStatus{
+				AppliedCpuIds:      []int{2, 3},
+				ObservedGeneration: tc.observedGeneration,
+			},
+		}
+
+		r, err := createPowerWorkloadReconcilerObject([]runtime.Object{workload})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		putPools := make(map[string][]int)
+		profileLookups := 0
+		listener, err := net.Listen("tcp", "127.0.0.1:5000")
+		if err != nil {
+			t.Fatal(fmt.Sprintf("%s - error creating Listener: %v", tc.testCase, err))
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/pools/", (func(w http.ResponseWriter, r *http.Request) {
+			p := appqos.Pool{}
+			_ = json.NewDecoder(r.Body).Decode(&p)
+			putPools[*p.Name] = *p.Cores
+		}))
+		mux.HandleFunc("/pools", (func(w http.ResponseWriter, r *http.Request) {
+			b, err := json.Marshal(appqosPools)
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+		mux.HandleFunc("/power_profiles", (func(w http.ResponseWriter, r *http.Request) {
+			profileLookups++
+			b, err := json.Marshal(appqosPowerProfiles)
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+		server := httptest.NewUnstartedServer(mux)
+		server.Listener.Close()
+		server.Listener = listener
+		server.Start()
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      "performance-example-node1-workload",
+				Namespace: PowerWorkloadNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		server.Close()
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling PowerWorkload object", tc.testCase))
+		}
+
+		if !reflect.DeepEqual(putPools, tc.expectedPutPools) {
+			t.Errorf("%s - Failed: Expected AppQoS Pool updates to be %v, got %v", tc.testCase, tc.expectedPutPools, putPools)
+		}
+
+		if profileLookups != tc.expectedProfileLookups {
+			t.Errorf("%s - Failed: Expected number of PowerProfile lookups to be %v, got %v", tc.testCase, tc.expectedProfileLookups, profileLookups)
+		}
+
+		err = r.Client.Get(context.TODO(), req.NamespacedName, workload)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload object", tc.testCase))
+		}
+
+		if workload.Status.ObservedGeneration != tc.expectedObservedGeneration {
+			t.Errorf("%s - Failed: Expected ObservedGeneration to be %v, got %v", tc.testCase, tc.expectedObservedGeneration, workload.Status.ObservedGeneration)
+		}
+	}
+}
+
+func TestDeletedNodePrunedFromWorkload(t *testing.T) {
+	tcases := []struct {
+		testCase         string
+		workloadNode     string
+		nodes            []string
+		expectedNodeInfo powerv1alpha1.NodeInfo
+	}{
+		{
+			testCase:         "Test Case 1",
+			workloadNode:     "example-node2",
+			nodes:            []string{"example-node1"},
+			expectedNodeInfo: powerv1alpha1.NodeInfo{},
+		},
+		{
+			testCase:     "Test Case 2",
+			workloadNode: "example-node2",
+			nodes:        []string{"example-node1", "example-node2"},
+			expectedNodeInfo: powerv1alpha1.NodeInfo{
+				Name:   "example-node2",
+				CpuIds: []int{2, 3},
+			},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+
+		workload := &powerv1alpha1.PowerWorkload{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node2-workload",
+				Namespace: PowerWorkloadNamespace,
+			},
+			Spec: powerv1alpha1.PowerWorkloadSpec{
+				Name: "performance-example-node2-workload",
+				Node: powerv1alpha1.NodeInfo{
+					Name:   tc.workloadNode,
+					CpuIds: []int{2, 3},
+				},
+				PowerProfile: "performance-example-node2",
+			},
+		}
+
+		objs := []runtime.Object{workload}
+		for _, nodeName := range tc.nodes {
+			objs = append(objs, &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: nodeName,
+				},
+			})
+		}
+
+		r, err := createPowerWorkloadReconcilerObject(objs)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      workload.Name,
+				Namespace: PowerWorkloadNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling PowerWorkload object", tc.testCase))
+		}
+
+		updatedWorkload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), req.NamespacedName, updatedWorkload)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload object", tc.testCase))
+		}
+
+		if !reflect.DeepEqual(updatedWorkload.Spec.Node, tc.expectedNodeInfo) {
+			t.Errorf("%s - Failed: Expected NodeInfo to be %v, got %v", tc.testCase, tc.expectedNodeInfo, updatedWorkload.Spec.Node)
+		}
+	}
+}
+
+func TestDefaultReleaseProfileAppliedToFreedCores(t *testing.T) {
+	tcases := []struct {
+		testCase               string
+		defaultReleaseProfile  string
+		releasePool            *appqos.Pool
+		expectedDefaultCores   []int
+		expectedReleaseCores   []int
+		expectedReleaseProfile *int
+	}{
+		{
+			testCase:               "Test Case 1 - release profile creates the Release pool",
+			defaultReleaseProfile:  "powersave",
+			expectedDefaultCores:   nil,
+			expectedReleaseCores:   []int{2, 3},
+			expectedReleaseProfile: intPtr(2),
+		},
+		{
+			testCase:               "Test Case 2 - no release profile",
+			defaultReleaseProfile:  "",
+			expectedDefaultCores:   []int{2, 3, 4, 5, 6, 7},
+			expectedReleaseCores:   nil,
+			expectedReleaseProfile: nil,
+		},
+		{
+			testCase:              "Test Case 3 - release profile extends the Release pool",
+			defaultReleaseProfile: "powersave",
+			releasePool: &appqos.Pool{
+				Name:         stringPtr("Release"),
+				ID:           intPtr(3),
+				Cores:        &[]int{0, 1},
+				PowerProfile: intPtr(2),
+			},
+			expectedDefaultCores:   nil,
+			expectedReleaseCores:   []int{0, 1, 2, 3},
+			expectedReleaseProfile: intPtr(2),
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		appqosPools := []appqos.Pool{
+			{
+				Name:  stringPtr("Default"),
+				ID:    intPtr(1),
+				Cores: &[]int{4, 5, 6, 7},
+			},
+			{
+				Name:         stringPtr("performance-example-node1-workload"),
+				ID:           intPtr(2),
+				Cores:        &[]int{2, 3},
+				PowerProfile: intPtr(1),
+			},
+		}
+		if tc.releasePool != nil {
+			appqosPools = append(appqosPools, *tc.releasePool)
+		}
+		appqosPowerProfiles := []appqos.PowerProfile{
+			{
+				Name: stringPtr("performance-example-node1"),
+				ID:   intPtr(1),
+			},
+			{
+				Name: stringPtr("powersave"),
+				ID:   intPtr(2),
+			},
+		}
+
+		r, err := createPowerWorkloadReconcilerObject([]runtime.Object{})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		r.DefaultReleaseProfile = tc.defaultReleaseProfile
+
+		putPools := make(map[string]appqos.Pool)
+		listener, err := net.Listen("tcp", "127.0.0.1:5000")
+		if err != nil {
+			t.Fatal(fmt.Sprintf("%s - error creating Listener: %v", tc.testCase, err))
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/pools/", (func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "PUT" {
+				p := appqos.Pool{}
+				_ = json.NewDecoder(r.Body).Decode(&p)
+				putPools[*p.Name] = p
+			}
+		}))
+		mux.HandleFunc("/pools", (func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "POST" {
+				p := appqos.Pool{}
+				_ = json.NewDecoder(r.Body).Decode(&p)
+				putPools[*p.Name] = p
+				w.WriteHeader(http.StatusCreated)
+				return
+			}
+			b, err := json.Marshal(appqosPools)
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+		mux.HandleFunc("/power_profiles", (func(w http.ResponseWriter, r *http.Request) {
+			b, err := json.Marshal(appqosPowerProfiles)
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+		server := httptest.NewUnstartedServer(mux)
+		server.Listener.Close()
+		server.Listener = listener
+		server.Start()
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      "performance-example-node1-workload",
+				Namespace: PowerWorkloadNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		server.Close()
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling PowerWorkload deletion", tc.testCase))
+		}
+
+		defaultPool, ok := putPools["Default"]
+		if tc.expectedDefaultCores == nil {
+			if ok {
+				t.Errorf("%s - Failed: Expected Default pool to be left alone, got cores %v and PowerProfile %v", tc.testCase, *defaultPool.Cores, defaultPool.PowerProfile)
+			}
+		} else if !ok {
+			t.Errorf("%s - Failed: Expected Default pool to be updated with the freed cores", tc.testCase)
+		} else {
+			if !reflect.DeepEqual(*defaultPool.Cores, tc.expectedDefaultCores) {
+				t.Errorf("%s - Failed: Expected Default pool cores to be %v, got %v", tc.testCase, tc.expectedDefaultCores, *defaultPool.Cores)
+			}
+			if defaultPool.PowerProfile != nil {
+				t.Errorf("%s - Failed: Expected Default pool to have no PowerProfile, got %v", tc.testCase, *defaultPool.PowerProfile)
+			}
+		}
+
+		releasePool, ok := putPools["Release"]
+		if tc.expectedReleaseCores == nil {
+			if ok {
+				t.Errorf("%s - Failed: Expected no Release pool, got cores %v", tc.testCase, *releasePool.Cores)
+			}
+			continue
+		}
+		if !ok {
+			t.Fatal(fmt.Sprintf("%s - Failed: Expected Release pool to be written with the freed cores", tc.testCase))
+		}
+
+		if !reflect.DeepEqual(*releasePool.Cores, tc.expectedReleaseCores) {
+			t.Errorf("%s - Failed: Expected Release pool cores to be %v, got %v", tc.testCase, tc.expectedReleaseCores, *releasePool.Cores)
+		}
+
+		if !reflect.DeepEqual(releasePool.PowerProfile, tc.expectedReleaseProfile) {
+			t.Errorf("%s - Failed: Expected Release pool PowerProfile to be %v, got %v", tc.testCase, tc.expectedReleaseProfile, releasePool.PowerProfile)
+		}
+	}
+}
+
+func TestReleasedCoresReclaimedByWorkload(t *testing.T) {
+	t.Setenv("NODE_NAME", "example-node1")
+	AppQoSClientAddress = "http://127.0.0.1:5000"
+
+	appqosPools := []appqos.Pool{
+		{
+			Name:  stringPtr("Default"),
+			ID:    intPtr(1),
+			Cores: &[]int{6, 7},
+		},
+		{
+			Name:         stringPtr("performance-example-node1-workload"),
+			ID:           intPtr(2),
+			Cores:        &[]int{2, 3},
+			PowerProfile: intPtr(1),
+		},
+		{
+			Name:         stringPtr("Release"),
+			ID:           intPtr(3),
+			Cores:        &[]int{4, 5},
+			PowerProfile: intPtr(2),
+		},
+	}
+	appqosPowerProfiles := []appqos.PowerProfile{
+		{
+			Name: stringPtr("performance-example-node1"),
+			ID:   intPtr(1),
+		},
+		{
+			Name: stringPtr("powersave"),
+			ID:   intPtr(2),
+		},
+	}
+
+	workload := &powerv1alpha1.PowerWorkload{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "performance-example-node1-workload",
+			Namespace: PowerWorkloadNamespace,
+		},
+		Spec: powerv1alpha1.PowerWorkloadSpec{
+			Name: "performance-example-node1-workload",
+			Node: powerv1alpha1.NodeInfo{
+				Name:   "example-node1",
+				CpuIds: []int{2, 3},
+			},
+			PowerProfile: "performance-example-node1",
+		},
+		Status: powerv1alpha1.PowerWorkloadStatus{
+			AppliedCpuIds: []int{2, 3},
+		},
+	}
+
+	r, err := createPowerWorkloadReconcilerObject([]runtime.Object{workload})
+	if err != nil {
+		t.Error(err)
+		t.Fatal("error creating reconciler object")
+	}
+	r.DefaultReleaseProfile = "powersave"
+
+	// Apply every Pool written so the second reconcile sees the result of the first
+	var releasePoolWrites []string
+	listener, err := net.Listen("tcp", "127.0.0.1:5000")
+	if err != nil {
+		t.Fatal(fmt.Sprintf("error creating Listener: %v", err))
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/pools/", (func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			for i := range appqosPools {
+				if r.URL.Path == fmt.Sprintf("/pools/%d", *appqosPools[i].ID) {
+					if *appqosPools[i].Name == "Release" {
+						releasePoolWrites = append(releasePoolWrites, "deleted")
+					}
+					appqosPools = append(appqosPools[:i], appqosPools[i+1:]...)
+					break
+				}
+			}
+			return
+		}
+		p := appqos.Pool{}
+		_ = json.NewDecoder(r.Body).Decode(&p)
+		for i := range appqosPools {
+			if *appqosPools[i].Name == *p.Name {
+				appqosPools[i].Cores = p.Cores
+				appqosPools[i].PowerProfile = p.PowerProfile
+			}
+		}
+		if *p.Name == "Release" {
+			releasePoolWrites = append(releasePoolWrites, fmt.Sprint(*p.Cores))
+		}
+	}))
+	mux.HandleFunc("/pools", (func(w http.ResponseWriter, r *http.Request) {
+		b, err := json.Marshal(appqosPools)
+		if err == nil {
+			fmt.Fprintln(w, string(b[:]))
+		}
+	}))
+	mux.HandleFunc("/power_profiles", (func(w http.ResponseWriter, r *http.Request) {
+		b, err := json.Marshal(appqosPowerProfiles)
+		if err == nil {
+			fmt.Fprintln(w, string(b[:]))
+		}
+	}))
+	server := httptest.NewUnstartedServer(mux)
+	server.Listener.Close()
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	req := reconcile.Request{
+		NamespacedName: client.ObjectKey{
+			Name:      "performance-example-node1-workload",
+			Namespace: PowerWorkloadNamespace,
+		},
+	}
+
+	tcases := []struct {
+		testCase              string
+		cpuIds                []int
+		expectedReleaseWrites []string
+	}{
+		{
+			testCase:              "Test Case 1 - claimed core taken out of the Release pool",
+			cpuIds:                []int{2, 3, 4},
+			expectedReleaseWrites: []string{"[5]"},
+		},
+		{
+			testCase:              "Test Case 2 - Release pool deleted once its last core is claimed",
+			cpuIds:                []int{2, 3, 4, 5},
+			expectedReleaseWrites: []string{"deleted"},
+		},
+	}
+
+	for _, tc := range tcases {
+		currentWorkload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), req.NamespacedName, currentWorkload)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload object", tc.testCase))
+		}
+		currentWorkload.Spec.Node.CpuIds = tc.cpuIds
+		err = r.Client.Update(context.TODO(), currentWorkload)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error updating PowerWorkload object", tc.testCase))
+		}
+
+		releasePoolWrites = nil
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling PowerWorkload object", tc.testCase))
+		}
+
+		if !reflect.DeepEqual(releasePoolWrites, tc.expectedReleaseWrites) {
+			t.Errorf("%s - Failed: Expected Release pool writes to be %v, got %v", tc.testCase, tc.expectedReleaseWrites, releasePoolWrites)
+		}
+	}
+}
+
+func TestSharedPoolRecomputedOnAllocationChange(t *testing.T) {
+	t.Setenv("NODE_NAME", "example-node1")
+	AppQoSClientAddress = "http://127.0.0.1:5000"
+
+	appqosPools := []appqos.Pool{
+		{
+			Name:         stringPtr("Shared"),
+			ID:           intPtr(1),
+			Cores:        &[]int{4, 5, 6, 7},
+			PowerProfile: intPtr(3),
+		},
+		{
+			Name:         stringPtr("performance-example-node1-workload"),
+			ID:           intPtr(2),
+			Cores:        &[]int{2, 3},
+			PowerProfile: intPtr(1),
+		},
+	}
+	appqosPowerProfiles := []appqos.PowerProfile{
+		{
+			Name: stringPtr("performance-example-node1"),
+			ID:   intPtr(1),
+		},
+		{
+			Name: stringPtr("shared-example-node1"),
+			ID:   intPtr(3),
+		},
+	}
+
+	workload := &powerv1alpha1.PowerWorkload{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "performance-example-node1-workload",
+			Namespace: PowerWorkloadNamespace,
+		},
+		Spec: powerv1alpha1.PowerWorkloadSpec{
+			Name: "performance-example-node1-workload",
+			Node: powerv1alpha1.NodeInfo{
+				Name:   "example-node1",
+				CpuIds: []int{2, 3, 4},
+			},
+			PowerProfile: "performance-example-node1",
+		},
+		Status: powerv1alpha1.PowerWorkloadStatus{
+			AppliedCpuIds: []int{2, 3},
+		},
+	}
+	sharedWorkload := &powerv1alpha1.PowerWorkload{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "shared-example-node1-workload",
+			Namespace: PowerWorkloadNamespace,
+		},
+		Spec: powerv1alpha1.PowerWorkloadSpec{
+			Name:         "shared-example-node1-workload",
+			AllCores:     true,
+			PowerProfile: "shared-example-node1",
+		},
+		Status: powerv1alpha1.PowerWorkloadStatus{
+			SharedCores: []int{4, 5, 6, 7},
+			Node:        "example-node1",
+		},
+	}
+
+	r, err := createPowerWorkloadReconcilerObject([]runtime.Object{workload, sharedWorkload})
+	if err != nil {
+		t.Error(err)
+		t.Fatal("error creating reconciler object")
+	}
+
+	// Apply every Pool written so the second reconcile sees the result of the first
+	var sharedPoolWrites []appqos.Pool
+	listener, err := net.Listen("tcp", "127.0.0.1:5000")
+	if err != nil {
+		t.Fatal(fmt.Sprintf("error creating Listener: %v", err))
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/pools/", (func(w http.ResponseWriter, r *http.Request) {
+		p := appqos.Pool{}
+		_ = json.NewDecoder(r.Body).Decode(&p)
+		for i := range appqosPools {
+			if *appqosPools[i].Name == *p.Name {
+				appqosPools[i].Cores = p.Cores
+				appqosPools[i].PowerProfile = p.PowerProfile
+			}
+		}
+		if *p.Name == "Shared" {
+			sharedPoolWrites = append(sharedPoolWrites, p)
+		}
+	}))
+	mux.HandleFunc("/pools", (func(w http.ResponseWriter, r *http.Request) {
+		b, err := json.Marshal(appqosPools)
+		if err == nil {
+			fmt.Fprintln(w, string(b[:]))
+		}
+	}))
+	mux.HandleFunc("/power_profiles", (func(w http.ResponseWriter, r *http.Request) {
+		b, err := json.Marshal(appqosPowerProfiles)
+		if err == nil {
+			fmt.Fprintln(w, string(b[:]))
+		}
+	}))
+	server := httptest.NewUnstartedServer(mux)
+	server.Listener.Close()
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	req := reconcile.Request{
+		NamespacedName: client.ObjectKey{
+			Name:      "performance-example-node1-workload",
+			Namespace: PowerWorkloadNamespace,
+		},
+	}
+
+	tcases := []struct {
+		testCase            string
+		cpuIds              []int
+		expectedSharedCores []int
+	}{
+		{
+			testCase:            "Test Case 1",
+			cpuIds:              []int{2, 3, 4},
+			expectedSharedCores: []int{5, 6, 7},
+		},
+		{
+			testCase:            "Test Case 2",
+			cpuIds:              []int{2},
+			expectedSharedCores: []int{3, 4, 5, 6, 7},
+		},
+	}
+
+	for _, tc := range tcases {
+		currentWorkload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), req.NamespacedName, currentWorkload)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload object", tc.testCase))
+		}
+		currentWorkload.Spec.Node.CpuIds = tc.cpuIds
+		err = r.Client.Update(context.TODO(), currentWorkload)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error updating PowerWorkload object", tc.testCase))
+		}
+
+		sharedPoolWrites = nil
+		_, err = r.Reconcile(req)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling PowerWorkload object", tc.testCase))
+		}
+
+		if len(sharedPoolWrites) != 1 {
+			t.Fatal(fmt.Sprintf("%s - Failed: Expected Shared pool to be written once, got %v", tc.testCase, len(sharedPoolWrites)))
+		}
+
+		if !reflect.DeepEqual(*sharedPoolWrites[0].Cores, tc.expectedSharedCores) {
+			t.Errorf("%s - Failed: Expected Shared pool cores to be %v, got %v", tc.testCase, tc.expectedSharedCores, *sharedPoolWrites[0].Cores)
+		}
+
+		if sharedPoolWrites[0].PowerProfile == nil || *sharedPoolWrites[0].PowerProfile != 3 {
+			t.Errorf("%s - Failed: Expected Shared pool PowerProfile to be re-applied as %v, got %v", tc.testCase, 3, sharedPoolWrites[0].PowerProfile)
+		}
+
+		updatedSharedWorkload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{
+			Name:      "shared-example-node1-workload",
+			Namespace: PowerWorkloadNamespace,
+		}, updatedSharedWorkload)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving Shared PowerWorkload object", tc.testCase))
+		}
+
+		if !reflect.DeepEqual(updatedSharedWorkload.Status.SharedCores, tc.expectedSharedCores) {
+			t.Errorf("%s - Failed: Expected Shared PowerWorkload SharedCores to be %v, got %v", tc.testCase, tc.expectedSharedCores, updatedSharedWorkload.Status.SharedCores)
+		}
+	}
+}
+
+func TestScopeModes(t *testing.T) {
+	tcases := []struct {
+		testCase                  string
+		scopeMode                 ScopeMode
+		sharedMaxFreq             int
+		expectedProfiles          map[string]int
+		expectedProfilesAfterFree map[string]int
+	}{
+		{
+			testCase:      "Test Case 1",
+			scopeMode:     ScopeCore,
+			sharedMaxFreq: 1500,
+			expectedProfiles: map[string]int{
+				"performance-example-node1-workload":         1,
+				"balance-performance-example-node1-workload": 2,
+				"balance-power-example-node1-workload":       3,
+			},
+			expectedProfilesAfterFree: map[string]int{
+				"balance-performance-example-node1-workload": 2,
+				"balance-power-example-node1-workload":       3,
+			},
+		},
+		{
+			testCase:      "Test Case 2",
+			scopeMode:     ScopePackage,
+			sharedMaxFreq: 1500,
+			expectedProfiles: map[string]int{
+				"performance-example-node1-workload":         1,
+				"balance-performance-example-node1-workload": 1,
+				"balance-power-example-node1-workload":       3,
+			},
+			expectedProfilesAfterFree: map[string]int{
+				"balance-performance-example-node1-workload": 2,
+				"balance-power-example-node1-workload":       3,
+			},
+		},
+		{
+			testCase:      "Test Case 3 - Shared pool outranks a PowerWorkload on its package",
+			scopeMode:     ScopePackage,
+			sharedMaxFreq: 3000,
+			expectedProfiles: map[string]int{
+				"performance-example-node1-workload":         1,
+				"balance-performance-example-node1-workload": 1,
+				"balance-power-example-node1-workload":       3,
+			},
+			expectedProfilesAfterFree: map[string]int{
+				"balance-performance-example-node1-workload": 4,
+				"balance-power-example-node1-workload":       3,
+			},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		// CPUs 0-3 are on package 0 and CPUs 4-7 on package 1
+		cpuDevicesPath := t.TempDir()
+		for cpu := 0; cpu < 8; cpu++ {
+			topologyPath := filepath.Join(cpuDevicesPath, fmt.Sprintf("cpu%d", cpu), "topology")
+			err := os.MkdirAll(topologyPath, 0755)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = ioutil.WriteFile(filepath.Join(topologyPath, "physical_package_id"), []byte(strconv.Itoa(cpu/4)), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		cpuhotplug.CPUDevicesPath = cpuDevicesPath
+
+		appqosPools := []appqos.Pool{
+			{
+				Name:         stringPtr("Shared"),
+				ID:           intPtr(1),
+				Cores:        &[]int{0, 1, 4, 6, 7},
+				PowerProfile: intPtr(4),
+			},
+			{
+				Name:         stringPtr("performance-example-node1-workload"),
+				ID:           intPtr(2),
+				Cores:        &[]int{2, 3},
+				PowerProfile: intPtr(1),
+			},
+			{
+				Name:         stringPtr("balance-power-example-node1-workload"),
+				ID:           intPtr(3),
+				Cores:        &[]int{5},
+				PowerProfile: intPtr(3),
+			},
+		}
+		appqosPowerProfiles := []appqos.PowerProfile{
+			{
+				Name:    stringPtr("performance-example-node1"),
+				ID:      intPtr(1),
+				MaxFreq: intPtr(3600),
+			},
+			{
+				Name:    stringPtr("balance-performance-example-node1"),
+				ID:      intPtr(2),
+				MaxFreq: intPtr(2800),
+			},
+			{
+				Name:    stringPtr("balance-power-example-node1"),
+				ID:      intPtr(3),
+				MaxFreq: intPtr(2000),
+			},
+			{
+				Name:    stringPtr("shared-example-node1"),
+				ID:      intPtr(4),
+				MaxFreq: intPtr(tc.sharedMaxFreq),
+			},
+		}
+
+		workloads := []runtime.Object{}
+		for _, workload := range []struct {
+			profile string
+			cpuIds  []int
+		}{
+			{"performance-example-node1", []int{2, 3}},
+			{"balance-performance-example-node1", []int{1}},
+			{"balance-power-example-node1", []int{5}},
+		} {
+			workloads = append(workloads, &powerv1alpha1.PowerWorkload{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      workload.profile + WorkloadNameSuffix,
+					Namespace: PowerWorkloadNamespace,
+				},
+				Spec: powerv1alpha1.PowerWorkloadSpec{
+					Name: workload.profile + WorkloadNameSuffix,
+					Node: powerv1alpha1.NodeInfo{
+						Name:   "example-node1",
+						CpuIds: workload.cpuIds,
+					},
+					PowerProfile: workload.profile,
+				},
+			})
+		}
+
+		r, err := createPowerWorkloadReconcilerObject(workloads)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		r.ScopeMode = tc.scopeMode
+
+		listener, err := net.Listen("tcp", "127.0.0.1:5000")
+		if err != nil {
+			t.Fatal(fmt.Sprintf("%s - error creating Listener: %v", tc.testCase, err))
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/pools/", (func(w http.ResponseWriter, r *http.Request) {
+			path := strings.Split(r.URL.Path, "/")
+			id, _ := strconv.Atoi(path[len(path)-1])
+			if r.Method == "DELETE" {
+				for i := range appqosPools {
+					if *appqosPools[i].ID == id {
+						appqosPools = append(appqosPools[:i], appqosPools[i+1:]...)
+						break
+					}
+				}
+				return
+			}
+
+			p := appqos.Pool{}
+			_ = json.NewDecoder(r.Body).Decode(&p)
+			for i := range appqosPools {
+				if *appqosPools[i].ID == id {
+					appqosPools[i].Cores = p.Cores
+					appqosPools[i].PowerProfile = p.PowerProfile
+				}
+			}
+		}))
+		mux.HandleFunc("/pools", (func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "POST" {
+				p := appqos.Pool{}
+				_ = json.NewDecoder(r.Body).Decode(&p)
+				p.ID = intPtr(len(appqosPools) + 10)
+				appqosPools = append(appqosPools, p)
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprintln(w, "\"okay\"")
+				return
+			}
+
+			b, err := json.Marshal(appqosPools)
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+		mux.HandleFunc("/power_profiles", (func(w http.ResponseWriter, r *http.Request) {
+			b, err := json.Marshal(appqosPowerProfiles)
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+		server := httptest.NewUnstartedServer(mux)
+		server.Listener.Close()
+		server.Listener = listener
+		server.Start()
+
+		poolProfiles := func() map[string]int {
+			profiles := make(map[string]int)
+			for _, pool := range appqosPools {
+				if *pool.Name != "Shared" && pool.PowerProfile != nil {
+					profiles[*pool.Name] = *pool.PowerProfile
+				}
+			}
+			return profiles
+		}
+
+		_, err = r.Reconcile(reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      "balance-performance-example-node1-workload",
+				Namespace: PowerWorkloadNamespace,
+			},
+		})
+		if err != nil {
+			server.Close()
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling PowerWorkload object", tc.testCase))
+		}
+
+		if !reflect.DeepEqual(poolProfiles(), tc.expectedProfiles) {
+			t.Errorf("%s - Failed: Expected Pool PowerProfiles to be %v, got %v", tc.testCase, tc.expectedProfiles, poolProfiles())
+		}
+
+		// Freeing the package's highest PowerProfile lets the remaining PowerWorkloads fall back to their own
+		err = r.Client.Delete(context.TODO(), workloads[0].(*powerv1alpha1.PowerWorkload))
+		if err != nil {
+			server.Close()
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error deleting PowerWorkload object", tc.testCase))
+		}
+
+		_, err = r.Reconcile(reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      "performance-example-node1-workload",
+				Namespace: PowerWorkloadNamespace,
+			},
+		})
+		server.Close()
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling deleted PowerWorkload object", tc.testCase))
+		}
+
+		if !reflect.DeepEqual(poolProfiles(), tc.expectedProfilesAfterFree) {
+			t.Errorf("%s - Failed: Expected Pool PowerProfiles after deletion to be %v, got %v", tc.testCase, tc.expectedProfilesAfterFree, poolProfiles())
+		}
+	}
+}
+
+func TestExternalDriftCondition(t *testing.T) {
+	tcases := []struct {
+		testCase                     string
+		poolCores                    []int
+		expectedConditionAfterDrift  *metav1.ConditionStatus
+		expectedConditionAfterRepair *metav1.ConditionStatus
+	}{
+		{
+			testCase:                     "Test Case 1 - Pool changed in AppQoS",
+			poolCores:                    []int{2},
+			expectedConditionAfterDrift:  conditionStatusPtr(metav1.ConditionTrue),
+			expectedConditionAfterRepair: conditionStatusPtr(metav1.ConditionFalse),
+		},
+		{
+			testCase:                     "Test Case 2 - Pool unchanged in AppQoS",
+			poolCores:                    []int{2, 3},
+			expectedConditionAfterDrift:  nil,
+			expectedConditionAfterRepair: nil,
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		appqosPools := []appqos.Pool{
+			{
+				Name:  stringPtr("Default"),
+				ID:    intPtr(1),
+				Cores: &[]int{3, 4, 5, 6, 7},
+			},
+			{
+				Name:         stringPtr("performance-example-node1-workload"),
+				ID:           intPtr(2),
+				Cores:        &tc.poolCores,
+				PowerProfile: intPtr(1),
+			},
+		}
+		appqosPowerProfiles := []appqos.PowerProfile{
+			{
+				Name: stringPtr("performance-example-node1"),
+				ID:   intPtr(1),
+			},
+		}
+
+		workload := &powerv1alpha1.PowerWorkload{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "performance-example-node1-workload",
+				Namespace:  PowerWorkloadNamespace,
+				Generation: 2,
+			},
+			Spec: powerv1alpha1.PowerWorkloadSpec{
+				Name: "performance-example-node1-workload",
+				Node: powerv1alpha1.NodeInfo{
+					Name:   "example-node1",
+					CpuIds: []int{2, 3},
+				},
+				PowerProfile: "performance-example-node1",
+			},
+			Status: powerv1alpha1.PowerWorkloadStatus{
+				AppliedCpuIds:      []int{2, 3},
+				ObservedGeneration: 2,
+			},
+		}
+
+		r, err := createPowerWorkloadReconcilerObject([]runtime.Object{workload})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		// AppQoS fails the first re-apply, so the drift is still outstanding after the first reconcile
+		profilesAvailable := false
+		listener, err := net.Listen("tcp", "127.0.0.1:5000")
+		if err != nil {
+			t.Fatal(fmt.Sprintf("%s - error creating Listener: %v", tc.testCase, err))
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/pools/", (func(w http.ResponseWriter, r *http.Request) {
+			p := appqos.Pool{}
+			_ = json.NewDecoder(r.Body).Decode(&p)
+			for i := range appqosPools {
+				if *appqosPools[i].Name == *p.Name {
+					appqosPools[i].Cores = p.Cores
+				}
+			}
+		}))
+		mux.HandleFunc("/pools", (func(w http.ResponseWriter, r *http.Request) {
+			b, err := json.Marshal(appqosPools)
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+		mux.HandleFunc("/power_profiles", (func(w http.ResponseWriter, r *http.Request) {
+			if !profilesAvailable {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			b, err := json.Marshal(appqosPowerProfiles)
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+		server := httptest.NewUnstartedServer(mux)
+		server.Listener.Close()
+		server.Listener = listener
+		server.Start()
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      "performance-example-node1-workload",
+				Namespace: PowerWorkloadNamespace,
+			},
+		}
+
+		_, _ = r.Reconcile(req)
+
+		afterDrift := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), req.NamespacedName, afterDrift)
+		if err != nil {
+			server.Close()
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload object", tc.testCase))
+		}
+
+		profilesAvailable = true
+		_, err = r.Reconcile(req)
+		server.Close()
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling PowerWorkload object", tc.testCase))
+		}
+
+		afterRepair := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), req.NamespacedName, afterRepair)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload object", tc.testCase))
+		}
+
+		checks := []struct {
+			stage    string
+			workload *powerv1alpha1.PowerWorkload
+			expected *metav1.ConditionStatus
+		}{
+			{"after drift", afterDrift, tc.expectedConditionAfterDrift},
+			{"after repair", afterRepair, tc.expectedConditionAfterRepair},
+		}
+		for _, check := range checks {
+			condition := meta.FindStatusCondition(check.workload.Status.Conditions, ExternalDriftCondition)
+			if check.expected == nil {
+				if condition != nil {
+					t.Errorf("%s - Failed: Expected no %s condition %s, got %v", tc.testCase, ExternalDriftCondition, check.stage, condition.Status)
+				}
+				continue
+			}
+			if condition == nil {
+				t.Errorf("%s - Failed: Expected %s condition %s to be %v, got none", tc.testCase, ExternalDriftCondition, check.stage, *check.expected)
+				continue
+			}
+			if condition.Status != *check.expected {
+				t.Errorf("%s - Failed: Expected %s condition %s to be %v, got %v", tc.testCase, ExternalDriftCondition, check.stage, *check.expected, condition.Status)
+			}
+		}
+	}
+}
+
+func conditionStatusPtr(status metav1.ConditionStatus) *metav1.ConditionStatus {
+	return &status
+}
+
+func TestPackageScopePoolBatching(t *testing.T) {
+	tcases := []struct {
+		testCase             string
+		capabilities         []string
+		expectedBatches      [][]string
+		expectedWorkloadPuts int
+		expectedPoolProfiles map[string]int
+	}{
+		{
+			testCase:             "Test Case 1 - AppQoS supports batches",
+			capabilities:         []string{"power", "batch"},
+			expectedBatches:      [][]string{{"balance-performance-example-node1-workload", "balance-power-example-node1-workload"}},
+			expectedWorkloadPuts: 0,
+			expectedPoolProfiles: map[string]int{
+				"performance-example-node1-workload":         1,
+				"balance-performance-example-node1-workload": 1,
+				"balance-power-example-node1-workload":       1,
+			},
+		},
+		{
+			testCase:             "Test Case 2 - AppQoS does not support batches",
+			capabilities:         []string{"power"},
+			expectedBatches:      [][]string{},
+			expectedWorkloadPuts: 2,
+			expectedPoolProfiles: map[string]int{
+				"performance-example-node1-workload":         1,
+				"balance-performance-example-node1-workload": 1,
+				"balance-power-example-node1-workload":       1,
+			},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		// CPUs 0-3 are on package 0 and CPUs 4-7 on package 1
+		cpuDevicesPath := t.TempDir()
+		for cpu := 0; cpu < 8; cpu++ {
+			topologyPath := filepath.Join(cpuDevicesPath, fmt.Sprintf("cpu%d", cpu), "topology")
+			err := os.MkdirAll(topologyPath, 0755)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = ioutil.WriteFile(filepath.Join(topologyPath, "physical_package_id"), []byte(strconv.Itoa(cpu/4)), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		cpuhotplug.CPUDevicesPath = cpuDevicesPath
+
+		appqosPools := []appqos.Pool{
+			{
+				Name:         stringPtr("Shared"),
+				ID:           intPtr(1),
+				Cores:        &[]int{2, 3, 4, 5, 6, 7},
+				PowerProfile: intPtr(4),
+			},
+			{
+				Name:         stringPtr("balance-performance-example-node1-workload"),
+				ID:           intPtr(2),
+				Cores:        &[]int{1},
+				PowerProfile: intPtr(2),
+			},
+			{
+				Name:         stringPtr("balance-power-example-node1-workload"),
+				ID:           intPtr(3),
+				Cores:        &[]int{0},
+				PowerProfile: intPtr(3),
+			},
+		}
+		appqosPowerProfiles := []appqos.PowerProfile{
+			{
+				Name:    stringPtr("performance-example-node1"),
+				ID:      intPtr(1),
+				MaxFreq: intPtr(3600),
+			},
+			{
+				Name:    stringPtr("balance-performance-example-node1"),
+				ID:      intPtr(2),
+				MaxFreq: intPtr(2800),
+			},
+			{
+				Name:    stringPtr("balance-power-example-node1"),
+				ID:      intPtr(3),
+				MaxFreq: intPtr(2000),
+			},
+			{
+				Name:    stringPtr("shared-example-node1"),
+				ID:      intPtr(4),
+				MaxFreq: intPtr(1500),
+			},
+		}
+
+		workloads := []runtime.Object{}
+		for _, workload := range []struct {
+			profile string
+			cpuIds  []int
+		}{
+			{"performance-example-node1", []int{2, 3}},
+			{"balance-performance-example-node1", []int{1}},
+			{"balance-power-example-node1", []int{0}},
+		} {
+			workloads = append(workloads, &powerv1alpha1.PowerWorkload{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      workload.profile + WorkloadNameSuffix,
+					Namespace: PowerWorkloadNamespace,
+				},
+				Spec: powerv1alpha1.PowerWorkloadSpec{
+					Name: workload.profile + WorkloadNameSuffix,
+					Node: powerv1alpha1.NodeInfo{
+						Name:   "example-node1",
+						CpuIds: workload.cpuIds,
+					},
+					PowerProfile: workload.profile,
+				},
+			})
+		}
+
+		r, err := createPowerWorkloadReconcilerObject(workloads)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		r.ScopeMode = ScopePackage
+
+		batches := [][]string{}
+		workloadPuts := 0
+		updatePool := func(p appqos.Pool, id int) {
+			for i := range appqosPools {
+				if *appqosPools[i].ID == id {
+					appqosPools[i].Cores = p.Cores
+					appqosPools[i].PowerProfile = p.PowerProfile
+				}
+			}
+		}
+
+		listener, err := net.Listen("tcp", "127.0.0.1:5000")
+		if err != nil {
+			t.Fatal(fmt.Sprintf("%s - error creating Listener: %v", tc.testCase, err))
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/pools/", (func(w http.ResponseWriter, r *http.Request) {
+			path := strings.Split(r.URL.Path, "/")
+			id, _ := strconv.Atoi(path[len(path)-1])
+			p := appqos.Pool{}
+			_ = json.NewDecoder(r.Body).Decode(&p)
+			if id != 1 {
+				workloadPuts++
+			}
+			updatePool(p, id)
+		}))
+		mux.HandleFunc("/pools", (func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "POST" {
+				p := appqos.Pool{}
+				_ = json.NewDecoder(r.Body).Decode(&p)
+				p.ID = intPtr(len(appqosPools) + 10)
+				appqosPools = append(appqosPools, p)
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprintln(w, "\"okay\"")
+				return
+			}
+
+			b, err := json.Marshal(appqosPools)
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+		mux.HandleFunc("/batch", (func(w http.ResponseWriter, r *http.Request) {
+			batch := appqos.Batch{}
+			_ = json.NewDecoder(r.Body).Decode(&batch)
+			names := []string{}
+			for _, p := range *batch.Pools {
+				names = append(names, *p.Name)
+				updatePool(p, *p.ID)
+			}
+			batches = append(batches, names)
+		}))
+		mux.HandleFunc("/caps", (func(w http.ResponseWriter, r *http.Request) {
+			b, err := json.Marshal(appqos.Capabilities{Capabilities: &tc.capabilities})
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+		mux.HandleFunc("/power_profiles", (func(w http.ResponseWriter, r *http.Request) {
+			b, err := json.Marshal(appqosPowerProfiles)
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+		server := httptest.NewUnstartedServer(mux)
+		server.Listener.Close()
+		server.Listener = listener
+		server.Start()
+
+		_, err = r.Reconcile(reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      "performance-example-node1-workload",
+				Namespace: PowerWorkloadNamespace,
+			},
+		})
+		server.Close()
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling PowerWorkload object", tc.testCase))
+		}
+
+		if !reflect.DeepEqual(batches, tc.expectedBatches) {
+			t.Errorf("%s - Failed: Expected AppQoS batches to be %v, got %v", tc.testCase, tc.expectedBatches, batches)
+		}
+
+		if workloadPuts != tc.expectedWorkloadPuts {
+			t.Errorf("%s - Failed: Expected number of individual PowerWorkload Pool updates to be %v, got %v", tc.testCase, tc.expectedWorkloadPuts, workloadPuts)
+		}
+
+		poolProfiles := make(map[string]int)
+		for _, pool := range appqosPools {
+			if *pool.Name != "Shared" && pool.PowerProfile != nil {
+				poolProfiles[*pool.Name] = *pool.PowerProfile
+			}
+		}
+		if !reflect.DeepEqual(poolProfiles, tc.expectedPoolProfiles) {
+			t.Errorf("%s - Failed: Expected Pool PowerProfiles to be %v, got %v", tc.testCase, tc.expectedPoolProfiles, poolProfiles)
+		}
+	}
+}
+
+func TestWorkloadUpdatePoolBatching(t *testing.T) {
+	tcases := []struct {
+		testCase              string
+		capabilities          []string
+		expectedBatches       [][]string
+		expectedPuts          int
+		expectedSharedCores   []int
+		expectedWorkloadCores []int
+	}{
+		{
+			testCase:              "Test Case 1 - AppQoS supports batches",
+			capabilities:          []string{"power", "batch"},
+			expectedBatches:       [][]string{{"Shared", "performance-example-node1-workload", "Shared"}},
+			expectedPuts:          0,
+			expectedSharedCores:   []int{1, 4, 5},
+			expectedWorkloadCores: []int{2, 3},
+		},
+		{
+			testCase:              "Test Case 2 - AppQoS does not support batches",
+			capabilities:          []string{"power"},
+			expectedBatches:       [][]string{},
+			expectedPuts:          3,
+			expectedSharedCores:   []int{1, 4, 5},
+			expectedWorkloadCores: []int{2, 3},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		appqosPools := []appqos.Pool{
+			{
+				Name:         stringPtr("Shared"),
+				ID:           intPtr(1),
+				Cores:        &[]int{3, 4, 5},
+				PowerProfile: intPtr(2),
+			},
+			{
+				Name:         stringPtr("performance-example-node1-workload"),
+				ID:           intPtr(2),
+				Cores:        &[]int{1, 2},
+				PowerProfile: intPtr(1),
+			},
+		}
+		appqosPowerProfiles := []appqos.PowerProfile{
+			{
+				Name:    stringPtr("performance-example-node1"),
+				ID:      intPtr(1),
+				MaxFreq: intPtr(3600),
+			},
+			{
+				Name:    stringPtr("shared-example-node1"),
+				ID:      intPtr(2),
+				MaxFreq: intPtr(1500),
+			},
+		}
+
+		workload := &powerv1alpha1.PowerWorkload{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1-workload",
+				Namespace: PowerWorkloadNamespace,
+			},
+			Spec: powerv1alpha1.PowerWorkloadSpec{
+				Name: "performance-example-node1-workload",
+				Node: powerv1alpha1.NodeInfo{
+					Name:   "example-node1",
+					CpuIds: []int{2, 3},
+				},
+				PowerProfile: "performance-example-node1",
+			},
+		}
+
+		r, err := createPowerWorkloadReconcilerObject([]runtime.Object{workload})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		batches := [][]string{}
+		puts := 0
+		updatePool := func(p appqos.Pool, id int) {
+			for i := range appqosPools {
+				if *appqosPools[i].ID == id {
+					appqosPools[i].Cores = p.Cores
+					appqosPools[i].PowerProfile = p.PowerProfile
+				}
+			}
+		}
+
+		listener, err := net.Listen("tcp", "127.0.0.1:5000")
+		if err != nil {
+			t.Fatal(fmt.Sprintf("%s - error creating Listener: %v", tc.testCase, err))
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/pools/", (func(w http.ResponseWriter, r *http.Request) {
+			path := strings.Split(r.URL.Path, "/")
+			id, _ := strconv.Atoi(path[len(path)-1])
+			if r.Method == "PUT" {
+				p := appqos.Pool{}
+				_ = json.NewDecoder(r.Body).Decode(&p)
+				puts++
+				updatePool(p, id)
+				return
+			}
+
+			for _, pool := range appqosPools {
+				if *pool.ID == id {
+					b, err := json.Marshal(pool)
+					if err == nil {
+						fmt.Fprintln(w, string(b[:]))
+					}
+				}
+			}
+		}))
+		mux.HandleFunc("/pools", (func(w http.ResponseWriter, r *http.Request) {
+			b, err := json.Marshal(appqosPools)
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+		mux.HandleFunc("/batch", (func(w http.ResponseWriter, r *http.Request) {
+			batch := appqos.Batch{}
+			_ = json.NewDecoder(r.Body).Decode(&batch)
+			names := []string{}
+			for _, p := range *batch.Pools {
+				names = append(names, *p.Name)
+				updatePool(p, *p.ID)
+			}
+			batches = append(batches, names)
+		}))
+		mux.HandleFunc("/caps", (func(w http.ResponseWriter, r *http.Request) {
+			b, err := json.Marshal(appqos.Capabilities{Capabilities: &tc.capabilities})
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+		mux.HandleFunc("/power_profiles", (func(w http.ResponseWriter, r *http.Request) {
+			b, err := json.Marshal(appqosPowerProfiles)
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+		server := httptest.NewUnstartedServer(mux)
+		server.Listener.Close()
+		server.Listener = listener
+		server.Start()
+
+		_, err = r.Reconcile(reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      "performance-example-node1-workload",
+				Namespace: PowerWorkloadNamespace,
+			},
+		})
+		server.Close()
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling PowerWorkload object", tc.testCase))
+		}
+
+		if !reflect.DeepEqual(batches, tc.expectedBatches) {
+			t.Errorf("%s - Failed: Expected AppQoS batches to be %v, got %v", tc.testCase, tc.expectedBatches, batches)
+		}
+
+		if puts != tc.expectedPuts {
+			t.Errorf("%s - Failed: Expected number of individual Pool updates to be %v, got %v", tc.testCase, tc.expectedPuts, puts)
+		}
+
+		if !reflect.DeepEqual(*appqosPools[0].Cores, tc.expectedSharedCores) {
+			t.Errorf("%s - Failed: Expected Shared pool cores to be %v, got %v", tc.testCase, tc.expectedSharedCores, *appqosPools[0].Cores)
+		}
+
+		if !reflect.DeepEqual(*appqosPools[1].Cores, tc.expectedWorkloadCores) {
+			t.Errorf("%s - Failed: Expected PowerWorkload Pool cores to be %v, got %v", tc.testCase, tc.expectedWorkloadCores, *appqosPools[1].Cores)
+		}
+	}
+}
+
+func TestProfileMaxLifetime(t *testing.T) {
+	tcases := []struct {
+		testCase               string
+		maxLifetime            *metav1.Duration
+		baseline               string
+		appliedAgo             time.Duration
+		expectedPoolProfile    int
+		expectedProfileExpired bool
+		expectRequeue          bool
+	}{
+		{
+			testCase:               "Test Case 1 - Lifetime passed, reverts to the baseline",
+			maxLifetime:            &metav1.Duration{Duration: time.Hour},
+			baseline:               "balance-power",
+			appliedAgo:             2 * time.Hour,
+			expectedPoolProfile:    3,
+			expectedProfileExpired: true,
+			expectRequeue:          false,
+		},
+		{
+			testCase:               "Test Case 2 - Lifetime passed, reverts to the Shared pool's PowerProfile",
+			maxLifetime:            &metav1.Duration{Duration: time.Hour},
+			appliedAgo:             2 * time.Hour,
+			expectedPoolProfile:    4,
+			expectedProfileExpired: true,
+			expectRequeue:          false,
+		},
+		{
+			testCase:               "Test Case 3 - Lifetime not yet passed",
+			maxLifetime:            &metav1.Duration{Duration: time.Hour},
+			baseline:               "balance-power",
+			appliedAgo:             10 * time.Minute,
+			expectedPoolProfile:    1,
+			expectedProfileExpired: false,
+			expectRequeue:          true,
+		},
+		{
+			testCase:               "Test Case 4 - No lifetime",
+			appliedAgo:             2 * time.Hour,
+			expectedPoolProfile:    1,
+			expectedProfileExpired: false,
+			expectRequeue:          false,
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		appqosPools := []appqos.Pool{
+			{
+				Name:         stringPtr("Shared"),
+				ID:           intPtr(1),
+				Cores:        &[]int{4, 5, 6, 7},
+				PowerProfile: intPtr(4),
+			},
+			{
+				Name:         stringPtr("performance-example-node1-workload"),
+				ID:           intPtr(2),
+				Cores:        &[]int{2, 3},
+				PowerProfile: intPtr(1),
+			},
+		}
+		appqosPowerProfiles := []appqos.PowerProfile{
+			{
+				Name: stringPtr("performance-example-node1"),
+				ID:   intPtr(1),
+			},
+			{
+				Name: stringPtr("balance-power-example-node1"),
+				ID:   intPtr(3),
+			},
+			{
+				Name: stringPtr("shared-example-node1"),
+				ID:   intPtr(4),
+			},
+		}
+
+		appliedTime := metav1.NewTime(time.Now().Add(-tc.appliedAgo))
+		workload := &powerv1alpha1.PowerWorkload{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "performance-example-node1-workload",
+				Namespace:  PowerWorkloadNamespace,
+				Generation: 2,
+			},
+			Spec: powerv1alpha1.PowerWorkloadSpec{
+				Name: "performance-example-node1-workload",
+				Node: powerv1alpha1.NodeInfo{
+					Name:   "example-node1",
+					CpuIds: []int{2, 3},
+				},
+				PowerProfile: "performance-example-node1",
+			},
+			Status: powerv1alpha1.PowerWorkloadStatus{
+				AppliedCpuIds:      []int{2, 3},
+				ObservedGeneration: 2,
+				AppliedTime:        &appliedTime,
+			},
+		}
+		profile := &powerv1alpha1.PowerProfile{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "performance-example-node1",
+				Namespace: PowerWorkloadNamespace,
+			},
+			Spec: powerv1alpha1.PowerProfileSpec{
+				Name:        "performance-example-node1",
+				Epp:         "performance",
+				MaxLifetime: tc.maxLifetime,
+				Baseline:    tc.baseline,
+			},
+		}
+
+		r, err := createPowerWorkloadReconcilerObject([]runtime.Object{workload, profile})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		listener, err := net.Listen("tcp", "127.0.0.1:5000")
+		if err != nil {
+			t.Fatal(fmt.Sprintf("%s - error creating Listener: %v", tc.testCase, err))
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/pools/", (func(w http.ResponseWriter, r *http.Request) {
+			path := strings.Split(r.URL.Path, "/")
+			id, _ := strconv.Atoi(path[len(path)-1])
+			p := appqos.Pool{}
+			_ = json.NewDecoder(r.Body).Decode(&p)
+			for i := range appqosPools {
+				if *appqosPools[i].ID == id {
+					appqosPools[i].Cores = p.Cores
+					appqosPools[i].PowerProfile = p.PowerProfile
+				}
+			}
+		}))
+		mux.HandleFunc("/pools", (func(w http.ResponseWriter, r *http.Request) {
+			b, err := json.Marshal(appqosPools)
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+		mux.HandleFunc("/power_profiles/", (func(w http.ResponseWriter, r *http.Request) {
+			path := strings.Split(r.URL.Path, "/")
+			id, _ := strconv.Atoi(path[len(path)-1])
+			for _, profile := range appqosPowerProfiles {
+				if *profile.ID == id {
+					b, err := json.Marshal(profile)
+					if err == nil {
+						fmt.Fprintln(w, string(b[:]))
+					}
+				}
+			}
+		}))
+		mux.HandleFunc("/power_profiles", (func(w http.ResponseWriter, r *http.Request) {
+			b, err := json.Marshal(appqosPowerProfiles)
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+		server := httptest.NewUnstartedServer(mux)
+		server.Listener.Close()
+		server.Listener = listener
+		server.Start()
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      "performance-example-node1-workload",
+				Namespace: PowerWorkloadNamespace,
+			},
+		}
+
+		result, err := r.Reconcile(req)
+		server.Close()
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling PowerWorkload object", tc.testCase))
+		}
+
+		if *appqosPools[1].PowerProfile != tc.expectedPoolProfile {
+			t.Errorf("%s - Failed: Expected Pool PowerProfile to be %v, got %v", tc.testCase, tc.expectedPoolProfile, *appqosPools[1].PowerProfile)
+		}
+
+		if (result.RequeueAfter > 0) != tc.expectRequeue {
+			t.Errorf("%s - Failed: Expected requeue to be %v, got RequeueAfter %v", tc.testCase, tc.expectRequeue, result.RequeueAfter)
+		}
+		if tc.expectRequeue && result.RequeueAfter > tc.maxLifetime.Duration-tc.appliedAgo {
+			t.Errorf("%s - Failed: Expected requeue before the lifetime passes, got RequeueAfter %v", tc.testCase, result.RequeueAfter)
+		}
+
+		updatedWorkload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), req.NamespacedName, updatedWorkload)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload object", tc.testCase))
+		}
+
+		if updatedWorkload.Status.ProfileExpired != tc.expectedProfileExpired {
+			t.Errorf("%s - Failed: Expected ProfileExpired to be %v, got %v", tc.testCase, tc.expectedProfileExpired, updatedWorkload.Status.ProfileExpired)
+		}
+	}
+}
+
+func TestAppQoSRestartReapply(t *testing.T) {
+	tcases := []struct {
+		testCase          string
+		appqosPools       []appqos.Pool
+		expectedLost      bool
+		expectedProfiles  []string
+		expectedWorkloads []string
+		expectedPosted    []string
+	}{
+		{
+			testCase: "Test Case 1 - AppQoS restarted and lost its Pools",
+			appqosPools: []appqos.Pool{
+				{Name: stringPtr("Default"), ID: intPtr(1), Cores: &[]int{0, 1, 2, 3, 4, 5, 6, 7}},
+			},
+			expectedLost:      true,
+			expectedProfiles:  []string{"performance-example-node1"},
+			expectedWorkloads: []string{"performance-example-node1-workload"},
+			expectedPosted:    []string{"performance-example-node1-workload"},
+		},
+		{
+			testCase: "Test Case 2 - AppQoS still holds its Pools",
+			appqosPools: []appqos.Pool{
+				{Name: stringPtr("Default"), ID: intPtr(1), Cores: &[]int{0, 1, 4, 5, 6, 7}},
+				{Name: stringPtr("performance-example-node1-workload"), ID: intPtr(2), Cores: &[]int{2, 3}, PowerProfile: intPtr(1)},
+			},
+			expectedLost: false,
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		appqosPools := tc.appqosPools
+		appqosPowerProfiles := []appqos.PowerProfile{
+			{
+				Name: stringPtr("performance-example-node1"),
+				ID:   intPtr(1),
+			},
+		}
+
+		objs := []runtime.Object{
+			&powerv1alpha1.PowerProfile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "performance-example-node1",
+					Namespace: PowerWorkloadNamespace,
+				},
+				Spec: powerv1alpha1.PowerProfileSpec{
+					Name: "performance-example-node1",
+					Epp:  "performance",
+				},
+			},
+			&powerv1alpha1.PowerWorkload{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "performance-example-node1-workload",
+					Namespace:  PowerWorkloadNamespace,
+					Generation: 1,
+				},
+				Spec: powerv1alpha1.PowerWorkloadSpec{
+					Name: "performance-example-node1-workload",
+					Node: powerv1alpha1.NodeInfo{
+						Name:   "example-node1",
+						CpuIds: []int{2, 3},
+					},
+					PowerProfile: "performance-example-node1",
+				},
+				Status: powerv1alpha1.PowerWorkloadStatus{
+					AppliedCpuIds:      []int{2, 3},
+					ObservedGeneration: 1,
+				},
+			},
+			&powerv1alpha1.PowerWorkload{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "performance-example-node2-workload",
+					Namespace: PowerWorkloadNamespace,
+				},
+				Spec: powerv1alpha1.PowerWorkloadSpec{
+					Name: "performance-example-node2-workload",
+					Node: powerv1alpha1.NodeInfo{
+						Name:   "example-node2",
+						CpuIds: []int{2, 3},
+					},
+					PowerProfile: "performance-example-node2",
+				},
+			},
+		}
+
+		r, err := createPowerWorkloadReconcilerObject(objs)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		posted := make([]string, 0)
+		listener, err := net.Listen("tcp", "127.0.0.1:5000")
+		if err != nil {
+			t.Fatal(fmt.Sprintf("%s - error creating Listener: %v", tc.testCase, err))
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/pools/", (func(w http.ResponseWriter, r *http.Request) {
+			p := appqos.Pool{}
+			_ = json.NewDecoder(r.Body).Decode(&p)
+			for i := range appqosPools {
+				if p.Name != nil && *appqosPools[i].Name == *p.Name {
+					appqosPools[i].Cores = p.Cores
+				}
+			}
+		}))
+		mux.HandleFunc("/pools", (func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "POST" {
+				p := appqos.Pool{}
+				_ = json.NewDecoder(r.Body).Decode(&p)
+				p.ID = intPtr(len(appqosPools) + 1)
+				appqosPools = append(appqosPools, p)
+				posted = append(posted, *p.Name)
+				w.WriteHeader(http.StatusCreated)
+				return
+			}
+			b, err := json.Marshal(appqosPools)
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+		mux.HandleFunc("/power_profiles", (func(w http.ResponseWriter, r *http.Request) {
+			b, err := json.Marshal(appqosPowerProfiles)
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+		server := httptest.NewUnstartedServer(mux)
+		server.Listener.Close()
+		server.Listener = listener
+		server.Start()
+
+		watcher := NewAppQoSRestartWatcher(r.Client, r.AppQoSClient, r.Log, "example-node1", time.Second)
+		watcher.Profiles = make(chan event.GenericEvent, 10)
+		watcher.Workloads = make(chan event.GenericEvent, 10)
+
+		lost, err := watcher.lostState(context.TODO())
+		if err != nil {
+			server.Close()
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error checking AppQoS for lost state", tc.testCase))
+		}
+
+		if lost != tc.expectedLost {
+			t.Errorf("%s - Failed: Expected AppQoS lost state to be %v, got %v", tc.testCase, tc.expectedLost, lost)
+		}
+		if !lost {
+			server.Close()
+			continue
+		}
+
+		err = watcher.reapply(context.TODO(), make(chan struct{}))
+		if err != nil {
+			server.Close()
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error re-applying after AppQoS restart", tc.testCase))
+		}
+		close(watcher.Profiles)
+		close(watcher.Workloads)
+
+		profiles := make([]string, 0)
+		for e := range watcher.Profiles {
+			profiles = append(profiles, e.Meta.GetName())
+		}
+		if !reflect.DeepEqual(profiles, tc.expectedProfiles) {
+			t.Errorf("%s - Failed: Expected PowerProfiles %v to be re-applied, got %v", tc.testCase, tc.expectedProfiles, profiles)
+		}
+
+		workloads := make([]string, 0)
+		for e := range watcher.Workloads {
+			workloads = append(workloads, e.Meta.GetName())
+
+			_, err = r.Reconcile(reconcile.Request{
+				NamespacedName: client.ObjectKey{
+					Name:      e.Meta.GetName(),
+					Namespace: e.Meta.GetNamespace(),
+				},
+			})
+			if err != nil {
+				server.Close()
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error reconciling re-applied PowerWorkload", tc.testCase))
+			}
+		}
+		server.Close()
+
+		if !reflect.DeepEqual(workloads, tc.expectedWorkloads) {
+			t.Errorf("%s - Failed: Expected PowerWorkloads %v to be re-applied, got %v", tc.testCase, tc.expectedWorkloads, workloads)
+		}
+
+		if !reflect.DeepEqual(posted, tc.expectedPosted) {
+			t.Errorf("%s - Failed: Expected Pools %v to be recreated in AppQoS, got %v", tc.testCase, tc.expectedPosted, posted)
+		}
+	}
+}
+
+func TestOrderedRollout(t *testing.T) {
+	tcases := []struct {
+		testCase         string
+		rolloutWindow    time.Duration
+		expectedDeferred bool
+		expectedPutOrder []string
+	}{
+		{
+			testCase:         "Test Case 1 - Reductions applied before increases",
+			rolloutWindow:    time.Minute,
+			expectedDeferred: true,
+			expectedPutOrder: []string{"reduced-example-node1-workload", "increased-example-node1-workload"},
+		},
+		{
+			testCase:         "Test Case 2 - Ordering disabled",
+			rolloutWindow:    0,
+			expectedDeferred: false,
+			expectedPutOrder: []string{"increased-example-node1-workload", "reduced-example-node1-workload"},
+		},
+		{
+			testCase:         "Test Case 3 - Rollout window passed",
+			rolloutWindow:    time.Nanosecond,
+			expectedDeferred: false,
+			expectedPutOrder: []string{"increased-example-node1-workload", "reduced-example-node1-workload"},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		appqosPools := []appqos.Pool{
+			{
+				Name:  stringPtr("Default"),
+				ID:    intPtr(1),
+				Cores: &[]int{0, 1},
+			},
+			{
+				Name:         stringPtr("increased-example-node1-workload"),
+				ID:           intPtr(2),
+				Cores:        &[]int{2, 3},
+				PowerProfile: intPtr(2),
+			},
+			{
+				Name:         stringPtr("reduced-example-node1-workload"),
+				ID:           intPtr(3),
+				Cores:        &[]int{4, 5},
+				PowerProfile: intPtr(1),
+			},
+		}
+		appqosPowerProfiles := []appqos.PowerProfile{
+			{
+				Name:    stringPtr("performance-example-node1"),
+				ID:      intPtr(1),
+				MinFreq: intPtr(2600),
+				MaxFreq: intPtr(3000),
+			},
+			{
+				Name:    stringPtr("balance-power-example-node1"),
+				ID:      intPtr(2),
+				MinFreq: intPtr(1600),
+				MaxFreq: intPtr(2000),
+			},
+		}
+
+		increasedWorkload := &powerv1alpha1.PowerWorkload{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "increased-example-node1-workload",
+				Namespace: PowerWorkloadNamespace,
+			},
+			Spec: powerv1alpha1.PowerWorkloadSpec{
+				Name: "increased-example-node1-workload",
+				Node: powerv1alpha1.NodeInfo{
+					Name:   "example-node1",
+					CpuIds: []int{2, 3},
+				},
+				PowerProfile: "performance-example-node1",
+			},
+			Status: powerv1alpha1.PowerWorkloadStatus{
+				AppliedCpuIds: []int{2, 3},
+			},
+		}
+		reducedWorkload := &powerv1alpha1.PowerWorkload{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "reduced-example-node1-workload",
+				Namespace: PowerWorkloadNamespace,
+			},
+			Spec: powerv1alpha1.PowerWorkloadSpec{
+				Name: "reduced-example-node1-workload",
+				Node: powerv1alpha1.NodeInfo{
+					Name:   "example-node1",
+					CpuIds: []int{4, 5},
+				},
+				PowerProfile: "balance-power-example-node1",
+			},
+			Status: powerv1alpha1.PowerWorkloadStatus{
+				AppliedCpuIds: []int{4, 5},
+			},
+		}
+
+		r, err := createPowerWorkloadReconcilerObject([]runtime.Object{increasedWorkload, reducedWorkload})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		r.RolloutWindow = tc.rolloutWindow
+
+		// Record the order Pools are written in, moving each to its new Power Profile as AppQoS would
+		putOrder := make([]string, 0)
+		listener, err := net.Listen("tcp", "127.0.0.1:5000")
+		if err != nil {
+			t.Fatal(fmt.Sprintf("%s - error creating Listener: %v", tc.testCase, err))
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/pools/", (func(w http.ResponseWriter, r *http.Request) {
+			p := appqos.Pool{}
+			_ = json.NewDecoder(r.Body).Decode(&p)
+			putOrder = append(putOrder, *p.Name)
+			for i := range appqosPools {
+				if *appqosPools[i].Name == *p.Name {
+					appqosPools[i].PowerProfile = p.PowerProfile
+				}
+			}
+		}))
+		mux.HandleFunc("/pools", (func(w http.ResponseWriter, r *http.Request) {
+			b, err := json.Marshal(appqosPools)
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+		mux.HandleFunc("/power_profiles", (func(w http.ResponseWriter, r *http.Request) {
+			b, err := json.Marshal(appqosPowerProfiles)
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+		server := httptest.NewUnstartedServer(mux)
+		server.Listener.Close()
+		server.Listener = listener
+		server.Start()
+
+		// Deliver the increase first, as a rollout might, then the reduction, then the increase again as a requeue would
+		deferred := false
+		for _, workloadName := range []string{"increased-example-node1-workload", "reduced-example-node1-workload", "increased-example-node1-workload"} {
+			req := reconcile.Request{
+				NamespacedName: client.ObjectKey{
+					Name:      workloadName,
+					Namespace: PowerWorkloadNamespace,
+				},
+			}
+
+			result, err := r.Reconcile(req)
+			if err != nil {
+				server.Close()
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error reconciling PowerWorkload object '%s'", tc.testCase, workloadName))
+			}
+			if result.RequeueAfter > 0 {
+				deferred = true
+			}
+		}
+		server.Close()
+
+		if deferred != tc.expectedDeferred {
+			t.Errorf("%s - Failed: Expected increase to be deferred to be %v, got %v", tc.testCase, tc.expectedDeferred, deferred)
+		}
+
+		if !reflect.DeepEqual(putOrder, tc.expectedPutOrder) {
+			t.Errorf("%s - Failed: Expected Pools to be written in order %v, got %v", tc.testCase, tc.expectedPutOrder, putOrder)
+		}
+	}
+}
+
+func TestWorkloadNamespaceMigration(t *testing.T) {
+	tcases := []struct {
+		testCase              string
+		workloadNode          string
+		existingWorkload      *powerv1alpha1.PowerWorkload
+		expectedMoved         bool
+		expectedCpuIds        []int
+		expectedNumContainers int
+	}{
+		{
+			testCase:              "Test Case 1 - PowerWorkload moved",
+			workloadNode:          "example-node1",
+			expectedMoved:         true,
+			expectedCpuIds:        []int{2, 3},
+			expectedNumContainers: 1,
+		},
+		{
+			testCase:     "Test Case 2 - PowerWorkload merged into the one in the workload namespace",
+			workloadNode: "example-node1",
+			existingWorkload: &powerv1alpha1.PowerWorkload{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "performance-example-node1-workload",
+					Namespace: "power-manager",
+				},
+				Spec: powerv1alpha1.PowerWorkloadSpec{
+					Name: "performance-example-node1-workload",
+					Node: powerv1alpha1.NodeInfo{
+						Name:   "example-node1",
+						CpuIds: []int{4},
+						Containers: []powerv1alpha1.Container{
+							{Name: "example-container-2", Pod: "example-pod-2", ExclusiveCPUs: []int{4}},
+						},
+					},
+					PowerProfile: "performance-example-node1",
+				},
+			},
+			expectedMoved:         true,
+			expectedCpuIds:        []int{4, 2, 3},
+			expectedNumContainers: 2,
+		},
+		{
+			testCase:      "Test Case 3 - PowerWorkload of another Node left in place",
+			workloadNode:  "example-node2",
+			expectedMoved: false,
+		},
+		{
+			testCase:     "Test Case 4 - Container of a same-named Pod in another namespace kept",
+			workloadNode: "example-node1",
+			existingWorkload: &powerv1alpha1.PowerWorkload{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "performance-example-node1-workload",
+					Namespace: "power-manager",
+				},
+				Spec: powerv1alpha1.PowerWorkloadSpec{
+					Name: "performance-example-node1-workload",
+					Node: powerv1alpha1.NodeInfo{
+						Name:   "example-node1",
+						CpuIds: []int{4},
+						Containers: []powerv1alpha1.Container{
+							{Name: "example-container-1", Pod: "example-pod-1", PodUID: "other-namespace-pod-uid", ExclusiveCPUs: []int{4}},
+						},
+					},
+					PowerProfile: "performance-example-node1",
+				},
+			},
+			expectedMoved:         true,
+			expectedCpuIds:        []int{4, 2, 3},
+			expectedNumContainers: 2,
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		workloadName := fmt.Sprintf("performance-%s-workload", tc.workloadNode)
+		workload := &powerv1alpha1.PowerWorkload{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      workloadName,
+				Namespace: "pod-namespace",
+			},
+			Spec: powerv1alpha1.PowerWorkloadSpec{
+				Name: workloadName,
+				Node: powerv1alpha1.NodeInfo{
+					Name:   tc.workloadNode,
+					CpuIds: []int{2, 3},
+					Containers: []powerv1alpha1.Container{
+						{Name: "example-container-1", Pod: "example-pod-1", PodUID: "example-pod-1-uid", ExclusiveCPUs: []int{2, 3}},
+					},
+				},
+				PowerProfile: fmt.Sprintf("performance-%s", tc.workloadNode),
+			},
+			Status: powerv1alpha1.PowerWorkloadStatus{
+				AppliedCpuIds: []int{2, 3},
+			},
+		}
+		objs := []runtime.Object{workload}
+		if tc.existingWorkload != nil {
+			objs = append(objs, tc.existingWorkload)
+		}
+
+		r, err := createPowerWorkloadReconcilerObject(objs)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		r.WorkloadNamespace = "power-manager"
+
+		appqosPools := []appqos.Pool{
+			{
+				Name:  stringPtr("Default"),
+				ID:    intPtr(1),
+				Cores: &[]int{0, 1},
+			},
+			{
+				Name:         stringPtr("performance-example-node1-workload"),
+				ID:           intPtr(2),
+				Cores:        &[]int{2, 3},
+				PowerProfile: intPtr(1),
+			},
+		}
+		server, err := createPowerWorkloadListeners(appqosPools, []appqos.PowerProfile{})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating Listeners", tc.testCase))
+		}
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      workloadName,
+				Namespace: "pod-namespace",
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		if err != nil {
+			server.Close()
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling PowerWorkload object", tc.testCase))
+		}
+
+		// The deletion of the original is reconciled too, and must leave the Pool to the moved PowerWorkload
+		if tc.expectedMoved {
+			_, err = r.Reconcile(req)
+			if err != nil {
+				server.Close()
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error reconciling deleted PowerWorkload object", tc.testCase))
+			}
+		}
+
+		pool, err := r.AppQoSClient.GetPoolByName(AppQoSClientAddress, "performance-example-node1-workload")
+		server.Close()
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving Pool from AppQoS", tc.testCase))
+		}
+		if reflect.DeepEqual(pool, &appqos.Pool{}) {
+			t.Errorf("%s - Failed: Expected Pool to remain in AppQoS", tc.testCase)
+		}
+
+		original := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), req.NamespacedName, original)
+		if errors.IsNotFound(err) != tc.expectedMoved {
+			t.Errorf("%s - Failed: Expected original PowerWorkload to be removed to be %v, got error %v", tc.testCase, tc.expectedMoved, err)
+		}
+
+		moved := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{Name: workloadName, Namespace: "power-manager"}, moved)
+		if !tc.expectedMoved {
+			if !errors.IsNotFound(err) {
+				t.Errorf("%s - Failed: Expected PowerWorkload not to be moved, got error %v", tc.testCase, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving moved PowerWorkload", tc.testCase))
+		}
+
+		if !reflect.DeepEqual(moved.Spec.Node.CpuIds, tc.expectedCpuIds) {
+			t.Errorf("%s - Failed: Expected moved PowerWorkload's Core List to be %v, got %v", tc.testCase, tc.expectedCpuIds, moved.Spec.Node.CpuIds)
+		}
+		if len(moved.Spec.Node.Containers) != tc.expectedNumContainers {
+			t.Errorf("%s - Failed: Expected moved PowerWorkload to have %v Containers, got %v", tc.testCase, tc.expectedNumContainers, len(moved.Spec.Node.Containers))
+		}
+		if tc.existingWorkload == nil && !reflect.DeepEqual(moved.Status.AppliedCpuIds, workload.Status.AppliedCpuIds) {
+			t.Errorf("%s - Failed: Expected moved PowerWorkload to keep applied Core List %v, got %v", tc.testCase, workload.Status.AppliedCpuIds, moved.Status.AppliedCpuIds)
+		}
+	}
+}
+
+func TestNodeReplacement(t *testing.T) {
+	tcases := []struct {
+		testCase              string
+		recordedUID           string
+		expectedUpdated       bool
+		expectedCpuIds        []int
+		expectedNumContainers int
+	}{
+		{
+			testCase:              "Test Case 1 - Node unchanged",
+			recordedUID:           "example-node1-uid",
+			expectedUpdated:       false,
+			expectedCpuIds:        []int{2, 3},
+			expectedNumContainers: 1,
+		},
+		{
+			testCase:              "Test Case 2 - Node UID recorded",
+			recordedUID:           "",
+			expectedUpdated:       true,
+			expectedCpuIds:        []int{2, 3},
+			expectedNumContainers: 1,
+		},
+		{
+			testCase:              "Test Case 3 - Node replaced",
+			recordedUID:           "replaced-node-uid",
+			expectedUpdated:       true,
+			expectedCpuIds:        nil,
+			expectedNumContainers: 0,
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "example-node1",
+				UID:  "example-node1-uid",
+			},
+		}
+		workload := &powerv1alpha1.PowerWorkload{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "performance-example-node1-workload",
+				Namespace:  PowerWorkloadNamespace,
+				Generation: 1,
+			},
+			Spec: powerv1alpha1.PowerWorkloadSpec{
+				Name: "performance-example-node1-workload",
+				Node: powerv1alpha1.NodeInfo{
+					Name:   "example-node1",
+					UID:    tc.recordedUID,
+					CpuIds: []int{2, 3},
+					Containers: []powerv1alpha1.Container{
+						{Name: "example-container-1", Pod: "example-pod-1", ExclusiveCPUs: []int{2, 3}},
+					},
+				},
+				PowerProfile: "performance-example-node1",
+			},
+			Status: powerv1alpha1.PowerWorkloadStatus{
+				ObservedGeneration: 1,
+				AppliedCpuIds:      []int{2, 3},
+			},
+		}
+
+		r, err := createPowerWorkloadReconcilerObject([]runtime.Object{node, workload})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		appqosPools := []appqos.Pool{
+			{
+				Name:  stringPtr("Default"),
+				ID:    intPtr(1),
+				Cores: &[]int{0, 1},
+			},
+			{
+				Name:         stringPtr("performance-example-node1-workload"),
+				ID:           intPtr(2),
+				Cores:        &[]int{2, 3},
+				PowerProfile: intPtr(1),
+			},
+		}
+		server, err := createPowerWorkloadListeners(appqosPools, []appqos.PowerProfile{})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating Listeners", tc.testCase))
+		}
+
+		req := reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      workload.Name,
+				Namespace: PowerWorkloadNamespace,
+			},
+		}
+
+		_, err = r.Reconcile(req)
+		server.Close()
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling PowerWorkload object", tc.testCase))
+		}
+
+		updatedWorkload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), req.NamespacedName, updatedWorkload)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving PowerWorkload object", tc.testCase))
+		}
+
+		updated := updatedWorkload.Spec.Node.UID != tc.recordedUID
+		if updated != tc.expectedUpdated {
+			t.Errorf("%s - Failed: Expected PowerWorkload to be updated to be %v, got %v", tc.testCase, tc.expectedUpdated, updated)
+		}
+
+		if updatedWorkload.Spec.Node.UID != "example-node1-uid" {
+			t.Errorf("%s - Failed: Expected NodeInfo UID to be 'example-node1-uid', got '%s'", tc.testCase, updatedWorkload.Spec.Node.UID)
+		}
+
+		if !reflect.DeepEqual(updatedWorkload.Spec.Node.CpuIds, tc.expectedCpuIds) {
+			t.Errorf("%s - Failed: Expected NodeInfo CpuIds to be %v, got %v", tc.testCase, tc.expectedCpuIds, updatedWorkload.Spec.Node.CpuIds)
+		}
+
+		if len(updatedWorkload.Spec.Node.Containers) != tc.expectedNumContainers {
+			t.Errorf("%s - Failed: Expected %d Containers in NodeInfo, got %d", tc.testCase, tc.expectedNumContainers, len(updatedWorkload.Spec.Node.Containers))
+		}
+	}
+}
+
+func TestSharedPoolComplement(t *testing.T) {
+	tcases := []struct {
+		testCase                 string
+		exclusiveWorkloads       map[string][]int
+		expectedUnallocatedCores []int
+	}{
+		{
+			testCase: "Test Case 1 - Exclusive cores removed from the Shared pool",
+			exclusiveWorkloads: map[string][]int{
+				"performance-example-node1-workload":   {2, 3},
+				"balance-power-example-node1-workload": {5},
+			},
+			expectedUnallocatedCores: []int{4, 6, 7},
+		},
+		{
+			testCase: "Test Case 2 - Released exclusive cores returned to the Shared pool",
+			exclusiveWorkloads: map[string][]int{
+				"balance-power-example-node1-workload": {5},
+			},
+			expectedUnallocatedCores: []int{2, 3, 4, 6, 7},
+		},
+		{
+			testCase:                 "Test Case 3 - No exclusive cores",
+			exclusiveWorkloads:       map[string][]int{},
+			expectedUnallocatedCores: []int{2, 3, 4, 5, 6, 7},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+
+		objs := []runtime.Object{
+			&corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "example-node1",
+				},
+				Status: corev1.NodeStatus{
+					Capacity: map[corev1.ResourceName]resource.Quantity{
+						corev1.ResourceCPU: *resource.NewQuantity(8, resource.DecimalSI),
+					},
+				},
+			},
+			&powerv1alpha1.PowerWorkload{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "shared-example-node1-workload",
+					Namespace: PowerWorkloadNamespace,
+				},
+				Spec: powerv1alpha1.PowerWorkloadSpec{
+					Name:         "shared-example-node1-workload",
+					AllCores:     true,
+					ReservedCPUs: []int{0, 1},
+					PowerProfile: "shared-example-node1",
+				},
+				Status: powerv1alpha1.PowerWorkloadStatus{
+					Node:        "example-node1",
+					SharedCores: []int{2, 3, 4, 5, 6, 7},
+				},
+			},
+			// Another Node's exclusive cores don't affect this Node's Shared pool
+			&powerv1alpha1.PowerWorkload{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "performance-example-node2-workload",
+					Namespace: PowerWorkloadNamespace,
+				},
+				Spec: powerv1alpha1.PowerWorkloadSpec{
+					Name: "performance-example-node2-workload",
+					Node: powerv1alpha1.NodeInfo{
+						Name:   "example-node2",
+						CpuIds: []int{4, 6},
+					},
+					PowerProfile: "performance-example-node2",
+				},
+			},
+		}
+		for workloadName, cpuIds := range tc.exclusiveWorkloads {
+			objs = append(objs, &powerv1alpha1.PowerWorkload{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      workloadName,
+					Namespace: PowerWorkloadNamespace,
+				},
+				Spec: powerv1alpha1.PowerWorkloadSpec{
+					Name: workloadName,
+					Node: powerv1alpha1.NodeInfo{
+						Name:   "example-node1",
+						CpuIds: cpuIds,
+					},
+				},
+			})
+		}
+
+		r, err := createPowerWorkloadReconcilerObject(objs)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+
+		sharedPoolReconciler := &SharedPoolReconciler{
+			Client:   r.Client,
+			Log:      r.Log,
+			NodeName: "example-node1",
+		}
+		_, err = sharedPoolReconciler.Reconcile(reconcile.Request{
+			NamespacedName: client.ObjectKey{
+				Name:      "performance-example-node2-workload",
+				Namespace: PowerWorkloadNamespace,
+			},
+		})
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error reconciling object", tc.testCase))
+		}
+
+		sharedWorkload := &powerv1alpha1.PowerWorkload{}
+		err = r.Client.Get(context.TODO(), client.ObjectKey{
+			Name:      "shared-example-node1-workload",
+			Namespace: PowerWorkloadNamespace,
+		}, sharedWorkload)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error retrieving Shared PowerWorkload", tc.testCase))
+		}
+
+		if !reflect.DeepEqual(sharedWorkload.Status.UnallocatedCores, tc.expectedUnallocatedCores) {
+			t.Errorf("%s - Failed: Expected Shared PowerWorkload unallocated cores to be %v, got %v", tc.testCase, tc.expectedUnallocatedCores, sharedWorkload.Status.UnallocatedCores)
+		}
+
+		// The cores applied to the Shared pool are left to the PowerWorkload controller
+		if !reflect.DeepEqual(sharedWorkload.Status.SharedCores, []int{2, 3, 4, 5, 6, 7}) {
+			t.Errorf("%s - Failed: Expected Shared PowerWorkload shared cores to be left at %v, got %v", tc.testCase, []int{2, 3, 4, 5, 6, 7}, sharedWorkload.Status.SharedCores)
+		}
+	}
+}
+
+func TestStartupReassertion(t *testing.T) {
+	tcases := []struct {
+		testCase          string
+		reassertOnStartup bool
+		expectedProfiles  map[string]int
+	}{
+		{
+			testCase:          "Test Case 1 - Reassertion enabled",
+			reassertOnStartup: true,
+			expectedProfiles: map[string]int{
+				"performance-example-node1-workload":         1,
+				"balance-performance-example-node1-workload": 2,
+			},
+		},
+		{
+			testCase:          "Test Case 2 - Reassertion disabled",
+			reassertOnStartup: false,
+			expectedProfiles: map[string]int{
+				"performance-example-node1-workload":         3,
+				"balance-performance-example-node1-workload": 3,
+			},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Setenv("NODE_NAME", "example-node1")
+		AppQoSClientAddress = "http://127.0.0.1:5000"
+
+		// Both PowerWorkloads were applied before the restart, but their Pools were given another profile in AppQoS since
+		appqosPools := []appqos.Pool{
+			{
+				Name:  stringPtr("Default"),
+				ID:    intPtr(1),
+				Cores: &[]int{6, 7},
+			},
+			{
+				Name:         stringPtr("performance-example-node1-workload"),
+				ID:           intPtr(2),
+				Cores:        &[]int{2, 3},
+				PowerProfile: intPtr(3),
+			},
+			{
+				Name:         stringPtr("balance-performance-example-node1-workload"),
+				ID:           intPtr(3),
+				Cores:        &[]int{4, 5},
+				PowerProfile: intPtr(3),
+			},
+		}
+		appqosPowerProfiles := []appqos.PowerProfile{
+			{
+				Name: stringPtr("performance-example-node1"),
+				ID:   intPtr(1),
+			},
+			{
+				Name: stringPtr("balance-performance-example-node1"),
+				ID:   intPtr(2),
+			},
+			{
+				Name: stringPtr("power-example-node1"),
+				ID:   intPtr(3),
+			},
+		}
+
+		workloads := make([]runtime.Object, 0)
+		for profile, cpuIds := range map[string][]int{
+			"performance-example-node1":         {2, 3},
+			"balance-performance-example-node1": {4, 5},
+		} {
+			workloads = append(workloads, &powerv1alpha1.PowerWorkload{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       profile + WorkloadNameSuffix,
+					Namespace:  PowerWorkloadNamespace,
+					Generation: 2,
+				},
+				Spec: powerv1alpha1.PowerWorkloadSpec{
+					Name: profile + WorkloadNameSuffix,
+					Node: powerv1alpha1.NodeInfo{
+						Name:   "example-node1",
+						CpuIds: cpuIds,
+					},
+					PowerProfile: profile,
+				},
+				Status: powerv1alpha1.PowerWorkloadStatus{
+					ObservedGeneration: 2,
+					AppliedCpuIds:      cpuIds,
+				},
+			})
+		}
+
+		r, err := createPowerWorkloadReconcilerObject(workloads)
+		if err != nil {
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error creating reconciler object", tc.testCase))
+		}
+		r.ReassertOnStartup = tc.reassertOnStartup
+
+		listener, err := net.Listen("tcp", "127.0.0.1:5000")
+		if err != nil {
+			t.Fatal(fmt.Sprintf("%s - error creating Listener: %v", tc.testCase, err))
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/pools/", (func(w http.ResponseWriter, r *http.Request) {
+			path := strings.Split(r.URL.Path, "/")
+			id, _ := strconv.Atoi(path[len(path)-1])
+			p := appqos.Pool{}
+			_ = json.NewDecoder(r.Body).Decode(&p)
+			for i := range appqosPools {
+				if *appqosPools[i].ID == id {
+					appqosPools[i].Cores = p.Cores
+					appqosPools[i].PowerProfile = p.PowerProfile
+				}
+			}
+		}))
+		mux.HandleFunc("/pools", (func(w http.ResponseWriter, r *http.Request) {
+			b, err := json.Marshal(appqosPools)
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+		mux.HandleFunc("/power_profiles", (func(w http.ResponseWriter, r *http.Request) {
+			b, err := json.Marshal(appqosPowerProfiles)
+			if err == nil {
+				fmt.Fprintln(w, string(b[:]))
+			}
+		}))
+		server := httptest.NewUnstartedServer(mux)
+		server.Listener.Close()
+		server.Listener = listener
+		server.Start()
+
+		reassertion := &StartupReassertion{
+			Client:    r.Client,
+			Log:       ctrl.Log.WithName("startup-reassertion"),
+			NodeName:  "example-node1",
+			Profiles:  make(chan event.GenericEvent, 10),
+			Workloads: make(chan event.GenericEvent, 10),
+		}
+		err = reassertion.Start(make(chan struct{}))
+		if err != nil {
+			server.Close()
+			t.Error(err)
+			t.Fatal(fmt.Sprintf("%s - error starting startup reassertion", tc.testCase))
+		}
+
+		if len(reassertion.Workloads) != len(workloads) {
+			t.Errorf("%s - Failed: Expected %d PowerWorkloads to be re-applied, got %d", tc.testCase, len(workloads), len(reassertion.Workloads))
+		}
+		for len(reassertion.Workloads) > 0 {
+			reapplied := <-reassertion.Workloads
+			_, err = r.Reconcile(reconcile.Request{
+				NamespacedName: client.ObjectKey{
+					Name:      reapplied.Meta.GetName(),
+					Namespace: reapplied.Meta.GetNamespace(),
+				},
+			})
+			if err != nil {
+				server.Close()
+				t.Error(err)
+				t.Fatal(fmt.Sprintf("%s - error reconciling PowerWorkload object", tc.testCase))
+			}
+		}
+		server.Close()
+
+		poolProfiles := make(map[string]int)
+		for _, pool := range appqosPools {
+			if pool.PowerProfile != nil {
+				poolProfiles[*pool.Name] = *pool.PowerProfile
+			}
+		}
+		if !reflect.DeepEqual(poolProfiles, tc.expectedProfiles) {
+			t.Errorf("%s - Failed: Expected Pool PowerProfiles to be %v, got %v", tc.testCase, tc.expectedProfiles, poolProfiles)
+		}
+	}
+}
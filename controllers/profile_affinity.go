@@ -0,0 +1,76 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/cpuhotplug"
+)
+
+// checkProfileAffinity compares the container's exclusive CPUs against the socket and NUMA node its PowerProfile
+// prefers, emitting a Warning Event on the Pod for the CPUs outside them. The kubelet assigns the cores, so a
+// mismatch is only reported and the Pod is still power-managed
+func (r *PowerPodReconciler) checkProfileAffinity(pod *corev1.Pod, containerName string, profile string, profileCRs []powerv1alpha1.PowerProfile, cores []int) {
+	logger := r.Log.WithValues("pod", pod.GetName(), "container", containerName)
+
+	var spec *powerv1alpha1.PowerProfileSpec
+	for i := range profileCRs {
+		if profileCRs[i].Name == profile {
+			spec = &profileCRs[i].Spec
+		}
+	}
+	if spec == nil || (spec.PreferredSocket == nil && spec.PreferredNUMA == nil) {
+		return
+	}
+
+	offSocketCores := make([]int, 0)
+	offNUMACores := make([]int, 0)
+	for _, core := range cores {
+		if spec.PreferredSocket != nil {
+			socket, err := cpuhotplug.GetPackageID(core)
+			if err != nil {
+				logger.Error(err, "error retrieving socket of CPU", "cpu", core)
+				return
+			}
+			if socket != *spec.PreferredSocket {
+				offSocketCores = append(offSocketCores, core)
+			}
+		}
+
+		if spec.PreferredNUMA != nil {
+			node, err := cpuhotplug.GetNUMANode(core)
+			if err != nil {
+				logger.Error(err, "error retrieving NUMA node of CPU", "cpu", core)
+				return
+			}
+			if node != *spec.PreferredNUMA {
+				offNUMACores = append(offNUMACores, core)
+			}
+		}
+	}
+
+	if len(offSocketCores) > 0 {
+		logger.Info("exclusive CPUs are not on the PowerProfile's preferred socket", "profile", profile, "preferredSocket", *spec.PreferredSocket, "cpus", offSocketCores)
+		r.Recorder.Eventf(pod, corev1.EventTypeWarning, "ProfileAffinityMismatch", "CPUs %v of Container '%s' are not on socket %d preferred by PowerProfile '%s'", offSocketCores, containerName, *spec.PreferredSocket, profile)
+	}
+	if len(offNUMACores) > 0 {
+		logger.Info("exclusive CPUs are not on the PowerProfile's preferred NUMA node", "profile", profile, "preferredNUMA", *spec.PreferredNUMA, "cpus", offNUMACores)
+		r.Recorder.Eventf(pod, corev1.EventTypeWarning, "ProfileAffinityMismatch", "CPUs %v of Container '%s' are not on NUMA node %d preferred by PowerProfile '%s'", offNUMACores, containerName, *spec.PreferredNUMA, profile)
+	}
+}
@@ -0,0 +1,68 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
+)
+
+// ListWorkloadsByFamily returns every PowerWorkload in the namespace belonging to the given profile family
+func ListWorkloadsByFamily(ctx context.Context, c client.Reader, namespace string, family string) ([]powerv1alpha1.PowerWorkload, error) {
+	workloads := &powerv1alpha1.PowerWorkloadList{}
+	err := c.List(ctx, workloads, client.InNamespace(namespace), client.MatchingLabels{powerv1alpha1.FamilyLabel: family})
+	if err != nil {
+		return nil, err
+	}
+
+	return workloads.Items, nil
+}
+
+// ListProfilesByFamily returns every PowerProfile in the namespace belonging to the given profile family
+func ListProfilesByFamily(ctx context.Context, c client.Reader, namespace string, family string) ([]powerv1alpha1.PowerProfile, error) {
+	profiles := &powerv1alpha1.PowerProfileList{}
+	err := c.List(ctx, profiles, client.InNamespace(namespace), client.MatchingLabels{powerv1alpha1.FamilyLabel: family})
+	if err != nil {
+		return nil, err
+	}
+
+	return profiles.Items, nil
+}
+
+// applyProfileFamily copies the family label of the PowerWorkload's PowerProfile onto the PowerWorkload,
+// so the PowerWorkload can be found with the rest of its family
+func applyProfileFamily(workload *powerv1alpha1.PowerWorkload, profiles []powerv1alpha1.PowerProfile) {
+	for _, profile := range profiles {
+		if profile.Name != workload.Spec.PowerProfile {
+			continue
+		}
+
+		family, ok := profile.Labels[powerv1alpha1.FamilyLabel]
+		if !ok {
+			return
+		}
+
+		if workload.Labels == nil {
+			workload.Labels = map[string]string{}
+		}
+		workload.Labels[powerv1alpha1.FamilyLabel] = family
+		return
+	}
+}
@@ -0,0 +1,117 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/appqos"
+)
+
+// workloadProfile returns the PowerProfile the PowerWorkload is based on, or nil if it doesn't exist
+func (r *PowerWorkloadReconciler) workloadProfile(workload *powerv1alpha1.PowerWorkload) (*powerv1alpha1.PowerProfile, error) {
+	profile := &powerv1alpha1.PowerProfile{}
+	err := r.Client.Get(context.TODO(), client.ObjectKey{
+		Namespace: workload.Namespace,
+		Name:      workload.Spec.PowerProfile,
+	}, profile)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return profile, nil
+}
+
+// lifetimeRemaining returns how much longer the PowerWorkload may keep its PowerProfile, measured from when it
+// was first applied, and whether the PowerProfile's lifetime is limited at all
+func lifetimeRemaining(workload *powerv1alpha1.PowerWorkload, profile *powerv1alpha1.PowerProfile, now time.Time) (time.Duration, bool) {
+	if workload.Spec.AllCores || profile == nil || profile.Spec.MaxLifetime == nil {
+		return 0, false
+	}
+
+	if workload.Status.AppliedTime == nil {
+		return profile.Spec.MaxLifetime.Duration, true
+	}
+
+	remaining := profile.Spec.MaxLifetime.Duration - now.Sub(workload.Status.AppliedTime.Time)
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return remaining, true
+}
+
+// profileLifetimeExpired reports whether the PowerWorkload has run with its PowerProfile for longer than the
+// PowerProfile's maxLifetime
+func profileLifetimeExpired(workload *powerv1alpha1.PowerWorkload, profile *powerv1alpha1.PowerProfile) bool {
+	remaining, limited := lifetimeRemaining(workload, profile, time.Now())
+	return limited && workload.Status.AppliedTime != nil && remaining == 0
+}
+
+// lifetimeResult requeues the PowerWorkload for when its PowerProfile's lifetime runs out, so it is reverted
+// to the baseline on time
+func lifetimeResult(workload *powerv1alpha1.PowerWorkload, profile *powerv1alpha1.PowerProfile) ctrl.Result {
+	remaining, limited := lifetimeRemaining(workload, profile, time.Now())
+	if !limited || workload.Status.ProfileExpired {
+		return ctrl.Result{}
+	}
+	if remaining == 0 {
+		return ctrl.Result{Requeue: true}
+	}
+
+	return ctrl.Result{RequeueAfter: remaining}
+}
+
+// baselineProfile returns the AppQoS Power Profile a PowerWorkload reverts to once its PowerProfile's lifetime has
+// passed: the PowerProfile's baseline, or the Shared pool's Power Profile if it has none
+func (r *PowerWorkloadReconciler) baselineProfile(profile *powerv1alpha1.PowerProfile, nodeName string) (*appqos.PowerProfile, error) {
+	if profile.Spec.Baseline != "" {
+		for _, name := range []string{profile.Spec.Baseline, fmt.Sprintf("%s-%s", profile.Spec.Baseline, nodeName)} {
+			baseline, err := r.AppQoSClient.GetProfileByName(name, AppQoSClientAddress)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(baseline, &appqos.PowerProfile{}) {
+				return baseline, nil
+			}
+		}
+
+		return nil, errors.NewServiceUnavailable(fmt.Sprintf("baseline PowerProfile '%s' not found in AppQoS instance", profile.Spec.Baseline))
+	}
+
+	sharedPool, err := r.AppQoSClient.GetSharedPool(AppQoSClientAddress)
+	if err != nil {
+		return nil, err
+	}
+	if sharedPool.Name == nil || *sharedPool.Name != appqos.SharedPoolName || sharedPool.PowerProfile == nil {
+		return nil, errors.NewServiceUnavailable(fmt.Sprintf("PowerProfile '%s' has no baseline and there is no Shared pool PowerProfile to revert to", profile.Name))
+	}
+
+	return r.AppQoSClient.GetPowerProfile(AppQoSClientAddress, *sharedPool.PowerProfile)
+}
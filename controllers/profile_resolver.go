@@ -0,0 +1,170 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ProfileResolver determines the PowerProfile requested by a Container
+type ProfileResolver interface {
+	// Resolve returns the name of the requested PowerProfile, or an empty string if
+	// this input method has nothing to say about the Container
+	Resolve(pod *corev1.Pod, container corev1.Container) (string, error)
+}
+
+// ProfileResolverChain consults each ProfileResolver in order, returning the first PowerProfile found
+type ProfileResolverChain []ProfileResolver
+
+func (chain ProfileResolverChain) Resolve(pod *corev1.Pod, container corev1.Container) (string, error) {
+	for _, resolver := range chain {
+		profileName, err := resolver.Resolve(pod, container)
+		if err != nil {
+			return "", err
+		}
+
+		if profileName != "" {
+			return profileName, nil
+		}
+	}
+
+	return "", nil
+}
+
+// profileQuantityError is returned for a Container whose 'power.intel.com/<profile>' quantity doesn't match the
+// number of CPUs it requests. PowerProfiles are applied per exclusive CPU, so the two must be equal
+type profileQuantityError struct {
+	*errors.StatusError
+	profile         string
+	profileQuantity resource.Quantity
+	cpuQuantity     resource.Quantity
+}
+
+// ResourceRequestResolver reads the PowerProfile from a 'power.intel.com/<profile>' resource request
+type ResourceRequestResolver struct{}
+
+func (ResourceRequestResolver) Resolve(pod *corev1.Pod, container corev1.Container) (string, error) {
+	profileName := ""
+	moreThanOneProfileError := errors.NewServiceUnavailable("Cannot have more than one Power Profile per Container")
+
+	for resource := range container.Resources.Requests {
+		if strings.HasPrefix(string(resource), ResourcePrefix) {
+			if profileName == "" {
+				profileName = string(resource[len(ResourcePrefix):])
+			} else {
+				// Cannot have more than one profile for a singular container
+				return "", moreThanOneProfileError
+			}
+		}
+	}
+
+	if profileName != "" {
+		// Check if there is a mismatch in CPU requests and PowerProfile requests
+		powerProfileResourceName := corev1.ResourceName(fmt.Sprintf("%s%s", ResourcePrefix, profileName))
+		numRequestsPowerProfile := container.Resources.Requests[powerProfileResourceName]
+		numLimitsPowerProfile := container.Resources.Limits[powerProfileResourceName]
+		numRequestsCPU := container.Resources.Requests[CPUResource]
+		numLimistCPU := container.Resources.Limits[CPUResource]
+		if numRequestsCPU != numRequestsPowerProfile || numLimistCPU != numLimitsPowerProfile {
+			return "", &profileQuantityError{
+				StatusError:     errors.NewServiceUnavailable("Mismatch between CPU requests and PowerProfile Requests"),
+				profile:         profileName,
+				profileQuantity: numRequestsPowerProfile,
+				cpuQuantity:     numRequestsCPU,
+			}
+		}
+	}
+
+	return profileName, nil
+}
+
+// AnnotationProfileResolver reads the PowerProfile from the Pod's PodProfileAnnotation, or failing that its
+// PowerProfile annotation, applying it to every Container in the Pod. It is intended as a fallback after the
+// ResourceRequestResolver, so it only covers Containers without a PowerProfile of their own
+type AnnotationProfileResolver struct{}
+
+func (AnnotationProfileResolver) Resolve(pod *corev1.Pod, container corev1.Container) (string, error) {
+	_, profileName := podAnnotationProfile(pod)
+	return profileName, nil
+}
+
+// podAnnotationProfile returns the Pod-level annotation naming a PowerProfile for all of the Pod's Containers and
+// the PowerProfile it names. The PodProfileAnnotation takes precedence over the PowerProfile annotation
+func podAnnotationProfile(pod *corev1.Pod) (string, string) {
+	for _, annotation := range []string{PodProfileAnnotation, PowerProfileAnnotation} {
+		if profileName := pod.GetAnnotations()[annotation]; profileName != "" {
+			return annotation, profileName
+		}
+	}
+
+	return "", ""
+}
+
+// profileResolver returns the chain of configured ProfileResolvers. In strict mode the annotation
+// fallback is dropped so only 'power.intel.com/' resource requests are honored
+func (r *PowerPodReconciler) profileResolver() ProfileResolver {
+	resolvers := make([]ProfileResolver, 0, len(r.ProfileResolvers))
+	for _, resolver := range r.ProfileResolvers {
+		if _, isAnnotation := resolver.(AnnotationProfileResolver); isAnnotation && r.StrictResourceRequests {
+			continue
+		}
+		resolvers = append(resolvers, resolver)
+	}
+
+	if len(resolvers) == 0 {
+		return ResourceRequestResolver{}
+	}
+
+	return ProfileResolverChain(resolvers)
+}
+
+// resolveProfile returns the PowerProfile the Container requests through the configured ProfileResolvers. A Pod
+// whose Pod-level profile annotation names a different PowerProfile to a Container's 'power.intel.com/' resource
+// request is ambiguous, so a Warning Event is emitted and the resource request takes precedence, whatever the
+// order of the ProfileResolvers. A PowerProfile requested in a different quantity to the Container's CPUs is
+// rejected with a Warning Event
+func (r *PowerPodReconciler) resolveProfile(pod *corev1.Pod, container corev1.Container) (string, error) {
+	profileName, err := r.profileResolver().Resolve(pod, container)
+	if err != nil {
+		if quantityErr, ok := err.(*profileQuantityError); ok {
+			r.Recorder.Eventf(pod, corev1.EventTypeWarning, "PowerProfileQuantityMismatch",
+				"Container '%s' requests %s of PowerProfile '%s' but %s CPUs, the PowerProfile must be requested once for every exclusive CPU",
+				container.Name, quantityErr.profileQuantity.String(), quantityErr.profile, quantityErr.cpuQuantity.String())
+		}
+		return "", err
+	}
+
+	annotation, annotationProfile := podAnnotationProfile(pod)
+	if annotationProfile == "" {
+		return profileName, nil
+	}
+
+	requestedProfile, err := ResourceRequestResolver{}.Resolve(pod, container)
+	if err != nil || requestedProfile == "" || requestedProfile == annotationProfile {
+		return profileName, nil
+	}
+
+	r.Recorder.Eventf(pod, corev1.EventTypeWarning, "PowerProfileConflict",
+		"Container '%s' requests PowerProfile '%s' but the Pod's '%s' annotation is '%s', the resource request takes precedence",
+		container.Name, requestedProfile, annotation, annotationProfile)
+	return requestedProfile, nil
+}
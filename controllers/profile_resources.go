@@ -0,0 +1,136 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	profileResourcesAdvertisedDesc = prometheus.NewDesc(
+		"power_profile_resources_advertised",
+		"Number of a PowerProfile's extended resources allocatable across the cluster's Nodes",
+		[]string{"profile"}, nil,
+	)
+
+	profileResourcesAllocatedDesc = prometheus.NewDesc(
+		"power_profile_resources_allocated",
+		"Number of a PowerProfile's extended resources requested by the Pods scheduled across the cluster",
+		[]string{"profile"}, nil,
+	)
+)
+
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+
+// ProfileResourceCollector reports, for every PowerProfile extended resource, how many are advertised by the
+// cluster's Nodes against how many its Pods have been scheduled with, so the scheduling capacity left for each
+// PowerProfile can be tracked. The counts are taken afresh on every scrape
+type ProfileResourceCollector struct {
+	Client client.Client
+	Log    logr.Logger
+}
+
+func (c *ProfileResourceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- profileResourcesAdvertisedDesc
+	ch <- profileResourcesAllocatedDesc
+}
+
+func (c *ProfileResourceCollector) Collect(ch chan<- prometheus.Metric) {
+	nodes := &corev1.NodeList{}
+	err := c.Client.List(context.TODO(), nodes)
+	if err != nil {
+		c.Log.Error(err, "error listing Nodes to report PowerProfile resource utilization")
+		return
+	}
+
+	pods := &corev1.PodList{}
+	err = c.Client.List(context.TODO(), pods)
+	if err != nil {
+		c.Log.Error(err, "error listing Pods to report PowerProfile resource utilization")
+		return
+	}
+
+	advertised, allocated := profileResourceUsage(nodes.Items, pods.Items)
+	for profile, quantity := range advertised {
+		ch <- prometheus.MustNewConstMetric(profileResourcesAdvertisedDesc, prometheus.GaugeValue, float64(quantity), profile)
+	}
+	for profile, quantity := range allocated {
+		ch <- prometheus.MustNewConstMetric(profileResourcesAllocatedDesc, prometheus.GaugeValue, float64(quantity), profile)
+	}
+}
+
+// profileResourceUsage totals each PowerProfile's extended resources advertised by the Nodes and requested by
+// the Pods scheduled to them. A Pod's request is counted the way the scheduler counts it: the larger of its app
+// containers' total and its largest init container. Pods that have finished no longer hold their resources
+func profileResourceUsage(nodes []corev1.Node, pods []corev1.Pod) (map[string]int64, map[string]int64) {
+	advertised := make(map[string]int64)
+	for _, node := range nodes {
+		resources := node.Status.Allocatable
+		if len(resources) == 0 {
+			resources = node.Status.Capacity
+		}
+		for name, quantity := range resources {
+			if profile, isProfile := profileResourceName(name); isProfile {
+				advertised[profile] += quantity.Value()
+			}
+		}
+	}
+
+	allocated := make(map[string]int64)
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+
+		podRequests := make(map[string]int64)
+		for _, container := range pod.Spec.Containers {
+			for name, quantity := range container.Resources.Requests {
+				if profile, isProfile := profileResourceName(name); isProfile {
+					podRequests[profile] += quantity.Value()
+				}
+			}
+		}
+		for _, container := range pod.Spec.InitContainers {
+			for name, quantity := range container.Resources.Requests {
+				if profile, isProfile := profileResourceName(name); isProfile && quantity.Value() > podRequests[profile] {
+					podRequests[profile] = quantity.Value()
+				}
+			}
+		}
+
+		for profile, quantity := range podRequests {
+			allocated[profile] += quantity
+		}
+	}
+
+	return advertised, allocated
+}
+
+// profileResourceName returns the PowerProfile the extended resource is for, if it is a PowerProfile's resource
+func profileResourceName(name corev1.ResourceName) (string, bool) {
+	if !strings.HasPrefix(string(name), ExtendedResourcePrefix) {
+		return "", false
+	}
+
+	return strings.TrimPrefix(string(name), ExtendedResourcePrefix), true
+}
@@ -0,0 +1,79 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// An update to a Pod's resources can leave it no longer Guaranteed, or leave one of its Containers without a whole
+// number of CPUs, at which point the kubelet hands the Container's exclusive cores back to the shared pool. The cores
+// recorded for such Containers in the State are released so they don't stay on the Container's PowerProfile
+
+// dequalifiedContainer reports whether the named Container is no longer given exclusive CPUs, either because the
+// Pod has left the Guaranteed QoS class or because the Container's CPU request is no longer a whole number
+func dequalifiedContainer(pod *corev1.Pod, containerName string) bool {
+	if pod.Status.QOSClass != corev1.PodQOSGuaranteed {
+		return true
+	}
+
+	containers := append(append([]corev1.Container{}, pod.Spec.Containers...), pod.Spec.InitContainers...)
+	for _, ephemeralContainer := range pod.Spec.EphemeralContainers {
+		containers = append(containers, corev1.Container(ephemeralContainer.EphemeralContainerCommon))
+	}
+	for _, container := range containers {
+		if container.Name == containerName {
+			return !exclusiveCPUs(pod, &container)
+		}
+	}
+
+	return false
+}
+
+// releaseDequalifiedContainers releases the cores of the Containers recorded in the State that are no longer given
+// exclusive CPUs from the PowerWorkloads of their PowerProfiles. A Pod left without any such Containers is dropped
+// from the State entirely
+func (r *PowerPodReconciler) releaseDequalifiedContainers(ctx context.Context, logger logr.Logger, namespace string, pod *corev1.Pod) error {
+	err := r.releaseFinishedContainers(ctx, logger, namespace, pod, "Containers no longer given exclusive CPUs", dequalifiedContainer)
+	if err != nil {
+		return err
+	}
+
+	powerPodState := r.State.GetPodFromState(pod.GetName())
+	if powerPodState.Name == "" || len(powerPodState.Containers) > 0 {
+		return nil
+	}
+
+	logger.Info("Pod is no longer given exclusive CPUs, removing it from internal state", "qosClass", pod.Status.QOSClass)
+	err = r.State.DeletePodFromState(pod.GetName())
+	if err != nil {
+		return err
+	}
+	r.State.DeleteRestartCounts(pod.GetName())
+	r.State.DeleteManagedCores(pod.GetName(), pod.GetNamespace())
+	if r.ReportPowerPods {
+		r.deletePowerPod(ctx, logger, pod.GetNamespace(), pod.GetName())
+	}
+	if r.AnnotateAppliedCPUs {
+		r.annotateAppliedCPUs(ctx, pod, nil)
+	}
+
+	return r.restoreParkedSiblings(pod.GetName())
+}
@@ -0,0 +1,103 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// queueTracker records when each reconcile request was first queued, so the backlog of requests
+// waiting for a worker can be reported. Requests leave the tracker when their reconcile starts
+type queueTracker struct {
+	mutex  sync.Mutex
+	queued map[reconcile.Request]time.Time
+}
+
+func (t *queueTracker) add(req reconcile.Request) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.queued == nil {
+		t.queued = make(map[reconcile.Request]time.Time)
+	}
+
+	// The work queue de-duplicates requests, so only the first add sets the age
+	if _, ok := t.queued[req]; !ok {
+		t.queued[req] = time.Now()
+	}
+}
+
+func (t *queueTracker) done(req reconcile.Request) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	delete(t.queued, req)
+}
+
+// backlog returns the number of requests waiting and how long the oldest of them has waited
+func (t *queueTracker) backlog() (int, time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	oldest := time.Duration(0)
+	for _, queuedAt := range t.queued {
+		if age := time.Since(queuedAt); age > oldest {
+			oldest = age
+		}
+	}
+
+	return len(t.queued), oldest
+}
+
+// queueBackpressureCollector exposes a controller's queued reconcile requests as gauges
+type queueBackpressureCollector struct {
+	tracker   *queueTracker
+	depth     *prometheus.Desc
+	oldestAge *prometheus.Desc
+}
+
+func newQueueBackpressureCollector(controllerName string, tracker *queueTracker) *queueBackpressureCollector {
+	constLabels := prometheus.Labels{"controller": controllerName}
+	return &queueBackpressureCollector{
+		tracker: tracker,
+		depth: prometheus.NewDesc(
+			"power_reconcile_queue_depth",
+			"Number of reconcile requests waiting for a worker",
+			nil, constLabels,
+		),
+		oldestAge: prometheus.NewDesc(
+			"power_reconcile_queue_oldest_item_age_seconds",
+			"How long the oldest waiting reconcile request has been queued",
+			nil, constLabels,
+		),
+	}
+}
+
+func (c *queueBackpressureCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.depth
+	ch <- c.oldestAge
+}
+
+func (c *queueBackpressureCollector) Collect(ch chan<- prometheus.Metric) {
+	depth, oldestAge := c.tracker.backlog()
+	ch <- prometheus.MustNewConstMetric(c.depth, prometheus.GaugeValue, float64(depth))
+	ch <- prometheus.MustNewConstMetric(c.oldestAge, prometheus.GaugeValue, oldestAge.Seconds())
+}
@@ -0,0 +1,131 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RetriesExhaustedAnnotation is set on a Pod that has failed to reconcile RetryBudget times in a row, holding the
+// last error. The Pod is not requeued again until it changes or the annotation is removed
+const RetriesExhaustedAnnotation = "power.intel.com/retries-exhausted"
+
+// podFingerprint summarizes the parts of the Pod whose change warrants another attempt at reconciling it: its
+// generation, phase and annotations, other than those the Node Agent sets itself
+func podFingerprint(pod *corev1.Pod) string {
+	annotations := make([]string, 0, len(pod.GetAnnotations()))
+	for key, value := range pod.GetAnnotations() {
+		if key == RetriesExhaustedAnnotation || key == MultiProfileAnnotation || strings.HasPrefix(key, AppliedCPUsAnnotationPrefix) {
+			continue
+		}
+		annotations = append(annotations, key+"="+value)
+	}
+	sort.Strings(annotations)
+
+	return fmt.Sprintf("%d/%s/%s", pod.GetGeneration(), pod.Status.Phase, strings.Join(annotations, ","))
+}
+
+// retriesExhausted reports whether the Pod has used up its retry budget and is unchanged since, in which case it
+// shouldn't be reconciled. A Pod being deleted is always reconciled so its cores are released
+func (r *PowerPodReconciler) retriesExhausted(ctx context.Context, req ctrl.Request) bool {
+	if r.RetryBudget <= 0 {
+		return false
+	}
+
+	budget, exists := r.State.GetRetryBudget(req.NamespacedName.Name)
+	if !exists || budget.Failures < r.RetryBudget {
+		return false
+	}
+
+	pod := &corev1.Pod{}
+	err := r.Get(ctx, req.NamespacedName, pod)
+	if err != nil || !pod.ObjectMeta.DeletionTimestamp.IsZero() {
+		return false
+	}
+
+	if _, marked := pod.GetAnnotations()[RetriesExhaustedAnnotation]; marked && podFingerprint(pod) == budget.Fingerprint {
+		return true
+	}
+
+	// The Pod has changed, so it gets a fresh budget
+	r.Log.WithValues("powerpod", req.NamespacedName).Info("Pod changed since exhausting its retry budget, retrying")
+	r.State.DeleteRetryBudget(req.NamespacedName.Name)
+	r.setRetriesExhaustedAnnotation(ctx, pod, "")
+	return false
+}
+
+// applyRetryBudget counts the reconcile's outcome against the Pod's retry budget. Once the budget is used up the
+// Pod is marked with a Warning Event and the RetriesExhaustedAnnotation and the error is swallowed so it stops
+// being requeued
+func (r *PowerPodReconciler) applyRetryBudget(ctx context.Context, req ctrl.Request, result ctrl.Result, reconcileErr error) (ctrl.Result, error) {
+	if r.RetryBudget <= 0 {
+		return result, reconcileErr
+	}
+
+	if reconcileErr == nil {
+		r.State.DeleteRetryBudget(req.NamespacedName.Name)
+		return result, nil
+	}
+
+	pod := &corev1.Pod{}
+	err := r.Get(ctx, req.NamespacedName, pod)
+	if err != nil {
+		return result, reconcileErr
+	}
+
+	failures := r.State.RecordReconcileFailure(req.NamespacedName.Name, podFingerprint(pod))
+	if failures < r.RetryBudget {
+		return result, reconcileErr
+	}
+
+	r.Log.WithValues("powerpod", req.NamespacedName).Info("Pod exhausted its retry budget, not requeueing until it changes", "failures", failures, "error", reconcileErr.Error())
+	r.Recorder.Eventf(pod, corev1.EventTypeWarning, "RetryBudgetExhausted", "Pod failed to reconcile %d times in a row and will not be retried until it changes: %v", failures, reconcileErr)
+	r.setRetriesExhaustedAnnotation(ctx, pod, reconcileErr.Error())
+	return ctrl.Result{}, nil
+}
+
+// setRetriesExhaustedAnnotation sets the Pod's RetriesExhaustedAnnotation to the message, or removes it if the message is empty
+func (r *PowerPodReconciler) setRetriesExhaustedAnnotation(ctx context.Context, pod *corev1.Pod, message string) {
+	current, annotated := pod.GetAnnotations()[RetriesExhaustedAnnotation]
+	if (message == "" && !annotated) || (message != "" && current == message) {
+		return
+	}
+
+	patch := client.MergeFrom(pod.DeepCopy())
+	annotations := pod.GetAnnotations()
+	if message != "" {
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[RetriesExhaustedAnnotation] = message
+	} else {
+		delete(annotations, RetriesExhaustedAnnotation)
+	}
+	pod.SetAnnotations(annotations)
+
+	err := r.Patch(ctx, pod, patch)
+	if err != nil {
+		r.Log.Error(err, "error updating Pod's retries-exhausted annotation", "pod", pod.GetName())
+	}
+}
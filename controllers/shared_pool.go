@@ -0,0 +1,124 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/util"
+)
+
+// SharedPoolReconciler keeps the Shared PowerWorkload's UnallocatedCores at the complement of the Node's exclusive
+// cores: every core the Kubelet reports, less the Shared PowerWorkload's reserved CPUs and the cores of the Node's
+// other PowerWorkloads. It works from the PowerWorkloads alone and writes nothing else, leaving the Shared pool in
+// AppQoS and the SharedCores applied to it to the PowerWorkload controller
+type SharedPoolReconciler struct {
+	client.Client
+	Log      logr.Logger
+	NodeName string
+}
+
+func (r *SharedPoolReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	logger := r.Log.WithValues("node", r.NodeName)
+
+	// Every change to a PowerWorkload may move cores in or out of the Shared pool, whichever triggered it
+	workloads := &powerv1alpha1.PowerWorkloadList{}
+	err := r.List(ctx, workloads)
+	if err != nil {
+		logger.Error(err, "error retrieving PowerWorkload list")
+		return ctrl.Result{}, err
+	}
+
+	var sharedWorkload *powerv1alpha1.PowerWorkload
+	exclusiveCPUs := make([]int, 0)
+	for i := range workloads.Items {
+		workload := &workloads.Items[i]
+		if workload.Spec.AllCores {
+			if strings.HasPrefix(workload.Name, "shared-") && (workload.Status.Node == r.NodeName || workload.Spec.Node.Name == r.NodeName) {
+				sharedWorkload = workload
+			}
+			continue
+		}
+
+		if workload.Spec.Node.Name == r.NodeName {
+			exclusiveCPUs = append(exclusiveCPUs, workload.Spec.Node.CpuIds...)
+		}
+	}
+	if sharedWorkload == nil {
+		return ctrl.Result{}, nil
+	}
+
+	node := &corev1.Node{}
+	err = r.Get(ctx, client.ObjectKey{Name: r.NodeName}, node)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+
+		logger.Error(err, "error retrieving Node")
+		return ctrl.Result{}, err
+	}
+
+	nodeCores, reported := kubeletCores(node)
+	if !reported {
+		logger.Info("Node reports no CPU capacity, cannot compute the Shared pool")
+		return ctrl.Result{}, nil
+	}
+
+	sharedCores := sharedPoolComplement(nodeCores, sharedWorkload.Spec.ReservedCPUs, exclusiveCPUs)
+	if sameCPUs(sharedCores, sharedWorkload.Status.UnallocatedCores) {
+		return ctrl.Result{}, nil
+	}
+
+	// Patching only the field this controller owns leaves the PowerWorkload controller's concurrent writes in place
+	logger.Info("Exclusive cores changed, updating Shared PowerWorkload", "workload", sharedWorkload.Name, "unallocatedCores", sharedCores)
+	patch := client.MergeFrom(sharedWorkload.DeepCopy())
+	sharedWorkload.Status.UnallocatedCores = sharedCores
+	err = r.Status().Patch(ctx, sharedWorkload, patch)
+	if err != nil {
+		logger.Error(err, "error updating status of Shared PowerWorkload")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// sharedPoolComplement returns the Node's cores that are neither reserved nor exclusively allocated
+func sharedPoolComplement(nodeCores []int, reservedCPUs []int, exclusiveCPUs []int) []int {
+	sharedCores := util.CPUListDifference(reservedCPUs, nodeCores)
+	sharedCores = util.CPUListDifference(exclusiveCPUs, sharedCores)
+	sort.Ints(sharedCores)
+
+	return sharedCores
+}
+
+func (r *SharedPoolReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("sharedpool").
+		For(&powerv1alpha1.PowerWorkload{}).
+		Complete(r)
+}
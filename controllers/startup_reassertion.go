@@ -0,0 +1,89 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
+)
+
+// StartupReassertion re-applies every PowerProfile and the Node's PowerWorkloads to AppQoS once when the Node
+// Agent starts, so AppQoS converges on the PowerWorkloads after an upgrade, or a change made to AppQoS while the
+// Node Agent was down, without waiting for a Pod to change. The PowerWorkload controller needs ReassertOnStartup
+// set, as otherwise a PowerWorkload whose generation was already applied is left as it is
+type StartupReassertion struct {
+	Client   client.Client
+	Log      logr.Logger
+	NodeName string
+
+	// Profiles and Workloads receive the PowerProfiles and PowerWorkloads to reconcile again. The PowerProfile
+	// and PowerWorkload controllers watch them through their Reapply fields
+	Profiles  chan event.GenericEvent
+	Workloads chan event.GenericEvent
+}
+
+// Start sends the PowerProfiles and PowerWorkloads to be reconciled once, so the reassertion can be run by the manager
+func (s *StartupReassertion) Start(stop <-chan struct{}) error {
+	s.Log.Info("Re-applying PowerProfiles and PowerWorkloads to AppQoS on startup")
+	err := reapplyToNode(context.TODO(), s.Client, s.NodeName, s.Profiles, s.Workloads, stop)
+	if err != nil {
+		s.Log.Error(err, "error re-applying PowerProfiles and PowerWorkloads on startup")
+	}
+
+	return nil
+}
+
+// workloadReassertions records the PowerWorkloads that have been re-applied to AppQoS since the Node Agent started
+type workloadReassertions struct {
+	mutex      sync.Mutex
+	reasserted map[client.ObjectKey]bool
+}
+
+func (w *workloadReassertions) record(key client.ObjectKey) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.reasserted == nil {
+		w.reasserted = make(map[client.ObjectKey]bool)
+	}
+	w.reasserted[key] = true
+}
+
+func (w *workloadReassertions) done(key client.ObjectKey) bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.reasserted[key]
+}
+
+// reassertionPending reports whether the PowerWorkload is still to be re-applied to AppQoS since the Node Agent started
+func (r *PowerWorkloadReconciler) reassertionPending(workload *powerv1alpha1.PowerWorkload) bool {
+	return r.ReassertOnStartup && !r.reassertions.done(client.ObjectKey{Namespace: workload.Namespace, Name: workload.Name})
+}
+
+// recordReassertion marks the PowerWorkload as re-applied to AppQoS since the Node Agent started
+func (r *PowerWorkloadReconciler) recordReassertion(workload *powerv1alpha1.PowerWorkload) {
+	if r.ReassertOnStartup {
+		r.reassertions.record(client.ObjectKey{Namespace: workload.Namespace, Name: workload.Name})
+	}
+}
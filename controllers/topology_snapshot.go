@@ -0,0 +1,171 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/podstate"
+)
+
+// TopologySnapshotPath is the path on the metrics server the topology snapshot is served from
+const TopologySnapshotPath = "/snapshot"
+
+// TopologySnapshot is a point-in-time document of the power topology, for dashboards and offline analysis
+type TopologySnapshot struct {
+	Timestamp time.Time          `json:"timestamp"`
+	Nodes     []NodeSnapshot     `json:"nodes"`
+	Profiles  []ProfileSnapshot  `json:"profiles"`
+	Workloads []WorkloadSnapshot `json:"workloads"`
+	Cores     []CoreAssignment   `json:"cores"`
+}
+
+// NodeSnapshot is a PowerNode and the cores in its Shared pool
+type NodeSnapshot struct {
+	Name           string `json:"name"`
+	SharedCores    []int  `json:"sharedCores"`
+	GuaranteedPods int    `json:"guaranteedPods"`
+}
+
+// ProfileSnapshot is a PowerProfile's settings
+type ProfileSnapshot struct {
+	Name     string `json:"name"`
+	Min      int    `json:"min"`
+	Max      int    `json:"max"`
+	Epp      string `json:"epp"`
+	Governor string `json:"governor,omitempty"`
+}
+
+// WorkloadSnapshot is a PowerWorkload and the cores it applies its PowerProfile to
+type WorkloadSnapshot struct {
+	Name    string `json:"name"`
+	Node    string `json:"node"`
+	Profile string `json:"profile"`
+	Cores   []int  `json:"cores"`
+}
+
+// CoreAssignment is a single exclusive core and the Container it is assigned to
+type CoreAssignment struct {
+	Node      string `json:"node"`
+	Core      int    `json:"core"`
+	Profile   string `json:"profile"`
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+}
+
+// BuildTopologySnapshot aggregates the PowerNodes, PowerProfiles and PowerWorkloads from the client with the
+// per-core assignments of the Guaranteed Pods held in the State
+func BuildTopologySnapshot(ctx context.Context, c client.Reader, state *podstate.State) (*TopologySnapshot, error) {
+	snapshot := &TopologySnapshot{
+		Timestamp: time.Now().UTC(),
+		Nodes:     make([]NodeSnapshot, 0),
+		Profiles:  make([]ProfileSnapshot, 0),
+		Workloads: make([]WorkloadSnapshot, 0),
+		Cores:     make([]CoreAssignment, 0),
+	}
+
+	nodes := &powerv1alpha1.PowerNodeList{}
+	err := c.List(ctx, nodes)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes.Items {
+		snapshot.Nodes = append(snapshot.Nodes, NodeSnapshot{
+			Name:           node.Spec.NodeName,
+			SharedCores:    node.Status.PowerNodeCPUState.SharedPool,
+			GuaranteedPods: len(node.Status.PowerNodeCPUState.GuaranteedPods),
+		})
+	}
+
+	profiles := &powerv1alpha1.PowerProfileList{}
+	err = c.List(ctx, profiles)
+	if err != nil {
+		return nil, err
+	}
+	for _, profile := range profiles.Items {
+		snapshot.Profiles = append(snapshot.Profiles, ProfileSnapshot{
+			Name:     profile.Spec.Name,
+			Min:      profile.Spec.Min,
+			Max:      profile.Spec.Max,
+			Epp:      profile.Spec.Epp,
+			Governor: profile.Spec.Governor,
+		})
+	}
+
+	workloads := &powerv1alpha1.PowerWorkloadList{}
+	err = c.List(ctx, workloads)
+	if err != nil {
+		return nil, err
+	}
+	for _, workload := range workloads.Items {
+		workloadSnapshot := WorkloadSnapshot{
+			Name:    workload.Name,
+			Node:    workload.Spec.Node.Name,
+			Profile: workload.Spec.PowerProfile,
+			Cores:   workload.Spec.Node.CpuIds,
+		}
+		// The Shared PowerWorkload's cores are only known once it has been applied to its Node
+		if workload.Spec.AllCores {
+			workloadSnapshot.Node = workload.Status.Node
+			workloadSnapshot.Cores = workload.Status.SharedCores
+		}
+		snapshot.Workloads = append(snapshot.Workloads, workloadSnapshot)
+	}
+
+	for _, pod := range state.GetGuaranteedPods() {
+		for _, container := range pod.Containers {
+			for _, core := range container.ExclusiveCPUs {
+				snapshot.Cores = append(snapshot.Cores, CoreAssignment{
+					Node:      pod.Node,
+					Core:      core,
+					Profile:   container.PowerProfile,
+					Pod:       pod.Name,
+					Container: container.Name,
+				})
+			}
+		}
+	}
+	sort.Slice(snapshot.Cores, func(i, j int) bool {
+		if snapshot.Cores[i].Node != snapshot.Cores[j].Node {
+			return snapshot.Cores[i].Node < snapshot.Cores[j].Node
+		}
+		return snapshot.Cores[i].Core < snapshot.Cores[j].Core
+	})
+
+	return snapshot, nil
+}
+
+// NewTopologySnapshotHandler serves the topology snapshot as JSON
+func NewTopologySnapshotHandler(c client.Reader, state *podstate.State) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		snapshot, err := BuildTopologySnapshot(r.Context(), c, state)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(snapshot)
+	})
+}
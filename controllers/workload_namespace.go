@@ -0,0 +1,149 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
+)
+
+// workloadNamespace returns the namespace the PowerWorkloads of a Pod in the namespace are kept in
+func (r *PowerPodReconciler) workloadNamespace(podNamespace string) string {
+	if r.WorkloadNamespace != "" {
+		return r.WorkloadNamespace
+	}
+
+	return podNamespace
+}
+
+// workloadNamespace returns the namespace the PowerWorkloads of a PowerProfile in the namespace are kept in
+func (r *PowerProfileReconciler) workloadNamespace(profileNamespace string) string {
+	if r.WorkloadNamespace != "" {
+		return r.WorkloadNamespace
+	}
+
+	return profileNamespace
+}
+
+// migrateWorkloadNamespace moves a PowerWorkload left in a Pod's namespace into the WorkloadNamespace, merging
+// its Containers and cores into any PowerWorkload already there for the same Node. The copy keeps the original's
+// status, so its Pool in AppQoS is taken over as it is rather than recreated. The State records PowerWorkloads by
+// name alone, so it holds good for the moved PowerWorkload without changes
+func (r *PowerWorkloadReconciler) migrateWorkloadNamespace(logger logr.Logger, workload *powerv1alpha1.PowerWorkload) error {
+	logger = logger.WithValues("workloadNamespace", r.WorkloadNamespace)
+
+	migrated := &powerv1alpha1.PowerWorkload{}
+	err := r.Client.Get(context.TODO(), client.ObjectKey{
+		Namespace: r.WorkloadNamespace,
+		Name:      workload.Name,
+	}, migrated)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+
+		migrated = &powerv1alpha1.PowerWorkload{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   r.WorkloadNamespace,
+				Name:        workload.Name,
+				Labels:      workload.Labels,
+				Annotations: workload.Annotations,
+			},
+			Spec: workload.Spec,
+		}
+		err = r.Client.Create(context.TODO(), migrated)
+		if err != nil {
+			return err
+		}
+
+		migrated.Status = workload.Status
+		migrated.Status.ObservedGeneration = 0
+		err = r.Client.Status().Update(context.TODO(), migrated)
+		if err != nil {
+			return err
+		}
+
+		logger.Info("moved PowerWorkload into the workload namespace")
+	} else {
+		if migrated.Spec.Node.Name != workload.Spec.Node.Name {
+			return errors.NewServiceUnavailable(fmt.Sprintf("PowerWorkload '%s' in the workload namespace is for Node '%s', not '%s'", workload.Name, migrated.Spec.Node.Name, workload.Spec.Node.Name))
+		}
+
+		migrated.Spec.Node.CpuIds = appendIfUnique(migrated.Spec.Node.CpuIds, workload.Spec.Node.CpuIds)
+		for _, container := range workload.Spec.Node.Containers {
+			if !workloadHasContainer(migrated.Spec.Node.Containers, container) {
+				migrated.Spec.Node.Containers = append(migrated.Spec.Node.Containers, container)
+			}
+		}
+		err = r.Client.Update(context.TODO(), migrated)
+		if err != nil {
+			return err
+		}
+
+		logger.Info("merged PowerWorkload into the one already in the workload namespace")
+	}
+
+	return r.Client.Delete(context.TODO(), workload)
+}
+
+// workloadMigrated reports whether a PowerWorkload of the name is in the WorkloadNamespace, in which case a
+// PowerWorkload of the same name deleted from another namespace was moved there and its Pool now belongs to the move
+func (r *PowerWorkloadReconciler) workloadMigrated(name string) (bool, error) {
+	err := r.Client.Get(context.TODO(), client.ObjectKey{
+		Namespace: r.WorkloadNamespace,
+		Name:      name,
+	}, &powerv1alpha1.PowerWorkload{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// workloadHasContainer checks whether the Container is already among the PowerWorkload's. Pods in different
+// namespaces can share a name, so Containers recorded with their Pod's UID are matched on it instead
+func workloadHasContainer(containers []powerv1alpha1.Container, container powerv1alpha1.Container) bool {
+	for _, existing := range containers {
+		if existing.Name != container.Name {
+			continue
+		}
+
+		if existing.PodUID != "" && container.PodUID != "" {
+			if existing.PodUID == container.PodUID {
+				return true
+			}
+			continue
+		}
+
+		if existing.Pod == container.Pod {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,123 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
+)
+
+// WorkloadReadOnlyCondition is set on a PowerWorkload when the Node Agent is not permitted to write to it
+const WorkloadReadOnlyCondition = "ReadOnly"
+
+// WorkloadReadOnlyRetryInterval is how long the PowerPod controller stays in read-only reporting mode
+// after a PowerWorkload write is forbidden before attempting writes again
+var WorkloadReadOnlyRetryInterval = 5 * time.Minute
+
+// writeWorkload performs a create, update or delete of a PowerWorkload. If RBAC denies the write the controller
+// falls back to read-only reporting, logging the intended change rather than hot-looping on the forbidden error.
+// The returned bool is true only if the write was made
+func (r *PowerPodReconciler) writeWorkload(ctx context.Context, logger logr.Logger, action string, workload *powerv1alpha1.PowerWorkload, write func() error) (bool, error) {
+	if r.workloadWritesReadOnly() {
+		logger.Info("Read-only mode, skipping PowerWorkload write", "action", action, "workload", workload.Name, "cpus", workload.Spec.Node.CpuIds)
+		return false, nil
+	}
+
+	err := write()
+	if err == nil {
+		// A deleted PowerWorkload created again needn't wait for the interval since its last write
+		workloadKey := client.ObjectKey{Namespace: workload.Namespace, Name: workload.Name}
+		if action == "delete" {
+			r.workloadWrites.forget(workloadKey)
+		} else {
+			r.workloadWrites.record(workloadKey, time.Now())
+			recordWorkloadCores(workload)
+		}
+		// A ReadOnly condition left from an earlier forbidden write no longer holds once a write succeeds
+		if action != "delete" && meta.IsStatusConditionTrue(workload.Status.Conditions, WorkloadReadOnlyCondition) {
+			r.setReadOnlyCondition(ctx, logger, workload, metav1.ConditionFalse, "WritePermitted", "Node Agent is permitted to write this PowerWorkload")
+		}
+		return true, nil
+	}
+	if !errors.IsForbidden(err) {
+		return false, err
+	}
+
+	logger.Error(err, "not permitted to write PowerWorkloads, switching to read-only mode", "retryIn", WorkloadReadOnlyRetryInterval.String())
+	logger.Info("Read-only mode, skipping PowerWorkload write", "action", action, "workload", workload.Name, "cpus", workload.Spec.Node.CpuIds)
+	r.readOnlyMutex.Lock()
+	r.workloadWritesForbiddenUntil = time.Now().Add(WorkloadReadOnlyRetryInterval)
+	r.readOnlyMutex.Unlock()
+
+	if action != "create" {
+		// The status subresource has separate permissions, so the condition can still be reported
+		r.setReadOnlyCondition(ctx, logger, workload, metav1.ConditionTrue, "WriteForbidden", fmt.Sprintf("Node Agent is not permitted to %s this PowerWorkload: %v", action, err))
+	}
+
+	return false, nil
+}
+
+// setReadOnlyCondition sets the ReadOnly condition on the PowerWorkload's status, if permitted
+func (r *PowerPodReconciler) setReadOnlyCondition(ctx context.Context, logger logr.Logger, workload *powerv1alpha1.PowerWorkload, status metav1.ConditionStatus, reason string, message string) {
+	current := &powerv1alpha1.PowerWorkload{}
+	err := r.Client.Get(ctx, client.ObjectKey{
+		Namespace: workload.Namespace,
+		Name:      workload.Name,
+	}, current)
+	if err != nil {
+		logger.Error(err, "error retrieving PowerWorkload to report read-only condition")
+		return
+	}
+
+	meta.SetStatusCondition(&current.Status.Conditions, metav1.Condition{
+		Type:    WorkloadReadOnlyCondition,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+	err = r.Client.Status().Update(ctx, current)
+	if err != nil {
+		logger.Error(err, "error reporting read-only condition on PowerWorkload")
+	}
+}
+
+func (r *PowerPodReconciler) workloadWritesReadOnly() bool {
+	r.readOnlyMutex.Lock()
+	defer r.readOnlyMutex.Unlock()
+	return time.Now().Before(r.workloadWritesForbiddenUntil)
+}
+
+// readOnlyResult requeues the request for when read-only mode ends so the skipped writes are retried
+func (r *PowerPodReconciler) readOnlyResult() ctrl.Result {
+	r.readOnlyMutex.Lock()
+	defer r.readOnlyMutex.Unlock()
+	if !time.Now().Before(r.workloadWritesForbiddenUntil) {
+		return ctrl.Result{}
+	}
+
+	return ctrl.Result{RequeueAfter: time.Until(r.workloadWritesForbiddenUntil)}
+}
@@ -0,0 +1,156 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
+)
+
+// WorkloadNodesAnnotation lists, comma-separated, the Nodes whose Pods have cores in a PowerWorkload
+const WorkloadNodesAnnotation = "power.intel.com/nodes"
+
+// shardWorkloadName returns the name of a PowerProfile's PowerWorkload shard. The first shard keeps the
+// unsharded name so PowerWorkloads created before sharding was enabled carry on being used
+func shardWorkloadName(profileName string, shard int) string {
+	if shard == 0 {
+		return fmt.Sprintf("%s%s", profileName, WorkloadNameSuffix)
+	}
+
+	return fmt.Sprintf("%s-shard-%d%s", profileName, shard, WorkloadNameSuffix)
+}
+
+// containerWorkloadName returns the PowerWorkload the Container's cores were added to, falling back to the
+// unsharded PowerWorkload of the PowerProfile for Containers recorded before sharding
+func containerWorkloadName(container powerv1alpha1.Container, profileName string) string {
+	if container.Workload != "" {
+		return container.Workload
+	}
+
+	return shardWorkloadName(profileName, 0)
+}
+
+// workloadNodes returns the Nodes recorded as having cores in the PowerWorkload
+func workloadNodes(workload *powerv1alpha1.PowerWorkload) []string {
+	nodes := workload.GetAnnotations()[WorkloadNodesAnnotation]
+	if nodes == "" {
+		return []string{}
+	}
+
+	return strings.Split(nodes, ",")
+}
+
+// setWorkloadNode adds the Node to, or removes it from, the Nodes recorded on the PowerWorkload
+func setWorkloadNode(workload *powerv1alpha1.PowerWorkload, nodeName string, present bool) {
+	nodes := make([]string, 0)
+	for _, node := range workloadNodes(workload) {
+		if node != nodeName {
+			nodes = append(nodes, node)
+		}
+	}
+	if present {
+		nodes = append(nodes, nodeName)
+	}
+	sort.Strings(nodes)
+
+	annotations := workload.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	if len(nodes) == 0 {
+		delete(annotations, WorkloadNodesAnnotation)
+	} else {
+		annotations[WorkloadNodesAnnotation] = strings.Join(nodes, ",")
+	}
+	workload.SetAnnotations(annotations)
+}
+
+// maxShardSelectionAttempts bounds how many times a shard is chosen again after it changed while being written
+const maxShardSelectionAttempts = 5
+
+// selectWorkloadShard returns the PowerWorkload of the PowerProfile the Node's cores belong in. A PowerWorkload's
+// NodeInfo describes a single Node, so shards are keyed per Node: a Node stays in the shard naming it, otherwise
+// it takes the lowest numbered shard that names no Node, creating it if needed. Shards found taken by another
+// Node since they were listed are passed over
+func (r *PowerPodReconciler) selectWorkloadShard(ctx context.Context, namespace string, profileName string, nodeName string, takenShards map[string]bool) (string, error) {
+	if r.MaxWorkloadNodes <= 0 {
+		return shardWorkloadName(profileName, 0), nil
+	}
+
+	workloadList := &powerv1alpha1.PowerWorkloadList{}
+	err := r.Client.List(ctx, workloadList, client.InNamespace(namespace))
+	if err != nil {
+		return "", err
+	}
+	workloads := make(map[string]*powerv1alpha1.PowerWorkload)
+	for i := range workloadList.Items {
+		workloads[workloadList.Items[i].Name] = &workloadList.Items[i]
+	}
+
+	// Shards are numbered contiguously but one can be deleted once empty, so every possible shard is checked
+	maxShard := len(workloadList.Items) + len(takenShards)
+	for shard := 0; shard <= maxShard; shard++ {
+		workload, exists := workloads[shardWorkloadName(profileName, shard)]
+		if !exists || takenShards[workload.Name] {
+			continue
+		}
+		if workload.Spec.Node.Name == nodeName {
+			return workload.Name, nil
+		}
+		for _, node := range workloadNodes(workload) {
+			if node == nodeName {
+				return workload.Name, nil
+			}
+		}
+	}
+
+	for shard := 0; ; shard++ {
+		workloadName := shardWorkloadName(profileName, shard)
+		if takenShards[workloadName] {
+			continue
+		}
+
+		workload, exists := workloads[workloadName]
+		if !exists || (workload.Spec.Node.Name == "" && len(workloadNodes(workload)) == 0) {
+			return workloadName, nil
+		}
+	}
+}
+
+// nodeUsesWorkload reports whether any Pod in the State, other than those being released, still has cores in
+// the PowerWorkload. The State only holds this Node's Pods
+func (r *PowerPodReconciler) nodeUsesWorkload(workloadName string, releasedUIDs map[string]bool) bool {
+	for _, pod := range r.State.GetGuaranteedPods() {
+		if releasedUIDs[pod.UID] {
+			continue
+		}
+
+		for _, container := range pod.Containers {
+			if container.Workload == workloadName {
+				return true
+			}
+		}
+	}
+
+	return false
+}
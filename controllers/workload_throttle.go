@@ -0,0 +1,409 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/util"
+)
+
+// pendingPodWrite is a Pod's change to a PowerWorkload held back by MinWorkloadWriteInterval
+type pendingPodWrite struct {
+	pod             *corev1.Pod
+	profileName     string
+	cores           []int
+	powerContainers []powerv1alpha1.Container
+	profiles        []powerv1alpha1.PowerProfile
+}
+
+func (w pendingPodWrite) podKey() types.NamespacedName {
+	return types.NamespacedName{Namespace: w.pod.GetNamespace(), Name: w.pod.GetName()}
+}
+
+// heldPodState is what is recorded for a Pod once its held changes have been written
+type heldPodState struct {
+	pod             *corev1.Pod
+	powerContainers []powerv1alpha1.Container
+}
+
+// workloadWriteQueue records when each PowerWorkload was last written and holds the Pod changes arriving within
+// MinWorkloadWriteInterval of it, so that they are written together once the interval has passed. A Pod with a
+// change held isn't recorded in the State until the change has been written
+type workloadWriteQueue struct {
+	mutex    sync.Mutex
+	written  map[client.ObjectKey]time.Time
+	pending  map[client.ObjectKey][]pendingPodWrite
+	flushing map[client.ObjectKey][]pendingPodWrite
+	held     map[types.NamespacedName]heldPodState
+	flushes  sync.WaitGroup
+}
+
+func (q *workloadWriteQueue) record(workloadKey client.ObjectKey, writtenAt time.Time) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.written == nil {
+		q.written = make(map[client.ObjectKey]time.Time)
+	}
+	q.written[workloadKey] = writtenAt
+}
+
+// forget drops the record of a deleted PowerWorkload's last write
+func (q *workloadWriteQueue) forget(workloadKey client.ObjectKey) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	delete(q.written, workloadKey)
+}
+
+// remaining returns how long until the PowerWorkload may be written again
+func (q *workloadWriteQueue) remaining(workloadKey client.ObjectKey, interval time.Duration) time.Duration {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	writtenAt, exists := q.written[workloadKey]
+	if !exists {
+		return 0
+	}
+
+	return interval - time.Since(writtenAt)
+}
+
+// drop discards the changes held for the Pod, which are superseded once it is reconciled again or deleted
+func (q *workloadWriteQueue) drop(podKey types.NamespacedName) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for workloadKey, writes := range q.pending {
+		q.pending[workloadKey] = withoutPodWrite(writes, podKey)
+	}
+	delete(q.held, podKey)
+}
+
+// holdPodState keeps what is to be recorded for the Pod until its changes are written, returning false if none
+// of its changes are held, in which case it can be recorded straight away
+func (q *workloadWriteQueue) holdPodState(podKey types.NamespacedName, pod *corev1.Pod, powerContainers []powerv1alpha1.Container) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if !q.holdsPodLocked(podKey) {
+		return false
+	}
+
+	if q.held == nil {
+		q.held = make(map[types.NamespacedName]heldPodState)
+	}
+	q.held[podKey] = heldPodState{
+		pod:             pod.DeepCopy(),
+		powerContainers: append([]powerv1alpha1.Container{}, powerContainers...),
+	}
+	return true
+}
+
+// releasePodState returns what is to be recorded for the Pod once none of its changes are held or being written
+func (q *workloadWriteQueue) releasePodState(podKey types.NamespacedName) (heldPodState, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	state, held := q.held[podKey]
+	if !held || q.holdsPodLocked(podKey) {
+		return heldPodState{}, false
+	}
+
+	delete(q.held, podKey)
+	return state, true
+}
+
+// holdsPodLocked reports whether any of the Pod's changes are held or being written. The caller must hold the
+// queue's mutex
+func (q *workloadWriteQueue) holdsPodLocked(podKey types.NamespacedName) bool {
+	for _, queued := range []map[client.ObjectKey][]pendingPodWrite{q.pending, q.flushing} {
+		for _, writes := range queued {
+			for _, write := range writes {
+				if write.podKey() == podKey {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// wait blocks until every scheduled flush has completed
+func (q *workloadWriteQueue) wait() {
+	q.flushes.Wait()
+}
+
+// withoutPodWrite returns the held changes less any of the Pod's
+func withoutPodWrite(writes []pendingPodWrite, podKey types.NamespacedName) []pendingPodWrite {
+	remaining := make([]pendingPodWrite, 0, len(writes))
+	for _, write := range writes {
+		if write.podKey() != podKey {
+			remaining = append(remaining, write)
+		}
+	}
+
+	return remaining
+}
+
+// queueWorkloadWrite holds the Pod's change to the PowerWorkload in place of any of the Pod's already held,
+// scheduling a flush for when the PowerWorkload may next be written if this is the first change held for it
+func (r *PowerPodReconciler) queueWorkloadWrite(logger logr.Logger, workloadKey client.ObjectKey, write pendingPodWrite, delay time.Duration) {
+	q := &r.workloadWrites
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.pending == nil {
+		q.pending = make(map[client.ObjectKey][]pendingPodWrite)
+	}
+
+	writes, scheduled := q.pending[workloadKey]
+	if !scheduled {
+		r.scheduleWorkloadFlush(logger, workloadKey, delay)
+	}
+
+	q.pending[workloadKey] = append(withoutPodWrite(writes, write.podKey()), write)
+}
+
+// scheduleWorkloadFlush writes the changes held for the PowerWorkload after the delay. The caller must hold the
+// queue's mutex
+func (r *PowerPodReconciler) scheduleWorkloadFlush(logger logr.Logger, workloadKey client.ObjectKey, delay time.Duration) {
+	q := &r.workloadWrites
+	q.flushes.Add(1)
+	time.AfterFunc(delay, func() {
+		defer q.flushes.Done()
+		r.flushWorkloadWrites(logger, workloadKey)
+	})
+}
+
+// flushWorkloadWrites writes every change held for the PowerWorkload as a single update, then records the Pods
+// left with no change held. Changes that couldn't be written, including while in read-only mode, are held again
+// and retried after the interval
+func (r *PowerPodReconciler) flushWorkloadWrites(logger logr.Logger, workloadKey client.ObjectKey) {
+	q := &r.workloadWrites
+	q.mutex.Lock()
+	writes := q.pending[workloadKey]
+	delete(q.pending, workloadKey)
+	if len(writes) == 0 {
+		q.mutex.Unlock()
+		return
+	}
+
+	// The Pods being written are still held, so a reconcile of one meanwhile doesn't record it ahead of the write
+	if q.flushing == nil {
+		q.flushing = make(map[client.ObjectKey][]pendingPodWrite)
+	}
+	q.flushing[workloadKey] = writes
+	q.mutex.Unlock()
+
+	ctx := context.Background()
+	err := r.writeHeldPods(ctx, logger, workloadKey, writes)
+	if err == nil && !r.workloadWritesReadOnly() {
+		r.finishWorkloadFlush(workloadKey)
+		for _, write := range writes {
+			r.recordHeldPodState(ctx, logger, write.podKey())
+		}
+		return
+	}
+	if err != nil {
+		logger.Error(err, "error writing held Pod changes to PowerWorkload, retrying", "workload", workloadKey.Name, "pods", len(writes), "retryAfter", r.MinWorkloadWriteInterval.String())
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	delete(q.flushing, workloadKey)
+
+	// A Pod changed again since this flush started replaces its change written here
+	pending, scheduled := q.pending[workloadKey]
+	for _, write := range pending {
+		writes = withoutPodWrite(writes, write.podKey())
+	}
+	if !scheduled {
+		r.scheduleWorkloadFlush(logger, workloadKey, r.MinWorkloadWriteInterval)
+	}
+	q.pending[workloadKey] = append(writes, pending...)
+}
+
+// finishWorkloadFlush marks the changes being written to the PowerWorkload as no longer in flight
+func (r *PowerPodReconciler) finishWorkloadFlush(workloadKey client.ObjectKey) {
+	q := &r.workloadWrites
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	delete(q.flushing, workloadKey)
+}
+
+// recordHeldPodState records the Pod whose changes have now all been written
+func (r *PowerPodReconciler) recordHeldPodState(ctx context.Context, logger logr.Logger, podKey types.NamespacedName) {
+	state, released := r.workloadWrites.releasePodState(podKey)
+	if !released {
+		return
+	}
+
+	err := r.recordPodState(ctx, logger.WithValues("pod", podKey), state.pod, state.powerContainers)
+	if err != nil {
+		logger.Error(err, "error recording Pod once its held changes were written", "pod", podKey)
+	}
+}
+
+// writeHeldPods applies the held changes to the PowerWorkload in one update. A PowerWorkload deleted while they
+// were held is created again by the first change, the others being held in turn
+func (r *PowerPodReconciler) writeHeldPods(ctx context.Context, logger logr.Logger, workloadKey client.ObjectKey, writes []pendingPodWrite) error {
+	r.workloadLocks.lock(workloadKey)
+	workload := &powerv1alpha1.PowerWorkload{}
+	err := r.Client.Get(ctx, workloadKey, workload)
+	if err == nil {
+		defer r.workloadLocks.unlock(workloadKey)
+		return r.updateWorkloadPods(ctx, logger, workload, writes)
+	}
+	r.workloadLocks.unlock(workloadKey)
+	if !errors.IsNotFound(err) {
+		return err
+	}
+
+	for _, write := range writes {
+		err = r.addPodToWorkload(ctx, logger, workloadKey.Namespace, write.pod, write.profileName, write.cores, write.powerContainers, write.profiles)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// updateWorkloadPods applies each Pod's change to the PowerWorkload and writes it in a single update
+func (r *PowerPodReconciler) updateWorkloadPods(ctx context.Context, logger logr.Logger, workload *powerv1alpha1.PowerWorkload, writes []pendingPodWrite) error {
+	// A PowerWorkload whose NodeInfo was cleared once its Node emptied takes the Node back, as the PowerWorkload
+	// controller only applies the re-added cores to AppQoS on the Node the NodeInfo names
+	if workload.Spec.Node.Name == "" {
+		logger.Info("Re-adding Node to emptied PowerWorkload", "workload", workload.Name, "node", writes[0].pod.Spec.NodeName)
+	}
+
+	removed := make([][]int, len(writes))
+	added := make([][]int, len(writes))
+	for i, write := range writes {
+		removed[i], added[i] = applyPodToWorkload(workload, write)
+	}
+
+	written, err := r.writeWorkload(ctx, logger, "update", workload, func() error {
+		return r.Client.Update(ctx, workload)
+	})
+	if err != nil {
+		logger.Error(err, "error while trying to update PowerWorkload")
+		return err
+	}
+	if !written {
+		return nil
+	}
+
+	for i, write := range writes {
+		pod := write.pod
+		podUID := string(pod.GetUID())
+		removedCPUs, addedCPUs := removed[i], added[i]
+		if len(removedCPUs) > 0 {
+			logger.Info("Pod's cpuset changed, removed cores it no longer holds from PowerWorkload", "workload", workload.Name, "cpus", removedCPUs)
+			recordCPUs(cpuReleasesTotal, pod.Spec.NodeName, write.profileName, podUID, len(removedCPUs))
+			r.AllocationNotifier.notify(AllocationEventReleased, pod.Spec.NodeName, write.profileName, podUID, removedCPUs)
+		}
+		recordCPUs(cpuAllocationsTotal, pod.Spec.NodeName, write.profileName, podUID, len(addedCPUs))
+		r.AllocationNotifier.notify(AllocationEventAllocated, pod.Spec.NodeName, write.profileName, podUID, addedCPUs)
+		r.recordDecision(DecisionRecord{
+			Action:         "update",
+			Workload:       workload.Name,
+			Namespace:      workload.Namespace,
+			Node:           pod.Spec.NodeName,
+			Profile:        write.profileName,
+			PodName:        pod.Name,
+			PodUID:         podUID,
+			AllocatedCores: addedCPUs,
+			ReleasedCores:  removedCPUs,
+			WorkloadCores:  workload.Spec.Node.CpuIds,
+		})
+	}
+
+	return nil
+}
+
+// applyPodToWorkload updates the PowerWorkload's Spec with the Pod's current cores and Containers, returning the
+// cores removed from and added to it. If the Node already exists in the Workload, we update the Node's CPU list,
+// if not we create the entry for the node
+func applyPodToWorkload(workload *powerv1alpha1.PowerWorkload, write pendingPodWrite) ([]int, []int) {
+	pod := write.pod
+	if workload.Spec.Node.Name == "" {
+		workload.Spec.Node.Name = pod.Spec.NodeName
+	}
+
+	// If the Pod is already in the PowerWorkload it has been updated, and its cpuset may have changed since. The
+	// Pod's current cores across all its Containers are its target set: cores it no longer holds are removed,
+	// new ones added and its previous entries replaced, so the PowerWorkload matches it in this one update
+	previousCPUs, workloadContainers := splitPodContainers(workload.Spec.Node.Containers, pod.Name)
+	removedCPUs := util.CPUListDifference(write.cores, previousCPUs)
+	addedCPUs := util.CPUListDifference(workload.Spec.Node.CpuIds, write.cores)
+	workload.Spec.Node.CpuIds = appendIfUnique(getNewWorkloadCPUList(removedCPUs, workload.Spec.Node.CpuIds), write.cores)
+	sort.Ints(workload.Spec.Node.CpuIds)
+
+	for _, container := range write.powerContainers {
+		workloadContainer := container
+		workloadContainer.Pod = pod.Name
+		workloadContainer.PodUID = string(pod.GetUID())
+		workloadContainers = append(workloadContainers, workloadContainer)
+	}
+	workload.Spec.Node.Containers = workloadContainers
+	setWorkloadNode(workload, pod.Spec.NodeName, true)
+	applyProfileFamily(workload, write.profiles)
+
+	return removedCPUs, addedCPUs
+}
+
+// podChangesWorkload reports whether applying the Pod's change would alter the PowerWorkload's Spec
+func podChangesWorkload(workload *powerv1alpha1.PowerWorkload, write pendingPodWrite) bool {
+	updated := workload.DeepCopy()
+	applyPodToWorkload(updated, write)
+
+	return !reflect.DeepEqual(updated.Spec, workload.Spec)
+}
+
+// splitPodContainers separates the PowerWorkload's entries for the Pod's Containers from those of every other
+// Container, returning the cores the Pod's Containers were previously recorded with alongside the other entries.
+// Entries of the Pod's Containers that no longer hold cores in the PowerWorkload are included, so they are dropped
+func splitPodContainers(workloadContainers []powerv1alpha1.Container, podName string) ([]int, []powerv1alpha1.Container) {
+	previousCPUs := make([]int, 0)
+	otherContainers := make([]powerv1alpha1.Container, 0)
+	for _, container := range workloadContainers {
+		if container.Pod == podName {
+			previousCPUs = append(previousCPUs, container.ExclusiveCPUs...)
+			continue
+		}
+
+		otherContainers = append(otherContainers, container)
+	}
+
+	return previousCPUs, otherContainers
+}
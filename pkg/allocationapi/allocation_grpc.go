@@ -0,0 +1,82 @@
+package allocationapi
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const serviceName = "power.allocation.v1.AllocationService"
+
+// AllocationServiceClient is the client API for the AllocationService
+type AllocationServiceClient interface {
+	GetAllocations(ctx context.Context, in *GetAllocationsRequest, opts ...grpc.CallOption) (*GetAllocationsResponse, error)
+}
+
+type allocationServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+// NewAllocationServiceClient returns a client of the AllocationService served over the connection
+func NewAllocationServiceClient(cc *grpc.ClientConn) AllocationServiceClient {
+	return &allocationServiceClient{cc}
+}
+
+func (c *allocationServiceClient) GetAllocations(ctx context.Context, in *GetAllocationsRequest, opts ...grpc.CallOption) (*GetAllocationsResponse, error) {
+	out := new(GetAllocationsResponse)
+	err := c.cc.Invoke(ctx, "/"+serviceName+"/GetAllocations", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AllocationServiceServer is the server API for the AllocationService
+type AllocationServiceServer interface {
+	GetAllocations(context.Context, *GetAllocationsRequest) (*GetAllocationsResponse, error)
+}
+
+// UnimplementedAllocationServiceServer can be embedded to have forward compatible implementations
+type UnimplementedAllocationServiceServer struct{}
+
+func (*UnimplementedAllocationServiceServer) GetAllocations(ctx context.Context, req *GetAllocationsRequest) (*GetAllocationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAllocations not implemented")
+}
+
+// RegisterAllocationServiceServer registers the implementation of the AllocationService with the gRPC server
+func RegisterAllocationServiceServer(s *grpc.Server, srv AllocationServiceServer) {
+	s.RegisterService(&allocationServiceDesc, srv)
+}
+
+func getAllocationsHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAllocationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AllocationServiceServer).GetAllocations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/" + serviceName + "/GetAllocations",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AllocationServiceServer).GetAllocations(ctx, req.(*GetAllocationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var allocationServiceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*AllocationServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetAllocations",
+			Handler:    getAllocationsHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "allocation.proto",
+}
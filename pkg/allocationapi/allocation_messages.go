@@ -0,0 +1,59 @@
+// Hand-maintained to match allocation.proto rather than generated by protoc. Keep the struct tags in step with
+// the field numbers there.
+
+package allocationapi
+
+import (
+	proto "github.com/golang/protobuf/proto"
+)
+
+type GetAllocationsRequest struct {
+	Node    string `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	Profile string `protobuf:"bytes,2,opt,name=profile,proto3" json:"profile,omitempty"`
+}
+
+func (m *GetAllocationsRequest) Reset()         { *m = GetAllocationsRequest{} }
+func (m *GetAllocationsRequest) String() string { return proto.CompactTextString(m) }
+func (*GetAllocationsRequest) ProtoMessage()    {}
+
+func (m *GetAllocationsRequest) GetNode() string {
+	if m != nil {
+		return m.Node
+	}
+	return ""
+}
+
+func (m *GetAllocationsRequest) GetProfile() string {
+	if m != nil {
+		return m.Profile
+	}
+	return ""
+}
+
+type CoreAllocation struct {
+	Node      string `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	Core      int32  `protobuf:"varint,2,opt,name=core,proto3" json:"core,omitempty"`
+	Profile   string `protobuf:"bytes,3,opt,name=profile,proto3" json:"profile,omitempty"`
+	Pod       string `protobuf:"bytes,4,opt,name=pod,proto3" json:"pod,omitempty"`
+	PodUid    string `protobuf:"bytes,5,opt,name=pod_uid,json=podUid,proto3" json:"pod_uid,omitempty"`
+	Container string `protobuf:"bytes,6,opt,name=container,proto3" json:"container,omitempty"`
+}
+
+func (m *CoreAllocation) Reset()         { *m = CoreAllocation{} }
+func (m *CoreAllocation) String() string { return proto.CompactTextString(m) }
+func (*CoreAllocation) ProtoMessage()    {}
+
+type GetAllocationsResponse struct {
+	Allocations []*CoreAllocation `protobuf:"bytes,1,rep,name=allocations,proto3" json:"allocations,omitempty"`
+}
+
+func (m *GetAllocationsResponse) Reset()         { *m = GetAllocationsResponse{} }
+func (m *GetAllocationsResponse) String() string { return proto.CompactTextString(m) }
+func (*GetAllocationsResponse) ProtoMessage()    {}
+
+func (m *GetAllocationsResponse) GetAllocations() []*CoreAllocation {
+	if m != nil {
+		return m.Allocations
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ package appqos
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -17,6 +18,9 @@ const (
 	PoolsEndpoint         = "/pools"
 	AppsEndpoint          = "/apps"
 	PowerProfilesEndpoint = "/power_profiles"
+	VersionEndpoint       = "/version"
+	CapabilitiesEndpoint  = "/caps"
+	BatchEndpoint         = "/batch"
 
 	HttpPrefix  = "http://"
 	HttpsPrefix = "https://"
@@ -198,6 +202,45 @@ func (ac *AppQoSClient) PutPool(pool *Pool, address string, id int) (string, err
 	return successStr, nil
 }
 
+// PostBatch /batch, applying every Pool in the batch in a single transaction
+func (ac *AppQoSClient) PostBatch(batch *Batch, address string) (string, error) {
+	batchFailedErr := errors.NewServiceUnavailable("Response status code error")
+
+	payloadBytes, err := json.Marshal(batch)
+	if err != nil {
+		return "Failed to marshal payload data", err
+	}
+	body := bytes.NewReader(payloadBytes)
+
+	httpString := fmt.Sprintf("%s%s", address, BatchEndpoint)
+	req, err := http.NewRequest("POST", httpString, body)
+	if err != nil {
+		return "Failed to create new HTTP POST request", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := ac.client.Do(req)
+	if err != nil {
+		return "Failed to set header for  HTTP POST request", err
+	}
+	defer resp.Body.Close()
+
+	buf := new(bytes.Buffer)
+	_, err = buf.ReadFrom(resp.Body)
+	if err != nil {
+		return "Failed to read from response body", err
+	}
+	respStr := buf.String()
+
+	if resp.StatusCode != 200 {
+		errStr := fmt.Sprintf("%s%v", "Fail: ", respStr)
+		return errStr, batchFailedErr
+	}
+
+	successStr := fmt.Sprintf("%s%v", "Success: ", resp.StatusCode)
+
+	return successStr, nil
+}
+
 // DeletePool /pools/{id}
 func (ac *AppQoSClient) DeletePool(address string, id int) error {
 	httpString := fmt.Sprintf("%s%s%s%s", address, PoolsEndpoint, "/", strconv.Itoa(id))
@@ -230,9 +273,14 @@ func (ac *AppQoSClient) DeletePool(address string, id int) error {
 
 // GetPowerProfiles /power_profiles
 func (ac *AppQoSClient) GetPowerProfiles(address string) ([]PowerProfile, error) {
+	return ac.GetPowerProfilesWithContext(context.Background(), address)
+}
+
+// GetPowerProfilesWithContext /power_profiles, aborting if the context is done
+func (ac *AppQoSClient) GetPowerProfilesWithContext(ctx context.Context, address string) ([]PowerProfile, error) {
 	httpString := fmt.Sprintf("%s%s", address, PowerProfilesEndpoint)
 
-	req, err := http.NewRequest("GET", httpString, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", httpString, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -394,6 +442,80 @@ func (ac *AppQoSClient) DeletePowerProfile(address string, id int) error {
 	return nil
 }
 
+// GetVersion /version
+func (ac *AppQoSClient) GetVersion(address string) (string, error) {
+	httpString := fmt.Sprintf("%s%s", address, VersionEndpoint)
+
+	req, err := http.NewRequest("GET", httpString, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := ac.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	receivedJSON, err := ioutil.ReadAll(resp.Body) // This reads raw request body
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode != 200 {
+		return "", errors.NewServiceUnavailable(string(receivedJSON))
+	}
+
+	version := &Version{}
+	err = json.Unmarshal([]byte(receivedJSON), version)
+	if err != nil {
+		return "", err
+	}
+
+	if version.Version == nil {
+		return "", errors.NewServiceUnavailable("AppQoS did not report a version")
+	}
+
+	return *version.Version, nil
+}
+
+// GetCapabilities /caps
+func (ac *AppQoSClient) GetCapabilities(address string) ([]string, error) {
+	httpString := fmt.Sprintf("%s%s", address, CapabilitiesEndpoint)
+
+	req, err := http.NewRequest("GET", httpString, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := ac.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	receivedJSON, err := ioutil.ReadAll(resp.Body) // This reads raw request body
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, errors.NewServiceUnavailable(string(receivedJSON))
+	}
+
+	capabilities := &Capabilities{}
+	err = json.Unmarshal([]byte(receivedJSON), capabilities)
+	if err != nil {
+		return nil, err
+	}
+
+	if capabilities.Capabilities == nil {
+		return []string{}, nil
+	}
+
+	return *capabilities.Capabilities, nil
+}
+
 func (ac *AppQoSClient) GetAddressPrefix() string {
 	if reflect.DeepEqual(ac.client, http.DefaultClient) {
 		return HttpPrefix
@@ -403,16 +525,37 @@ func (ac *AppQoSClient) GetAddressPrefix() string {
 }
 
 func (ac *AppQoSClient) GetProfileByName(profileName string, nodeAddress string) (*PowerProfile, error) {
-	profiles, err := ac.GetPowerProfiles(nodeAddress)
+	return ac.GetProfileByNameWithContext(context.Background(), profileName, nodeAddress)
+}
+
+// GetProfileByNameWithContext is GetProfileByName, aborting if the context is done
+func (ac *AppQoSClient) GetProfileByNameWithContext(ctx context.Context, profileName string, nodeAddress string) (*PowerProfile, error) {
+	profiles, err := ac.GetPowerProfilesWithContext(ctx, nodeAddress)
 	if err != nil {
 		return &PowerProfile{}, err
 	}
 
+	// Only an exact match is accepted, and more than one means AppQoS holds duplicates that can't be told apart
+	matches := make([]PowerProfile, 0)
 	for _, profile := range profiles {
-		if *profile.Name == profileName {
-			return &profile, nil
+		if profile.Name != nil && *profile.Name == profileName {
+			matches = append(matches, profile)
 		}
 	}
 
+	if len(matches) > 1 {
+		ids := make([]int, 0)
+		for _, match := range matches {
+			if match.ID != nil {
+				ids = append(ids, *match.ID)
+			}
+		}
+		return &PowerProfile{}, errors.NewServiceUnavailable(fmt.Sprintf("%d Power Profiles named '%s' found in AppQoS, with IDs %v", len(matches), profileName, ids))
+	}
+
+	if len(matches) == 1 {
+		return &matches[0], nil
+	}
+
 	return &PowerProfile{}, nil
 }
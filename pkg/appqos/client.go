@@ -5,9 +5,15 @@ package appqos
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"sync"
 	"time"
+
+	"github.com/go-logr/logr"
+	"golang.org/x/time/rate"
 )
 
 var certPath = "/etc/certs/public/appqos.crt"
@@ -17,14 +23,51 @@ var caPath = "/etc/certs/public/ca.crt"
 // AppQoSClient is used by the operator to become a client to AppQoS
 type AppQoSClient struct {
 	client *http.Client
+
+	writeLimitMutex sync.Mutex
+	writeLimit      rate.Limit
+	writeBurst      int
+	writeLimiters   map[string]*rate.Limiter
+
+	reachabilityMutex    sync.Mutex
+	reachable            map[string]bool
+	reachabilityObserver func(address string, reachable bool)
+
+	addressMutex     sync.Mutex
+	addressResolvers map[string]func() (string, error)
+	resolvedHosts    map[string]string
+
+	payloadLogMutex sync.Mutex
+	payloadLogger   logr.Logger
+}
+
+// Credentials holds the paths of the certificate, key and CA the client presents to and verifies AppQoS with
+type Credentials struct {
+	CertPath string
+	KeyPath  string
+	CAPath   string
+}
+
+// DefaultCredentials returns the credentials mounted into the Node Agent by default
+func DefaultCredentials() Credentials {
+	return Credentials{
+		CertPath: certPath,
+		KeyPath:  keyPath,
+		CAPath:   caPath,
+	}
 }
 
 func NewOperatorAppQoSClient() (*AppQoSClient, error) {
-	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	return NewOperatorAppQoSClientWithCredentials(DefaultCredentials())
+}
+
+// NewOperatorAppQoSClientWithCredentials returns a client that authenticates to AppQoS with the given credentials
+func NewOperatorAppQoSClientWithCredentials(credentials Credentials) (*AppQoSClient, error) {
+	cert, err := tls.LoadX509KeyPair(credentials.CertPath, credentials.KeyPath)
 	if err != nil {
 		return &AppQoSClient{}, err
 	}
-	caCert, err := ioutil.ReadFile(caPath)
+	caCert, err := ioutil.ReadFile(credentials.CAPath)
 	if err != nil {
 		return &AppQoSClient{}, err
 	}
@@ -42,17 +85,17 @@ func NewOperatorAppQoSClient() (*AppQoSClient, error) {
 		},
 	}
 
-	client := &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig:     tlsConfig,
-			TLSHandshakeTimeout: 2000 * time.Millisecond,
+	appQoSClient := &AppQoSClient{}
+	appQoSClient.client = &http.Client{
+		Transport: &reachabilityTransport{
+			base: &http.Transport{
+				TLSClientConfig:     tlsConfig,
+				TLSHandshakeTimeout: 2000 * time.Millisecond,
+			},
+			client: appQoSClient,
 		},
 	}
 
-	appQoSClient := &AppQoSClient{
-		client: client,
-	}
-
 	return appQoSClient, nil
 }
 
@@ -61,10 +104,216 @@ func NewDefaultAppQoSClient() *AppQoSClient {
 	tr := &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 	}
-	defaultClient := &http.Client{Transport: tr}
-	appQoSClient := &AppQoSClient{
-		client: defaultClient,
-	}
+	appQoSClient := &AppQoSClient{}
+	appQoSClient.client = &http.Client{Transport: &reachabilityTransport{base: tr, client: appQoSClient}}
 
 	return appQoSClient
 }
+
+// SetWriteRateLimit paces writes to each AppQoS instance with a token bucket refilled at writesPerSecond
+// and holding at most burst tokens. A writesPerSecond of zero removes the limit
+func (ac *AppQoSClient) SetWriteRateLimit(writesPerSecond float64, burst int) {
+	ac.writeLimitMutex.Lock()
+	defer ac.writeLimitMutex.Unlock()
+
+	ac.writeLimit = rate.Limit(writesPerSecond)
+	ac.writeBurst = burst
+	ac.writeLimiters = make(map[string]*rate.Limiter)
+}
+
+// ReserveWrite takes a write token for the AppQoS instance at the address. If none is available no
+// token is taken and the time until one will be is returned, so the caller can requeue
+func (ac *AppQoSClient) ReserveWrite(address string) time.Duration {
+	ac.writeLimitMutex.Lock()
+	defer ac.writeLimitMutex.Unlock()
+
+	if ac.writeLimit == 0 {
+		return 0
+	}
+
+	limiter, exists := ac.writeLimiters[address]
+	if !exists {
+		limiter = rate.NewLimiter(ac.writeLimit, ac.writeBurst)
+		ac.writeLimiters[address] = limiter
+	}
+
+	reservation := limiter.Reserve()
+	delay := reservation.Delay()
+	if delay > 0 {
+		reservation.Cancel()
+	}
+
+	return delay
+}
+
+// SetReachabilityObserver registers a function called with the outcome of every connection attempt to an AppQoS instance
+func (ac *AppQoSClient) SetReachabilityObserver(observer func(address string, reachable bool)) {
+	ac.reachabilityMutex.Lock()
+	defer ac.reachabilityMutex.Unlock()
+
+	ac.reachabilityObserver = observer
+}
+
+// Reachable reports whether the last connection attempt to the AppQoS instance at the address succeeded.
+// known is false if no attempt has been made yet
+func (ac *AppQoSClient) Reachable(address string) (reachable bool, known bool) {
+	ac.reachabilityMutex.Lock()
+	defer ac.reachabilityMutex.Unlock()
+
+	reachable, known = ac.reachable[address]
+	return reachable, known
+}
+
+// SetAddressResolver sends requests for the address to the host resolve reports the AppQoS instance to be on,
+// such as the IP of an AppQoS Pod, keeping the address's scheme and port. The resolved host is cached and looked
+// up again whenever a connection to it fails, so an AppQoS Pod rescheduled with a new IP is followed
+func (ac *AppQoSClient) SetAddressResolver(address string, resolve func() (string, error)) {
+	ac.addressMutex.Lock()
+	defer ac.addressMutex.Unlock()
+
+	if ac.addressResolvers == nil {
+		ac.addressResolvers = make(map[string]func() (string, error))
+		ac.resolvedHosts = make(map[string]string)
+	}
+	ac.addressResolvers[address] = resolve
+	delete(ac.resolvedHosts, address)
+}
+
+// ForgetResolvedHost drops the host cached for the address, so the next request looks it up again even if the
+// previous host is still reachable
+func (ac *AppQoSClient) ForgetResolvedHost(address string) {
+	ac.addressMutex.Lock()
+	defer ac.addressMutex.Unlock()
+
+	delete(ac.resolvedHosts, address)
+}
+
+// resolveHost returns the host requests for the address are sent to, looking it up again if refresh is set or
+// none is cached. resolved is false if the address has no resolver, in which case it is used as is
+func (ac *AppQoSClient) resolveHost(address string, refresh bool) (host string, resolved bool, err error) {
+	ac.addressMutex.Lock()
+	defer ac.addressMutex.Unlock()
+
+	resolve, exists := ac.addressResolvers[address]
+	if !exists {
+		return "", false, nil
+	}
+
+	if host, cached := ac.resolvedHosts[address]; cached && !refresh {
+		return host, true, nil
+	}
+
+	host, err = resolve()
+	if err != nil {
+		return "", true, err
+	}
+	ac.resolvedHosts[address] = host
+
+	return host, true, nil
+}
+
+func (ac *AppQoSClient) recordReachability(address string, reachable bool) {
+	ac.reachabilityMutex.Lock()
+	if ac.reachable == nil {
+		ac.reachable = make(map[string]bool)
+	}
+	ac.reachable[address] = reachable
+	observer := ac.reachabilityObserver
+	ac.reachabilityMutex.Unlock()
+
+	if observer != nil {
+		observer(address, reachable)
+	}
+}
+
+// reachabilityTransport records whether each request reached the AppQoS instance. Any HTTP response,
+// including an error status, means the instance is reachable
+type reachabilityTransport struct {
+	base   http.RoundTripper
+	client *AppQoSClient
+
+	// redirectBases verify the certificates of hosts requests were redirected to against the original host name
+	redirectMutex sync.Mutex
+	redirectBases map[string]http.RoundTripper
+}
+
+func (t *reachabilityTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if logger, enabled := t.client.enabledPayloadLogger(); enabled {
+		return t.roundTripLogged(logger, req)
+	}
+
+	return t.roundTrip(req)
+}
+
+func (t *reachabilityTransport) roundTrip(req *http.Request) (*http.Response, error) {
+	address := fmt.Sprintf("%s://%s", req.URL.Scheme, req.URL.Host)
+
+	host, resolved, err := t.client.resolveHost(address, false)
+	if err != nil {
+		t.client.recordReachability(address, false)
+		return nil, err
+	}
+	if !resolved {
+		resp, err := t.base.RoundTrip(req)
+		t.client.recordReachability(address, err == nil)
+		return resp, err
+	}
+
+	base := t.redirectBase(req.URL.Hostname())
+	resp, err := base.RoundTrip(requestToHost(req, host))
+	if err != nil && (req.Body == nil || req.GetBody != nil) {
+		// AppQoS may have moved, so look it up again and retry if it has
+		newHost, _, resolveErr := t.client.resolveHost(address, true)
+		if resolveErr == nil && newHost != host {
+			retry := requestToHost(req, newHost)
+			var bodyErr error
+			if req.GetBody != nil {
+				retry.Body, bodyErr = req.GetBody()
+			}
+			if bodyErr == nil {
+				resp, err = base.RoundTrip(retry)
+			}
+		}
+	}
+	t.client.recordReachability(address, err == nil)
+
+	return resp, err
+}
+
+// redirectBase returns the transport for requests redirected away from the host name. AppQoS's certificate is
+// issued for the host name rather than the host requests are redirected to, so the TLS handshake is made to
+// verify it against the host name
+func (t *reachabilityTransport) redirectBase(hostName string) http.RoundTripper {
+	transport, ok := t.base.(*http.Transport)
+	if !ok || transport.TLSClientConfig == nil || transport.TLSClientConfig.ServerName != "" {
+		return t.base
+	}
+
+	t.redirectMutex.Lock()
+	defer t.redirectMutex.Unlock()
+
+	if base, exists := t.redirectBases[hostName]; exists {
+		return base
+	}
+
+	redirectTransport := transport.Clone()
+	redirectTransport.TLSClientConfig.ServerName = hostName
+	if t.redirectBases == nil {
+		t.redirectBases = make(map[string]http.RoundTripper)
+	}
+	t.redirectBases[hostName] = redirectTransport
+
+	return redirectTransport
+}
+
+// requestToHost returns a copy of the request sent to the host instead, on the same port
+func requestToHost(req *http.Request, host string) *http.Request {
+	hostReq := req.Clone(req.Context())
+	hostReq.URL.Host = host
+	if port := req.URL.Port(); port != "" {
+		hostReq.URL.Host = net.JoinHostPort(host, port)
+	}
+	hostReq.Host = ""
+
+	return hostReq
+}
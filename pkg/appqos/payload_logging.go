@@ -0,0 +1,125 @@
+package appqos
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/go-logr/logr"
+)
+
+// PayloadLogVerbosity is the verbosity at which request and response bodies are logged
+const PayloadLogVerbosity = 2
+
+// RedactedValue replaces the value of any sensitive field in a logged body
+const RedactedValue = "[REDACTED]"
+
+// sensitiveFieldMarkers are the substrings of a lower-cased JSON field name that mark its value as sensitive
+var sensitiveFieldMarkers = []string{"password", "token", "secret", "key", "cert"}
+
+// SetPayloadLogger logs the body of every request to and response from AppQoS at PayloadLogVerbosity,
+// with sensitive fields redacted. Nothing is read or logged unless the logger is enabled at that verbosity
+func (ac *AppQoSClient) SetPayloadLogger(logger logr.Logger) {
+	ac.payloadLogMutex.Lock()
+	defer ac.payloadLogMutex.Unlock()
+
+	ac.payloadLogger = logger
+}
+
+// enabledPayloadLogger returns the payload logger at PayloadLogVerbosity if one is set and enabled
+func (ac *AppQoSClient) enabledPayloadLogger() (logr.Logger, bool) {
+	ac.payloadLogMutex.Lock()
+	defer ac.payloadLogMutex.Unlock()
+
+	if ac.payloadLogger == nil {
+		return nil, false
+	}
+
+	logger := ac.payloadLogger.V(PayloadLogVerbosity)
+	return logger, logger.Enabled()
+}
+
+// roundTripLogged sends the request as roundTrip does, logging its body and the response's.
+// Both bodies are read in full and replaced so the caller and retries can still read them
+func (t *reachabilityTransport) roundTripLogged(logger logr.Logger, req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		reqBody, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		req = req.Clone(req.Context())
+		req.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(reqBody)), nil
+		}
+		logger.Info("AppQoS request", "method", req.Method, "url", req.URL.String(), "body", redactBody(reqBody))
+	} else {
+		logger.Info("AppQoS request", "method", req.Method, "url", req.URL.String())
+	}
+
+	resp, err := t.roundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+	logger.Info("AppQoS response", "method", req.Method, "url", req.URL.String(), "status", resp.StatusCode, "body", redactBody(respBody))
+
+	return resp, nil
+}
+
+// redactBody returns the body as a string with the values of sensitive fields replaced.
+// Bodies that are not JSON are returned as they are
+func redactBody(body []byte) string {
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return string(body)
+	}
+
+	redacted, err := json.Marshal(redactValue(decoded))
+	if err != nil {
+		return string(body)
+	}
+
+	return string(redacted)
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for field, fieldValue := range v {
+			if isSensitiveField(field) {
+				v[field] = RedactedValue
+				continue
+			}
+			v[field] = redactValue(fieldValue)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = redactValue(v[i])
+		}
+	}
+
+	return value
+}
+
+func isSensitiveField(field string) bool {
+	field = strings.ToLower(field)
+	for _, marker := range sensitiveFieldMarkers {
+		if strings.Contains(field, marker) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -32,6 +32,21 @@ type App struct {
 	ID     *int    `json:"id,omitempty"`
 }
 
+// Version - AppQoS version, used to check compatibility with the operator
+type Version struct {
+	Version *string `json:"version,omitempty"`
+}
+
+// Capabilities - the features, such as 'cat', 'mba', 'sstbf' and 'power', AppQoS supports on the node
+type Capabilities struct {
+	Capabilities *[]string `json:"capabilities,omitempty"`
+}
+
+// Batch - a set of Pool updates AppQoS applies atomically, on nodes advertising the 'batch' capability
+type Batch struct {
+	Pools *[]Pool `json:"pools,omitempty"`
+}
+
 type EmptyMessage struct {
 	Message *string `json:"message,omitempty"`
 }
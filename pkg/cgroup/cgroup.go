@@ -0,0 +1,55 @@
+package cgroup
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/cpuset"
+)
+
+// CgroupPath is the root of the cgroup filesystem
+var CgroupPath = "/sys/fs/cgroup"
+
+// cpusetPatterns are where a Guaranteed Pod's Container cpuset is found for the cgroupfs and systemd cgroup
+// drivers, under cgroup v1 and then v2. The Pod UID and Container ID are substituted in
+var cpusetPatterns = []string{
+	"cpuset/kubepods/pod%[1]s/%[3]s/cpuset.cpus",
+	"cpuset/kubepods.slice/kubepods-pod%[2]s.slice/*-%[3]s.scope/cpuset.cpus",
+	"kubepods/pod%[1]s/%[3]s/cpuset.cpus.effective",
+	"kubepods.slice/kubepods-pod%[2]s.slice/*-%[3]s.scope/cpuset.cpus.effective",
+}
+
+// GetContainerCPUs returns the CPUs in the cpuset cgroup of the Container, given the Pod's UID and the
+// Container's ID with or without its runtime prefix, such as 'docker://'
+func GetContainerCPUs(podUID string, containerID string) ([]int, error) {
+	if index := strings.Index(containerID, "://"); index >= 0 {
+		containerID = containerID[index+3:]
+	}
+	systemdPodUID := strings.ReplaceAll(podUID, "-", "_")
+
+	for _, pattern := range cpusetPatterns {
+		matches, err := filepath.Glob(filepath.Join(CgroupPath, fmt.Sprintf(pattern, podUID, systemdPodUID, containerID)))
+		if err != nil {
+			return []int{}, err
+		}
+		if len(matches) == 0 {
+			continue
+		}
+
+		cpusByte, err := ioutil.ReadFile(matches[0])
+		if err != nil {
+			return []int{}, err
+		}
+
+		cpus, err := cpuset.Parse(strings.TrimSpace(string(cpusByte)))
+		if err != nil {
+			return []int{}, err
+		}
+
+		return cpus.ToSlice(), nil
+	}
+
+	return []int{}, fmt.Errorf("no cpuset cgroup found for Container '%s' of Pod '%s'", containerID, podUID)
+}
@@ -0,0 +1,82 @@
+package cpuhotplug
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/pkg/cpuset"
+)
+
+// CPUDevicesPath is the sysfs directory holding the per-CPU topology and hotplug files
+var CPUDevicesPath = "/sys/devices/system/cpu"
+
+// GetThreadSiblings returns the hyperthreads sharing a physical core with the given CPU, including the CPU itself
+func GetThreadSiblings(cpu int) ([]int, error) {
+	siblingsFile := filepath.Join(CPUDevicesPath, fmt.Sprintf("cpu%d", cpu), "topology", "thread_siblings_list")
+	siblingsByte, err := ioutil.ReadFile(siblingsFile)
+	if err != nil {
+		return []int{}, err
+	}
+
+	siblings, err := cpuset.Parse(strings.TrimSpace(string(siblingsByte)))
+	if err != nil {
+		return []int{}, err
+	}
+
+	return siblings.ToSlice(), nil
+}
+
+// GetPackageID returns the physical package, or socket, the given CPU belongs to
+func GetPackageID(cpu int) (int, error) {
+	packageFile := filepath.Join(CPUDevicesPath, fmt.Sprintf("cpu%d", cpu), "topology", "physical_package_id")
+	packageByte, err := ioutil.ReadFile(packageFile)
+	if err != nil {
+		return -1, err
+	}
+
+	return strconv.Atoi(strings.TrimSpace(string(packageByte)))
+}
+
+// GetNUMANode returns the NUMA node the given CPU belongs to, read from the nodeN link in the CPU's sysfs directory
+func GetNUMANode(cpu int) (int, error) {
+	nodeLinks, err := filepath.Glob(filepath.Join(CPUDevicesPath, fmt.Sprintf("cpu%d", cpu), "node*"))
+	if err != nil {
+		return -1, err
+	}
+	if len(nodeLinks) == 0 {
+		return -1, fmt.Errorf("no NUMA node found for CPU %d", cpu)
+	}
+
+	return strconv.Atoi(strings.TrimPrefix(filepath.Base(nodeLinks[0]), "node"))
+}
+
+// IsCPUOnline reports whether the given CPU is online. CPUs without an online file, such as the boot CPU,
+// cannot be taken offline so are always online
+func IsCPUOnline(cpu int) (bool, error) {
+	onlineFile := filepath.Join(CPUDevicesPath, fmt.Sprintf("cpu%d", cpu), "online")
+	onlineByte, err := ioutil.ReadFile(onlineFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return true, nil
+		}
+
+		return false, err
+	}
+
+	return strings.TrimSpace(string(onlineByte)) == "1", nil
+}
+
+// SetCPUOnline brings the given CPU online or takes it offline
+func SetCPUOnline(cpu int, online bool) error {
+	onlineFile := filepath.Join(CPUDevicesPath, fmt.Sprintf("cpu%d", cpu), "online")
+	value := "0"
+	if online {
+		value = "1"
+	}
+
+	return ioutil.WriteFile(onlineFile, []byte(value), 0644)
+}
@@ -0,0 +1,32 @@
+package cpumanager
+
+import (
+	"encoding/json"
+	"io/ioutil"
+)
+
+// StaticPolicy is the CPU Manager policy that gives Guaranteed Pods exclusive cores
+const StaticPolicy = "static"
+
+// StatePath is the kubelet's CPU Manager checkpoint file, which records the policy the kubelet is running
+var StatePath = "/var/lib/kubelet/cpu_manager_state"
+
+type checkpoint struct {
+	PolicyName string `json:"policyName"`
+}
+
+// GetPolicy returns the name of the CPU Manager policy the kubelet is running, e.g. 'none' or 'static'
+func GetPolicy() (string, error) {
+	checkpointByte, err := ioutil.ReadFile(StatePath)
+	if err != nil {
+		return "", err
+	}
+
+	state := &checkpoint{}
+	err = json.Unmarshal(checkpointByte, state)
+	if err != nil {
+		return "", err
+	}
+
+	return state.PolicyName, nil
+}
@@ -0,0 +1,64 @@
+package memorymanager
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+)
+
+// StatePath is the kubelet's Memory Manager checkpoint file, which records the NUMA nodes each Container's
+// memory is pinned to. The PodResources API only reports memory from Kubernetes 1.22 on, so the checkpoint is
+// read instead
+var StatePath = "/var/lib/kubelet/memory_manager_state"
+
+type block struct {
+	NUMAAffinity []int  `json:"numaAffinity"`
+	Type         string `json:"type"`
+	Size         uint64 `json:"size"`
+}
+
+type checkpoint struct {
+	PolicyName string                        `json:"policyName"`
+	Entries    map[string]map[string][]block `json:"entries"`
+}
+
+// GetContainerNUMANodes returns the NUMA nodes the Memory Manager pinned the container's memory to, across all of
+// its memory and hugepages blocks. No nodes are returned if the Memory Manager isn't enabled or didn't pin the
+// container, as it only does under its Static policy for Guaranteed Pods
+func GetContainerNUMANodes(podUID string, containerName string) ([]int, error) {
+	checkpointByte, err := ioutil.ReadFile(StatePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []int{}, nil
+		}
+
+		return []int{}, err
+	}
+
+	state := &checkpoint{}
+	err = json.Unmarshal(checkpointByte, state)
+	if err != nil {
+		return []int{}, err
+	}
+
+	nodes := make([]int, 0)
+	for _, memoryBlock := range state.Entries[podUID][containerName] {
+		for _, node := range memoryBlock.NUMAAffinity {
+			if !containsInt(nodes, node) {
+				nodes = append(nodes, node)
+			}
+		}
+	}
+
+	return nodes, nil
+}
+
+func containsInt(list []int, value int) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -47,9 +47,9 @@ func getV1Client(socket string, connectionTimeout time.Duration, maxMsgSize int)
 	return podresourcesapi.NewPodResourcesListerClient(conn), nil
 }
 
-func (p *PodResourcesClient) listPodResources() (*podresourcesapi.ListPodResourcesResponse, error) {
+func (p *PodResourcesClient) listPodResources(ctx context.Context) (*podresourcesapi.ListPodResourcesResponse, error) {
 	req := podresourcesapi.ListPodResourcesRequest{}
-	resp, err := p.Client.List(context.TODO(), &req)
+	resp, err := p.Client.List(ctx, &req)
 	if err != nil {
 		fmt.Println("Can't receive response:", err)
 		return &podresourcesapi.ListPodResourcesResponse{}, err
@@ -58,22 +58,46 @@ func (p *PodResourcesClient) listPodResources() (*podresourcesapi.ListPodResourc
 }
 
 // GetContainerCPUs returns a string in cpuset format of CPUs allocated to the container
-func (p *PodResourcesClient) GetContainerCPUs(podName, containerName string) (string, error) {
-	podresourcesResponse, err := p.listPodResources()
+func (p *PodResourcesClient) GetContainerCPUs(ctx context.Context, podName, containerName string) (string, error) {
+	podresourcesResponse, err := p.listPodResources(ctx)
 	if err != nil {
 		return "", err
 	}
+
+	container, err := findContainer(podresourcesResponse, podName, containerName)
+	if err != nil {
+		return "", err
+	}
+
+	cpuSetString := cpuIDsToString(container.CpuIds)
+	return cpuSetString, nil
+}
+
+// findContainer returns the resources of the named container of the named Pod. If the Pod is listed without it,
+// e.g. because the Pod's name was reused by a Pod with other containers, the error names the containers listed
+// instead so another container's cores are never used in its place
+func findContainer(podresourcesResponse *podresourcesapi.ListPodResourcesResponse, podName, containerName string) (*podresourcesapi.ContainerResources, error) {
+	podListed := false
+	listedContainers := make([]string, 0)
 	for _, podresource := range podresourcesResponse.PodResources {
-		if podresource.Name == podName {
-			for _, container := range podresource.Containers {
-				if container.Name == containerName {
-					cpuSetString := cpuIDsToString(container.CpuIds)
-					return cpuSetString, nil
-				}
+		if podresource.Name != podName {
+			continue
+		}
+
+		podListed = true
+		for _, container := range podresource.Containers {
+			if container.Name == containerName {
+				return container, nil
 			}
+			listedContainers = append(listedContainers, container.Name)
 		}
 	}
-	return "", errors.NewServiceUnavailable(fmt.Sprintf("cpus for Pod:%v Container:%v not found", podName, containerName))
+
+	if podListed {
+		return nil, errors.NewServiceUnavailable(fmt.Sprintf("PodResources API listed Containers %v for Pod:%v but not the requested Container:%v", listedContainers, podName, containerName))
+	}
+
+	return nil, errors.NewServiceUnavailable(fmt.Sprintf("cpus for Pod:%v Container:%v not found", podName, containerName))
 }
 
 // cpuIDsToString returns a string in cpuset format
@@ -88,3 +112,50 @@ func cpuIDsToString(cpuIds []int64) string {
 
 	return cpuSetString
 }
+
+// GetContainerDeviceNUMANodes returns, for each device resource allocated to the container, the NUMA nodes
+// the device plugin hinted the devices are local to. Resources whose devices carry no topology are omitted
+func (p *PodResourcesClient) GetContainerDeviceNUMANodes(ctx context.Context, podName, containerName string) (map[string][]int, error) {
+	podresourcesResponse, err := p.listPodResources(ctx)
+	if err != nil {
+		return map[string][]int{}, err
+	}
+	for _, podresource := range podresourcesResponse.PodResources {
+		if podresource.Name == podName {
+			for _, container := range podresource.Containers {
+				if container.Name == containerName {
+					return deviceNUMANodes(container.Devices), nil
+				}
+			}
+		}
+	}
+	return map[string][]int{}, errors.NewServiceUnavailable(fmt.Sprintf("devices for Pod:%v Container:%v not found", podName, containerName))
+}
+
+// deviceNUMANodes groups the hinted NUMA nodes of the devices by resource name
+func deviceNUMANodes(devices []*podresourcesapi.ContainerDevices) map[string][]int {
+	nodes := make(map[string][]int)
+	for _, device := range devices {
+		if device.Topology == nil || len(device.Topology.Nodes) == 0 {
+			continue
+		}
+
+		for _, node := range device.Topology.Nodes {
+			if !containsInt(nodes[device.ResourceName], int(node.ID)) {
+				nodes[device.ResourceName] = append(nodes[device.ResourceName], int(node.ID))
+			}
+		}
+	}
+
+	return nodes
+}
+
+func containsInt(ints []int, value int) bool {
+	for _, i := range ints {
+		if i == value {
+			return true
+		}
+	}
+
+	return false
+}
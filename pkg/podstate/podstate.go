@@ -1,11 +1,50 @@
 package podstate
 
 import (
+	"encoding/json"
+	"sync"
+	"time"
+
 	powerv1alpha1 "gitlab.devtools.intel.com/OrchSW/CNO/power-operator.git/api/v1alpha1"
 )
 
+// State is the Node Agent's record of its Guaranteed Pods and what has been done to their cores. It is safe for
+// concurrent use, so Pods can be reconciled in parallel, but must not be copied once in use
 type State struct {
+	mutex sync.RWMutex
+
 	GuaranteedPods []powerv1alpha1.GuaranteedPod
+
+	// ParkedSiblings holds the sibling hyperthreads taken offline for each Pod so they can be restored on deletion
+	ParkedSiblings map[string][]int
+
+	// RestartCounts holds the last observed RestartCount of each of a Pod's Containers, so a restart that
+	// changes the Container's cpuset without the Pod leaving the Running phase can be detected
+	RestartCounts map[string]map[string]int32
+
+	// ProfileChanges holds when each core last had its PowerProfile switched, so further switches can be
+	// held back until a cooldown has passed
+	ProfileChanges map[int]time.Time
+
+	// RetryBudgets holds the consecutive failed reconciles of each Pod, so a Pod that keeps failing can stop
+	// being requeued until it changes
+	RetryBudgets map[string]RetryBudget
+
+	// ManagedCores holds, by namespace/name, the namespace of each Pod and how many of its exclusive cores are
+	// power-managed, so the cores a namespace holds on the Node can be checked against its quota
+	ManagedCores map[string]NamespaceCores
+}
+
+// NamespaceCores holds the namespace of a Pod and how many of its exclusive cores are power-managed
+type NamespaceCores struct {
+	Namespace string
+	Cores     int
+}
+
+// RetryBudget holds a Pod's consecutive failed reconciles and the fingerprint of the Pod they failed against
+type RetryBudget struct {
+	Failures    int
+	Fingerprint string
 }
 
 //func NewState(appqosclient *appqos.AppQoSClient) (*State, error) {
@@ -13,11 +52,19 @@ func NewState() (*State, error) {
 	state := &State{}
 	guaranteedPods := make([]powerv1alpha1.GuaranteedPod, 0)
 	state.GuaranteedPods = guaranteedPods
+	state.ParkedSiblings = make(map[string][]int)
+	state.RestartCounts = make(map[string]map[string]int32)
+	state.ProfileChanges = make(map[int]time.Time)
+	state.RetryBudgets = make(map[string]RetryBudget)
+	state.ManagedCores = make(map[string]NamespaceCores)
 
 	return state, nil
 }
 
 func (s *State) UpdateStateGuaranteedPods(guaranteedPod powerv1alpha1.GuaranteedPod) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	for i, existingPod := range s.GuaranteedPods {
 		if existingPod.Name == guaranteedPod.Name {
 			s.GuaranteedPods[i] = guaranteedPod
@@ -30,6 +77,9 @@ func (s *State) UpdateStateGuaranteedPods(guaranteedPod powerv1alpha1.Guaranteed
 }
 
 func (s *State) GetPodFromState(podName string) powerv1alpha1.GuaranteedPod {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
 	for _, existingPod := range s.GuaranteedPods {
 		if existingPod.Name == podName {
 			return existingPod
@@ -39,6 +89,16 @@ func (s *State) GetPodFromState(podName string) powerv1alpha1.GuaranteedPod {
 	return powerv1alpha1.GuaranteedPod{}
 }
 
+// GetGuaranteedPods returns a copy of the Guaranteed Pods in the State, which stays unchanged by later updates
+func (s *State) GetGuaranteedPods() []powerv1alpha1.GuaranteedPod {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	guaranteedPods := make([]powerv1alpha1.GuaranteedPod, len(s.GuaranteedPods))
+	copy(guaranteedPods, s.GuaranteedPods)
+	return guaranteedPods
+}
+
 func (s *State) GetCPUsFromPodState(podState powerv1alpha1.GuaranteedPod) []int {
 	cpus := make([]int, 0)
 	for _, container := range podState.Containers {
@@ -49,6 +109,9 @@ func (s *State) GetCPUsFromPodState(podState powerv1alpha1.GuaranteedPod) []int
 }
 
 func (s *State) DeletePodFromState(podName string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	for i, pod := range s.GuaranteedPods {
 		if pod.Name == podName {
 			s.GuaranteedPods = append(s.GuaranteedPods[:i], s.GuaranteedPods[i+1:]...)
@@ -57,3 +120,165 @@ func (s *State) DeletePodFromState(podName string) error {
 
 	return nil
 }
+
+func (s *State) UpdateParkedSiblings(podName string, cpus []int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.ParkedSiblings[podName] = cpus
+}
+
+func (s *State) GetParkedSiblings(podName string) []int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if cpus, exists := s.ParkedSiblings[podName]; exists {
+		return cpus
+	}
+
+	return []int{}
+}
+
+func (s *State) DeleteParkedSiblings(podName string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.ParkedSiblings, podName)
+}
+
+func (s *State) UpdateRestartCounts(podName string, restartCounts map[string]int32) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.RestartCounts[podName] = restartCounts
+}
+
+// GetRestartCount returns the last observed RestartCount of the Container and whether one has been observed
+func (s *State) GetRestartCount(podName string, containerName string) (int32, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	restartCount, exists := s.RestartCounts[podName][containerName]
+	return restartCount, exists
+}
+
+func (s *State) DeleteRestartCounts(podName string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.RestartCounts, podName)
+}
+
+func (s *State) RecordProfileChange(cpus []int, changeTime time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, cpu := range cpus {
+		s.ProfileChanges[cpu] = changeTime
+	}
+}
+
+// GetLastProfileChange returns the most recent time any of the cores had its PowerProfile switched, or the
+// zero time if none of them has been switched
+func (s *State) GetLastProfileChange(cpus []int) time.Time {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	lastChange := time.Time{}
+	for _, cpu := range cpus {
+		if changeTime, exists := s.ProfileChanges[cpu]; exists && changeTime.After(lastChange) {
+			lastChange = changeTime
+		}
+	}
+
+	return lastChange
+}
+
+// RecordReconcileFailure counts a failed reconcile of the Pod and returns its consecutive failures. A Pod whose
+// fingerprint has changed since its last failure starts counting again
+func (s *State) RecordReconcileFailure(podName string, fingerprint string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	budget := s.RetryBudgets[podName]
+	if budget.Fingerprint != fingerprint {
+		budget = RetryBudget{Fingerprint: fingerprint}
+	}
+	budget.Failures++
+	s.RetryBudgets[podName] = budget
+
+	return budget.Failures
+}
+
+func (s *State) GetRetryBudget(podName string) (RetryBudget, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	budget, exists := s.RetryBudgets[podName]
+	return budget, exists
+}
+
+func (s *State) DeleteRetryBudget(podName string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.RetryBudgets, podName)
+}
+
+func (s *State) UpdateManagedCores(podName string, namespace string, cores int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.ManagedCores[managedCoresKey(namespace, podName)] = NamespaceCores{Namespace: namespace, Cores: cores}
+}
+
+// GetNamespaceManagedCores returns how many exclusive cores the namespace's Pods have power-managed, leaving
+// out those of the given Pod so its own cores are not counted twice when it is re-allocated
+func (s *State) GetNamespaceManagedCores(namespace string, exceptPod string) int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	cores := 0
+	for podKey, managed := range s.ManagedCores {
+		if managed.Namespace == namespace && podKey != managedCoresKey(namespace, exceptPod) {
+			cores += managed.Cores
+		}
+	}
+
+	return cores
+}
+
+func (s *State) DeleteManagedCores(podName string, namespace string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.ManagedCores, managedCoresKey(namespace, podName))
+}
+
+// managedCoresKey keys a Pod's ManagedCores by namespace as well as name, as same-named Pods in different
+// namespaces count against different quotas
+func managedCoresKey(namespace string, podName string) string {
+	return namespace + "/" + podName
+}
+
+// MarshalJSON encodes the State's exported fields, holding the lock so they are not updated while being encoded
+func (s *State) MarshalJSON() ([]byte, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return json.Marshal(struct {
+		GuaranteedPods []powerv1alpha1.GuaranteedPod
+		ParkedSiblings map[string][]int
+		RestartCounts  map[string]map[string]int32
+		ProfileChanges map[int]time.Time
+		RetryBudgets   map[string]RetryBudget
+		ManagedCores   map[string]NamespaceCores
+	}{
+		GuaranteedPods: s.GuaranteedPods,
+		ParkedSiblings: s.ParkedSiblings,
+		RestartCounts:  s.RestartCounts,
+		ProfileChanges: s.ProfileChanges,
+		RetryBudgets:   s.RetryBudgets,
+		ManagedCores:   s.ManagedCores,
+	})
+}